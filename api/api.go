@@ -27,6 +27,7 @@ import (
 	"github.com/apache/incubator-devlake/logger"
 
 	"github.com/apache/incubator-devlake/config"
+	"github.com/apache/incubator-devlake/plugins/helper"
 	"github.com/apache/incubator-devlake/services"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -53,6 +54,13 @@ func CreateApiService() {
 	gin.SetMode(v.GetString("MODE"))
 	router := gin.Default()
 
+	// the histograms of the collectors are exposed to the scraping of Prometheus, ahead of the migration check
+	if v.GetBool("METRICS_ENABLED") {
+		registry := helper.NewPrometheusRegistry()
+		helper.SetMetricsRegistry(registry)
+		router.GET("/metrics", gin.WrapH(registry))
+	}
+
 	// Wait for user confirmation if db migration is needed
 	router.GET("/proceed-db-migration", func(ctx *gin.Context) {
 		if !services.MigrationRequireConfirmation() {
@@ -122,6 +122,8 @@ GET /pipelines/:pipelineId
 // @Description 	"id": 1,
 // @Description 	"name": "test-pipeline",
 // @Description 	...
+// @Description 	"collectorStats": [{"taskId": 1, "subtaskName": "collectIssues", "rawTable": "_raw_jira_api_issues", "requests": 10, "bytes": 1048576, "durationMs": 5000, "inputBatches": 2, "inputDurationMs": 120}],
+// @Description 	"subtaskResults": [{"taskId": 1, "subtaskName": "collectEpics", "records": 1243, "pages": 13}]
 // @Description }
 // @Tags framework/pipelines
 // @Param pipelineId path int true "query"
@@ -141,6 +143,16 @@ func Get(c *gin.Context) {
 		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting pipeline"))
 		return
 	}
+	pipeline.CollectorStats, err = services.GetPipelineCollectorStats(id)
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	pipeline.SubtaskResults, err = services.GetPipelineSubtaskResults(id)
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
 	shared.ApiOutputSuccess(c, pipeline, http.StatusOK)
 }
 
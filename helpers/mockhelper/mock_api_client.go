@@ -0,0 +1,266 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mockhelper holds the test doubles built on top of plugins/helper, they are kept apart from unithelper
+// since the tests of plugins/helper depend on unithelper
+package mockhelper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/helper/common"
+)
+
+var _ helper.RateLimitedApiClient = (*MockApiClient)(nil)
+var _ helper.ContextualApiClient = (*MockApiClient)(nil)
+
+// MockApiResponse is a canned response served by MockApiClient, the status code defaults to 200
+type MockApiResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+// MockApiRequest is a request made to MockApiClient
+type MockApiRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   interface{}
+}
+
+// mockApiRoute serves the response to the requests of the path matching the query params of the route
+type mockApiRoute struct {
+	method   string
+	path     string
+	query    url.Values
+	response *MockApiResponse
+}
+
+// MockApiClient is a RateLimitedApiClient serving canned responses without any http round trip, for the unit tests
+// of the collectors. The collector under test is the real one, so its `Query`, `AfterResponse` and `ResponseParser`
+// are exercised as they are in production, and the requests it made could be asserted by `Requests` afterward.
+// Requests are served one by one right when they are made, so the pages are collected in a deterministic order
+//
+//	apiClient := mockhelper.NewMockApiClient().
+//		OnGet("api/2/search", url.Values{"startAt": {"0"}}, &mockhelper.MockApiResponse{Body: `{"total":2,"issues":[...]}`}).
+//		OnGet("api/2/search", url.Values{"startAt": {"1"}}, &mockhelper.MockApiResponse{Body: `{"total":2,"issues":[...]}`})
+//	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{ApiClient: apiClient, ...})
+type MockApiClient struct {
+	mu            sync.Mutex
+	routes        []mockApiRoute
+	requests      []MockApiRequest
+	errs          []error
+	afterFunction common.ApiClientAfterResponse
+}
+
+// NewMockApiClient creates a MockApiClient without any response, requests not matching any response fail the
+// collection with a NotFound error
+func NewMockApiClient() *MockApiClient {
+	return &MockApiClient{}
+}
+
+// OnGet serves the response to the GET requests of the path carrying all the given query params, params left out
+// of `query` are not compared. Responses registered first take precedence
+func (c *MockApiClient) OnGet(path string, query url.Values, response *MockApiResponse) *MockApiClient {
+	return c.On(http.MethodGet, path, query, response)
+}
+
+// OnPost serves the response to the POST requests like OnGet
+func (c *MockApiClient) OnPost(path string, query url.Values, response *MockApiResponse) *MockApiClient {
+	return c.On(http.MethodPost, path, query, response)
+}
+
+// On serves the response to the requests of the method and path carrying all the given query params
+func (c *MockApiClient) On(method string, path string, query url.Values, response *MockApiResponse) *MockApiClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.routes = append(c.routes, mockApiRoute{method: method, path: path, query: query, response: response})
+	return c
+}
+
+// Requests returns the requests made so far in the order they were made
+func (c *MockApiClient) Requests() []MockApiRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]MockApiRequest(nil), c.requests...)
+}
+
+// DoGetAsync serves the canned response of the request to the handler right away
+func (c *MockApiClient) DoGetAsync(path string, query url.Values, header http.Header, handler common.ApiAsyncCallback) {
+	c.do(http.MethodGet, path, query, nil, header, handler)
+}
+
+// DoPostAsync serves the canned response of the request to the handler right away
+func (c *MockApiClient) DoPostAsync(path string, query url.Values, body interface{}, header http.Header, handler common.ApiAsyncCallback) {
+	c.do(http.MethodPost, path, query, body, header, handler)
+}
+
+// DoGetAsyncWithContext serves the request like DoGetAsync, requests of a canceled context are dropped
+func (c *MockApiClient) DoGetAsyncWithContext(ctx context.Context, path string, query url.Values, header http.Header, handler common.ApiAsyncCallback) {
+	if ctx.Err() == nil {
+		c.DoGetAsync(path, query, header, handler)
+	}
+}
+
+// DoPostAsyncWithContext serves the request like DoPostAsync, requests of a canceled context are dropped
+func (c *MockApiClient) DoPostAsyncWithContext(ctx context.Context, path string, query url.Values, body interface{}, header http.Header, handler common.ApiAsyncCallback) {
+	if ctx.Err() == nil {
+		c.DoPostAsync(path, query, body, header, handler)
+	}
+}
+
+func (c *MockApiClient) do(method string, path string, query url.Values, body interface{}, header http.Header, handler common.ApiAsyncCallback) {
+	c.mu.Lock()
+	c.requests = append(c.requests, MockApiRequest{Method: method, Path: path, Query: query, Header: header, Body: body})
+	response := c.match(method, path, query)
+	afterFunction := c.afterFunction
+	c.mu.Unlock()
+
+	request := &helper.RequestErrorData{Method: method, Url: path, Query: query}
+	if response == nil {
+		c.fail(errors.NotFound.New(fmt.Sprintf("no response is mocked for %s", request)))
+		return
+	}
+	res := response.toHttpResponse(method, path, query)
+	if afterFunction != nil {
+		err := afterFunction(res)
+		if err == helper.ErrIgnoreAndContinue {
+			return
+		}
+		if err != nil {
+			c.fail(errors.Default.Wrap(err, fmt.Sprintf("error running afterRequest for %s", request)))
+			return
+		}
+		// the body read by the callback is replayed to the handler, as the real client does
+		res.Body = io.NopCloser(bytes.NewBufferString(response.Body))
+	}
+	if res.StatusCode >= helper.HttpMinStatusRetryCode {
+		c.fail(errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("mocked status %d for %s", res.StatusCode, request)))
+		return
+	}
+	err := handler(res)
+	if err != nil {
+		c.fail(errors.Default.Wrap(err, fmt.Sprintf("failed to handle the response of %s", request)))
+	}
+}
+
+// match returns the response of the first route matching the request, nil if none
+func (c *MockApiClient) match(method string, path string, query url.Values) *MockApiResponse {
+	for _, route := range c.routes {
+		if route.method != method || route.path != path {
+			continue
+		}
+		matched := true
+		for name, values := range route.query {
+			if !reflect.DeepEqual(query[name], values) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route.response
+		}
+	}
+	return nil
+}
+
+func (c *MockApiClient) fail(err errors.Error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errs = append(c.errs, err)
+}
+
+func (response *MockApiResponse) toHttpResponse(method string, path string, query url.Values) *http.Response {
+	statusCode := response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	header := response.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewBufferString(response.Body)),
+		Request: &http.Request{
+			Method: method,
+			URL:    &url.URL{Path: path, RawQuery: query.Encode()},
+		},
+	}
+}
+
+// WaitAsync returns the errors of the requests made so far, all of them were done already
+func (c *MockApiClient) WaitAsync() errors.Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.errs) == 1 {
+		return errors.Convert(c.errs[0])
+	}
+	if len(c.errs) > 0 {
+		return errors.Default.Combine(c.errs)
+	}
+	return nil
+}
+
+// HasError tells if any request failed so far
+func (c *MockApiClient) HasError() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.errs) > 0
+}
+
+// NextTick runs the task right away
+func (c *MockApiClient) NextTick(task func() errors.Error) {
+	err := task()
+	if err != nil {
+		c.fail(err)
+	}
+}
+
+// GetNumOfWorkers returns 1 since the requests are served one by one
+func (c *MockApiClient) GetNumOfWorkers() int {
+	return 1
+}
+
+// GetAfterFunction returns the callback called with every response before the handler
+func (c *MockApiClient) GetAfterFunction() common.ApiClientAfterResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.afterFunction
+}
+
+// SetAfterFunction sets the callback called with every response before the handler
+func (c *MockApiClient) SetAfterFunction(callback common.ApiClientAfterResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.afterFunction = callback
+}
+
+// Release does nothing, there is no resource to release
+func (c *MockApiClient) Release() {}
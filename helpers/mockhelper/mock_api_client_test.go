@@ -0,0 +1,125 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockhelper
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMockApiClient(t *testing.T) {
+	var saved []string
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		for _, row := range args.Get(0).([]*helper.RawData) {
+			saved = append(saved, string(row.Data))
+		}
+	}).Return(nil)
+
+	// the pages are told apart by the `startAt` param, the other params are not compared
+	apiClient := NewMockApiClient().
+		OnGet("api/2/search", url.Values{"startAt": {"0"}}, &MockApiResponse{Body: `{"total":3,"issues":[{"id":1},{"id":2}]}`}).
+		OnGet("api/2/search", url.Values{"startAt": {"2"}}, &MockApiResponse{Body: `{"total":3,"issues":[{"id":3}]}`})
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx:    unithelper.DummySubTaskContext(mockDal),
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:   apiClient,
+		UrlTemplate: "api/2/search",
+		PageSize:    2,
+		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+			return url.Values{
+				"jql":        {"type = Epic"},
+				"startAt":    {strconv.Itoa(reqData.Pager.Skip)},
+				"maxResults": {strconv.Itoa(reqData.Pager.Size)},
+			}, nil
+		},
+		GetTotalPages: func(res *http.Response, args *helper.ApiCollectorArgs) (int, errors.Error) {
+			var page struct {
+				Total int `json:"total"`
+			}
+			err := helper.UnmarshalResponse(res, &page)
+			return (page.Total + args.PageSize - 1) / args.PageSize, err
+		},
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			return helper.DecodeJsonArrayFieldFromResponse(res, "issues")
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+
+	assert.Equal(t, []string{`{"id":1}`, `{"id":2}`, `{"id":3}`}, saved)
+	requests := apiClient.Requests()
+	if assert.Len(t, requests, 2) {
+		assert.Equal(t, http.MethodGet, requests[0].Method)
+		assert.Equal(t, "api/2/search", requests[0].Path)
+		assert.Equal(t, "type = Epic", requests[0].Query.Get("jql"))
+		assert.Equal(t, "0", requests[0].Query.Get("startAt"))
+		assert.Equal(t, "2", requests[1].Query.Get("startAt"))
+		assert.Equal(t, "2", requests[1].Query.Get("maxResults"))
+	}
+}
+
+func TestMockApiClientFailures(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil)
+	collect := func(apiClient *MockApiClient) errors.Error {
+		collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+			RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+				Ctx:    unithelper.DummySubTaskContext(mockDal),
+				Table:  "whatever rawtable",
+				Params: "whatever params",
+			},
+			ApiClient:      apiClient,
+			UrlTemplate:    "api/2/search",
+			ResponseParser: helper.GetRawMessageArrayFromResponse,
+		})
+		assert.Nil(t, err)
+		return collector.Execute()
+	}
+
+	// requests without any response mocked fail the collection
+	err := collect(NewMockApiClient().OnGet("api/2/issue", nil, &MockApiResponse{Body: `[]`}))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no response is mocked")
+
+	// the default AfterResponse of the collector is run against the mocked responses as well
+	err = collect(NewMockApiClient().OnGet("api/2/search", nil, &MockApiResponse{StatusCode: http.StatusUnauthorized}))
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.Unauthorized, err.GetType())
+
+	err = collect(NewMockApiClient().OnGet("api/2/search", nil, &MockApiResponse{StatusCode: http.StatusInternalServerError}))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "mocked status 500")
+}
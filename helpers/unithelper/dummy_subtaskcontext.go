@@ -18,6 +18,7 @@ limitations under the License.
 package unithelper
 
 import (
+	"context"
 	"github.com/apache/incubator-devlake/mocks"
 	"github.com/apache/incubator-devlake/plugins/core/dal"
 	"github.com/stretchr/testify/mock"
@@ -25,11 +26,18 @@ import (
 
 // DummySubTaskContext FIXME ...
 func DummySubTaskContext(db dal.Dal) *mocks.SubTaskContext {
+	return DummySubTaskContextWithContext(db, context.Background())
+}
+
+// DummySubTaskContextWithContext is DummySubTaskContext running with the given context, i.e. the one of the raw data
+// only mode
+func DummySubTaskContextWithContext(db dal.Dal, ctx context.Context) *mocks.SubTaskContext {
 	mockCtx := new(mocks.SubTaskContext)
 	mockCtx.On("GetDal").Return(db)
 	mockCtx.On("GetLogger").Return(DummyLogger())
 	mockCtx.On("SetProgress", mock.Anything, mock.Anything)
 	mockCtx.On("IncProgress", mock.Anything, mock.Anything)
 	mockCtx.On("GetName").Return("test")
+	mockCtx.On("GetContext").Return(ctx).Maybe()
 	return mockCtx
 }
@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unithelper
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// AssertIdempotent runs the subtask twice against the MemoryDal, and asserts that the second run leaves the rows of
+// the tables exactly as the first one did, i.e. an extraction re-run over the same raw data neither accumulates nor
+// loses tool rows
+func AssertIdempotent(t *testing.T, db *MemoryDal, run func() errors.Error, tables ...string) bool {
+	snapshot := func() map[string][]string {
+		snapshot := make(map[string][]string, len(tables))
+		for _, table := range tables {
+			for _, row := range db.Rows(table) {
+				blob, err := json.Marshal(row)
+				assert.Nil(t, err)
+				snapshot[table] = append(snapshot[table], string(blob))
+			}
+		}
+		return snapshot
+	}
+	if !assert.Nil(t, run(), "the first run failed") {
+		return false
+	}
+	first := snapshot()
+	if !assert.Nil(t, run(), "the second run failed") {
+		return false
+	}
+	second := snapshot()
+	ok := true
+	for _, table := range tables {
+		ok = assert.Len(t, second[table], len(first[table]), "rows of %s changed by the second run", table) && ok
+		ok = assert.ElementsMatch(t, first[table], second[table], "rows of %s changed by the second run", table) && ok
+	}
+	return ok
+}
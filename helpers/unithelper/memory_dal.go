@@ -0,0 +1,307 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unithelper
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/utils"
+	"gorm.io/gorm/schema"
+)
+
+var _ dal.Dal = (*MemoryDal)(nil)
+
+// MemoryDal is a dal.Dal keeping the tables in memory, for the unit tests of the subtasks reading and writing
+// records by the dal, i.e. the extractors. Only the subset of the dal used by the extractors is supported: rows
+// are filtered by the `column = ?` and `column IN ?` conditions of `Where` joined by `AND`, and CreateOrUpdate
+// upserts the rows by their primary keys as the databases do. The other methods panic
+type MemoryDal struct {
+	dal.Dal
+	mu     sync.Mutex
+	tables map[string][]interface{}
+}
+
+// NewMemoryDal creates a MemoryDal without any table
+func NewMemoryDal() *MemoryDal {
+	return &MemoryDal{tables: make(map[string][]interface{})}
+}
+
+// Insert appends the rows to the table as they are, i.e. the raw rows to be extracted
+func (d *MemoryDal) Insert(table string, rows ...interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tables[table] = append(d.tables[table], rows...)
+}
+
+// Rows returns the rows of the table in the order they were inserted
+func (d *MemoryDal) Rows(table string) []interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]interface{}(nil), d.tables[table]...)
+}
+
+// AutoMigrate does nothing, tables are created on their first rows
+func (d *MemoryDal) AutoMigrate(entity interface{}, clauses ...dal.Clause) errors.Error {
+	return nil
+}
+
+// Count counts the rows of the `From` table matching the `Where` clauses
+func (d *MemoryDal) Count(clauses ...dal.Clause) (int64, errors.Error) {
+	rows, err := d.query(clauses)
+	return int64(len(rows)), err
+}
+
+// Cursor iterates the rows of the `From` table matching the `Where` clauses, they are read by Fetch
+func (d *MemoryDal) Cursor(clauses ...dal.Clause) (dal.Rows, errors.Error) {
+	rows, err := d.query(clauses)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryRows{rows: rows, current: -1}, nil
+}
+
+// Fetch copies the current row of the cursor into dst, which must be a pointer to the type of the row
+func (d *MemoryDal) Fetch(cursor dal.Rows, dst interface{}) errors.Error {
+	rows := cursor.(*memoryRows)
+	reflect.ValueOf(dst).Elem().Set(reflect.Indirect(reflect.ValueOf(rows.rows[rows.current])))
+	return nil
+}
+
+// Create appends the entity, or the entities of a slice, to their table
+func (d *MemoryDal) Create(entity interface{}, clauses ...dal.Clause) errors.Error {
+	table, rows := d.entities(entity, clauses)
+	d.Insert(table, rows...)
+	return nil
+}
+
+// CreateOrUpdate upserts the entity, or the entities of a slice, by their primary keys
+func (d *MemoryDal) CreateOrUpdate(entity interface{}, clauses ...dal.Clause) errors.Error {
+	table, rows := d.entities(entity, clauses)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, row := range rows {
+		key := d.primaryKey(row)
+		replaced := false
+		for i, existing := range d.tables[table] {
+			if d.primaryKey(existing) == key {
+				d.tables[table][i] = row
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			d.tables[table] = append(d.tables[table], row)
+		}
+	}
+	return nil
+}
+
+// Delete deletes the rows of the table of the entity matching the `Where` clauses
+func (d *MemoryDal) Delete(entity interface{}, clauses ...dal.Clause) errors.Error {
+	table, _ := d.entities(entity, clauses)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	kept := make([]interface{}, 0, len(d.tables[table]))
+	for _, row := range d.tables[table] {
+		matched, err := matchRow(row, clauses)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			kept = append(kept, row)
+		}
+	}
+	d.tables[table] = kept
+	return nil
+}
+
+// GetPrimaryKeyFields returns the fields tagged by `primaryKey` as dalgorm does
+func (d *MemoryDal) GetPrimaryKeyFields(t reflect.Type) []reflect.StructField {
+	return utils.WalkFields(t, func(field *reflect.StructField) bool {
+		return strings.Contains(strings.ToLower(field.Tag.Get("gorm")), "primarykey")
+	})
+}
+
+// query returns the rows of the `From` table matching the `Where` clauses
+func (d *MemoryDal) query(clauses []dal.Clause) ([]interface{}, errors.Error) {
+	var table string
+	for _, c := range clauses {
+		if c.Type == dal.FromClause {
+			table = tableName(c.Data)
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var rows []interface{}
+	for _, row := range d.tables[table] {
+		matched, err := matchRow(row, clauses)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// entities returns the table and the rows of the entity, which is either a row or a slice of them
+func (d *MemoryDal) entities(entity interface{}, clauses []dal.Clause) (string, []interface{}) {
+	var rows []interface{}
+	v := reflect.ValueOf(entity)
+	if v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			rows = append(rows, v.Index(i).Interface())
+		}
+	} else {
+		rows = append(rows, entity)
+	}
+	for _, c := range clauses {
+		if c.Type == dal.FromClause {
+			return tableName(c.Data), rows
+		}
+	}
+	elem := v.Type()
+	if elem.Kind() == reflect.Slice {
+		elem = elem.Elem()
+	}
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return tableName(reflect.New(elem).Interface()), rows
+}
+
+// primaryKey returns the values of the primary key fields of the row joined
+func (d *MemoryDal) primaryKey(row interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	var values []string
+	for _, field := range d.GetPrimaryKeyFields(v.Type()) {
+		values = append(values, fmt.Sprint(v.FieldByName(field.Name).Interface()))
+	}
+	return strings.Join(values, ":")
+}
+
+func tableName(table interface{}) string {
+	switch t := table.(type) {
+	case string:
+		return t
+	case dal.Tabler:
+		return t.TableName()
+	}
+	panic(fmt.Sprintf("unsupported table %v", table))
+}
+
+// matchRow tells if the row matches all the `Where` clauses
+func matchRow(row interface{}, clauses []dal.Clause) (bool, errors.Error) {
+	columns := columnValues(reflect.Indirect(reflect.ValueOf(row)))
+	for _, c := range clauses {
+		if c.Type != dal.WhereClause {
+			continue
+		}
+		where := c.Data.(dal.DalClause)
+		conditions := strings.Split(where.Expr, " AND ")
+		if len(conditions) != len(where.Params) {
+			return false, errors.Default.New(fmt.Sprintf("unsupported condition %s", where.Expr))
+		}
+		for i, condition := range conditions {
+			parts := strings.Fields(condition)
+			if len(parts) != 3 || parts[2] != "?" {
+				return false, errors.Default.New(fmt.Sprintf("unsupported condition %s", condition))
+			}
+			value, ok := columns[parts[0]]
+			if !ok {
+				return false, errors.Default.New(fmt.Sprintf("unknown column %s", parts[0]))
+			}
+			var matched bool
+			switch strings.ToUpper(parts[1]) {
+			case "=":
+				matched = fmt.Sprint(value) == fmt.Sprint(where.Params[i])
+			case "IN":
+				params := reflect.ValueOf(where.Params[i])
+				for j := 0; j < params.Len() && !matched; j++ {
+					matched = fmt.Sprint(value) == fmt.Sprint(params.Index(j).Interface())
+				}
+			default:
+				return false, errors.Default.New(fmt.Sprintf("unsupported condition %s", condition))
+			}
+			if !matched {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// columnValues returns the values of the row by their column names, fields of the embedded structs included
+func columnValues(v reflect.Value) map[string]interface{} {
+	columns := make(map[string]interface{})
+	naming := schema.NamingStrategy{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for column, value := range columnValues(v.Field(i)) {
+				columns[column] = value
+			}
+			continue
+		}
+		column := naming.ColumnName("", field.Name)
+		for _, setting := range strings.Split(field.Tag.Get("gorm"), ";") {
+			if strings.HasPrefix(setting, "column:") {
+				column = strings.TrimPrefix(setting, "column:")
+			}
+		}
+		columns[column] = v.Field(i).Interface()
+	}
+	return columns
+}
+
+// memoryRows is the cursor of MemoryDal, it is read by MemoryDal.Fetch only
+type memoryRows struct {
+	rows    []interface{}
+	current int
+}
+
+func (r *memoryRows) Next() bool {
+	r.current++
+	return r.current < len(r.rows)
+}
+
+func (r *memoryRows) Close() error {
+	return nil
+}
+
+func (r *memoryRows) Scan(dest ...any) error {
+	return errors.Default.New("rows of MemoryDal are read by Fetch")
+}
+
+func (r *memoryRows) Columns() ([]string, error) {
+	return nil, errors.Default.New("rows of MemoryDal are read by Fetch")
+}
+
+func (r *memoryRows) ColumnTypes() ([]*sql.ColumnType, error) {
+	return nil, errors.Default.New("rows of MemoryDal are read by Fetch")
+}
@@ -31,6 +31,7 @@ var alreadyInBracketsRegex = regexp.MustCompile(`\[.*?]+`)
 type DefaultLogger struct {
 	log    *logrus.Logger
 	config *core.LoggerConfig
+	fields logrus.Fields
 }
 
 func NewDefaultLogger(log *logrus.Logger) (core.Logger, errors.Error) {
@@ -54,7 +55,11 @@ func (l *DefaultLogger) Log(level core.LogLevel, format string, a ...interface{}
 		if l.config.Prefix != "" {
 			msg = fmt.Sprintf("%s %s", l.config.Prefix, msg)
 		}
-		l.log.Log(logrus.Level(level), msg)
+		if len(l.fields) > 0 {
+			l.log.WithFields(l.fields).Log(logrus.Level(level), msg)
+		} else {
+			l.log.Log(logrus.Level(level), msg)
+		}
 	}
 }
 
@@ -107,7 +112,24 @@ func (l *DefaultLogger) Nested(newPrefix string) core.Logger {
 	return newLogger
 }
 
-func (l *DefaultLogger) getLogger(prefix string) (core.Logger, errors.Error) {
+func (l *DefaultLogger) WithFields(fields map[string]interface{}) core.Logger {
+	newLogger, err := l.getLogger(l.config.Prefix)
+	if err != nil {
+		l.Error(err, "error getting a new logger")
+		return l
+	}
+	merged := make(logrus.Fields, len(l.fields)+len(fields))
+	for name, value := range l.fields {
+		merged[name] = value
+	}
+	for name, value := range fields {
+		merged[name] = value
+	}
+	newLogger.fields = merged
+	return newLogger
+}
+
+func (l *DefaultLogger) getLogger(prefix string) (*DefaultLogger, errors.Error) {
 	newLogrus := logrus.New()
 	newLogrus.SetLevel(l.log.Level)
 	newLogrus.SetFormatter(l.log.Formatter)
@@ -118,6 +140,7 @@ func (l *DefaultLogger) getLogger(prefix string) (core.Logger, errors.Error) {
 			Path:   l.config.Path,
 			Prefix: prefix,
 		},
+		fields: l.fields,
 	}
 	return newLogger, nil
 }
@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newJsonLogger(buf *bytes.Buffer) *DefaultLogger {
+	log := logrus.New()
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.SetOutput(buf)
+	logger, _ := NewDefaultLogger(log)
+	return logger.(*DefaultLogger)
+}
+
+func TestWithFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	root := newJsonLogger(buf)
+	taskLogger := root.WithFields(map[string]interface{}{"connectionId": 1, "boardId": 2})
+	// the fields are inherited by nested loggers and overridden by the same names
+	subtaskLogger := taskLogger.Nested("collectEpics").WithFields(map[string]interface{}{"subtask": "collectEpics", "boardId": 3})
+	subtaskLogger.Info("collected %d epics", 10)
+
+	entry := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, " [collectEpics] collected 10 epics", entry["msg"])
+	assert.Equal(t, float64(1), entry["connectionId"])
+	assert.Equal(t, float64(3), entry["boardId"])
+	assert.Equal(t, "collectEpics", entry["subtask"])
+
+	// the original loggers are left as they were
+	buf.Reset()
+	taskLogger.Info("done")
+	entry = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, float64(2), entry["boardId"])
+	assert.NotContains(t, entry, "subtask")
+
+	buf.Reset()
+	root.Info("done")
+	assert.NotContains(t, buf.String(), "connectionId")
+}
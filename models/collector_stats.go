@@ -0,0 +1,42 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+)
+
+// CollectorStats is the cost of collecting a raw table within a subtask, for capacity planning
+type CollectorStats struct {
+	PipelineId  uint64 `json:"pipelineId" gorm:"primaryKey"`
+	TaskId      uint64 `json:"taskId" gorm:"primaryKey"`
+	SubtaskName string `json:"subtaskName" gorm:"primaryKey;type:varchar(255)"`
+	RawTable    string `json:"rawTable" gorm:"primaryKey;type:varchar(255)"`
+	Requests    int    `json:"requests"`
+	Bytes       int64  `json:"bytes"`
+	DurationMs  int64  `json:"durationMs"`
+	// InputBatches and InputDurationMs are the number of inputs fetched from the iterator of the collector and the
+	// time spent on it, i.e. reading a db cursor, telling whether a slow collection is bound by the db or the api
+	InputBatches    int       `json:"inputBatches"`
+	InputDurationMs int64     `json:"inputDurationMs"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+func (CollectorStats) TableName() string {
+	return "_devlake_collector_stats"
+}
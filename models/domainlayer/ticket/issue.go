@@ -51,6 +51,10 @@ type Issue struct {
 	Severity                string `gorm:"type:varchar(255)"`
 	Component               string `gorm:"type:varchar(255)"`
 	DeploymentId            string `gorm:"type:varchar(255)"`
+	// IsDeleted marks the issues deleted from the source they were collected from, DeletedDate is when the deletion
+	// was found. The issues are kept rather than removed so the metrics of the past remain the same
+	IsDeleted   bool
+	DeletedDate *time.Time
 }
 
 func (Issue) TableName() string {
@@ -61,6 +65,7 @@ const (
 	BUG         = "BUG"
 	REQUIREMENT = "REQUIREMENT"
 	INCIDENT    = "INCIDENT"
+	EPIC        = "EPIC"
 
 	TODO        = "TODO"
 	DONE        = "DONE"
@@ -0,0 +1,56 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+var _ core.MigrationScript = (*createCollectorStats)(nil)
+
+type collectorStats20221117 struct {
+	PipelineId  uint64 `gorm:"primaryKey"`
+	TaskId      uint64 `gorm:"primaryKey"`
+	SubtaskName string `gorm:"primaryKey;type:varchar(255)"`
+	RawTable    string `gorm:"primaryKey;type:varchar(255)"`
+	Requests    int
+	Bytes       int64
+	DurationMs  int64
+	CreatedAt   time.Time
+}
+
+func (collectorStats20221117) TableName() string {
+	return "_devlake_collector_stats"
+}
+
+type createCollectorStats struct{}
+
+func (*createCollectorStats) Up(basicRes core.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(collectorStats20221117{})
+}
+
+func (*createCollectorStats) Version() uint64 {
+	return 20221117000001
+}
+
+func (*createCollectorStats) Name() string {
+	return "Create collector stats table"
+}
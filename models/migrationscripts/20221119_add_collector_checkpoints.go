@@ -0,0 +1,55 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+var _ core.MigrationScript = (*createCollectorCheckpoints)(nil)
+
+type collectorCheckpoint20221119 struct {
+	RawTable  string `gorm:"primaryKey;type:varchar(255)"`
+	Params    string `gorm:"primaryKey;type:varchar(255)"`
+	PageHash  string `gorm:"primaryKey;type:varchar(32)"`
+	Url       string
+	ResumeKey string
+	Done      bool
+	CreatedAt time.Time
+}
+
+func (collectorCheckpoint20221119) TableName() string {
+	return "_devlake_collector_checkpoints"
+}
+
+type createCollectorCheckpoints struct{}
+
+func (*createCollectorCheckpoints) Up(basicRes core.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(collectorCheckpoint20221119{})
+}
+
+func (*createCollectorCheckpoints) Version() uint64 {
+	return 20221119000001
+}
+
+func (*createCollectorCheckpoints) Name() string {
+	return "Create collector checkpoints table"
+}
@@ -0,0 +1,50 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+var _ core.MigrationScript = (*addDeletedToIssues)(nil)
+
+type issue20221122 struct {
+	IsDeleted   bool
+	DeletedDate *time.Time
+}
+
+func (issue20221122) TableName() string {
+	return "issues"
+}
+
+type addDeletedToIssues struct{}
+
+func (*addDeletedToIssues) Up(basicRes core.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(&issue20221122{})
+}
+
+func (*addDeletedToIssues) Version() uint64 {
+	return 20221122000001
+}
+
+func (*addDeletedToIssues) Name() string {
+	return "add columns `is_deleted` and `deleted_date` at issues"
+}
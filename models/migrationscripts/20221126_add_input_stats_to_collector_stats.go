@@ -0,0 +1,48 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+var _ core.MigrationScript = (*addInputStatsToCollectorStats)(nil)
+
+type collectorStats20221126 struct {
+	InputBatches    int
+	InputDurationMs int64
+}
+
+func (collectorStats20221126) TableName() string {
+	return "_devlake_collector_stats"
+}
+
+type addInputStatsToCollectorStats struct{}
+
+func (*addInputStatsToCollectorStats) Up(basicRes core.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(&collectorStats20221126{})
+}
+
+func (*addInputStatsToCollectorStats) Version() uint64 {
+	return 20221126000001
+}
+
+func (*addInputStatsToCollectorStats) Name() string {
+	return "add columns `input_batches` and `input_duration_ms` at _devlake_collector_stats"
+}
@@ -0,0 +1,53 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+var _ core.MigrationScript = (*createScopeLocks)(nil)
+
+type scopeLock20221129 struct {
+	RawTable  string `gorm:"primaryKey;type:varchar(255)"`
+	Params    string `gorm:"primaryKey;type:varchar(255)"`
+	Holder    string `gorm:"type:varchar(255)"`
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (scopeLock20221129) TableName() string {
+	return "_devlake_scope_locks"
+}
+
+type createScopeLocks struct{}
+
+func (*createScopeLocks) Up(basicRes core.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(scopeLock20221129{})
+}
+
+func (*createScopeLocks) Version() uint64 {
+	return 20221129000001
+}
+
+func (*createScopeLocks) Name() string {
+	return "Create scope locks table"
+}
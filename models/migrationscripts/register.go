@@ -57,5 +57,11 @@ func All() []core.MigrationScript {
 		new(addSkipOnFail),
 		new(modifyCommitsDiffs),
 		new(addProjectTables),
+		new(createCollectorStats),
+		new(createCollectorCheckpoints),
+		new(addSubtaskResultsToTasks),
+		new(addDeletedToIssues),
+		new(addInputStatsToCollectorStats),
+		new(createScopeLocks),
 	}
 }
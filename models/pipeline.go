@@ -39,6 +39,10 @@ type Pipeline struct {
 	Message       string         `json:"message"`
 	SpentSeconds  int            `json:"spentSeconds"`
 	Stage         int            `json:"stage"`
+	// CollectorStats is only loaded for the pipeline detail
+	CollectorStats []*CollectorStats `json:"collectorStats,omitempty" gorm:"-"`
+	// SubtaskResults is only loaded for the pipeline detail
+	SubtaskResults []*SubtaskResult `json:"subtaskResults,omitempty" gorm:"-"`
 }
 
 // We use a 2D array because the request body must be an array of a set of tasks
@@ -40,6 +40,11 @@ type TaskProgressDetail struct {
 	FinishedRecords  int    `json:"finishedRecords"`
 	SubTaskName      string `json:"subTaskName"`
 	SubTaskNumber    int    `json:"subTaskNumber"`
+	// below are only available when the current subtask is a collector
+	RawTable         string `json:"rawTable"`
+	FinishedPages    int    `json:"finishedPages"`
+	TotalPages       int    `json:"totalPages"`
+	CollectedRecords int    `json:"collectedRecords"`
 }
 
 type Task struct {
@@ -60,6 +65,20 @@ type Task struct {
 	FinishedAt    *time.Time `json:"finishedAt" gorm:"index"`
 	SpentSeconds  int        `json:"spentSeconds"`
 	SkipOnFail    bool       `json:"-"`
+	// SubtaskResults are the results reported by the subtasks, in the form of []SubtaskResult
+	SubtaskResults datatypes.JSON `json:"subtaskResults"`
+}
+
+// SubtaskResult is the result reported by a subtask, i.e. the number of records collected
+type SubtaskResult struct {
+	TaskId      uint64 `json:"taskId,omitempty"`
+	SubtaskName string `json:"subtaskName"`
+	Records     int    `json:"records"`
+	Pages       int    `json:"pages"`
+	// DryRunRequests is the number of requests the subtask would issue, it is reported by dry runs only
+	DryRunRequests int `json:"dryRunRequests,omitempty"`
+	// Warnings are reported by subtasks which succeeded with outcomes likely to be wrong
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type NewTask struct {
@@ -45,6 +45,10 @@ type Logger interface {
 	// Nested return a new logger instance. `name` is the extra prefix to be prepended to each message. Leaving it blank
 	// will add no additional prefix. The new Logger will inherit the properties of the original.
 	Nested(name string) Logger
+	// WithFields return a new logger instance attaching the fields to each message as structured data, i.e. the ids
+	// of the connection and the scope being collected. The new Logger inherits the fields of the original, which are
+	// overridden by the given ones of the same names.
+	WithFields(fields map[string]interface{}) Logger
 	// GetConfig Returns a copy of the LoggerConfig associated with this Logger. This is meant to be used by the framework.
 	GetConfig() *LoggerConfig
 	// SetStream sets the output of this Logger. This is meant to be used by the framework.
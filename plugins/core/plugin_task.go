@@ -19,6 +19,8 @@ package core
 
 import (
 	"context"
+	"time"
+
 	"github.com/apache/incubator-devlake/errors"
 )
 
@@ -30,6 +32,7 @@ const (
 	SubTaskSetProgress
 	SubTaskIncProgress
 	SetCurrentSubTask
+	CollectorProgress
 )
 
 type RunningProgress struct {
@@ -38,6 +41,9 @@ type RunningProgress struct {
 	Total         int
 	SubTaskName   string
 	SubTaskNumber int
+	// RawTable and Records are only available for CollectorProgress
+	RawTable string
+	Records  int
 }
 
 // ExecContext This interface define all resources that needed for task/subtask execution
@@ -56,6 +62,45 @@ type SubTaskContext interface {
 	TaskContext() TaskContext
 }
 
+// CollectorProgressReporter is an optional interface of SubTaskContext, it accepts the detailed progress of
+// collectors, i.e. pages completed out of total pages (-1 if unknown) and number of records collected
+type CollectorProgressReporter interface {
+	ReportCollectorProgress(rawTable string, pages int, totalPages int, records int)
+}
+
+// CollectorStats is the cost of a collection, i.e. number of requests made, bytes downloaded and the wall-clock
+// duration, along with the number of inputs fetched from the iterator and the time spent fetching them
+type CollectorStats struct {
+	RawTable      string
+	Requests      int
+	Bytes         int64
+	Duration      time.Duration
+	InputBatches  int
+	InputDuration time.Duration
+}
+
+// CollectorStatsReporter is an optional interface of SubTaskContext, it accepts the stats of collectors once they
+// are finished, stats of the same raw table are added up
+type CollectorStatsReporter interface {
+	ReportCollectorStats(stats CollectorStats)
+}
+
+// SubTaskResult is the outcome of a subtask, i.e. the number of records collected and pages fetched by collectors
+type SubTaskResult struct {
+	Records int
+	Pages   int
+	// DryRunRequests is the number of requests the collectors would issue, counted in dry-run mode
+	DryRunRequests int
+	// Warnings tell about outcomes which are likely wrong without failing the subtask, i.e. nothing was collected
+	Warnings []string
+}
+
+// SubTaskResultReporter is an optional interface of SubTaskContext, it accepts the results reported by the subtask,
+// results reported multiple times are added up
+type SubTaskResultReporter interface {
+	ReportSubTaskResult(result SubTaskResult)
+}
+
 // TaskContext This interface define all resources that needed for task execution
 type TaskContext interface {
 	ExecContext
@@ -94,6 +139,10 @@ type SubTaskMeta struct {
 	EnabledByDefault bool
 	Description      string
 	DomainTypes      []string
+	// DependsOn lists the names of the subtasks which must run before this one, i.e. the collector of the raw
+	// table an extractor reads. The framework runs enabled subtasks after their dependencies and refuses to run
+	// an enabled subtask of which any dependency is disabled
+	DependsOn []string
 }
 
 // PluginTask Implement this interface to let framework run tasks for you
@@ -109,3 +158,21 @@ type CloseablePluginTask interface {
 	PluginTask
 	Close(taskCtx TaskContext) errors.Error
 }
+
+// SubTaskValidator is an optional interface of PluginTask, it validates the enabled subtasks against the data
+// prepared for them, i.e. the raw fields requested by the collectors against the ones consumed by the extractors.
+// The task fails before running any subtask if they don't match
+type SubTaskValidator interface {
+	ValidateSubTasks(taskData interface{}, subtasks map[string]bool) errors.Error
+}
+
+// SubTaskFilter is an optional interface of PluginTask, it selects the subtasks applying to the type of the
+// connection of a task, i.e. the subtasks of epics don't apply to connections without epics. The subtasks left out
+// are disabled unless `Required`, the ones kept are enabled by their EnabledByDefault. It is consulted once the
+// task data was prepared, and only if the user didn't specify the subtasks to run
+type SubTaskFilter interface {
+	// GetConnectionType returns the type of the connection of the task data returned by PrepareTaskData
+	GetConnectionType(taskData interface{}) string
+	// FilterSubTasks returns the subtasks of SubTaskMetas applying to the connection type
+	FilterSubTasks(connectionType string) []SubTaskMeta
+}
@@ -0,0 +1,49 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import "context"
+
+type shutdownKey struct{}
+
+// WithShutdown attaches the shutdown signal of the process to the context of a task. Unlike cancelling the context,
+// which aborts the requests in flight, the signal asks the subtasks to stop starting new work and to save what they
+// have done so far, so the task is resumed rather than repeated by the next run
+func WithShutdown(ctx context.Context, shutdown <-chan struct{}) context.Context {
+	return context.WithValue(ctx, shutdownKey{}, shutdown)
+}
+
+// ShutdownSignal returns the channel closed once the process is shutting down, nil is returned if the context
+// carries no signal, which blocks forever
+func ShutdownSignal(ctx context.Context) <-chan struct{} {
+	if ctx == nil {
+		return nil
+	}
+	shutdown, _ := ctx.Value(shutdownKey{}).(<-chan struct{})
+	return shutdown
+}
+
+// IsShuttingDown tells if the process carried by the context is shutting down
+func IsShuttingDown(ctx context.Context) bool {
+	select {
+	case <-ShutdownSignal(ctx):
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/helper/common"
+)
+
+// adaptiveConcurrencyPollInterval is how often a request waiting for a slot checks if it should give up
+const adaptiveConcurrencyPollInterval = 100 * time.Millisecond
+
+// adaptiveConcurrencyLatencyFactor is how many times slower than the smoothed latency a response has to be to be
+// taken for a latency spike
+const adaptiveConcurrencyLatencyFactor = 3
+
+// adaptiveConcurrencyWarmup is the number of responses the smoothed latency is learned from before latency spikes
+// are told
+const adaptiveConcurrencyWarmup = 5
+
+// AdaptiveConcurrency limits the number of requests in flight in the AIMD way: the limit starts at `min` and grows
+// by 1 every `limit` successful responses, i.e. about once per round trip. It is halved on a throttled response
+// (429/503) or a latency spike, at most once per round trip, so the responses of the requests issued before the
+// back off don't halve it again. The limit is kept within [min, max]
+type AdaptiveConcurrency struct {
+	mu            sync.Mutex
+	min           int
+	max           int
+	limit         float64
+	inFlight      int
+	latency       time.Duration
+	samples       int
+	lastDecreased time.Time
+	released      chan struct{}
+}
+
+// NewAdaptiveConcurrency creates a controller limiting the concurrency within [min, max], min is 1 if less than 1
+// and max is min if less than min
+func NewAdaptiveConcurrency(min int, max int) *AdaptiveConcurrency {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &AdaptiveConcurrency{
+		min:      min,
+		max:      max,
+		limit:    float64(min),
+		released: make(chan struct{}),
+	}
+}
+
+// Acquire blocks till the number of requests in flight is under the limit, and returns the start of the request
+// to be passed to Release. It gives up and returns false once ctx is done or `aborted` returns true, which is
+// polled while waiting, i.e. the HasError of the api client, since the slot of a failed request is never released
+func (c *AdaptiveConcurrency) Acquire(ctx context.Context, aborted func() bool) (time.Time, bool) {
+	for {
+		c.mu.Lock()
+		if c.inFlight < int(c.limit) {
+			c.inFlight++
+			c.mu.Unlock()
+			return time.Now(), true
+		}
+		released := c.released
+		c.mu.Unlock()
+		if aborted != nil && aborted() {
+			return time.Time{}, false
+		}
+		var done <-chan struct{}
+		if ctx != nil {
+			done = ctx.Done()
+		}
+		select {
+		case <-released:
+		case <-done:
+			return time.Time{}, false
+		case <-time.After(adaptiveConcurrencyPollInterval):
+		}
+	}
+}
+
+// Release frees the slot of the request started at `startedAt` and adapts the limit to its response
+func (c *AdaptiveConcurrency) Release(startedAt time.Time, statusCode int) {
+	now := time.Now()
+	latency := now.Sub(startedAt)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+	// wake up all waiting requests, they compete for the slot again
+	close(c.released)
+	c.released = make(chan struct{})
+	if statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable {
+		c.decrease(now)
+		return
+	}
+	if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+		return
+	}
+	if c.samples >= adaptiveConcurrencyWarmup && latency > c.latency*adaptiveConcurrencyLatencyFactor {
+		c.decrease(now)
+		return
+	}
+	// the latency is smoothed by the exponential moving average
+	if c.samples == 0 {
+		c.latency = latency
+	} else {
+		c.latency += (latency - c.latency) / 8
+	}
+	c.samples++
+	c.limit += 1 / c.limit
+	if c.limit > float64(c.max) {
+		c.limit = float64(c.max)
+	}
+}
+
+// Throttled halves the limit for a throttled response, i.e. one retried by the api client silently
+func (c *AdaptiveConcurrency) Throttled(res *http.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.decrease(time.Now())
+}
+
+// Limit returns the current limit of the requests in flight
+func (c *AdaptiveConcurrency) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int(c.limit)
+}
+
+// decrease halves the limit unless it was halved within the last round trip
+func (c *AdaptiveConcurrency) decrease(now time.Time) {
+	if now.Sub(c.lastDecreased) < c.latency {
+		return
+	}
+	c.lastDecreased = now
+	c.limit /= 2
+	if c.limit < float64(c.min) {
+		c.limit = float64(c.min)
+	}
+}
+
+// concurrencySlotKey is the key of the concurrencySlot in the context of a request
+type concurrencySlotKey struct{}
+
+// concurrencySlot is the slot of a request acquired from an AdaptiveConcurrency, released once by its response
+type concurrencySlot struct {
+	once        sync.Once
+	concurrency *AdaptiveConcurrency
+	startedAt   time.Time
+}
+
+func acquireConcurrencySlot(ctx context.Context, concurrency *AdaptiveConcurrency, aborted func() bool) (*concurrencySlot, bool) {
+	startedAt, ok := concurrency.Acquire(ctx, aborted)
+	if !ok {
+		return nil, false
+	}
+	return &concurrencySlot{concurrency: concurrency, startedAt: startedAt}, true
+}
+
+func (slot *concurrencySlot) release(statusCode int) {
+	slot.once.Do(func() {
+		slot.concurrency.Release(slot.startedAt, statusCode)
+	})
+}
+
+// releaseConcurrencySlot releases the slot carried by the request of the response before calling `next`. The slot
+// is released by the afterResponse since responses ignored by it never make it to the handler of the request
+func releaseConcurrencySlot(next common.ApiClientAfterResponse) common.ApiClientAfterResponse {
+	return func(res *http.Response) errors.Error {
+		if res.Request != nil {
+			if slot, ok := res.Request.Context().Value(concurrencySlotKey{}).(*concurrencySlot); ok {
+				slot.release(res.StatusCode)
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		return next(res)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// simulateAdaptiveConcurrency issues requests from `workers` goroutines through the controller for `duration`, the
+// server responds with 429 if more than `capacity` requests are in flight. The limits observed in the second half
+// and the share of throttled responses in it are returned
+func simulateAdaptiveConcurrency(c *AdaptiveConcurrency, workers int, capacity int32, duration time.Duration) ([]int, float64) {
+	var inFlight int32
+	var mu sync.Mutex
+	var limits []int
+	var requests, throttled int
+	started := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Since(started) < duration {
+				startedAt, ok := c.Acquire(nil, nil)
+				if !ok {
+					return
+				}
+				status := http.StatusOK
+				if atomic.AddInt32(&inFlight, 1) > capacity {
+					status = http.StatusTooManyRequests
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&inFlight, -1)
+				c.Release(startedAt, status)
+				if time.Since(started) < duration/2 {
+					continue
+				}
+				mu.Lock()
+				limits = append(limits, c.Limit())
+				requests++
+				if status == http.StatusTooManyRequests {
+					throttled++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return limits, float64(throttled) / float64(requests)
+}
+
+func TestAdaptiveConcurrencySettlesBelowThrottling(t *testing.T) {
+	c := NewAdaptiveConcurrency(1, 50)
+	limits, throttledShare := simulateAdaptiveConcurrency(c, 60, 8, 2*time.Second)
+	assert.NotEmpty(t, limits)
+	sum, highest := 0, 0
+	for _, limit := range limits {
+		sum += limit
+		if limit > highest {
+			highest = limit
+		}
+	}
+	// the limit oscillates around the capacity rather than ramping up to the max
+	assert.Less(t, highest, 16)
+	assert.GreaterOrEqual(t, highest, 4)
+	assert.Less(t, float64(sum)/float64(len(limits)), 8.0)
+	assert.Less(t, throttledShare, 0.2)
+}
+
+func TestAdaptiveConcurrencyRampsUpToMax(t *testing.T) {
+	c := NewAdaptiveConcurrency(2, 4)
+	assert.Equal(t, 2, c.Limit())
+	simulateAdaptiveConcurrency(c, 8, 100, 300*time.Millisecond)
+	assert.Equal(t, 4, c.Limit())
+
+	// throttled responses retried by the api client halve the limit, down to the min
+	c.Throttled(nil)
+	assert.Equal(t, 2, c.Limit())
+}
+
+func TestAdaptiveConcurrencyAcquireAborted(t *testing.T) {
+	c := NewAdaptiveConcurrency(1, 1)
+	_, ok := c.Acquire(nil, nil)
+	assert.True(t, ok)
+	// the slot of a failed request is never released, the waiting request gives up once the client has an error
+	_, ok = c.Acquire(nil, func() bool { return true })
+	assert.False(t, ok)
+}
+
+func TestReleaseConcurrencySlot(t *testing.T) {
+	c := NewAdaptiveConcurrency(1, 1)
+	slot, ok := acquireConcurrencySlot(nil, c, nil)
+	assert.True(t, ok)
+	req, _ := http.NewRequestWithContext(context.WithValue(context.Background(), concurrencySlotKey{}, slot), http.MethodGet, "http://localhost", nil)
+	afterResponse := releaseConcurrencySlot(nil)
+	// the slot is released once even if the response was retried
+	assert.Nil(t, afterResponse(&http.Response{StatusCode: http.StatusOK, Request: req}))
+	assert.Nil(t, afterResponse(&http.Response{StatusCode: http.StatusOK, Request: req}))
+	assert.Equal(t, 0, c.inFlight)
+}
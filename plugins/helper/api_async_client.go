@@ -43,6 +43,11 @@ type ApiAsyncClient struct {
 	maxRetry     int
 	scheduler    *WorkerScheduler
 	numOfWorkers int
+	requests     int
+	duration     time.Duration
+	sharedLimit  *SharedRateLimiter
+	// sharedWorkers is the capacity of the FairScheduler of the process set by API_SHARED_WORKERS, 0 if disabled
+	sharedWorkers int
 }
 
 const defaultTimeout = 120 * time.Second
@@ -59,6 +64,19 @@ func CreateAsyncApiClient(
 		return nil, errors.BadInput.Wrap(err, "failed to parse API_RETRY")
 	}
 
+	// requests throttled by 429/503 are retried by the api client itself, honoring the `Retry-After` header
+	throttledRetry, err := utils.StrToIntOr(taskCtx.GetConfig("API_THROTTLED_RETRY"), 3)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to parse API_THROTTLED_RETRY")
+	}
+	apiClient.SetMaxThrottledRetry(throttledRetry)
+
+	// the workers shared fairly by the clients of the process, see ShareWorkers
+	sharedWorkers, err := utils.StrToIntOr(taskCtx.GetConfig("API_SHARED_WORKERS"), 0)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to parse API_SHARED_WORKERS")
+	}
+
 	timeoutConf := taskCtx.GetConfig("API_TIMEOUT")
 	if timeoutConf != "" {
 		// override timeout value if API_TIMEOUT is provided
@@ -120,13 +138,48 @@ func CreateAsyncApiClient(
 
 	// finally, wrap around api client with async sematic
 	return &ApiAsyncClient{
-		apiClient,
-		retry,
-		scheduler,
-		numOfWorkers,
+		ApiClient:     apiClient,
+		maxRetry:      retry,
+		scheduler:     scheduler,
+		numOfWorkers:  numOfWorkers,
+		requests:      requests,
+		duration:      duration,
+		sharedWorkers: sharedWorkers,
 	}, nil
 }
 
+// ShareRateLimit makes the client share its rate limit with all clients of the same key within the process, i.e.
+// the clients of a connection created by tasks running in parallel, so the aggregate rate of their requests
+// respects the limit of the connection. Every request, retries included, waits for the shared limiter in addition
+// to the scheduler of the client
+func (apiClient *ApiAsyncClient) ShareRateLimit(key string) errors.Error {
+	limiter, err := GetSharedRateLimiter(key, apiClient.requests, apiClient.duration)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to share the rate limit of %s", key))
+	}
+	apiClient.sharedLimit = limiter
+	return nil
+}
+
+// ShareWorkers makes the requests of the client wait for a worker of the FairScheduler of the process, which is
+// shared fairly among the keys by their weights, i.e. the connections by their priorities, so the many requests of a
+// huge collection don't hold back the collections of the other connections. A worker is taken by every attempt of a
+// request and held till its response body was read, neither while waiting to retry a throttled request nor while
+// the response is handled. Nothing is shared unless API_SHARED_WORKERS is set
+func (apiClient *ApiAsyncClient) ShareWorkers(key string, weight int) errors.Error {
+	if apiClient.sharedWorkers <= 0 {
+		return nil
+	}
+	scheduler, err := GetFairScheduler(apiClient.sharedWorkers)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to share the workers with %s", key))
+	}
+	apiClient.workers = scheduler
+	apiClient.workersKey = key
+	apiClient.workersWeight = weight
+	return nil
+}
+
 // GetMaxRetry returns the maximum retry attempts for a request
 func (apiClient *ApiAsyncClient) GetMaxRetry() int {
 	return apiClient.maxRetry
@@ -148,6 +201,19 @@ func (apiClient *ApiAsyncClient) DoAsync(
 	header http.Header,
 	handler common.ApiAsyncCallback,
 	retry int,
+) {
+	apiClient.doAsync(apiClient.ctx, method, path, query, body, header, handler, retry)
+}
+
+func (apiClient *ApiAsyncClient) doAsync(
+	ctx context.Context,
+	method string,
+	path string,
+	query url.Values,
+	body interface{},
+	header http.Header,
+	handler common.ApiAsyncCallback,
+	retry int,
 ) {
 	var request func() errors.Error
 	request = func() errors.Error {
@@ -155,14 +221,20 @@ func (apiClient *ApiAsyncClient) DoAsync(
 		var res *http.Response
 		var respBody []byte
 
+		if apiClient.sharedLimit != nil {
+			e := apiClient.sharedLimit.Wait(ctx)
+			if e != nil {
+				return e
+			}
+		}
 		apiClient.logger.Debug("endpoint: %s  method: %s  header: %s  body: %s query: %s", path, method, header, body, query)
-		res, err = apiClient.Do(method, path, query, body, header)
+		res, err = apiClient.DoWithContext(ctx, method, path, query, body, header)
 		// make sure response body is read successfully, or we might have to retry
 		if err == nil {
-			// make sure response.Body stream will be closed to avoid running out of file handle
-			defer func(readCloser io.ReadCloser) { _ = readCloser.Close() }(res.Body)
-			// replace NetworkStream with MemoryBuffer
+			// replace NetworkStream with MemoryBuffer, the stream is closed right away to avoid running out of file
+			// handle, and to let the shared worker go before the response is handled
 			respBody, err = io.ReadAll(res.Body)
+			_ = res.Body.Close()
 			if err == nil {
 				res.Body = io.NopCloser(bytes.NewBuffer(respBody))
 			}
@@ -176,7 +248,8 @@ func (apiClient *ApiAsyncClient) DoAsync(
 		// check
 		needRetry := false
 		if err != nil {
-			needRetry = true
+			// the throttled retries of the client are used up, retrying again would only multiply them
+			needRetry = !errors.Is(err, ErrThrottledRetryExceeded)
 		} else if res.StatusCode >= HttpMinStatusRetryCode {
 			needRetry = true
 			err = errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("Http DoAsync error: %s", body))
@@ -185,7 +258,9 @@ func (apiClient *ApiAsyncClient) DoAsync(
 		//  if it needs retry, check and retry
 		if needRetry {
 			// check whether we still have retry times and not error from handler and canceled error
-			if retry < apiClient.maxRetry && err != context.Canceled {
+			// retrying is pointless once the connection is known to be unhealthy
+			breaker := apiClient.GetCircuitBreaker()
+			if retry < apiClient.maxRetry && err != context.Canceled && (ctx == nil || ctx.Err() == nil) && (breaker == nil || !breaker.IsOpen()) {
 				apiClient.logger.Warn(err, "retry #%d calling %s", retry, path)
 				retry++
 				apiClient.scheduler.NextTick(func() errors.Error {
@@ -197,14 +272,20 @@ func (apiClient *ApiAsyncClient) DoAsync(
 		}
 
 		if err != nil {
-			err = errors.Default.Wrap(err, fmt.Sprintf("retry exceeded %d times calling %s", retry, path))
+			request := newRequestErrorData(method, path, query, res)
+			err = errors.Default.Wrap(err, fmt.Sprintf("retry exceeded %d times calling %s", retry, request), errors.WithData(request))
 			apiClient.logger.Error(err, "")
 			return errors.Convert(err)
 		}
 
 		// it is important to let handler have a chance to handle error, or it can hang indefinitely
 		// when error occurs
-		return handler(res)
+		e := handler(res)
+		if e != nil {
+			request := newRequestErrorData(method, path, query, res)
+			return errors.Default.Wrap(e, fmt.Sprintf("failed to handle the response of %s", request), errors.WithData(request))
+		}
+		return nil
 	}
 	apiClient.scheduler.SubmitBlocking(request)
 }
@@ -230,6 +311,30 @@ func (apiClient *ApiAsyncClient) DoPostAsync(
 	apiClient.DoAsync(http.MethodPost, path, query, body, header, handler, 0)
 }
 
+// DoGetAsyncWithContext enqueues an api get request like DoGetAsync, the request is bound to the given context, so
+// it could be aborted separately from other requests of the client
+func (apiClient *ApiAsyncClient) DoGetAsyncWithContext(
+	ctx context.Context,
+	path string,
+	query url.Values,
+	header http.Header,
+	handler common.ApiAsyncCallback,
+) {
+	apiClient.doAsync(ctx, http.MethodGet, path, query, nil, header, handler, 0)
+}
+
+// DoPostAsyncWithContext enqueues an api post request like DoPostAsync, bound to the given context
+func (apiClient *ApiAsyncClient) DoPostAsyncWithContext(
+	ctx context.Context,
+	path string,
+	query url.Values,
+	body interface{},
+	header http.Header,
+	handler common.ApiAsyncCallback,
+) {
+	apiClient.doAsync(ctx, http.MethodPost, path, query, body, header, handler, 0)
+}
+
 // WaitAsync blocks until all async requests were done
 func (apiClient *ApiAsyncClient) WaitAsync() errors.Error {
 	return apiClient.scheduler.Wait()
@@ -268,4 +373,16 @@ type RateLimitedApiClient interface {
 	Release()
 }
 
+// ContextualApiClient is implemented by the RateLimitedApiClient able to bind requests to contexts other than its own
+type ContextualApiClient interface {
+	DoGetAsyncWithContext(ctx context.Context, path string, query url.Values, header http.Header, handler common.ApiAsyncCallback)
+	DoPostAsyncWithContext(ctx context.Context, path string, query url.Values, body interface{}, header http.Header, handler common.ApiAsyncCallback)
+}
+
+// throttleObservableApiClient is implemented by the api clients able to report the throttled responses they retry
+type throttleObservableApiClient interface {
+	SetThrottleObserver(observer func(res *http.Response))
+}
+
 var _ RateLimitedApiClient = (*ApiAsyncClient)(nil)
+var _ ContextualApiClient = (*ApiAsyncClient)(nil)
@@ -0,0 +1,135 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/apache/incubator-devlake/errors"
+)
+
+// DefaultCircuitBreakerThreshold is the number of consecutive authentication failures tripping a circuit breaker
+const DefaultCircuitBreakerThreshold = 5
+
+// CircuitBreaker short-circuits the requests of all api clients sharing it, i.e. clients of the same connection,
+// once `threshold` consecutive requests were rejected with 401. A revoked credential would otherwise be tried by
+// every request of every subtask, which wastes time and might get the account locked. 403 is not counted since it is
+// returned for the resources the credential has no permission to, which callers might recover from, i.e. by
+// collecting the issues without their changelogs. Any other response resets the count, and an open breaker stays
+// open till it is reset explicitly, i.e. by testing the connection
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	key       string
+	threshold int
+	failures  int
+	open      bool
+}
+
+// NewCircuitBreaker creates a breaker tripped by `threshold` consecutive authentication failures
+func NewCircuitBreaker(key string, threshold int) *CircuitBreaker {
+	breaker := &CircuitBreaker{key: key}
+	breaker.SetThreshold(threshold)
+	return breaker
+}
+
+// SetThreshold changes the number of consecutive failures tripping the breaker, DefaultCircuitBreakerThreshold is
+// used if it is less than 1
+func (b *CircuitBreaker) SetThreshold(threshold int) {
+	if threshold < 1 {
+		threshold = DefaultCircuitBreakerThreshold
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.threshold = threshold
+}
+
+// Allow returns the error short-circuiting the request if the breaker is open
+func (b *CircuitBreaker) Allow() errors.Error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return nil
+	}
+	return errors.Unauthorized.New(fmt.Sprintf(
+		"connection %s is unhealthy, %d consecutive requests were rejected with 401, please check the credential and test the connection",
+		b.key, b.failures,
+	))
+}
+
+// Record counts the response, the breaker is tripped once the threshold is reached
+func (b *CircuitBreaker) Record(res *http.Response) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if res.StatusCode != http.StatusUnauthorized {
+		if !b.open {
+			b.failures = 0
+		}
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.open = true
+	}
+}
+
+// IsOpen returns true if the breaker was tripped
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+// Reset closes the breaker and clears the count
+func (b *CircuitBreaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.open = false
+}
+
+var circuitBreakers = struct {
+	sync.Mutex
+	breakers map[string]*CircuitBreaker
+}{breakers: make(map[string]*CircuitBreaker)}
+
+// GetCircuitBreaker returns the breaker of `key` within the process, i.e. `jira:1` for the connection 1 of jira, it is
+// created on the first call and its threshold is updated by the following ones
+func GetCircuitBreaker(key string, threshold int) *CircuitBreaker {
+	circuitBreakers.Lock()
+	defer circuitBreakers.Unlock()
+	breaker, ok := circuitBreakers.breakers[key]
+	if ok {
+		breaker.SetThreshold(threshold)
+		return breaker
+	}
+	breaker = NewCircuitBreaker(key, threshold)
+	circuitBreakers.breakers[key] = breaker
+	return breaker
+}
+
+// ResetCircuitBreaker resets the breaker of `key` if there is one
+func ResetCircuitBreaker(key string) {
+	circuitBreakers.Lock()
+	breaker, ok := circuitBreakers.breakers[key]
+	circuitBreakers.Unlock()
+	if ok {
+		breaker.Reset()
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiClientCircuitBreaker(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	key := "test:circuit-breaker"
+	defer ResetCircuitBreaker(key)
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	apiClient.ShareCircuitBreaker(key, 3)
+	// another client of the same connection shares the breaker
+	otherClient := &ApiClient{}
+	otherClient.Setup(server.URL, nil, 10*time.Second)
+	otherClient.ShareCircuitBreaker(key, 3)
+
+	for i := 0; i < 3; i++ {
+		res, err := apiClient.Get("whatever", nil, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	}
+	assert.True(t, apiClient.GetCircuitBreaker().IsOpen())
+
+	// requests fail fast without hitting the server
+	for _, client := range []*ApiClient{apiClient, otherClient} {
+		res, err := client.Get("whatever", nil, nil)
+		assert.Nil(t, res)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, errors.Unauthorized, err.GetType())
+			assert.Contains(t, err.Error(), "connection test:circuit-breaker is unhealthy")
+		}
+	}
+	assert.Equal(t, 3, hits)
+
+	// testing the connection resets the breaker
+	ResetCircuitBreaker(key)
+	_, err := otherClient.Get("whatever", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, hits)
+	assert.False(t, otherClient.GetCircuitBreaker().IsOpen())
+}
+
+func TestCircuitBreakerResetBySuccess(t *testing.T) {
+	breaker := NewCircuitBreaker("test", 2)
+	breaker.Record(&http.Response{StatusCode: http.StatusUnauthorized})
+	breaker.Record(&http.Response{StatusCode: http.StatusOK})
+	breaker.Record(&http.Response{StatusCode: http.StatusUnauthorized})
+	assert.False(t, breaker.IsOpen())
+	assert.Nil(t, breaker.Allow())
+	breaker.Record(&http.Response{StatusCode: http.StatusUnauthorized})
+	assert.True(t, breaker.IsOpen())
+	assert.NotNil(t, breaker.Allow())
+}
+
+func TestCircuitBreakerIgnoresForbidden(t *testing.T) {
+	// 403 is returned for the resources the credential has no permission to, the credential itself is fine
+	breaker := NewCircuitBreaker("test", 2)
+	breaker.Record(&http.Response{StatusCode: http.StatusUnauthorized})
+	for i := 0; i < 5; i++ {
+		breaker.Record(&http.Response{StatusCode: http.StatusForbidden})
+	}
+	assert.False(t, breaker.IsOpen())
+	breaker.Record(&http.Response{StatusCode: http.StatusUnauthorized})
+	assert.False(t, breaker.IsOpen())
+	breaker.Record(&http.Response{StatusCode: http.StatusUnauthorized})
+	assert.True(t, breaker.IsOpen())
+}
@@ -18,17 +18,25 @@ limitations under the License.
 package helper
 
 import (
+	"bufio"
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"github.com/apache/incubator-devlake/errors"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -40,6 +48,16 @@ import (
 // ErrIgnoreAndContinue is a error which should be ignored
 var ErrIgnoreAndContinue = errors.Default.New("ignore and continue")
 
+// ErrThrottledRetryExceeded is wrapped by the error of a request still throttled after all the retries of the client,
+// the retries are used up already, so it is not retried again by ApiAsyncClient
+var ErrThrottledRetryExceeded = errors.Default.New("throttled retry exceeded")
+
+// throttledRetryBackoff is the wait before the first retry of a throttled request without `Retry-After` header, it
+// doubles on every attempt up to maxThrottledRetryBackoff
+var throttledRetryBackoff = time.Second
+
+const maxThrottledRetryBackoff = time.Minute
+
 // ApiClientGetter will be used for uint test
 type ApiClientGetter interface {
 	Get(
@@ -58,6 +76,20 @@ type ApiClient struct {
 	afterResponse common.ApiClientAfterResponse
 	ctx           context.Context
 	logger        core.Logger
+	// maxThrottledRetry is the max number of retries of a request throttled by 429/503, 0 to disable
+	maxThrottledRetry int
+	// breaker short-circuits the requests after repeated authentication failures, nil to disable
+	breaker *CircuitBreaker
+	// redactor redacts the records collected through the client before they are saved, nil to disable
+	redactor *JsonRedactor
+	// throttleObserver is called with every throttled response, including the ones retried silently
+	throttleObserver func(res *http.Response)
+	// requestSigner signs every attempt of a request right before it is sent, nil to disable
+	requestSigner common.ApiClientRequestSigner
+	// workers is the FairScheduler every attempt of a request takes a worker of, nil to disable, see ShareWorkers
+	workers       *FairScheduler
+	workersKey    string
+	workersWeight int
 }
 
 // NewApiClient FIXME ...
@@ -95,7 +127,24 @@ func NewApiClient(
 		timeout,
 	)
 	// create the Transport
-	apiClient.client.Transport = &http.Transport{}
+	apiClient.client.Transport = newTransport()
+
+	// size the pool of idle connections
+	maxIdleConnsPerHost, err := utils.StrToIntOr(br.GetConfig("API_MAX_IDLE_CONNS_PER_HOST"), defaultMaxIdleConnsPerHost)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "failed to parse API_MAX_IDLE_CONNS_PER_HOST")
+	}
+	var idleConnTimeout time.Duration
+	if idleConnTimeoutConf := br.GetConfig("API_IDLE_CONN_TIMEOUT"); idleConnTimeoutConf != "" {
+		idleConnTimeout, err = time.ParseDuration(idleConnTimeoutConf)
+		if err != nil {
+			return nil, errors.BadInput.Wrap(err, "failed to parse API_IDLE_CONN_TIMEOUT")
+		}
+	}
+	err = apiClient.SetConnectionPool(maxIdleConnsPerHost, idleConnTimeout)
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
 
 	// set insecureSkipVerify
 	insecureSkipVerify, err := utils.StrToBoolOr(br.GetConfig("IN_SECURE_SKIP_VERIFY"), false)
@@ -116,6 +165,26 @@ func NewApiClient(
 	return apiClient, nil
 }
 
+const (
+	// defaultMaxIdleConnsPerHost keeps an idle connection for each of the 10 workers of a collector with some headroom,
+	// the 2 connections kept by net/http otherwise make most requests open a new connection to the server
+	defaultMaxIdleConnsPerHost = 16
+	defaultMaxIdleConns        = 100
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// newTransport creates the transport of a client, it negotiates HTTP/2 with the servers supporting it even with a
+// custom TLS config, so concurrent requests are multiplexed over a single connection
+func newTransport() *http.Transport {
+	return &http.Transport{
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        defaultMaxIdleConns,
+		MaxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     defaultIdleConnTimeout,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
 // Setup FIXME ...
 func (apiClient *ApiClient) Setup(
 	endpoint string,
@@ -189,12 +258,122 @@ func (apiClient *ApiClient) SetProxy(proxyUrl string) errors.Error {
 	if err != nil {
 		return errors.Convert(err)
 	}
-	if pu.Scheme == "http" || pu.Scheme == "socks5" {
+	if pu.Scheme == "http" || pu.Scheme == "https" || pu.Scheme == "socks5" {
 		apiClient.client.Transport.(*http.Transport).Proxy = http.ProxyURL(pu)
 	}
 	return nil
 }
 
+// SetTLSConfig trusts the PEM encoded CA certificates on top of the system roots, and skips the verification of the
+// server certificate if insecureSkipVerify is set. The settings apply to the transport of this client only, so
+// clients of other connections are not affected
+func (apiClient *ApiClient) SetTLSConfig(caCertificate string, insecureSkipVerify bool) errors.Error {
+	if caCertificate == "" && !insecureSkipVerify {
+		return nil
+	}
+	transport, ok := apiClient.client.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = newTransport()
+		apiClient.client.Transport = transport
+	}
+	tlsConfig := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+	if caCertificate != "" {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM([]byte(caCertificate)) {
+			return errors.BadInput.New("invalid CA certificate, no PEM encoded certificate was found")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	if insecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	transport.TLSClientConfig = tlsConfig
+	return nil
+}
+
+// SetConnectionPool sets the number of idle connections kept per host for reuse and how long they are kept, 0 keeps
+// the default timeout. Connections to servers without HTTP/2 can't be multiplexed, so the pool should be at least as
+// large as the concurrency of the collectors to spare them a TLS handshake per request
+func (apiClient *ApiClient) SetConnectionPool(maxIdleConnsPerHost int, idleConnTimeout time.Duration) errors.Error {
+	if maxIdleConnsPerHost < 0 {
+		return errors.BadInput.New("maxIdleConnsPerHost must not be negative")
+	}
+	if idleConnTimeout < 0 {
+		return errors.BadInput.New("idleConnTimeout must not be negative")
+	}
+	transport, ok := apiClient.client.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = newTransport()
+		apiClient.client.Transport = transport
+	}
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	if transport.MaxIdleConns != 0 && transport.MaxIdleConns < maxIdleConnsPerHost {
+		transport.MaxIdleConns = maxIdleConnsPerHost
+	}
+	if idleConnTimeout > 0 {
+		transport.IdleConnTimeout = idleConnTimeout
+	}
+	return nil
+}
+
+// SetRedactor makes the collectors redact the records collected through the client before saving them into the raw
+// tables, so the redaction applies to every collector of the connection alike
+func (apiClient *ApiClient) SetRedactor(redactor *JsonRedactor) {
+	apiClient.redactor = redactor
+}
+
+// GetRedactor returns the redactor of the records collected through the client, nil if they are saved as they are
+func (apiClient *ApiClient) GetRedactor() *JsonRedactor {
+	return apiClient.redactor
+}
+
+// ShareCircuitBreaker makes the client short-circuit its requests along with all clients sharing the breaker of
+// `key`, once `threshold` consecutive requests of them were rejected with 401
+func (apiClient *ApiClient) ShareCircuitBreaker(key string, threshold int) {
+	apiClient.breaker = GetCircuitBreaker(key, threshold)
+}
+
+// GetCircuitBreaker returns the breaker shared by the client, nil if there is none
+func (apiClient *ApiClient) GetCircuitBreaker() *CircuitBreaker {
+	return apiClient.breaker
+}
+
+// GetMaxThrottledRetry returns the max number of retries of a request throttled by the server
+func (apiClient *ApiClient) GetMaxThrottledRetry() int {
+	return apiClient.maxThrottledRetry
+}
+
+// SetMaxThrottledRetry sets the max number of retries of a request responded with 429 or 503, the `Retry-After`
+// header would be honored between the retries. 0 disables the retry
+func (apiClient *ApiClient) SetMaxThrottledRetry(maxThrottledRetry int) {
+	apiClient.maxThrottledRetry = maxThrottledRetry
+}
+
+// GetRequestSigner returns the signer of the requests, nil if they are not signed
+func (apiClient *ApiClient) GetRequestSigner() common.ApiClientRequestSigner {
+	return apiClient.requestSigner
+}
+
+// SetRequestSigner sets the callback computing the dynamic headers of a request, i.e. the HMAC signature required
+// by an api gateway in front of the server. Unlike the beforeRequest, it is called on every attempt of the request,
+// including the retries, right before it is sent with the final url, headers and body. The body could be read by
+// the signer, it is rewound for sending afterward
+func (apiClient *ApiClient) SetRequestSigner(signer common.ApiClientRequestSigner) {
+	apiClient.requestSigner = signer
+}
+
+// SetThrottleObserver sets the callback called with every response throttled by 429 or 503, before it is retried,
+// nil to unset. Unlike the afterResponse, it sees the responses retried by the client itself
+func (apiClient *ApiClient) SetThrottleObserver(observer func(res *http.Response)) {
+	apiClient.throttleObserver = observer
+}
+
 // SetLogger FIXME ...
 func (apiClient *ApiClient) SetLogger(logger core.Logger) {
 	apiClient.logger = logger
@@ -206,6 +385,12 @@ func (apiClient *ApiClient) logDebug(format string, a ...interface{}) {
 	}
 }
 
+func (apiClient *ApiClient) logWarn(err error, format string, a ...interface{}) {
+	if apiClient.logger != nil {
+		apiClient.logger.Warn(err, format, a...)
+	}
+}
+
 func (apiClient *ApiClient) logError(err error, format string, a ...interface{}) {
 	if apiClient.logger != nil {
 		apiClient.logger.Error(err, format, a...)
@@ -220,6 +405,24 @@ func (apiClient *ApiClient) Do(
 	body interface{},
 	headers http.Header,
 ) (*http.Response, errors.Error) {
+	return apiClient.DoWithContext(apiClient.ctx, method, path, query, body, headers)
+}
+
+// DoWithContext sends the request bound to the given context instead of the one of the client, so it could be
+// aborted separately from other requests of the client
+func (apiClient *ApiClient) DoWithContext(
+	ctx context.Context,
+	method string,
+	path string,
+	query url.Values,
+	body interface{},
+	headers http.Header,
+) (*http.Response, errors.Error) {
+	if apiClient.breaker != nil {
+		if err := apiClient.breaker.Allow(); err != nil {
+			return nil, err
+		}
+	}
 	uri, err := GetURIStringPointer(apiClient.endpoint, path, query)
 	if err != nil {
 		return nil, errors.Default.Wrap(err, fmt.Sprintf("Unable to construct URI from %s, %s, %s", apiClient.endpoint, path, query))
@@ -234,8 +437,8 @@ func (apiClient *ApiClient) Do(
 		reqBody = bytes.NewBuffer(reqJson)
 	}
 	var req *http.Request
-	if apiClient.ctx != nil {
-		req, err = errors.Convert01(http.NewRequestWithContext(apiClient.ctx, method, *uri, reqBody))
+	if ctx != nil {
+		req, err = errors.Convert01(http.NewRequestWithContext(ctx, method, *uri, reqBody))
 	} else {
 		req, err = errors.Convert01(http.NewRequest(method, *uri, reqBody))
 	}
@@ -243,6 +446,9 @@ func (apiClient *ApiClient) Do(
 		return nil, errors.Default.Wrap(err, fmt.Sprintf("unable to create API request for %s", *uri))
 	}
 	req.Header.Set("Content-Type", "application/json")
+	// responses are decompressed by decompressResponse, setting the header on our own stops the transport from
+	// asking for gzip and decompressing it behind our back, where deflate and the wire bytes are out of reach
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
 
 	// populate headers
 	if apiClient.headers != nil {
@@ -265,14 +471,65 @@ func (apiClient *ApiClient) Do(
 		}
 	}
 	apiClient.logDebug("[api-client] %v %v", method, *uri)
-	res, err = errors.Convert01(apiClient.client.Do(req))
+	for retry := 0; ; retry++ {
+		err = apiClient.signRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		release, err := apiClient.acquireWorker(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res, err = errors.Convert01(apiClient.client.Do(req))
+		if err != nil {
+			release()
+			apiClient.logError(err, "[api-client] failed to request %s with error", req.URL.String())
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("error running beforeRequest for %s", req.URL.String()))
+		}
+		if apiClient.throttleObserver != nil && isThrottled(res) {
+			apiClient.throttleObserver(res)
+		}
+		if apiClient.maxThrottledRetry <= 0 || !isThrottled(res) {
+			// the worker is held till the body was read, rather than till the response was handled
+			res.Body = &workerBody{ReadCloser: res.Body, release: release}
+			break
+		}
+		// the worker is not held while waiting to retry
+		res.Body.Close()
+		release()
+		if retry >= apiClient.maxThrottledRetry {
+			return nil, errors.HttpStatus(res.StatusCode).Wrap(ErrThrottledRetryExceeded, fmt.Sprintf("%s was still throttled after %d retries", req.URL.String(), retry))
+		}
+		wait := getThrottledRetryWait(res, retry)
+		apiClient.logWarn(nil, "[api-client] %s responded with %d, retry #%d in %v", req.URL.String(), res.StatusCode, retry+1, wait)
+		err = sleepWithContext(ctx, wait)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("canceled while waiting to retry %s", req.URL.String()))
+		}
+		if req.GetBody != nil {
+			req.Body, err = errors.Convert01(req.GetBody())
+			if err != nil {
+				return nil, errors.Default.Wrap(err, fmt.Sprintf("unable to rewind API request body for %s", req.URL.String()))
+			}
+		}
+	}
+	if apiClient.breaker != nil {
+		apiClient.breaker.Record(res)
+	}
+	err = apiClient.decompressResponse(res)
 	if err != nil {
-		apiClient.logError(err, "[api-client] failed to request %s with error", req.URL.String())
-		return nil, errors.Default.Wrap(err, fmt.Sprintf("error running beforeRequest for %s", req.URL.String()))
+		res.Body.Close()
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("unable to decompress the response of %s", req.URL.String()))
 	}
 	// after receive
 	if apiClient.afterResponse != nil {
+		// the body read by the callback is replayed to the reader of the response, i.e. the ResponseParser
+		body := &replayableBody{ReadCloser: res.Body}
+		res.Body = body
 		err = apiClient.afterResponse(res)
+		if res.Body == body {
+			res.Body = body.replay()
+		}
 		if err == ErrIgnoreAndContinue {
 			res.Body.Close()
 			return res, err
@@ -285,6 +542,200 @@ func (apiClient *ApiClient) Do(
 	return res, nil
 }
 
+// signRequest signs the request by the requestSigner if there is one, the body read by it is rewound
+func (apiClient *ApiClient) signRequest(req *http.Request) errors.Error {
+	if apiClient.requestSigner == nil {
+		return nil
+	}
+	err := apiClient.requestSigner(req)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("error signing the request for %s", req.URL.String()))
+	}
+	if req.GetBody != nil {
+		req.Body, err = errors.Convert01(req.GetBody())
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("unable to rewind API request body for %s", req.URL.String()))
+		}
+	}
+	return nil
+}
+
+// decompressResponse replaces the body of a response compressed by gzip or deflate with the decompressed one, so
+// the callbacks and the ResponseParser read it as is. The `Content-Length` counts the bytes on the wire, it is reset
+// to -1 (unknown) like the transport does for the responses it decompresses, so it won't be taken for the length of
+// the body, and both the wire and decompressed bytes are logged once the body is closed
+func (apiClient *ApiClient) decompressResponse(res *http.Response) errors.Error {
+	encoding := strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "x-gzip" && encoding != "deflate" {
+		return nil
+	}
+	wire := &countingReader{Reader: res.Body}
+	var reader io.Reader
+	if encoding == "deflate" {
+		var err error
+		reader, err = newDeflateReader(wire)
+		if err != nil {
+			return errors.Convert(err)
+		}
+	} else {
+		gzipReader, err := gzip.NewReader(wire)
+		if err == io.EOF {
+			// bodies of HEAD requests and 204 responses are empty even if they are "compressed"
+			reader = bytes.NewReader(nil)
+		} else if err != nil {
+			return errors.Convert(err)
+		} else {
+			reader = gzipReader
+		}
+	}
+	uri := res.Request.URL.String()
+	res.Body = &decompressedBody{
+		countingReader: countingReader{Reader: reader},
+		wire:           wire,
+		body:           res.Body,
+		onClose: func(wireBytes, bodyBytes int64) {
+			apiClient.logDebug("[api-client] %s: %d bytes of %s on the wire, %d bytes decompressed", uri, wireBytes, encoding, bodyBytes)
+		},
+	}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+	return nil
+}
+
+// newDeflateReader reads a body of the `deflate` encoding, which should be zlib wrapped, but is raw deflate for
+// some servers
+func newDeflateReader(body io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err == io.EOF {
+		return bytes.NewReader(nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.Reader.Read(p)
+	reader.n += int64(n)
+	return n, err
+}
+
+// decompressedBody is the body of a compressed response read decompressed, closing it closes the body on the wire
+type decompressedBody struct {
+	countingReader
+	wire    *countingReader
+	body    io.Closer
+	onClose func(wireBytes, bodyBytes int64)
+	closed  bool
+}
+
+func (body *decompressedBody) Close() error {
+	if !body.closed {
+		body.closed = true
+		body.onClose(body.wire.n, body.countingReader.n)
+	}
+	return body.body.Close()
+}
+
+// replayableBody records what is read from the body of a response, so the next reader could read it once again. It
+// can't be closed, the body is closed by the next reader
+type replayableBody struct {
+	io.ReadCloser
+	read bytes.Buffer
+}
+
+func (body *replayableBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.read.Write(p[:n])
+	return n, err
+}
+
+func (body *replayableBody) Close() error {
+	return nil
+}
+
+// replay returns the body to be read from the start
+func (body *replayableBody) replay() io.ReadCloser {
+	if body.read.Len() == 0 {
+		return body.ReadCloser
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(&body.read, body.ReadCloser), body.ReadCloser}
+}
+
+// sleepWithContext waits for the given duration, unless the context is canceled
+func sleepWithContext(ctx context.Context, d time.Duration) errors.Error {
+	if ctx == nil {
+		time.Sleep(d)
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return errors.Convert(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isThrottled(res *http.Response) bool {
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable
+}
+
+// getThrottledRetryWait returns how long to wait before retrying a throttled request. The `Retry-After` header is
+// honored if present, otherwise the wait grows exponentially with the retries. A random jitter up to half of the wait
+// is added, so the workers throttled at the same time wouldn't hit the server all at once again
+func getThrottledRetryWait(res *http.Response, retry int) time.Duration {
+	wait, ok := parseRetryAfter(res.Header.Get("Retry-After"))
+	if !ok {
+		wait = throttledRetryBackoff << retry
+		if wait <= 0 || wait > maxThrottledRetryBackoff {
+			wait = maxThrottledRetryBackoff
+		}
+	}
+	if wait > 0 {
+		wait += time.Duration(rand.Int63n(int64(wait)/2 + 1))
+	}
+	return wait
+}
+
+// parseRetryAfter parses the `Retry-After` header, which is either a number of seconds or a http date
+func parseRetryAfter(retryAfter string) (time.Duration, bool) {
+	if retryAfter == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
 // Get FIXME ...
 func (apiClient *ApiClient) Get(
 	path string,
@@ -318,6 +769,48 @@ func UnmarshalResponse(res *http.Response, v interface{}) errors.Error {
 	return nil
 }
 
+// acquireWorker takes a worker of the FairScheduler shared by the client for an attempt of a request, the release
+// is a no-op if no worker is shared
+func (apiClient *ApiClient) acquireWorker(ctx context.Context) (func(), errors.Error) {
+	if apiClient.workers == nil {
+		return func() {}, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	err := apiClient.workers.Acquire(ctx, apiClient.workersKey, apiClient.workersWeight)
+	if err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			apiClient.workers.Release(apiClient.workersKey)
+		})
+	}, nil
+}
+
+// workerBody releases the worker taken by the request once the body was read through or closed, whichever comes
+// first
+type workerBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *workerBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *workerBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
 // GetURIStringPointer FIXME ...
 func GetURIStringPointer(baseUrl string, relativePath string, query url.Values) (*string, errors.Error) {
 	// If the base URL doesn't end with a slash, and has a relative path attached
@@ -0,0 +1,528 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiClientRetryThrottled(t *testing.T) {
+	throttled := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if throttled < 2 {
+			throttled++
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	apiClient.SetMaxThrottledRetry(3)
+
+	res, err := apiClient.Get("whatever", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 2, throttled)
+	var body struct {
+		Ok bool `json:"ok"`
+	}
+	assert.Nil(t, UnmarshalResponse(res, &body))
+	assert.True(t, body.Ok)
+}
+
+func TestApiClientRetryThrottledExceeded(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	apiClient.SetMaxThrottledRetry(2)
+
+	_, err := apiClient.Get("whatever", nil, nil)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.HttpStatus(http.StatusServiceUnavailable), err.GetType())
+		assert.True(t, errors.Is(err, ErrThrottledRetryExceeded))
+	}
+	assert.Equal(t, 3, requests)
+}
+
+func TestApiAsyncClientRetryThrottledExceeded(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	apiClient.SetLogger(unithelper.DummyLogger())
+	apiClient.SetMaxThrottledRetry(2)
+	scheduler, err := NewWorkerScheduler(context.Background(), 1, 100, time.Second, 1, unithelper.DummyLogger())
+	assert.Nil(t, err)
+	asyncClient := &ApiAsyncClient{ApiClient: apiClient, maxRetry: 3, scheduler: scheduler}
+	defer asyncClient.Release()
+
+	handled := false
+	asyncClient.DoGetAsync("whatever", nil, nil, func(res *http.Response) errors.Error {
+		handled = true
+		return nil
+	})
+	assert.NotNil(t, asyncClient.WaitAsync())
+	assert.False(t, handled)
+	// the throttled retries of the client are not multiplied by the retries of the async client
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestGetThrottledRetryWait(t *testing.T) {
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("Retry-After", "2")
+	wait := getThrottledRetryWait(res, 0)
+	assert.True(t, wait >= 2*time.Second && wait <= 3*time.Second, wait)
+
+	res.Header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	assert.Equal(t, time.Duration(0), getThrottledRetryWait(res, 0))
+
+	// exponential backoff without Retry-After
+	res.Header.Del("Retry-After")
+	wait = getThrottledRetryWait(res, 2)
+	assert.True(t, wait >= 4*throttledRetryBackoff && wait <= 6*throttledRetryBackoff, wait)
+	wait = getThrottledRetryWait(res, 100)
+	assert.True(t, wait >= maxThrottledRetryBackoff && wait <= maxThrottledRetryBackoff*3/2, wait)
+}
+
+func TestApiClientTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+	caCertificate := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	newApiClient := func(caCertificate string, insecureSkipVerify bool) (*ApiClient, errors.Error) {
+		apiClient := &ApiClient{}
+		apiClient.Setup(server.URL, nil, 10*time.Second)
+		return apiClient, apiClient.SetTLSConfig(caCertificate, insecureSkipVerify)
+	}
+
+	// the self-signed certificate is rejected by the system roots
+	apiClient, err := newApiClient("", false)
+	assert.Nil(t, err)
+	_, err = apiClient.Get("whatever", nil, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "certificate")
+	}
+
+	// and accepted once the connection trusts it
+	apiClient, err = newApiClient(caCertificate, false)
+	assert.Nil(t, err)
+	res, err := apiClient.Get("whatever", nil, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	apiClient, err = newApiClient("", true)
+	assert.Nil(t, err)
+	res, err = apiClient.Get("whatever", nil, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	_, err = newApiClient("not a certificate", false)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.BadInput, err.GetType())
+	}
+}
+
+func TestApiClientAfterResponseKeepsBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	var remaining string
+	apiClient.SetAfterFunction(func(res *http.Response) errors.Error {
+		remaining = res.Header.Get("X-RateLimit-Remaining")
+		// peek into the body, and close it as a careless callback would do
+		peek := make([]byte, 3)
+		_, err := io.ReadFull(res.Body, peek)
+		assert.Nil(t, err)
+		assert.Equal(t, `{"o`, string(peek))
+		res.Body.Close()
+		return nil
+	})
+
+	res, err := apiClient.Get("whatever", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "42", remaining)
+	var body struct {
+		Ok bool `json:"ok"`
+	}
+	assert.Nil(t, UnmarshalResponse(res, &body))
+	assert.True(t, body.Ok)
+}
+
+func TestApiClientDecompressResponse(t *testing.T) {
+	compressors := map[string]func(w io.Writer) io.WriteCloser{
+		"gzip": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		// deflate is supposed to be zlib wrapped, but some servers send it raw
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		"raw deflate": func(w io.Writer) io.WriteCloser {
+			writer, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return writer
+		},
+	}
+	payload := []byte(`{"issues":[{"key":"EPIC-1"},{"key":"EPIC-2"}],"total":2}`)
+	for name, compressor := range compressors {
+		t.Run(name, func(t *testing.T) {
+			compressed := &bytes.Buffer{}
+			writer := compressor(compressed)
+			_, err := writer.Write(payload)
+			assert.Nil(t, err)
+			assert.Nil(t, writer.Close())
+
+			var acceptEncoding string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				acceptEncoding = r.Header.Get("Accept-Encoding")
+				if name == "gzip" {
+					w.Header().Set("Content-Encoding", "gzip")
+				} else {
+					w.Header().Set("Content-Encoding", "deflate")
+				}
+				_, _ = w.Write(compressed.Bytes())
+			}))
+			defer server.Close()
+
+			apiClient := &ApiClient{}
+			apiClient.Setup(server.URL, nil, 10*time.Second)
+			var peeked []byte
+			apiClient.SetAfterFunction(func(res *http.Response) errors.Error {
+				peeked = make([]byte, 9)
+				_, err := io.ReadFull(res.Body, peeked)
+				assert.Nil(t, err)
+				return nil
+			})
+			res, err := apiClient.Get("search", nil, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, "gzip, deflate", acceptEncoding)
+			// the callbacks and the parser see the decompressed body, the wire length is not taken for its length
+			assert.Equal(t, `{"issues"`, string(peeked))
+			assert.Equal(t, int64(-1), res.ContentLength)
+			assert.Empty(t, res.Header.Get("Content-Encoding"))
+			assert.True(t, res.Uncompressed)
+			body, readErr := io.ReadAll(res.Body)
+			assert.Nil(t, readErr)
+			assert.Nil(t, res.Body.Close())
+			assert.Equal(t, payload, body)
+		})
+	}
+}
+
+func TestApiClientDecompressEmptyResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	res, err := apiClient.Get("whatever", nil, nil)
+	assert.Nil(t, err)
+	body, readErr := io.ReadAll(res.Body)
+	assert.Nil(t, readErr)
+	assert.Empty(t, body)
+}
+
+// newCountingTLSServer starts a TLS server answering small pages, it counts the connections accepted, each of them
+// costing a TLS handshake
+func newCountingTLSServer(enableHTTP2 bool) (*httptest.Server, *int64) {
+	connections := new(int64)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"values":[{"id":1}],"isLastPage":false}`))
+	}))
+	server.EnableHTTP2 = enableHTTP2
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(connections, 1)
+		}
+	}
+	server.StartTLS()
+	return server, connections
+}
+
+func newTrustingApiClient(t testing.TB, server *httptest.Server, transport *http.Transport) *ApiClient {
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	apiClient.client.Transport = transport
+	caCertificate := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	err := apiClient.SetTLSConfig(caCertificate, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return apiClient
+}
+
+func TestApiClientNegotiatesHTTP2(t *testing.T) {
+	server, connections := newCountingTLSServer(true)
+	defer server.Close()
+
+	// the custom TLS config of the connection doesn't turn HTTP/2 off
+	apiClient := newTrustingApiClient(t, server, newTransport())
+	// the requests fired before the first connection is up dial on their own
+	res, err := apiClient.Get("whatever", nil, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, res.ProtoMajor)
+		res.Body.Close()
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := apiClient.Get("whatever", nil, nil)
+			if assert.Nil(t, err) {
+				assert.Equal(t, 2, res.ProtoMajor)
+				_, _ = io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1), atomic.LoadInt64(connections))
+}
+
+func TestApiClientSetConnectionPool(t *testing.T) {
+	apiClient := &ApiClient{}
+	apiClient.Setup("https://example.com/", nil, 10*time.Second)
+	assert.Nil(t, apiClient.SetConnectionPool(200, time.Minute))
+	transport := apiClient.client.Transport.(*http.Transport)
+	assert.True(t, transport.ForceAttemptHTTP2)
+	assert.Equal(t, 200, transport.MaxIdleConnsPerHost)
+	// the pool of a host never exceeds the pool of all hosts
+	assert.Equal(t, 200, transport.MaxIdleConns)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+
+	assert.Nil(t, apiClient.SetConnectionPool(4, 0))
+	assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+
+	err := apiClient.SetConnectionPool(-1, 0)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.BadInput, err.GetType())
+	}
+}
+
+// BenchmarkApiClientManySmallPages collects many small pages with 10 concurrent workers like the collectors do, the
+// `handshakes/op` metric is the number of connections opened to the server per 100 pages
+func BenchmarkApiClientManySmallPages(b *testing.B) {
+	const workers = 10
+	const pages = 100
+	cases := []struct {
+		name        string
+		enableHTTP2 bool
+		transport   func() *http.Transport
+	}{
+		{"net/http defaults", true, func() *http.Transport { return &http.Transport{} }},
+		{"pooled http/1.1", false, newTransport},
+		{"pooled http/2", true, newTransport},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			server, connections := newCountingTLSServer(c.enableHTTP2)
+			defer server.Close()
+			apiClient := newTrustingApiClient(b, server, c.transport())
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				var wg sync.WaitGroup
+				for w := 0; w < workers; w++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for p := 0; p < pages/workers; p++ {
+							res, err := apiClient.Get(fmt.Sprintf("issues?page=%d", p), nil, nil)
+							if err != nil {
+								b.Error(err)
+								return
+							}
+							_, _ = io.Copy(io.Discard, res.Body)
+							res.Body.Close()
+						}
+					}()
+				}
+				wg.Wait()
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(atomic.LoadInt64(connections))/float64(b.N), "handshakes/op")
+		})
+	}
+}
+
+func TestApiClientRequestSigner(t *testing.T) {
+	secret := []byte("gateway secret")
+	sign := func(method string, path string, body []byte, nonce string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(method + "\n" + path + "\n" + string(body) + "\n" + nonce))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	// the first attempt is throttled and retried by the client, the second one fails and is retried by the scheduler
+	var mu sync.Mutex
+	var signatures []bool
+	var nonces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		nonce := r.Header.Get("X-Nonce")
+		mu.Lock()
+		defer mu.Unlock()
+		signatures = append(signatures, r.Header.Get("X-Signature") == sign(r.Method, r.URL.Path, body, nonce) && string(body) == `{"page":1}`)
+		nonces = append(nonces, nonce)
+		switch len(signatures) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer server.Close()
+
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	apiClient.SetLogger(unithelper.DummyLogger())
+	apiClient.SetMaxThrottledRetry(1)
+	var attempts int32
+	apiClient.SetRequestSigner(func(req *http.Request) errors.Error {
+		// the signer reads the final body, which is still sent afterward
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return errors.Convert(err)
+		}
+		nonce := fmt.Sprint(atomic.AddInt32(&attempts, 1))
+		req.Header.Set("X-Nonce", nonce)
+		req.Header.Set("X-Signature", sign(req.Method, req.URL.Path, body, nonce))
+		return nil
+	})
+	scheduler, err := NewWorkerScheduler(context.Background(), 1, 100, time.Second, 1, unithelper.DummyLogger())
+	assert.Nil(t, err)
+	asyncClient := &ApiAsyncClient{ApiClient: apiClient, maxRetry: 1, scheduler: scheduler}
+	defer asyncClient.Release()
+
+	asyncClient.DoPostAsync("whatever", nil, map[string]int{"page": 1}, nil, func(res *http.Response) errors.Error {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		return nil
+	})
+	assert.Nil(t, asyncClient.WaitAsync())
+	assert.Equal(t, []bool{true, true, true}, signatures)
+	assert.Equal(t, []string{"1", "2", "3"}, nonces)
+
+	// a failed signing fails the request without sending it
+	apiClient.SetRequestSigner(func(req *http.Request) errors.Error {
+		return errors.Default.New("no key")
+	})
+	_, err = apiClient.Get("whatever", nil, nil)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no key")
+	assert.Len(t, signatures, 3)
+}
+
+func TestApiAsyncClientSharedWorkerReleased(t *testing.T) {
+	workers, err := NewFairScheduler(1)
+	assert.Nil(t, err)
+	inUse := func() int {
+		workers.mu.Lock()
+		defer workers.mu.Unlock()
+		return workers.inUse
+	}
+	throttled := make(chan struct{})
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// every attempt holds the only worker
+		assert.Equal(t, 1, inUse())
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			close(throttled)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	apiClient.SetLogger(unithelper.DummyLogger())
+	apiClient.SetMaxThrottledRetry(1)
+	scheduler, err := NewWorkerScheduler(context.Background(), 1, 100, time.Second, 1, unithelper.DummyLogger())
+	assert.Nil(t, err)
+	asyncClient := &ApiAsyncClient{ApiClient: apiClient, maxRetry: 1, scheduler: scheduler}
+	asyncClient.workers = workers
+	asyncClient.workersKey = "jira:1"
+	asyncClient.workersWeight = 1
+	defer asyncClient.Release()
+
+	asyncClient.DoGetAsync("whatever", nil, nil, func(res *http.Response) errors.Error {
+		// the body was read, the worker is free while the response is handled
+		assert.Equal(t, 0, inUse())
+		return nil
+	})
+	// the worker is free while the throttled request waits to be retried
+	<-throttled
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if assert.Nil(t, workers.Acquire(ctx, "jira:2", 1)) {
+		workers.Release("jira:2")
+	}
+	assert.Nil(t, asyncClient.WaitAsync())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	assert.Equal(t, 0, inUse())
+}
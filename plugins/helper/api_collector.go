@@ -19,13 +19,17 @@ package helper
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/apache/incubator-devlake/errors"
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"text/template"
+	"time"
 
 	"github.com/apache/incubator-devlake/plugins/core"
 	"github.com/apache/incubator-devlake/plugins/core/dal"
@@ -45,8 +49,23 @@ type RequestData struct {
 	Params    interface{}
 	Input     interface{}
 	InputJSON []byte
+	// CustomData is returned by `GetNextPageCustomData` for the page to be fetched, i.e. a cursor/page token
+	CustomData interface{}
+	// stall is shared by the pages fetched one after another from the first one
+	stall *PageStallDetector
 }
 
+// ErrFinishCollect is returned by `GetNextPageCustomData` to tell there is no more page to be fetched
+var ErrFinishCollect = errors.Default.New("finish collect")
+
+// ErrCollectionInterrupted is returned by `Execute` if the collection was cut short by the shutdown of the process,
+// the records collected were saved and the pages checkpointed, so a collection with `ResumeKey` is resumed by the
+// next run. See core.WithShutdown
+var ErrCollectionInterrupted = errors.Default.New("the collection was interrupted by shutdown")
+
+// UnknownTotalPages is returned by `GetTotalPages` when the response doesn't tell the total number of pages
+const UnknownTotalPages = -1
+
 // AsyncResponseHandler FIXME ...
 type AsyncResponseHandler func(res *http.Response) error
 
@@ -73,20 +92,109 @@ type ApiCollectorArgs struct {
 	// GetTotalPages is to tell `ApiCollector` total number of pages based on response of the first page.
 	// so `ApiCollector` could collect those pages in parallel for us
 	GetTotalPages func(res *http.Response, args *ApiCollectorArgs) (int, errors.Error)
+	// GetPageSize is for APIs capping the requested page size silently, it reads the page size honored by the server
+	// from the response of the first page of an input. If it is smaller than `PageSize`, the following pages of the
+	// input are counted by `GetTotalPages` and offset by the honored one, so no record would be skipped
+	GetPageSize func(res *http.Response, args *ApiCollectorArgs) (int, errors.Error)
+	// IsLastPage is for APIs that don't return a reliable total number of pages, pages are fetched one after another
+	// till it returns true or an empty page is returned. When used along with `GetTotalPages`, it takes over only if
+	// `GetTotalPages` returns `UnknownTotalPages` for the first page
+	IsLastPage func(res *http.Response, args *ApiCollectorArgs) (bool, errors.Error)
+	// GetNextPageCustomData is for APIs paginated by cursors, it derives the `CustomData` of the next page from the
+	// response of the previous one and pages are fetched one after another till `ErrFinishCollect` is returned.
+	// When used along with `GetTotalPages`, the chain starts from the first page in addition to the pages counted
+	// by `GetTotalPages`, so it is up to the two of them to tell which scheme the response follows
+	GetNextPageCustomData func(prevReqData *RequestData, prevPageResponse *http.Response) (interface{}, errors.Error)
 	// Concurrency specify qps for api that doesn't return total number of pages/records
 	// NORMALLY, DO NOT SPECIFY THIS PARAMETER, unless you know what it means
-	Concurrency    int
+	Concurrency int
+	// MaxConcurrency makes the number of requests in flight adaptive, see AdaptiveConcurrency. It starts from
+	// MinConcurrency, ramps up while the responses are fast and successful, and backs off on 429/503 or latency
+	// spikes. It takes the place of `Concurrency` for APIs that don't return the total number of pages. 0 disables it
+	MaxConcurrency int
+	// MinConcurrency is the lower bound of the adaptive concurrency, 1 if omitted
+	MinConcurrency int
 	ResponseParser func(res *http.Response) ([]json.RawMessage, errors.Error)
-	AfterResponse  common.ApiClientAfterResponse
-	RequestBody    func(reqData *RequestData) map[string]interface{}
-	Method         string
+	// ResponseTransformer rewrites every record returned by `ResponseParser` before it is saved, i.e. to redact or
+	// unwrap fields. Returning nil drops the record, and a failure skips just the record with a warning
+	ResponseTransformer func(msg json.RawMessage) (json.RawMessage, errors.Error)
+	// AfterResponse is called with every response before its body is parsed, i.e. to inspect the headers or to
+	// short-circuit the response by an error or `ErrIgnoreAndContinue`. What it reads from the body is replayed to
+	// `ResponseParser`, so it doesn't have to restore the body
+	AfterResponse common.ApiClientAfterResponse
+	RequestBody   func(reqData *RequestData) map[string]interface{}
+	Method        string
+	// DryRun makes `Execute` count the requests it would issue without calling the api nor touching the raw table,
+	// the result can be retrieved by `GetDryRunRequests` afterward, and is reported as the result of the subtask
+	DryRun bool
+	// EstimateTotalPages is to tell `ApiCollector` total number of pages of an input in DryRun mode, since there is
+	// no response to feed `GetTotalPages` with. 1 page per input is assumed if omitted
+	EstimateTotalPages func(reqData *RequestData) (int, errors.Error)
+	// PageTimeout aborts the collection with a timeout error if no page was completed within it, the pending
+	// requests are canceled. The countdown restarts on every completed page, so long collections are not affected
+	// as long as they make progress. It requires the ApiClient to be a ContextualApiClient
+	PageTimeout time.Duration
+	// ResumeKey makes the collection resumable: completed pages are checkpointed, and a run failed halfway is
+	// resumed by the next one with the same ResumeKey, which keeps the raw rows collected and skips requesting the
+	// pages again. Pages driving the pagination, i.e. the first page of an input, are still requested but not saved
+	// again. It must identify the query being collected, such as the time range and filters, since checkpoints of a
+	// different ResumeKey are discarded
+	ResumeKey string
+	// RawBatchSize makes the raw rows of multiple pages bulk inserted RawBatchSize at a time rather than page by
+	// page, which saves round trips to the database on large collections. The rows left in the buffer are inserted
+	// once all requests were done. 0 inserts the rows of every page as soon as it is collected
+	RawBatchSize int
+	// SkipUnchangedRecords skips saving a record if it was saved by a previous collection with the same params,
+	// input and content, i.e. records collected again by an incremental collection since they were updated right at
+	// the boundary of the time range. Records are compared by the md5 of the input and the data
+	SkipUnchangedRecords bool
+	// MaxRecords stops the collection once that many records were saved, even if there are more pages. Records of
+	// the page crossing it are truncated, pages in flight are dropped, and no more input is fetched. 0 means no cap
+	MaxRecords int
+	// AfterSaveRawData is called with the raw rows right after they were inserted, batch by batch, i.e. to export
+	// them elsewhere without reading the raw table again. Rows skipped as unchanged are left out, and a failure
+	// fails the collection
+	AfterSaveRawData func(rows []*RawData) errors.Error
+	// CompressRawData gzips the raw rows before they are saved, which are decompressed by `ApiExtractor` on read.
+	// Rows are flagged as compressed one by one, so those saved before without compression are still read as they
+	// are. Rows handed over to `AfterSaveRawData` are compressed as saved, see `RawData.GetData`
+	CompressRawData bool
+	// Paginator supplies the pagination of the API in place of the built-in ones, it can't be combined with
+	// `GetTotalPages`, `IsLastPage` or `GetNextPageCustomData`. See OffsetPaginator for the default one
+	Paginator Paginator
+	// ScopeLock keeps other collections of the raw table and params from running along with this one, i.e. by the
+	// pipelines of another blueprint sharing the scope. The lock is held for the whole collection, DryRun takes none
+	ScopeLock ScopeLock
+	// ScopeLockWait is how long the collection waits for the lock held by another one before it fails with
+	// ErrScopeLocked, 0 fails right away
+	ScopeLockWait time.Duration
 }
 
 // ApiCollector FIXME ...
 type ApiCollector struct {
 	*RawDataSubTask
-	args        *ApiCollectorArgs
-	urlTemplate *template.Template
+	args           *ApiCollectorArgs
+	urlTemplate    *template.Template
+	dryRunRequests int
+	progress       *collectorProgress
+	watchdog       *pageWatchdog
+	requests       int64
+	bytes          int64
+	checkpoints    map[string]bool
+	rawWriter      *rawDataWriter
+	capMu          sync.Mutex
+	savedRecords   int
+	capped         bool
+	// concurrency limits the requests in flight if MaxConcurrency was set
+	concurrency *AdaptiveConcurrency
+	// metrics records the durations of the collection and the pages if the metrics are enabled
+	metrics      MetricsRegistry
+	metricLabels map[string]string
+	// interrupted is set once a page or an input was skipped since the process is shutting down
+	interrupted int32
+	// inputBatches and inputDuration are only touched by the goroutine iterating the input
+	inputBatches  int
+	inputDuration time.Duration
 }
 
 // NewApiCollector allocates a new ApiCollector with the given args.
@@ -112,10 +220,23 @@ func NewApiCollector(args ApiCollectorArgs) (*ApiCollector, errors.Error) {
 	if args.ResponseParser == nil {
 		return nil, errors.Default.New("ResponseParser is required")
 	}
+	if args.Paginator != nil && (args.GetTotalPages != nil || args.IsLastPage != nil || args.GetNextPageCustomData != nil) {
+		return nil, errors.Default.New("Paginator can't be combined with GetTotalPages, IsLastPage or GetNextPageCustomData")
+	}
+	if args.MinConcurrency < 0 || args.MaxConcurrency < 0 || (args.MaxConcurrency > 0 && args.MinConcurrency > args.MaxConcurrency) {
+		return nil, errors.Default.New(fmt.Sprintf("MinConcurrency %d and MaxConcurrency %d are out of order", args.MinConcurrency, args.MaxConcurrency))
+	}
+	if _, ok := args.ApiClient.(ContextualApiClient); args.PageTimeout > 0 && !ok {
+		return nil, errors.Default.New("PageTimeout requires the ApiClient to be able to bind requests to contexts")
+	}
+	if _, ok := args.ApiClient.(ContextualApiClient); args.MaxConcurrency > 0 && !ok {
+		return nil, errors.Default.New("MaxConcurrency requires the ApiClient to be able to bind requests to contexts")
+	}
 	apiCollector := &ApiCollector{
 		RawDataSubTask: rawDataSubTask,
 		args:           &args,
 		urlTemplate:    tpl,
+		progress:       newCollectorProgress(args.Ctx, rawDataSubTask.GetTable()),
 	}
 	if args.AfterResponse != nil {
 		apiCollector.SetAfterResponse(args.AfterResponse)
@@ -127,11 +248,65 @@ func NewApiCollector(args ApiCollectorArgs) (*ApiCollector, errors.Error) {
 			return nil
 		})
 	}
+	if args.MaxConcurrency > 0 {
+		apiCollector.concurrency = NewAdaptiveConcurrency(args.MinConcurrency, args.MaxConcurrency)
+	}
 	return apiCollector, nil
 }
 
 // Execute will start collection
 func (collector *ApiCollector) Execute() errors.Error {
+	if collector.args.DryRun {
+		return collector.dryRun()
+	}
+	skip, err := collector.skipCollection()
+	if err != nil || skip {
+		return err
+	}
+	// a collection started during the shutdown would delete the raw rows collected before without collecting any
+	if collector.isShuttingDown() {
+		return ErrCollectionInterrupted
+	}
+	if collector.args.ScopeLock != nil {
+		unlock, err := collector.args.ScopeLock.Lock(collector.args.Ctx.GetContext(), ScopeLockKey{RawTable: collector.table, Params: collector.params}, collector.args.ScopeLockWait)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := unlock(); err != nil {
+				collector.args.Ctx.GetLogger().Warn(err, "failed to unlock the scope of %s", collector.table)
+			}
+		}()
+	}
+	if collector.concurrency != nil {
+		// the api client is shared by the collectors of the task, which would wrap its afterResponse over and over if
+		// the releasing of the slots was left installed after the collection
+		afterResponse := collector.GetAfterResponse()
+		collector.SetAfterResponse(releaseConcurrencySlot(afterResponse))
+		defer collector.SetAfterResponse(afterResponse)
+	}
+	defer collector.reportStats(time.Now())
+	if registry := GetMetricsRegistry(); registry.Enabled() {
+		collector.metrics = registry
+		collector.metricLabels = map[string]string{
+			MetricLabelPlugin:  collector.args.Ctx.TaskContext().GetName(),
+			MetricLabelSubtask: collector.args.Ctx.GetName(),
+		}
+		defer collector.observe(MetricCollectorDuration, time.Now())
+	}
+	if collector.args.PageTimeout <= 0 {
+		return collector.execute()
+	}
+	collector.watchdog = newPageWatchdog(collector.args.Ctx.GetContext(), collector.args.PageTimeout)
+	defer collector.watchdog.stop()
+	err = collector.execute()
+	if collector.watchdog.hasTimedOut() {
+		return errors.Timeout.Wrap(err, fmt.Sprintf("no page of %s was completed within %v, the collection was aborted", collector.table, collector.args.PageTimeout))
+	}
+	return err
+}
+
+func (collector *ApiCollector) execute() errors.Error {
 	logger := collector.args.Ctx.GetLogger()
 	logger.Info("start api collection")
 
@@ -141,9 +316,38 @@ func (collector *ApiCollector) Execute() errors.Error {
 	if err != nil {
 		return errors.Default.Wrap(err, "error auto-migrating collector")
 	}
+	collector.rawWriter = newRawDataWriter(db, collector.table, collector.params, collector.args.RawBatchSize)
+	if collector.args.SkipUnchangedRecords {
+		collector.rawWriter.skipUnchanged()
+	}
+	if collector.args.PrimaryKeyExtractor != nil {
+		collector.rawWriter.replaceByKey()
+	}
+	if collector.args.CompressRawData {
+		collector.rawWriter.compress()
+	}
+	if client, ok := collector.args.ApiClient.(redactingApiClient); ok {
+		collector.rawWriter.redact(client.GetRedactor())
+	}
+	collector.rawWriter.afterSave = collector.args.AfterSaveRawData
+	if collector.concurrency != nil {
+		// the responses retried by the client itself are throttled as well
+		if client, ok := collector.args.ApiClient.(throttleObservableApiClient); ok {
+			client.SetThrottleObserver(collector.concurrency.Throttled)
+			defer client.SetThrottleObserver(nil)
+		}
+		defer func() {
+			logger.Info("concurrency of %s ended at %d", collector.table, collector.concurrency.Limit())
+		}()
+	}
+
+	resuming, err := collector.prepareCheckpoints()
+	if err != nil {
+		return err
+	}
 
-	// flush data if not incremental collection
-	if !collector.args.Incremental {
+	// flush data if not incremental collection, the data collected is kept when resuming
+	if !collector.args.Incremental && !resuming {
 		err = db.Delete(&RawData{}, dal.From(collector.table), dal.Where("params = ?", collector.params))
 		if err != nil {
 			return errors.Default.Wrap(err, "error deleting data from collector")
@@ -159,25 +363,34 @@ func (collector *ApiCollector) Execute() errors.Error {
 			return errors.Default.New("api_collector can not Execute with nil apiClient")
 		}
 		for {
-			if !iterator.HasNext() || apiClient.HasError() {
+			if !iterator.HasNext() || apiClient.HasError() || collector.isCapped() || collector.isShuttingDown() {
 				err = collector.args.ApiClient.WaitAsync()
 				if err != nil {
 					return err
 				}
-				if !iterator.HasNext() || apiClient.HasError() {
+				if !iterator.HasNext() || apiClient.HasError() || collector.isCapped() {
+					break
+				}
+				if collector.isShuttingDown() {
+					collector.markInterrupted()
 					break
 				}
 			}
-			input, err := iterator.Fetch()
+			var input interface{}
+			fetchStartedAt := time.Now()
+			input, err = iterator.Fetch()
+			collector.inputFetched(time.Since(fetchStartedAt))
 			if err != nil {
 				break
 			}
 			collector.exec(input)
 		}
+		logger.Info("%d inputs of %s were fetched in %v, %v per input on average", collector.inputBatches, collector.table, collector.inputDuration, collector.averageInputDuration())
 	} else {
 		// or we just did it once
 		collector.exec(nil)
 	}
+	collector.progress.setExhausted()
 
 	if err != nil {
 		return errors.Default.Wrap(err, "error executing collector")
@@ -187,13 +400,180 @@ func (collector *ApiCollector) Execute() errors.Error {
 	if err != nil {
 		logger.Error(err, "end api collection error")
 		err = errors.Default.Wrap(err, "Error waiting for async Collector execution")
-	} else {
-		logger.Info("end api collection without error")
+	} else if err = collector.rawWriter.flush(); err == nil {
+		if collector.args.SkipUnchangedRecords {
+			logger.Info("%d unchanged records were skipped", collector.rawWriter.getSkipped())
+		}
+		if collector.isInterrupted() {
+			// the checkpoints are kept for the next run to resume the collection
+			logger.Warn(nil, "api collection of %s was interrupted by shutdown, the records collected so far were saved", collector.table)
+			err = ErrCollectionInterrupted
+		} else {
+			logger.Info("end api collection without error")
+			err = collector.clearCheckpoints()
+		}
 	}
+	collector.progress.report(true)
 
 	return err
 }
 
+// inputFetched records the time spent fetching an input from the iterator, a slow db cursor behind the iterator
+// shows up as the latency of the fetches rather than of the requests
+func (collector *ApiCollector) inputFetched(latency time.Duration) {
+	collector.inputBatches++
+	collector.inputDuration += latency
+	collector.args.Ctx.GetLogger().Debug("input %d of %s was fetched in %v", collector.inputBatches, collector.table, latency)
+}
+
+// averageInputDuration returns the average time spent fetching an input, 0 if no input was fetched
+func (collector *ApiCollector) averageInputDuration() time.Duration {
+	if collector.inputBatches == 0 {
+		return 0
+	}
+	return collector.inputDuration / time.Duration(collector.inputBatches)
+}
+
+// observe records the time since `startedAt` into the histogram of the collector if the metrics are enabled
+func (collector *ApiCollector) observe(name string, startedAt time.Time) {
+	if collector.metrics == nil {
+		return
+	}
+	collector.metrics.ObserveHistogram(name, collector.metricLabels, time.Since(startedAt).Seconds())
+}
+
+// reportStats hands the number of requests, bytes downloaded, the duration of the collection and the time spent on
+// the input over to the subtask context, along with the number of records and pages collected as the result of the
+// subtask, if it is able to carry them
+func (collector *ApiCollector) reportStats(startedAt time.Time) {
+	if reporter, ok := collector.args.Ctx.(core.SubTaskResultReporter); ok {
+		pages, records := collector.progress.getTotals()
+		reporter.ReportSubTaskResult(core.SubTaskResult{Records: records, Pages: pages})
+	}
+	reporter, ok := collector.args.Ctx.(core.CollectorStatsReporter)
+	if !ok {
+		return
+	}
+	reporter.ReportCollectorStats(core.CollectorStats{
+		RawTable:      collector.table,
+		Requests:      int(atomic.LoadInt64(&collector.requests)),
+		Bytes:         atomic.LoadInt64(&collector.bytes),
+		Duration:      time.Since(startedAt),
+		InputBatches:  collector.inputBatches,
+		InputDuration: collector.inputDuration,
+	})
+}
+
+// dryRun walks through the input and counts the requests would be issued
+func (collector *ApiCollector) dryRun() errors.Error {
+	logger := collector.args.Ctx.GetLogger()
+	logger.Info("start api collection in dry-run mode")
+	collector.dryRunRequests = 0
+	if collector.args.Input != nil {
+		iterator := collector.args.Input
+		defer iterator.Close()
+		for iterator.HasNext() {
+			input, err := iterator.Fetch()
+			if err != nil {
+				return errors.Default.Wrap(err, "error fetching input in dry-run mode")
+			}
+			pages, err := collector.estimateTotalPages(input)
+			if err != nil {
+				return err
+			}
+			collector.dryRunRequests += pages
+		}
+	} else {
+		pages, err := collector.estimateTotalPages(nil)
+		if err != nil {
+			return err
+		}
+		collector.dryRunRequests = pages
+	}
+	logger.Info("end api collection in dry-run mode, ~%d api requests would be issued", collector.dryRunRequests)
+	if reporter, ok := collector.args.Ctx.(core.SubTaskResultReporter); ok {
+		reporter.ReportSubTaskResult(core.SubTaskResult{DryRunRequests: collector.dryRunRequests})
+	}
+	return nil
+}
+
+func (collector *ApiCollector) estimateTotalPages(input interface{}) (int, errors.Error) {
+	if collector.args.PageSize <= 0 || collector.args.EstimateTotalPages == nil {
+		return 1, nil
+	}
+	inputJson, err := json.Marshal(input)
+	if err != nil {
+		return 0, errors.Convert(err)
+	}
+	pages, err := collector.args.EstimateTotalPages(&RequestData{
+		Pager: &Pager{
+			Page: 1,
+			Size: collector.args.PageSize,
+		},
+		Params:    collector.args.Params,
+		Input:     input,
+		InputJSON: inputJson,
+	})
+	if err != nil {
+		return 0, errors.Default.Wrap(err, "error estimating total pages")
+	}
+	return pages, nil
+}
+
+// takeRecords truncates the rows of a page to what is left of MaxRecords, the rows are returned as they are if
+// there is no cap
+func (collector *ApiCollector) takeRecords(rows []*RawData) []*RawData {
+	if collector.args.MaxRecords <= 0 {
+		return rows
+	}
+	collector.capMu.Lock()
+	defer collector.capMu.Unlock()
+	left := collector.args.MaxRecords - collector.savedRecords
+	if left <= 0 {
+		return nil
+	}
+	if len(rows) >= left {
+		rows = rows[:left]
+		collector.capped = true
+		collector.args.Ctx.GetLogger().Info("%d records were collected into %s, the collection stops at MaxRecords", collector.args.MaxRecords, collector.table)
+	}
+	collector.savedRecords += len(rows)
+	return rows
+}
+
+// isShuttingDown tells if the process is shutting down, no more request is to be issued then
+func (collector *ApiCollector) isShuttingDown() bool {
+	return core.IsShuttingDown(collector.args.Ctx.GetContext())
+}
+
+// markInterrupted records that the collection was cut short by the shutdown
+func (collector *ApiCollector) markInterrupted() {
+	atomic.StoreInt32(&collector.interrupted, 1)
+}
+
+// isInterrupted tells if the collection was cut short by the shutdown
+func (collector *ApiCollector) isInterrupted() bool {
+	return atomic.LoadInt32(&collector.interrupted) == 1
+}
+
+// isCapped tells if MaxRecords was reached
+func (collector *ApiCollector) isCapped() bool {
+	collector.capMu.Lock()
+	defer collector.capMu.Unlock()
+	return collector.capped
+}
+
+// GetRecords returns the number of records collected by the last `Execute`
+func (collector *ApiCollector) GetRecords() int {
+	_, records := collector.progress.getTotals()
+	return records
+}
+
+// GetDryRunRequests returns the number of requests counted by the last `Execute` in DryRun mode
+func (collector *ApiCollector) GetDryRunRequests() int {
+	return collector.dryRunRequests
+}
+
 func (collector *ApiCollector) exec(input interface{}) {
 	inputJson, err := json.Marshal(input)
 	if err != nil {
@@ -206,11 +586,30 @@ func (collector *ApiCollector) exec(input interface{}) {
 		Page: 1,
 		Size: collector.args.PageSize,
 	}
-	if collector.args.PageSize <= 0 {
+	if collector.args.Paginator != nil {
+		collector.progress.addTotalPages(1, false)
+		reqData.stall = NewPageStallDetector()
+		collector.fetchPagesByPaginator(reqData)
+	} else if collector.args.PageSize <= 0 {
+		collector.progress.addTotalPages(1, false)
 		collector.fetchAsync(reqData, nil)
 	} else if collector.args.GetTotalPages != nil {
+		collector.progress.expectTotalPages()
+		if collector.args.IsLastPage != nil || collector.args.GetNextPageCustomData != nil {
+			// the pages might be chained one after another from the first one
+			reqData.stall = NewPageStallDetector()
+		}
 		collector.fetchPagesDetermined(reqData)
+	} else if collector.args.IsLastPage != nil {
+		collector.progress.addTotalPages(1, false)
+		reqData.stall = NewPageStallDetector()
+		collector.fetchPagesUntilLast(reqData)
+	} else if collector.args.GetNextPageCustomData != nil {
+		collector.progress.addTotalPages(1, false)
+		reqData.stall = NewPageStallDetector()
+		collector.fetchPagesSequentially(reqData)
 	} else {
+		collector.progress.setUndetermined()
 		collector.fetchPagesUndetermined(reqData)
 	}
 }
@@ -219,18 +618,40 @@ func (collector *ApiCollector) exec(input interface{}) {
 func (collector *ApiCollector) fetchPagesDetermined(reqData *RequestData) {
 	// fetch first page
 	collector.fetchAsync(reqData, func(count int, body []byte, res *http.Response) errors.Error {
-		totalPages, err := collector.args.GetTotalPages(res, collector.args)
+		args, err := collector.honorPageSize(reqData, body, res)
+		if err != nil {
+			return err
+		}
+		totalPages, err := collector.args.GetTotalPages(res, args)
 		if err != nil {
 			return errors.Default.Wrap(err, "fetchPagesDetermined get totalPages failed")
 		}
+		if totalPages == UnknownTotalPages && collector.args.IsLastPage != nil {
+			collector.progress.addTotalPages(1, true)
+			res.Body = io.NopCloser(bytes.NewBuffer(body))
+			return collector.fetchPageAfter(reqData, res)
+		}
+		// the first page was fetched even if there is no record at all
+		if totalPages > 0 {
+			collector.progress.addTotalPages(totalPages, true)
+		} else {
+			collector.progress.addTotalPages(1, true)
+		}
+		if collector.args.GetNextPageCustomData != nil {
+			res.Body = io.NopCloser(bytes.NewBuffer(body))
+			err = collector.fetchNextPage(reqData, res)
+			if err != nil {
+				return err
+			}
+		}
 		// spawn a none blocking go routine to fetch other pages
 		collector.args.ApiClient.NextTick(func() errors.Error {
 			for page := 2; page <= totalPages; page++ {
 				reqDataTemp := &RequestData{
 					Pager: &Pager{
 						Page: page,
-						Skip: collector.args.PageSize * (page - 1),
-						Size: collector.args.PageSize,
+						Skip: args.PageSize * (page - 1),
+						Size: args.PageSize,
 					},
 					Input:     reqData.Input,
 					InputJSON: reqData.InputJSON,
@@ -254,6 +675,10 @@ func (collector *ApiCollector) fetchPagesUndetermined(reqData *RequestData) {
 	// goroutine #3 fetches pages 3/6/9...
 	apiClient := collector.args.ApiClient
 	concurrency := collector.args.Concurrency
+	if collector.concurrency != nil {
+		// the pages are fetched by as many goroutines as allowed at most, the requests in flight are limited anyway
+		concurrency = collector.args.MaxConcurrency
+	}
 	if concurrency == 0 {
 		// normally when a multi-pages api depends on a another resource, like jira changelogs depend on issue ids
 		// it tend to have less page, like 1 or 2 pages in total
@@ -278,7 +703,7 @@ func (collector *ApiCollector) fetchPagesUndetermined(reqData *RequestData) {
 		}
 		var collect func() errors.Error
 		collect = func() errors.Error {
-			collector.fetchAsync(&reqDataCopy, func(count int, body []byte, res *http.Response) errors.Error {
+			collector.fetchAsyncCounting(&reqDataCopy, func(count int) errors.Error {
 				if count < collector.args.PageSize {
 					return nil
 				}
@@ -295,6 +720,105 @@ func (collector *ApiCollector) fetchPagesUndetermined(reqData *RequestData) {
 	}
 }
 
+// fetchPagesUntilLast fetches data of all pages for APIs that don't return a reliable total number of pages, one
+// page after another
+func (collector *ApiCollector) fetchPagesUntilLast(reqData *RequestData) {
+	collector.fetchAsync(reqData, func(count int, body []byte, res *http.Response) errors.Error {
+		if reqData.Pager.Page == 1 {
+			// the following pages inherit the honored page size from the first one
+			_, err := collector.honorPageSize(reqData, body, res)
+			if err != nil {
+				return err
+			}
+		}
+		return collector.fetchPageAfter(reqData, res)
+	})
+}
+
+// honorPageSize shrinks the page size of `reqData` to the one honored by the server if `GetPageSize` tells it was
+// capped, the args bearing the honored page size are returned for counting the pages
+func (collector *ApiCollector) honorPageSize(reqData *RequestData, body []byte, res *http.Response) (*ApiCollectorArgs, errors.Error) {
+	if collector.args.GetPageSize == nil {
+		return collector.args, nil
+	}
+	pageSize, err := collector.args.GetPageSize(res, collector.args)
+	res.Body = io.NopCloser(bytes.NewBuffer(body))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to get the page size honored by the server")
+	}
+	if pageSize <= 0 || pageSize >= reqData.Pager.Size {
+		return collector.args, nil
+	}
+	collector.args.Ctx.GetLogger().Info("page size of %s was capped from %d to %d by the server", collector.table, reqData.Pager.Size, pageSize)
+	reqData.Pager.Size = pageSize
+	args := *collector.args
+	args.PageSize = pageSize
+	return &args, nil
+}
+
+// fetchPageAfter enqueues the page following `reqData` unless `IsLastPage` tells it was the last one
+func (collector *ApiCollector) fetchPageAfter(reqData *RequestData, res *http.Response) errors.Error {
+	isLastPage, err := collector.args.IsLastPage(res, collector.args)
+	if err != nil {
+		return errors.Default.Wrap(err, "fetchPageAfter check last page failed")
+	}
+	if isLastPage {
+		return nil
+	}
+	collector.progress.setUndetermined()
+	nextReqData := &RequestData{
+		Pager: &Pager{
+			Page: reqData.Pager.Page + 1,
+			Skip: reqData.Pager.Skip + reqData.Pager.Size,
+			Size: reqData.Pager.Size,
+		},
+		Input:     reqData.Input,
+		InputJSON: reqData.InputJSON,
+		stall:     reqData.stall,
+	}
+	collector.args.ApiClient.NextTick(func() errors.Error {
+		collector.fetchPagesUntilLast(nextReqData)
+		return nil
+	})
+	return nil
+}
+
+// fetchPagesSequentially fetches data of all pages for APIs paginated by cursors, one page after another
+func (collector *ApiCollector) fetchPagesSequentially(reqData *RequestData) {
+	collector.fetchAsync(reqData, func(count int, body []byte, res *http.Response) errors.Error {
+		return collector.fetchNextPage(reqData, res)
+	})
+}
+
+// fetchNextPage enqueues the page following `reqData` unless `GetNextPageCustomData` tells it was the last one
+func (collector *ApiCollector) fetchNextPage(reqData *RequestData, res *http.Response) errors.Error {
+	customData, err := collector.args.GetNextPageCustomData(reqData, res)
+	if err == ErrFinishCollect {
+		return nil
+	}
+	if err != nil {
+		return errors.Default.Wrap(err, "fetchNextPage get next page custom data failed")
+	}
+	// there is no telling how many pages are left
+	collector.progress.setUndetermined()
+	nextReqData := &RequestData{
+		Pager: &Pager{
+			Page: reqData.Pager.Page + 1,
+			Skip: reqData.Pager.Skip + reqData.Pager.Size,
+			Size: reqData.Pager.Size,
+		},
+		Input:      reqData.Input,
+		InputJSON:  reqData.InputJSON,
+		CustomData: customData,
+		stall:      reqData.stall,
+	}
+	collector.args.ApiClient.NextTick(func() errors.Error {
+		collector.fetchPagesSequentially(nextReqData)
+		return nil
+	})
+	return nil
+}
+
 func (collector *ApiCollector) generateUrl(pager *Pager, input interface{}) (string, errors.Error) {
 	var buf bytes.Buffer
 	err := collector.urlTemplate.Execute(&buf, &RequestData{
@@ -318,7 +842,23 @@ func (collector *ApiCollector) SetAfterResponse(f common.ApiClientAfterResponse)
 	collector.args.ApiClient.SetAfterFunction(f)
 }
 
+// fetchAsync enqueues the request of the page, `handler` is called with the body of the response once the page was
+// saved. The response is buffered for the handler, it is streamed to the ResponseParser without one
 func (collector *ApiCollector) fetchAsync(reqData *RequestData, handler func(int, []byte, *http.Response) errors.Error) {
+	collector.fetchPageAsync(reqData, handler, handler != nil)
+}
+
+// fetchAsyncCounting is fetchAsync for the handlers which only need the number of records of the page, so the
+// response is streamed to the ResponseParser rather than buffered
+func (collector *ApiCollector) fetchAsyncCounting(reqData *RequestData, handler func(int) errors.Error) {
+	collector.fetchPageAsync(reqData, func(count int, _ []byte, _ *http.Response) errors.Error {
+		return handler(count)
+	}, false)
+}
+
+// fetchPageAsync enqueues the request of the page, the response is read into memory as a whole only if `buffered`
+// or the stall of the pagination is to be detected, since a page of a large api might take hundreds of megabytes
+func (collector *ApiCollector) fetchPageAsync(reqData *RequestData, handler func(int, []byte, *http.Response) errors.Error, buffered bool) {
 	if reqData.Pager == nil {
 		reqData.Pager = &Pager{
 			Page: 1,
@@ -353,53 +893,167 @@ func (collector *ApiCollector) fetchAsync(reqData *RequestData, handler func(int
 		}
 	}
 	logger := collector.args.Ctx.GetLogger()
+	if collector.isCapped() {
+		logger.Debug("fetchAsync === skipping %s %v since MaxRecords was reached", apiUrl, apiQuery)
+		return
+	}
+	if collector.isShuttingDown() {
+		logger.Debug("fetchAsync === skipping %s %v since the process is shutting down", apiUrl, apiQuery)
+		collector.markInterrupted()
+		return
+	}
+	hash := pageHash(apiUrl, apiQuery)
+	checkpointed := collector.isCheckpointed(hash)
+	if checkpointed && handler == nil {
+		logger.Debug("fetchAsync === skipping %s %v collected by the previous run", apiUrl, apiQuery)
+		collector.args.Ctx.IncProgress(1)
+		collector.progress.pageDone(0)
+		collector.watchdog.pageDone()
+		return
+	}
 	logger.Debug("fetchAsync <<< enqueueing for %s %v", apiUrl, apiQuery)
+	enqueuedAt := time.Now()
 	responseHandler := func(res *http.Response) errors.Error {
+		defer collector.observe(MetricCollectorPageDuration, enqueuedAt)
 		defer logger.Debug("fetchAsync >>> done for %s %v %v", apiUrl, apiQuery, collector.args.RequestBody)
 		logger := collector.args.Ctx.GetLogger()
-		// read body to buffer
-		body, err := io.ReadAll(res.Body)
-		if err != nil {
-			return errors.Default.Wrap(err, fmt.Sprintf("error reading response from %s", apiUrl))
+		atomic.AddInt64(&collector.requests, 1)
+		var body []byte
+		var items []json.RawMessage
+		var err errors.Error
+		if buffered || reqData.stall != nil {
+			// read body to buffer
+			var e error
+			body, e = io.ReadAll(res.Body)
+			if e != nil {
+				return errors.Default.Wrap(e, fmt.Sprintf("error reading response from %s", apiUrl))
+			}
+			res.Body.Close()
+			atomic.AddInt64(&collector.bytes, int64(len(body)))
+			// a page fetched again by a pagination which doesn't advance is not saved, the collection aborts instead
+			if reqData.stall != nil {
+				if err := reqData.stall.Check(hash, body); err != nil {
+					return errors.Default.Wrap(err, fmt.Sprintf("pagination of %s stalled", apiUrl))
+				}
+			}
+			res.Body = io.NopCloser(bytes.NewBuffer(body))
+			// convert body to array of RawJSON
+			items, err = collector.args.ResponseParser(res)
+		} else {
+			// the parser reads the body as it streams in, so the whole of it is never held in memory
+			wire := res.Body
+			counter := &countingReader{Reader: wire}
+			res.Body = io.NopCloser(counter)
+			items, err = collector.args.ResponseParser(res)
+			wire.Close()
+			atomic.AddInt64(&collector.bytes, counter.n)
 		}
-		res.Body.Close()
-		res.Body = io.NopCloser(bytes.NewBuffer(body))
-		// convert body to array of RawJSON
-		items, err := collector.args.ResponseParser(res)
 		if err != nil {
 			return errors.Default.Wrap(err, fmt.Sprintf("error parsing response from %s", apiUrl))
 		}
+		// pages in flight are dropped once MaxRecords was reached
+		if collector.isCapped() {
+			return nil
+		}
 		// save to db
 		count := len(items)
-		if count == 0 {
+		urlString := res.Request.URL.String()
+		if count == 0 || checkpointed {
+			if !checkpointed {
+				err = collector.saveCheckpoint(hash, urlString, true)
+				if err != nil {
+					return err
+				}
+			}
 			collector.args.Ctx.IncProgress(1)
+			collector.progress.pageDone(0)
+			collector.watchdog.pageDone()
+			if count > 0 && handler != nil {
+				res.Body = io.NopCloser(bytes.NewBuffer(body))
+				return handler(count, body, res)
+			}
 			return nil
 		}
-		db := collector.args.Ctx.GetDal()
-		urlString := res.Request.URL.String()
-		rows := make([]*RawData, count)
-		for i, msg := range items {
-			rows[i] = &RawData{
+		rows := make([]*RawData, 0, count)
+		for _, msg := range items {
+			if collector.args.ResponseTransformer != nil {
+				msg, err = collector.args.ResponseTransformer(msg)
+				if err != nil {
+					logger.Warn(err, "failed to transform a record from %s, skipping it", urlString)
+					continue
+				}
+				if msg == nil {
+					continue
+				}
+			}
+			row := &RawData{
 				Params: collector.params,
 				Data:   msg,
 				Url:    urlString,
 				Input:  reqData.InputJSON,
 			}
+			if collector.args.PrimaryKeyExtractor != nil {
+				row.RecordKey, err = collector.args.PrimaryKeyExtractor(msg)
+				if err != nil {
+					return errors.Default.Wrap(err, fmt.Sprintf("error extracting the primary key of a record from %s", urlString))
+				}
+			}
+			rows = append(rows, row)
+		}
+		rows = collector.takeRecords(rows)
+		// records might be dropped by the transformer
+		if len(rows) > 0 {
+			// the page is pending till the rows are inserted, so it wouldn't be skipped if interrupted in between
+			err = collector.saveCheckpoint(hash, urlString, false)
+			if err != nil {
+				return err
+			}
+			err = collector.rawWriter.write(rows, func() errors.Error {
+				return collector.saveCheckpoint(hash, urlString, true)
+			})
+		} else {
+			err = collector.saveCheckpoint(hash, urlString, true)
 		}
-		err = db.Create(rows, dal.From(collector.table))
 		if err != nil {
-			return errors.Default.Wrap(err, fmt.Sprintf("error inserting raw rows into %s", collector.table))
+			return err
 		}
-		logger.Debug("fetchAsync === total %d rows were saved into database", count)
+		logger.Debug("fetchAsync === total %d rows were collected", len(rows))
 		// increase progress only when it was not nested
 		collector.args.Ctx.IncProgress(1)
-		if handler != nil {
+		collector.progress.pageDone(len(rows))
+		collector.watchdog.pageDone()
+		// the following pages are not fetched once MaxRecords was reached
+		if handler != nil && !collector.isCapped() {
 			res.Body = io.NopCloser(bytes.NewBuffer(body))
 			return handler(count, body, res)
 		}
 		return nil
 	}
-	if collector.args.Method == http.MethodPost {
+	var ctx context.Context
+	if collector.watchdog != nil {
+		// bind the request to the watchdog, so it could be canceled once the collection stalls
+		ctx = collector.watchdog.ctx
+	}
+	if collector.concurrency != nil {
+		if ctx == nil {
+			ctx = collector.args.Ctx.GetContext()
+		}
+		slot, ok := acquireConcurrencySlot(ctx, collector.concurrency, collector.args.ApiClient.HasError)
+		if !ok {
+			logger.Debug("fetchAsync === skipping %s %v since the collection was aborted", apiUrl, apiQuery)
+			return
+		}
+		// the slot is carried by the context of the request, it is released by the afterResponse
+		ctx = context.WithValue(ctx, concurrencySlotKey{}, slot)
+	}
+	if ctx != nil {
+		apiClient := collector.args.ApiClient.(ContextualApiClient)
+		if collector.args.Method == http.MethodPost {
+			apiClient.DoPostAsyncWithContext(ctx, apiUrl, apiQuery, reqBody, apiHeader, responseHandler)
+		} else {
+			apiClient.DoGetAsyncWithContext(ctx, apiUrl, apiQuery, apiHeader, responseHandler)
+		}
+	} else if collector.args.Method == http.MethodPost {
 		collector.args.ApiClient.DoPostAsync(apiUrl, apiQuery, reqBody, apiHeader, responseHandler)
 	} else {
 		collector.args.ApiClient.DoGetAsync(apiUrl, apiQuery, apiHeader, responseHandler)
@@ -0,0 +1,162 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+)
+
+// CollectorCheckpoint records a page collected by a resumable ApiCollector. It is saved as pending before the raw
+// rows of the page are inserted and marked done afterward, so a page interrupted in between is found pending by the
+// next run, whose raw rows are deleted and the page gets collected again instead of being skipped
+type CollectorCheckpoint struct {
+	RawTable  string `gorm:"primaryKey;type:varchar(255)"`
+	Params    string `gorm:"primaryKey;type:varchar(255)"`
+	PageHash  string `gorm:"primaryKey;type:varchar(32)"`
+	Url       string
+	ResumeKey string
+	Done      bool
+	CreatedAt time.Time
+}
+
+func (CollectorCheckpoint) TableName() string {
+	return "_devlake_collector_checkpoints"
+}
+
+// GetResumeKey returns the `ResumeKey` of the interrupted collection of the raw table, "" is returned if the
+// previous collection was completed or not resumable
+func GetResumeKey(db dal.Dal, args RawDataSubTaskArgs) (string, errors.Error) {
+	rawDataSubTask, err := NewRawDataSubTask(args)
+	if err != nil {
+		return "", err
+	}
+	return GetTableResumeKey(db, rawDataSubTask.GetTable(), rawDataSubTask.GetParams())
+}
+
+// GetTableResumeKey is GetResumeKey by the raw table and the params as they are saved, i.e. recorded by a
+// collection earlier
+func GetTableResumeKey(db dal.Dal, table string, params string) (string, errors.Error) {
+	var resumeKeys []string
+	err := db.Pluck("resume_key", &resumeKeys,
+		dal.From(&CollectorCheckpoint{}),
+		dal.Where("raw_table = ? AND params = ?", table, params),
+		dal.Limit(1),
+	)
+	if err != nil {
+		return "", errors.Default.Wrap(err, fmt.Sprintf("failed to load the checkpoints of %s", table))
+	}
+	if len(resumeKeys) == 0 {
+		return "", nil
+	}
+	return resumeKeys[0], nil
+}
+
+// pageHash identifies a page by the request, which doesn't depend on the endpoint of the connection
+func pageHash(apiUrl string, apiQuery url.Values) string {
+	sum := md5.Sum([]byte(apiUrl + "?" + apiQuery.Encode()))
+	return hex.EncodeToString(sum[:])
+}
+
+// prepareCheckpoints discards the checkpoints of a different `ResumeKey` and the raw rows of pages interrupted by
+// the previous run, the pages done are loaded to be skipped. It returns true if the collection is being resumed
+func (collector *ApiCollector) prepareCheckpoints() (bool, errors.Error) {
+	if collector.args.ResumeKey == "" {
+		return false, nil
+	}
+	db := collector.args.Ctx.GetDal()
+	scope := dal.Where("raw_table = ? AND params = ?", collector.table, collector.params)
+	err := db.Delete(&CollectorCheckpoint{}, scope, dal.Where("resume_key != ?", collector.args.ResumeKey))
+	if err != nil {
+		return false, errors.Default.Wrap(err, "error deleting stale checkpoints")
+	}
+	var checkpoints []CollectorCheckpoint
+	err = db.All(&checkpoints, scope)
+	if err != nil {
+		return false, errors.Default.Wrap(err, "error loading checkpoints")
+	}
+	if len(checkpoints) == 0 {
+		return false, nil
+	}
+	var pending []string
+	collector.checkpoints = make(map[string]bool, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		if checkpoint.Done {
+			collector.checkpoints[checkpoint.PageHash] = true
+		} else {
+			pending = append(pending, checkpoint.Url)
+		}
+	}
+	if len(pending) > 0 {
+		err = db.Delete(&RawData{}, dal.From(collector.table), dal.Where("params = ? AND url IN ?", collector.params, pending))
+		if err != nil {
+			return false, errors.Default.Wrap(err, "error deleting raw rows of interrupted pages")
+		}
+		err = db.Delete(&CollectorCheckpoint{}, scope, dal.Where("done = ?", false))
+		if err != nil {
+			return false, errors.Default.Wrap(err, "error deleting checkpoints of interrupted pages")
+		}
+	}
+	collector.args.Ctx.GetLogger().Info("resuming the collection of %s, %d pages were collected, %d pages were interrupted", collector.table, len(collector.checkpoints), len(pending))
+	return true, nil
+}
+
+// isCheckpointed tells if the page was collected by the interrupted run
+func (collector *ApiCollector) isCheckpointed(hash string) bool {
+	return collector.checkpoints[hash]
+}
+
+// saveCheckpoint records the given page as pending or done
+func (collector *ApiCollector) saveCheckpoint(hash, urlString string, done bool) errors.Error {
+	if collector.args.ResumeKey == "" {
+		return nil
+	}
+	err := collector.args.Ctx.GetDal().CreateOrUpdate(&CollectorCheckpoint{
+		RawTable:  collector.table,
+		Params:    collector.params,
+		PageHash:  hash,
+		Url:       urlString,
+		ResumeKey: collector.args.ResumeKey,
+		Done:      done,
+	})
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("error saving checkpoint of %s", urlString))
+	}
+	return nil
+}
+
+// clearCheckpoints deletes the checkpoints once the collection was completed, so the next run starts over
+func (collector *ApiCollector) clearCheckpoints() errors.Error {
+	if collector.args.ResumeKey == "" {
+		return nil
+	}
+	err := collector.args.Ctx.GetDal().Delete(
+		&CollectorCheckpoint{},
+		dal.Where("raw_table = ? AND params = ?", collector.table, collector.params),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "error deleting checkpoints")
+	}
+	return nil
+}
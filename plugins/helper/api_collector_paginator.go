@@ -0,0 +1,101 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/apache/incubator-devlake/errors"
+)
+
+// Paginator drives the pagination of `ApiCollector` for APIs paginated by schemes it doesn't know of, i.e. the
+// `Link` header of GitHub, the `X-Next-Page` header of GitLab or the cursor tokens of Jira. The pages are fetched
+// one after another, while the concurrency across the inputs, the retries and the saving of the raw rows are left
+// to the collector
+type Paginator interface {
+	// NextRequest returns the request of the page following `prev`, which was responded by `res`, false is returned
+	// once `prev` was the last page. The body of `res` might be read, it is replayed to the collector afterward.
+	// The request returned is fed to `UrlTemplate`, `Query` and `Header` like any other, its Input is inherited
+	// from `prev` if omitted
+	NextRequest(prev *RequestData, res *http.Response) (*RequestData, bool, errors.Error)
+}
+
+// OffsetPaginator pages by the offset of the records, like `startAt` and `maxResults` of Jira, till the total
+// number of records told by the responses is reached. `Query` maps `Pager.Skip` and `Pager.Size` to the parameters
+// of the API
+type OffsetPaginator struct {
+	// GetTotal returns the total number of records told by a response
+	GetTotal func(res *http.Response) (int, errors.Error)
+}
+
+// NextRequest advances the offset by the size of the page unless the total number of records was reached
+func (paginator *OffsetPaginator) NextRequest(prev *RequestData, res *http.Response) (*RequestData, bool, errors.Error) {
+	if prev.Pager == nil || prev.Pager.Size <= 0 {
+		return nil, false, nil
+	}
+	total, err := paginator.GetTotal(res)
+	if err != nil {
+		return nil, false, errors.Default.Wrap(err, "failed to get the total number of records")
+	}
+	skip := prev.Pager.Skip + prev.Pager.Size
+	if skip >= total {
+		return nil, false, nil
+	}
+	return &RequestData{
+		Pager: &Pager{
+			Page: prev.Pager.Page + 1,
+			Skip: skip,
+			Size: prev.Pager.Size,
+		},
+	}, true, nil
+}
+
+// fetchPagesByPaginator fetches the pages told by `Paginator` one after another, starting from `reqData`
+func (collector *ApiCollector) fetchPagesByPaginator(reqData *RequestData) {
+	collector.fetchAsync(reqData, func(count int, body []byte, res *http.Response) errors.Error {
+		res.Body = io.NopCloser(bytes.NewBuffer(body))
+		next, ok, err := collector.args.Paginator.NextRequest(reqData, res)
+		res.Body = io.NopCloser(bytes.NewBuffer(body))
+		if err != nil {
+			return errors.Default.Wrap(err, "failed to get the next page from the paginator")
+		}
+		if !ok || next == nil {
+			return nil
+		}
+		if next.Pager == nil {
+			next.Pager = &Pager{
+				Page: reqData.Pager.Page + 1,
+				Skip: reqData.Pager.Skip + reqData.Pager.Size,
+				Size: reqData.Pager.Size,
+			}
+		}
+		if next.Input == nil {
+			next.Input = reqData.Input
+			next.InputJSON = reqData.InputJSON
+		}
+		next.stall = reqData.stall
+		collector.progress.setUndetermined()
+		collector.args.ApiClient.NextTick(func() errors.Error {
+			collector.fetchPagesByPaginator(next)
+			return nil
+		})
+		return nil
+	})
+}
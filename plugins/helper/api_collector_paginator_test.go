@@ -0,0 +1,152 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/helper/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// nextPageHeaderPaginator follows the `X-Next-Page` header like GitLab does
+type nextPageHeaderPaginator struct{}
+
+func (nextPageHeaderPaginator) NextRequest(prev *RequestData, res *http.Response) (*RequestData, bool, errors.Error) {
+	next := res.Header.Get("X-Next-Page")
+	if next == "" {
+		return nil, false, nil
+	}
+	page, err := strconv.Atoi(next)
+	if err != nil {
+		return nil, false, errors.Convert(err)
+	}
+	return &RequestData{Pager: &Pager{Page: page, Size: prev.Pager.Size}}, true, nil
+}
+
+func TestFetchPagesByPaginator(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	var saved int
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved += len(args.Get(0).([]*RawData))
+	}).Return(nil).Times(3)
+
+	var pages []string
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		page := args.Get(1).(url.Values).Get("page")
+		pages = append(pages, page)
+		header := http.Header{}
+		if page != "3" {
+			header.Set("X-Next-Page", map[string]string{"1": "2", "2": "3"}[page])
+		}
+		res := &http.Response{
+			Request: &http.Request{URL: &url.URL{}},
+			Header:  header,
+			Body:    ioutil.NopCloser(bytes.NewBufferString(fmt.Sprintf(`[%s1,%s2]`, page, page))),
+		}
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	}).Times(3)
+	mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+		handler := args.Get(0).(func() errors.Error)
+		assert.Nil(t, handler())
+	}).Twice()
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    unithelper.DummySubTaskContext(mockDal),
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:   mockApi,
+		UrlTemplate: "whatever url",
+		PageSize:    2,
+		Paginator:   nextPageHeaderPaginator{},
+		Query: func(reqData *RequestData) (url.Values, errors.Error) {
+			return url.Values{"page": {strconv.Itoa(reqData.Pager.Page)}}, nil
+		},
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			var items []json.RawMessage
+			err := UnmarshalResponse(res, &items)
+			return items, err
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	assert.Equal(t, []string{"1", "2", "3"}, pages)
+	assert.Equal(t, 6, saved)
+	mockDal.AssertExpectations(t)
+	mockApi.AssertExpectations(t)
+
+	// the paginator replaces the built-in paginations
+	_, err = NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    unithelper.DummySubTaskContext(mockDal),
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:   mockApi,
+		UrlTemplate: "whatever url",
+		Paginator:   nextPageHeaderPaginator{},
+		GetTotalPages: func(res *http.Response, args *ApiCollectorArgs) (int, errors.Error) {
+			return 1, nil
+		},
+		ResponseParser: GetRawMessageArrayFromResponse,
+	})
+	assert.NotNil(t, err)
+}
+
+func TestOffsetPaginator(t *testing.T) {
+	paginator := &OffsetPaginator{
+		GetTotal: func(res *http.Response) (int, errors.Error) {
+			body := &struct {
+				Total int `json:"total"`
+			}{}
+			err := UnmarshalResponse(res, body)
+			return body.Total, err
+		},
+	}
+	response := func() *http.Response {
+		return &http.Response{Body: ioutil.NopCloser(bytes.NewBufferString(`{"total":250}`))}
+	}
+	next, ok, err := paginator.NextRequest(&RequestData{Pager: &Pager{Page: 1, Size: 100}, Input: "K-1"}, response())
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, &Pager{Page: 2, Skip: 100, Size: 100}, next.Pager)
+	next, ok, err = paginator.NextRequest(&RequestData{Pager: &Pager{Page: 3, Skip: 200, Size: 100}}, response())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, next)
+}
@@ -0,0 +1,121 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+const collectorProgressInterval = time.Second
+
+// collectorProgress keeps track of pages and records collected by an ApiCollector, and reports them to the
+// subtask context at most once per interval, so fast endpoints wouldn't flood the progress channel
+type collectorProgress struct {
+	mu            sync.Mutex
+	reporter      core.CollectorProgressReporter
+	rawTable      string
+	interval      time.Duration
+	lastReported  time.Time
+	pages         int
+	records       int
+	totalPages    int
+	pendingTotals int
+	undetermined  bool
+	exhausted     bool
+}
+
+func newCollectorProgress(ctx core.SubTaskContext, rawTable string) *collectorProgress {
+	// not all implementations of SubTaskContext are able to carry the detailed progress
+	reporter, _ := ctx.(core.CollectorProgressReporter)
+	return &collectorProgress{
+		reporter: reporter,
+		rawTable: rawTable,
+		interval: collectorProgressInterval,
+	}
+}
+
+// getTotals returns the number of pages and records collected so far
+func (p *collectorProgress) getTotals() (int, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pages, p.records
+}
+
+// expectTotalPages marks an input whose total number of pages will be known after the first page
+func (p *collectorProgress) expectTotalPages() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pendingTotals++
+}
+
+// addTotalPages adds up the total number of pages of an input
+func (p *collectorProgress) addTotalPages(pages int, expected bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if expected {
+		p.pendingTotals--
+	}
+	p.totalPages += pages
+}
+
+// setUndetermined marks the total number of pages unknown till the end of collection
+func (p *collectorProgress) setUndetermined() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.undetermined = true
+}
+
+// setExhausted marks all inputs were consumed, so the total number of pages can be determined
+func (p *collectorProgress) setExhausted() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.exhausted = true
+}
+
+// pageDone records a completed page along with the number of records it contains
+func (p *collectorProgress) pageDone(records int) {
+	p.mu.Lock()
+	p.pages++
+	p.records += records
+	p.mu.Unlock()
+	p.report(false)
+}
+
+// report sends the progress to the reporter if the interval elapsed or `force` is true
+func (p *collectorProgress) report(force bool) {
+	if p.reporter == nil {
+		return
+	}
+	p.mu.Lock()
+	now := time.Now()
+	if !force && now.Sub(p.lastReported) < p.interval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastReported = now
+	pages, records := p.pages, p.records
+	totalPages := -1
+	if p.exhausted && p.pendingTotals == 0 && !p.undetermined {
+		totalPages = p.totalPages
+	}
+	p.mu.Unlock()
+	p.reporter.ReportCollectorProgress(p.rawTable, pages, totalPages, records)
+}
@@ -0,0 +1,83 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/stretchr/testify/assert"
+)
+
+type progressRecorder struct {
+	reports []core.RunningProgress
+}
+
+func (r *progressRecorder) ReportCollectorProgress(rawTable string, pages int, totalPages int, records int) {
+	r.reports = append(r.reports, core.RunningProgress{
+		Type:     core.CollectorProgress,
+		Current:  pages,
+		Total:    totalPages,
+		RawTable: rawTable,
+		Records:  records,
+	})
+}
+
+func TestCollectorProgressThrottled(t *testing.T) {
+	recorder := &progressRecorder{}
+	progress := &collectorProgress{
+		reporter: recorder,
+		rawTable: "_raw_whatever",
+		interval: time.Hour,
+	}
+	progress.expectTotalPages()
+	progress.pageDone(100)
+	progress.addTotalPages(3, true)
+	progress.pageDone(100)
+	progress.pageDone(50)
+	// only the first page got reported within the interval
+	assert.Equal(t, 1, len(recorder.reports))
+	assert.Equal(t, -1, recorder.reports[0].Total)
+
+	progress.setExhausted()
+	progress.report(true)
+	assert.Equal(t, 2, len(recorder.reports))
+	assert.Equal(t, core.RunningProgress{
+		Type:     core.CollectorProgress,
+		Current:  3,
+		Total:    3,
+		RawTable: "_raw_whatever",
+		Records:  250,
+	}, recorder.reports[1])
+}
+
+func TestCollectorProgressUndetermined(t *testing.T) {
+	recorder := &progressRecorder{}
+	progress := &collectorProgress{
+		reporter: recorder,
+		interval: 0,
+	}
+	progress.setUndetermined()
+	progress.pageDone(10)
+	progress.setExhausted()
+	progress.report(true)
+	assert.Equal(t, 2, len(recorder.reports))
+	assert.Equal(t, -1, recorder.reports[1].Total)
+	assert.Equal(t, 10, recorder.reports[1].Records)
+}
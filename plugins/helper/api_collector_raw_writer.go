@@ -0,0 +1,300 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+)
+
+// rawDataWriter inserts the raw rows of collected pages into the raw table. With a positive batchSize the rows of
+// multiple pages are buffered and bulk inserted batchSize at a time, the callbacks of the pages, i.e. marking their
+// checkpoints done, are invoked only after their rows were flushed
+type rawDataWriter struct {
+	mu        sync.Mutex
+	db        dal.Dal
+	table     string
+	params    string
+	batchSize int
+	rows      []*RawData
+	flushed   []func() errors.Error
+	// skipUnchangedRows skips rows already saved with the same params and hash
+	skipUnchangedRows bool
+	// replaceKeyedRows replaces the rows saved with the same params and record key
+	replaceKeyedRows bool
+	// skipped is the number of rows skipped since they were unchanged
+	skipped int
+	// compressRows gzips the data of the rows before they are inserted
+	compressRows bool
+	// redactor redacts the rows before they are buffered, nil to save them as they are
+	redactor *JsonRedactor
+	// afterSave is called with the rows inserted, nil to do nothing
+	afterSave func(rows []*RawData) errors.Error
+}
+
+// redactingApiClient is implemented by the api clients whose records are redacted before they are saved
+type redactingApiClient interface {
+	GetRedactor() *JsonRedactor
+}
+
+func newRawDataWriter(db dal.Dal, table string, params string, batchSize int) *rawDataWriter {
+	return &rawDataWriter{
+		db:        db,
+		table:     table,
+		params:    params,
+		batchSize: batchSize,
+	}
+}
+
+// skipUnchanged makes the writer hash the rows and skip the ones saved before with the same params and hash
+func (w *rawDataWriter) skipUnchanged() {
+	w.skipUnchangedRows = true
+}
+
+// replaceByKey makes the writer delete the rows saved before with the same params and record key as the rows
+// being inserted, so a record collected again replaces the previous one instead of being duplicated
+func (w *rawDataWriter) replaceByKey() {
+	w.replaceKeyedRows = true
+}
+
+// compress makes the writer gzip the data of the rows, after they were hashed, so unchanged rows are still
+// detected by the hashes of their data as collected
+func (w *rawDataWriter) compress() {
+	w.compressRows = true
+}
+
+// redact makes the writer redact the rows by the redactor, nil to save them as they are
+func (w *rawDataWriter) redact(redactor *JsonRedactor) {
+	w.redactor = redactor
+}
+
+// write inserts the rows of a page, or buffers them till the batch is full. The rows are redacted once before
+// anything else, so the hashes of unchanged rows are computed over the redacted data which is saved
+func (w *rawDataWriter) write(rows []*RawData, onFlushed func() errors.Error) errors.Error {
+	if w.redactor != nil {
+		for _, row := range rows {
+			data, err := w.redactor.Redact(row.Data)
+			if err != nil {
+				return errors.Default.Wrap(err, fmt.Sprintf("error redacting a raw row of %s", w.table))
+			}
+			row.Data = data
+		}
+	}
+	if w.batchSize <= 0 {
+		// rows of pages in parallel are compared with the saved ones one page after another
+		if w.skipUnchangedRows || w.replaceKeyedRows {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+		}
+		rows, err := w.prepare(rows)
+		if err != nil {
+			return err
+		}
+		if len(rows) > 0 {
+			err = w.deleteReplaced(rows)
+			if err != nil {
+				return err
+			}
+			err = w.db.Create(rows, dal.From(w.table))
+			if err != nil {
+				return errors.Default.Wrap(err, fmt.Sprintf("error inserting raw rows into %s", w.table))
+			}
+			err = w.saved(rows)
+			if err != nil {
+				return err
+			}
+		}
+		return onFlushed()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rows = append(w.rows, rows...)
+	w.flushed = append(w.flushed, onFlushed)
+	if len(w.rows) < w.batchSize {
+		return nil
+	}
+	return w.flushLocked()
+}
+
+// getSkipped returns the number of rows skipped since they were unchanged
+func (w *rawDataWriter) getSkipped() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.skipped
+}
+
+// flush inserts the buffered rows
+func (w *rawDataWriter) flush() errors.Error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flushLocked()
+}
+
+// flushLocked keeps the buffer if it failed halfway. The ids of the rows inserted by the batches succeeded are
+// filled in by then, and rows with an existing id are skipped, so flushing the buffer again wouldn't duplicate them
+func (w *rawDataWriter) flushLocked() errors.Error {
+	rows, err := w.prepare(w.rows)
+	if err != nil {
+		return err
+	}
+	w.rows = rows
+	for start := 0; start < len(w.rows); start += w.batchSize {
+		end := start + w.batchSize
+		if end > len(w.rows) {
+			end = len(w.rows)
+		}
+		err = w.deleteReplaced(w.rows[start:end])
+		if err != nil {
+			return err
+		}
+		err = w.db.CreateIfNotExist(w.rows[start:end], dal.From(w.table))
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("error inserting raw rows into %s", w.table))
+		}
+	}
+	// the rows are handed over once all of them were inserted, so none is handed over twice if flushing failed
+	saved := w.rows
+	w.rows = nil
+	err = w.saved(saved)
+	if err != nil {
+		return err
+	}
+	flushed := w.flushed
+	w.flushed = nil
+	for _, onFlushed := range flushed {
+		err := onFlushed()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saved hands the rows inserted over to afterSave
+func (w *rawDataWriter) saved(rows []*RawData) errors.Error {
+	if w.afterSave == nil || len(rows) == 0 {
+		return nil
+	}
+	return w.afterSave(rows)
+}
+
+// prepare drops the rows unchanged, and the rows replaced by a later one of the same record key, then compresses
+// the rows kept if enabled
+func (w *rawDataWriter) prepare(rows []*RawData) ([]*RawData, errors.Error) {
+	rows, err := w.filterUnchanged(rows)
+	if err != nil {
+		return nil, err
+	}
+	rows = w.dropReplaced(rows)
+	if w.compressRows {
+		for _, row := range rows {
+			err = row.compress()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rows, nil
+}
+
+// dropReplaced drops the rows replaced by a later one of the same record key
+func (w *rawDataWriter) dropReplaced(rows []*RawData) []*RawData {
+	if !w.replaceKeyedRows {
+		return rows
+	}
+	last := make(map[string]int, len(rows))
+	for i, row := range rows {
+		if row.RecordKey != "" {
+			last[row.RecordKey] = i
+		}
+	}
+	if len(last) == 0 {
+		return rows
+	}
+	kept := make([]*RawData, 0, len(rows))
+	for i, row := range rows {
+		if row.RecordKey == "" || last[row.RecordKey] == i {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// deleteReplaced deletes the rows saved before with the record keys of the given rows
+func (w *rawDataWriter) deleteReplaced(rows []*RawData) errors.Error {
+	if !w.replaceKeyedRows {
+		return nil
+	}
+	keys := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.RecordKey != "" {
+			keys = append(keys, row.RecordKey)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	err := w.db.Delete(&RawData{}, dal.From(w.table), dal.Where("params = ? AND record_key IN ?", w.params, keys))
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("error deleting the raw rows replaced from %s", w.table))
+	}
+	return nil
+}
+
+// filterUnchanged fills in the hashes of the rows and drops the ones saved before, along with the duplicates among
+// them. All rows are kept if skipping is not enabled
+func (w *rawDataWriter) filterUnchanged(rows []*RawData) ([]*RawData, errors.Error) {
+	if !w.skipUnchangedRows || len(rows) == 0 {
+		return rows, nil
+	}
+	hashes := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.Hash == "" {
+			hash := md5.New()
+			hash.Write(row.Input)
+			hash.Write([]byte("\n"))
+			hash.Write(row.Data)
+			row.Hash = hex.EncodeToString(hash.Sum(nil))
+		}
+		hashes = append(hashes, row.Hash)
+	}
+	var saved []string
+	err := w.db.Pluck("hash", &saved, dal.From(w.table), dal.Where("params = ? AND hash IN ?", w.params, hashes))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("error loading the hashes of raw rows from %s", w.table))
+	}
+	seen := make(map[string]bool, len(saved)+len(rows))
+	for _, hash := range saved {
+		seen[hash] = true
+	}
+	kept := make([]*RawData, 0, len(rows))
+	for _, row := range rows {
+		if seen[row.Hash] {
+			w.skipped++
+			continue
+		}
+		seen[row.Hash] = true
+		kept = append(kept, row)
+	}
+	return kept, nil
+}
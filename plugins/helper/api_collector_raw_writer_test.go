@@ -0,0 +1,205 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newRawRows(count int) []*RawData {
+	rows := make([]*RawData, count)
+	for i := range rows {
+		rows[i] = &RawData{Params: `{"ConnectionId":1}`, Data: []byte(fmt.Sprintf(`{"id":%d}`, i)), Url: "whatever"}
+	}
+	return rows
+}
+
+func TestRawDataWriterBatches(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	var batches []int
+	mockDal.On("CreateIfNotExist", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		batches = append(batches, len(args.Get(0).([]*RawData)))
+	}).Return(nil)
+
+	flushed := 0
+	onFlushed := func() errors.Error {
+		flushed++
+		return nil
+	}
+	writer := newRawDataWriter(mockDal, "_raw_whatever", `{"ConnectionId":1}`, 250)
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, writer.write(newRawRows(100), onFlushed))
+	}
+	// the pages are done only once their rows were inserted
+	assert.Equal(t, []int{250, 50}, batches)
+	assert.Equal(t, 3, flushed)
+	assert.Nil(t, writer.flush())
+	assert.Equal(t, []int{250, 50, 200}, batches)
+	assert.Equal(t, 5, flushed)
+	assert.Nil(t, writer.flush())
+	assert.Equal(t, 3, len(batches))
+	mockDal.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRawDataWriterFailure(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("CreateIfNotExist", mock.Anything, mock.Anything).Return(errors.Default.New("db is gone")).Once()
+	mockDal.On("CreateIfNotExist", mock.Anything, mock.Anything).Return(nil)
+
+	flushed := 0
+	writer := newRawDataWriter(mockDal, "_raw_whatever", `{"ConnectionId":1}`, 100)
+	err := writer.write(newRawRows(100), func() errors.Error {
+		flushed++
+		return nil
+	})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "_raw_whatever")
+	}
+	assert.Equal(t, 0, flushed)
+	// the buffer is kept for the rows to be inserted by the next flush
+	assert.Nil(t, writer.flush())
+	assert.Equal(t, 1, flushed)
+}
+
+func TestRawDataWriterPageByPage(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil).Twice()
+
+	flushed := 0
+	writer := newRawDataWriter(mockDal, "_raw_whatever", `{"ConnectionId":1}`, 0)
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, writer.write(newRawRows(100), func() errors.Error {
+			flushed++
+			return nil
+		}))
+	}
+	assert.Equal(t, 2, flushed)
+	mockDal.AssertExpectations(t)
+}
+
+func TestRawDataWriterAfterSave(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("CreateIfNotExist", mock.Anything, mock.Anything).Return(errors.Default.New("db is gone")).Once()
+	mockDal.On("CreateIfNotExist", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	var saved []int
+	afterSave := func(rows []*RawData) errors.Error {
+		saved = append(saved, len(rows))
+		return nil
+	}
+	onFlushed := func() errors.Error { return nil }
+	writer := newRawDataWriter(mockDal, "_raw_whatever", `{"ConnectionId":1}`, 150)
+	writer.afterSave = afterSave
+	assert.Nil(t, writer.write(newRawRows(100), onFlushed))
+	assert.NotNil(t, writer.write(newRawRows(100), onFlushed))
+	// nothing is handed over till the rows were inserted
+	assert.Empty(t, saved)
+	assert.Nil(t, writer.flush())
+	assert.Equal(t, []int{200}, saved)
+
+	writer = newRawDataWriter(mockDal, "_raw_whatever", `{"ConnectionId":1}`, 0)
+	writer.afterSave = afterSave
+	assert.Nil(t, writer.write(newRawRows(30), onFlushed))
+	assert.Nil(t, writer.write(nil, onFlushed))
+	assert.Equal(t, []int{200, 30}, saved)
+}
+
+// BenchmarkRawDataWriter writes 100k raw rows in pages of 100, page by page and in batches. The database is faked
+// with a fixed round trip per statement plus a cost per row, run it against a real one for absolute numbers
+func BenchmarkRawDataWriter(b *testing.B) {
+	const roundTrip, perRow = 500 * time.Microsecond, 2 * time.Microsecond
+	pages := newRawRows(100)
+	for _, batchSize := range []int{0, 500, 1000, 5000} {
+		b.Run(fmt.Sprintf("batch-%d", batchSize), func(b *testing.B) {
+			mockDal := new(mocks.Dal)
+			insert := func(args mock.Arguments) {
+				time.Sleep(roundTrip + time.Duration(len(args.Get(0).([]*RawData)))*perRow)
+			}
+			mockDal.On("Create", mock.Anything, mock.Anything).Run(insert).Return(nil)
+			mockDal.On("CreateIfNotExist", mock.Anything, mock.Anything).Run(insert).Return(nil)
+			onFlushed := func() errors.Error { return nil }
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				writer := newRawDataWriter(mockDal, "_raw_whatever", `{"ConnectionId":1}`, batchSize)
+				for i := 0; i < 1000; i++ {
+					if err := writer.write(pages, onFlushed); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := writer.flush(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRawDataWriterSkipUnchanged(t *testing.T) {
+	saved := newRawRows(3)
+	mockDal := new(mocks.Dal)
+	mockDal.On("Pluck", "hash", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		hashes := args.Get(1).(*[]string)
+		for _, row := range saved {
+			*hashes = append(*hashes, row.Hash)
+		}
+	}).Return(nil)
+	var inserted []*RawData
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		inserted = append(inserted, args.Get(0).([]*RawData)...)
+	}).Return(nil)
+
+	writer := newRawDataWriter(mockDal, "_raw_whatever", `{"ConnectionId":1}`, 0)
+	writer.skipUnchanged()
+	// the previous collection saved the first 3 records
+	_, err := writer.filterUnchanged(saved)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, writer.getSkipped())
+	writer.skipped = 0
+
+	rows := newRawRows(5)
+	// the content of the 2nd record was changed, and the last one was collected twice
+	rows[1].Data = []byte(`{"id":1,"updated":true}`)
+	rows = append(rows, newRawRows(5)[4])
+	flushed := false
+	assert.Nil(t, writer.write(rows, func() errors.Error {
+		flushed = true
+		return nil
+	}))
+	assert.True(t, flushed)
+	assert.Equal(t, 3, writer.getSkipped())
+	if assert.Len(t, inserted, 3) {
+		assert.Equal(t, rows[1].Data, inserted[0].Data)
+		assert.Equal(t, rows[3].Data, inserted[1].Data)
+		assert.Equal(t, rows[4].Data, inserted[2].Data)
+		assert.Len(t, inserted[0].Hash, 32)
+	}
+
+	// records of a different input are saved on their own
+	rows = newRawRows(1)
+	rows[0].Input = []byte(`{"key":"K-1"}`)
+	assert.Nil(t, writer.write(rows, func() errors.Error { return nil }))
+	assert.Len(t, inserted, 4)
+}
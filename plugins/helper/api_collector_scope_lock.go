@@ -0,0 +1,185 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	goerror "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrScopeLocked is wrapped by the error of `ScopeLock.Lock` if the scope was still locked by another holder once
+// the wait was over
+var ErrScopeLocked = errors.Default.New("the scope is being collected by another collection")
+
+// ScopeLockKey identifies the scope of a collection by its raw table and params, i.e. the connection and the board
+type ScopeLockKey struct {
+	RawTable string
+	Params   string
+}
+
+// ScopeLock keeps two collections of the same scope from running at the same time, i.e. in the pipelines of two
+// blueprints sharing a board, which would race on the raw table. It might be backed by anything shared by the
+// processes, see DalScopeLock for the one backed by the database
+type ScopeLock interface {
+	// Lock acquires the lock of the scope, waiting up to `wait` while it is held by another holder, 0 fails right
+	// away. The lock is held till the returned function is called
+	Lock(ctx context.Context, key ScopeLockKey, wait time.Duration) (unlock func() errors.Error, err errors.Error)
+}
+
+// ScopeLockRecord is a lock held on a scope by a DalScopeLock. The lock expires unless it is renewed by its holder,
+// so a lock left by a process gone is taken over once it expired
+type ScopeLockRecord struct {
+	RawTable  string `gorm:"primaryKey;type:varchar(255)"`
+	Params    string `gorm:"primaryKey;type:varchar(255)"`
+	Holder    string `gorm:"type:varchar(255)"`
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (ScopeLockRecord) TableName() string {
+	return "_devlake_scope_locks"
+}
+
+const (
+	defaultScopeLockTtl          = time.Minute
+	defaultScopeLockPollInterval = time.Second
+)
+
+// DalScopeLock is the ScopeLock backed by the database, a lock is a row of the scope inserted by its holder, the
+// primary key rejects the rows of the other holders. The row is renewed every third of the ttl while it is held
+type DalScopeLock struct {
+	db           dal.Dal
+	ttl          time.Duration
+	pollInterval time.Duration
+}
+
+var _ ScopeLock = (*DalScopeLock)(nil)
+
+// NewDalScopeLock creates a DalScopeLock on the database
+func NewDalScopeLock(db dal.Dal) *DalScopeLock {
+	return &DalScopeLock{
+		db:           db,
+		ttl:          defaultScopeLockTtl,
+		pollInterval: defaultScopeLockPollInterval,
+	}
+}
+
+// Lock polls the row of the scope till it is inserted, or the wait is over
+func (l *DalScopeLock) Lock(ctx context.Context, key ScopeLockKey, wait time.Duration) (func() errors.Error, errors.Error) {
+	holder := uuid.NewString()
+	deadline := time.Now().Add(wait)
+	// the lock might not be bound to any context
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	for {
+		current, err := l.tryLock(key, holder)
+		if err != nil {
+			return nil, err
+		}
+		if current == holder {
+			return l.keepAlive(key, holder), nil
+		}
+		delay := time.Until(deadline)
+		if delay <= 0 {
+			return nil, errors.Default.Wrap(ErrScopeLocked, fmt.Sprintf("%s of %s is locked by %s", key.Params, key.RawTable, current))
+		}
+		if delay > l.pollInterval {
+			delay = l.pollInterval
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-done:
+			timer.Stop()
+			return nil, errors.Convert(ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// tryLock inserts the row of the scope after deleting it if it expired, the holder of the row is returned
+func (l *DalScopeLock) tryLock(key ScopeLockKey, holder string) (string, errors.Error) {
+	now := time.Now()
+	scope := dal.Where("raw_table = ? AND params = ?", key.RawTable, key.Params)
+	err := l.db.Delete(&ScopeLockRecord{}, scope, dal.Where("expires_at < ?", now))
+	if err != nil {
+		return "", errors.Default.Wrap(err, "error deleting the expired scope lock")
+	}
+	createErr := l.db.Create(&ScopeLockRecord{
+		RawTable:  key.RawTable,
+		Params:    key.Params,
+		Holder:    holder,
+		ExpiresAt: now.Add(l.ttl),
+	})
+	if createErr == nil {
+		return holder, nil
+	}
+	// the row of another holder rejects the insert
+	current := &ScopeLockRecord{}
+	err = l.db.First(current, scope)
+	if err != nil {
+		if goerror.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.Default.Wrap(createErr, "error inserting the scope lock")
+		}
+		return "", errors.Default.Wrap(err, "error loading the scope lock")
+	}
+	return current.Holder, nil
+}
+
+// keepAlive renews the row of the scope till the returned function is called, which deletes the row
+func (l *DalScopeLock) keepAlive(key ScopeLockKey, holder string) func() errors.Error {
+	held := dal.Where("raw_table = ? AND params = ? AND holder = ?", key.RawTable, key.Params, holder)
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				// a failed renewal is retried by the next tick, the lock is only lost once it expired
+				_ = l.db.UpdateColumn(&ScopeLockRecord{}, "expires_at", time.Now().Add(l.ttl), held)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() errors.Error {
+		var err errors.Error
+		once.Do(func() {
+			close(stop)
+			<-stopped
+			err = l.db.Delete(&ScopeLockRecord{}, held)
+			if err != nil {
+				err = errors.Default.Wrap(err, "error deleting the scope lock")
+			}
+		})
+		return err
+	}
+}
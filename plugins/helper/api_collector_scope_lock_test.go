@@ -0,0 +1,178 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// newScopeLockDal mocks the table of the scope locks by a map, the rows are keyed by the raw table and params and
+// matched against the holder or the expiry by the clauses of DalScopeLock
+func newScopeLockDal(t *testing.T) *mocks.Dal {
+	var mu sync.Mutex
+	rows := map[ScopeLockKey]*ScopeLockRecord{}
+	matches := func(row *ScopeLockRecord, clauses []dal.Clause) bool {
+		for _, clause := range clauses {
+			where := clause.Data.(dal.DalClause)
+			if where.Params[0] == row.RawTable && where.Params[1] == row.Params {
+				if strings.Contains(where.Expr, "holder") && where.Params[2] != row.Holder {
+					return false
+				}
+				continue
+			}
+			if strings.Contains(where.Expr, "expires_at") && !row.ExpiresAt.Before(where.Params[0].(time.Time)) {
+				return false
+			}
+			if !strings.Contains(where.Expr, "expires_at") {
+				return false
+			}
+		}
+		return true
+	}
+	mockDal := mocks.NewDal(t)
+	mockDal.On("Delete", mock.Anything, mock.Anything).Return(func(_ interface{}, clauses ...dal.Clause) errors.Error {
+		mu.Lock()
+		defer mu.Unlock()
+		for key, row := range rows {
+			if matches(row, clauses) {
+				delete(rows, key)
+			}
+		}
+		return nil
+	}).Maybe()
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(func(entity interface{}, _ ...dal.Clause) errors.Error {
+		mu.Lock()
+		defer mu.Unlock()
+		row := *entity.(*ScopeLockRecord)
+		key := ScopeLockKey{RawTable: row.RawTable, Params: row.Params}
+		if rows[key] != nil {
+			return errors.Default.New("duplicate entry")
+		}
+		rows[key] = &row
+		return nil
+	}).Maybe()
+	mockDal.On("First", mock.Anything, mock.Anything).Return(func(dst interface{}, clauses ...dal.Clause) errors.Error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range rows {
+			if matches(row, clauses) {
+				*dst.(*ScopeLockRecord) = *row
+				return nil
+			}
+		}
+		return errors.Convert(gorm.ErrRecordNotFound)
+	}).Maybe()
+	mockDal.On("UpdateColumn", mock.Anything, "expires_at", mock.Anything, mock.Anything).Return(func(_ interface{}, _ string, value interface{}, clauses ...dal.Clause) errors.Error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range rows {
+			if matches(row, clauses) {
+				row.ExpiresAt = value.(time.Time)
+			}
+		}
+		return nil
+	}).Maybe()
+	return mockDal
+}
+
+func TestDalScopeLock(t *testing.T) {
+	lock := NewDalScopeLock(newScopeLockDal(t))
+	lock.pollInterval = 10 * time.Millisecond
+	key := ScopeLockKey{RawTable: "_raw_jira_api_epics", Params: `{"ConnectionId":1,"BoardId":2}`}
+
+	unlock, err := lock.Lock(context.Background(), key, 0)
+	assert.Nil(t, err)
+
+	// a second acquirer fails right away while the first holds the lock
+	_, err = lock.Lock(context.Background(), key, 0)
+	assert.True(t, errors.Is(err, ErrScopeLocked))
+
+	// another scope is not affected
+	unlockOther, err := lock.Lock(context.Background(), ScopeLockKey{RawTable: key.RawTable, Params: `{"ConnectionId":1,"BoardId":3}`}, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, unlockOther())
+
+	// a waiting acquirer blocks till the first one unlocks
+	acquired := make(chan errors.Error)
+	go func() {
+		unlock, err := lock.Lock(context.Background(), key, time.Minute)
+		if err == nil {
+			err = unlock()
+		}
+		acquired <- err
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("the lock was acquired while it was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Nil(t, unlock())
+	select {
+	case err = <-acquired:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the lock was not acquired once it was released")
+	}
+	// unlocking twice does nothing
+	assert.Nil(t, unlock())
+
+	// the wait is cut short by the context
+	unlock, err = lock.Lock(context.Background(), key, 0)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, err = lock.Lock(ctx, key, time.Minute)
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrScopeLocked))
+	assert.Nil(t, unlock())
+}
+
+func TestDalScopeLockExpiry(t *testing.T) {
+	lock := NewDalScopeLock(newScopeLockDal(t))
+	lock.pollInterval = 10 * time.Millisecond
+	key := ScopeLockKey{RawTable: "_raw_jira_api_epics", Params: `{"ConnectionId":1,"BoardId":2}`}
+
+	// the lock is kept by its holder beyond the ttl
+	lock.ttl = 60 * time.Millisecond
+	unlock, err := lock.Lock(context.Background(), key, 0)
+	assert.Nil(t, err)
+	time.Sleep(150 * time.Millisecond)
+	_, err = lock.Lock(context.Background(), key, 0)
+	assert.True(t, errors.Is(err, ErrScopeLocked))
+	assert.Nil(t, unlock())
+
+	// a lock left by a holder gone is taken over once it expired
+	gone := NewDalScopeLock(lock.db)
+	gone.ttl = -time.Second
+	_, err = gone.tryLock(key, "gone")
+	assert.Nil(t, err)
+	unlock, err = lock.Lock(context.Background(), key, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, unlock())
+}
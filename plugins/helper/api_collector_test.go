@@ -19,14 +19,25 @@ package helper
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/apache/incubator-devlake/errors"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/apache/incubator-devlake/helpers/unithelper"
 	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
 	"github.com/apache/incubator-devlake/plugins/helper/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -99,3 +110,1129 @@ func TestFetchPageUndetermined(t *testing.T) {
 
 	mockDal.AssertExpectations(t)
 }
+
+func TestDryRun(t *testing.T) {
+	// dal and api client must not be touched in dry-run mode
+	mockDal := new(mocks.Dal)
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+
+	mockInput := new(mocks.Iterator)
+	mockInput.On("HasNext").Return(true).Times(3)
+	mockInput.On("HasNext").Return(false).Once()
+	mockInput.On("Fetch").Return([]interface{}{1, 2, 3}, nil).Times(3)
+	mockInput.On("Close").Return(nil)
+
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:      mockApi,
+		Input:          mockInput,
+		UrlTemplate:    "whatever url",
+		PageSize:       2,
+		GetTotalPages:  func(res *http.Response, args *ApiCollectorArgs) (int, errors.Error) { return 0, nil },
+		ResponseParser: GetRawMessageArrayFromResponse,
+		DryRun:         true,
+		EstimateTotalPages: func(reqData *RequestData) (int, errors.Error) {
+			size := len(reqData.Input.([]interface{}))
+			return (size + reqData.Pager.Size - 1) / reqData.Pager.Size, nil
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	assert.Equal(t, 6, collector.GetDryRunRequests())
+
+	mockDal.AssertExpectations(t)
+	mockApi.AssertNotCalled(t, "DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockInput.AssertExpectations(t)
+}
+
+func TestFetchPagesSequentially(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil).Times(3)
+
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+
+	// simulate an api returns the cursor of next page in the response body, the last page has no cursor
+	var cursors []string
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		cursor := args.Get(1).(url.Values).Get("cursor")
+		cursors = append(cursors, cursor)
+		body := map[string]string{
+			"":   `{"items":[1,2],"next":"c2"}`,
+			"c2": `{"items":[3,4],"next":"c3"}`,
+			"c3": `{"items":[5]}`,
+		}[cursor]
+		res := &http.Response{
+			Request: &http.Request{
+				URL: &url.URL{},
+			},
+			Body: ioutil.NopCloser(bytes.NewBufferString(body)),
+		}
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	}).Times(3)
+	mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+		handler := args.Get(0).(func() errors.Error)
+		assert.Nil(t, handler())
+	}).Twice()
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	type page struct {
+		Items []json.RawMessage `json:"items"`
+		Next  string            `json:"next"`
+	}
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:   mockApi,
+		UrlTemplate: "whatever url",
+		PageSize:    2,
+		Query: func(reqData *RequestData) (url.Values, errors.Error) {
+			query := url.Values{}
+			if reqData.CustomData != nil {
+				query.Set("cursor", reqData.CustomData.(string))
+			}
+			return query, nil
+		},
+		GetNextPageCustomData: func(prevReqData *RequestData, prevPageResponse *http.Response) (interface{}, errors.Error) {
+			body := &page{}
+			err := UnmarshalResponse(prevPageResponse, body)
+			if err != nil {
+				return nil, err
+			}
+			if body.Next == "" {
+				return nil, ErrFinishCollect
+			}
+			return body.Next, nil
+		},
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			body := &page{}
+			err := UnmarshalResponse(res, body)
+			return body.Items, err
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	assert.Equal(t, []string{"", "c2", "c3"}, cursors)
+
+	mockDal.AssertExpectations(t)
+	mockApi.AssertExpectations(t)
+}
+
+func TestFetchPagesWithoutReliableTotal(t *testing.T) {
+	// 5 records in total, 2 records per page
+	pages := []string{
+		`{"items":[1,2],"isLast":false%s}`,
+		`{"items":[3,4],"isLast":false%s}`,
+		`{"items":[5],"isLast":true%s}`,
+	}
+	cases := []struct {
+		name     string
+		total    string
+		requests int
+	}{
+		{name: "reliable total", total: `,"total":5`, requests: 3},
+		{name: "missing total", total: ``, requests: 3},
+		{name: "unknown total", total: `,"total":-1`, requests: 3},
+		// an empty result set is reported with 0 total, only the first page is fetched
+		{name: "zero total", total: `,"total":0`, requests: 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mockDal := new(mocks.Dal)
+			mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+			mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			mockDal.On("Create", mock.Anything, mock.Anything).Return(nil)
+			mockCtx := unithelper.DummySubTaskContext(mockDal)
+
+			requests := 0
+			mockApi := new(mocks.RateLimitedApiClient)
+			mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				requests++
+				page, _ := strconv.Atoi(args.Get(1).(url.Values).Get("page"))
+				res := &http.Response{
+					Request: &http.Request{
+						URL: &url.URL{},
+					},
+					Body: ioutil.NopCloser(bytes.NewBufferString(fmt.Sprintf(pages[page-1], c.total))),
+				}
+				handler := args.Get(3).(common.ApiAsyncCallback)
+				assert.Nil(t, handler(res))
+			})
+			mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+				handler := args.Get(0).(func() errors.Error)
+				assert.Nil(t, handler())
+			})
+			mockApi.On("WaitAsync").Return(nil)
+			mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+			mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+			type page struct {
+				Items  []json.RawMessage `json:"items"`
+				Total  *int              `json:"total"`
+				IsLast bool              `json:"isLast"`
+			}
+			collector, err := NewApiCollector(ApiCollectorArgs{
+				RawDataSubTaskArgs: RawDataSubTaskArgs{
+					Ctx:    mockCtx,
+					Table:  "whatever rawtable",
+					Params: "whatever params",
+				},
+				ApiClient:   mockApi,
+				UrlTemplate: "whatever url",
+				PageSize:    2,
+				Query: func(reqData *RequestData) (url.Values, errors.Error) {
+					query := url.Values{}
+					query.Set("page", fmt.Sprintf("%v", reqData.Pager.Page))
+					return query, nil
+				},
+				GetTotalPages: func(res *http.Response, args *ApiCollectorArgs) (int, errors.Error) {
+					body := &page{}
+					err := UnmarshalResponse(res, body)
+					if err != nil {
+						return 0, err
+					}
+					if body.Total == nil || *body.Total < 0 {
+						return UnknownTotalPages, nil
+					}
+					return (*body.Total + args.PageSize - 1) / args.PageSize, nil
+				},
+				IsLastPage: func(res *http.Response, args *ApiCollectorArgs) (bool, errors.Error) {
+					body := &page{}
+					err := UnmarshalResponse(res, body)
+					return body.IsLast, err
+				},
+				ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+					body := &page{}
+					err := UnmarshalResponse(res, body)
+					return body.Items, err
+				},
+			})
+
+			assert.Nil(t, err)
+			assert.Nil(t, collector.Execute())
+			assert.Equal(t, c.requests, requests)
+		})
+	}
+}
+
+func TestResponseTransformer(t *testing.T) {
+	var saved []string
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		for _, row := range args.Get(0).([]*RawData) {
+			saved = append(saved, string(row.Data))
+		}
+	}).Return(nil).Once()
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		res := &http.Response{
+			Request: &http.Request{
+				URL: &url.URL{},
+			},
+			Body: ioutil.NopCloser(bytes.NewBufferString(
+				`[{"id":1,"secret":"a"},{"id":2,"secret":"b"},{"id":3,"drop":true},"malformed"]`,
+			)),
+		}
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	}).Once()
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:      mockApi,
+		UrlTemplate:    "whatever url",
+		ResponseParser: GetRawMessageArrayFromResponse,
+		// redact `secret`, drop records marked with `drop`, and fail records that are not objects
+		ResponseTransformer: func(msg json.RawMessage) (json.RawMessage, errors.Error) {
+			record := map[string]interface{}{}
+			err := json.Unmarshal(msg, &record)
+			if err != nil {
+				return nil, errors.Convert(err)
+			}
+			if record["drop"] == true {
+				return nil, nil
+			}
+			delete(record, "secret")
+			return errors.Convert01(json.Marshal(record))
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	assert.Equal(t, []string{`{"id":1}`, `{"id":2}`}, saved)
+	mockDal.AssertExpectations(t)
+}
+
+// redactingMockApiClient is an api client configured with a redactor like the clients of the connections
+type redactingMockApiClient struct {
+	*mocks.RateLimitedApiClient
+	redactor *JsonRedactor
+}
+
+func (c *redactingMockApiClient) GetRedactor() *JsonRedactor {
+	return c.redactor
+}
+
+func TestRedactedRawData(t *testing.T) {
+	var saved []string
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		for _, row := range args.Get(0).([]*RawData) {
+			saved = append(saved, string(row.Data))
+		}
+	}).Return(nil).Once()
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		res := &http.Response{
+			Request: &http.Request{
+				URL: &url.URL{},
+			},
+			Body: ioutil.NopCloser(bytes.NewBufferString(
+				`[{"id":1,"fields":{"reporter":{"emailAddress":"a@example.com"}}},{"id":2}]`,
+			)),
+		}
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	}).Once()
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+	redactor, err := NewJsonRedactor([]string{"fields.reporter.emailAddress"}, RedactionNull)
+	assert.Nil(t, err)
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:      &redactingMockApiClient{RateLimitedApiClient: mockApi, redactor: redactor},
+		UrlTemplate:    "whatever url",
+		ResponseParser: GetRawMessageArrayFromResponse,
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	// records without the field are saved as they are
+	assert.Equal(t, []string{`{"fields":{"reporter":{"emailAddress":null}},"id":1}`, `{"id":2}`}, saved)
+	mockDal.AssertExpectations(t)
+}
+
+type statsRecordingSubTaskContext struct {
+	core.SubTaskContext
+	stats   []core.CollectorStats
+	results []core.SubTaskResult
+}
+
+func (c *statsRecordingSubTaskContext) ReportCollectorStats(stats core.CollectorStats) {
+	c.stats = append(c.stats, stats)
+}
+
+func (c *statsRecordingSubTaskContext) ReportSubTaskResult(result core.SubTaskResult) {
+	c.results = append(c.results, result)
+}
+
+func TestCollectorStats(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil).Twice()
+	mockCtx := &statsRecordingSubTaskContext{SubTaskContext: unithelper.DummySubTaskContext(mockDal)}
+
+	bodies := []string{`[{"id":1},{"id":2}]`, `[{"id":3}]`}
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		res := &http.Response{
+			Request: &http.Request{
+				URL: &url.URL{},
+			},
+			Body: ioutil.NopCloser(bytes.NewBufferString(bodies[0])),
+		}
+		bodies = bodies[1:]
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	}).Twice()
+	mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+		assert.Nil(t, args.Get(0).(func() errors.Error)())
+	}).Once()
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:      mockApi,
+		UrlTemplate:    "whatever url",
+		PageSize:       2,
+		ResponseParser: GetRawMessageArrayFromResponse,
+		GetTotalPages: func(res *http.Response, args *ApiCollectorArgs) (int, errors.Error) {
+			return 2, nil
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	if assert.Len(t, mockCtx.stats, 1) {
+		stats := mockCtx.stats[0]
+		assert.Equal(t, "_raw_whatever rawtable", stats.RawTable)
+		assert.Equal(t, 2, stats.Requests)
+		assert.Equal(t, int64(len(`[{"id":1},{"id":2}]`)+len(`[{"id":3}]`)), stats.Bytes)
+		assert.True(t, stats.Duration > 0)
+		assert.Equal(t, 0, stats.InputBatches)
+	}
+	assert.Equal(t, []core.SubTaskResult{{Records: 3, Pages: 2}}, mockCtx.results)
+	mockDal.AssertExpectations(t)
+	mockApi.AssertExpectations(t)
+}
+
+func TestCollectorInputStats(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil).Twice()
+	mockCtx := &statsRecordingSubTaskContext{SubTaskContext: unithelper.DummySubTaskContext(mockDal)}
+
+	// a slow db cursor behind the iterator
+	mockInput := new(mocks.Iterator)
+	mockInput.On("HasNext").Return(true).Twice()
+	mockInput.On("HasNext").Return(false).Twice()
+	mockInput.On("Fetch").Return(1, nil).After(10 * time.Millisecond).Twice()
+	mockInput.On("Close").Return(nil)
+
+	mockApi := new(mocks.RateLimitedApiClient)
+	page := 0
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		page++
+		res := &http.Response{
+			Request: &http.Request{URL: &url.URL{}},
+			Body:    ioutil.NopCloser(bytes.NewBufferString(fmt.Sprintf(`[{"id":%d}]`, page))),
+		}
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	}).Twice()
+	mockApi.On("HasError").Return(false)
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:      mockApi,
+		Input:          mockInput,
+		UrlTemplate:    "whatever url",
+		ResponseParser: GetRawMessageArrayFromResponse,
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	if assert.Len(t, mockCtx.stats, 1) {
+		stats := mockCtx.stats[0]
+		assert.Equal(t, 2, stats.Requests)
+		assert.Equal(t, 2, stats.InputBatches)
+		assert.True(t, stats.InputDuration >= 20*time.Millisecond, stats.InputDuration)
+		assert.True(t, stats.InputDuration <= stats.Duration)
+	}
+	mockInput.AssertExpectations(t)
+	mockApi.AssertExpectations(t)
+}
+
+func TestResumeCollection(t *testing.T) {
+	pageQuery := func(page int) url.Values {
+		return url.Values{"page": {strconv.Itoa(page)}}
+	}
+	// pages 1 and 2 were done by the interrupted run, while page 3 was interrupted halfway
+	checkpoints := []CollectorCheckpoint{
+		{PageHash: pageHash("whatever url", pageQuery(1)), Url: "u1", ResumeKey: "key", Done: true},
+		{PageHash: pageHash("whatever url", pageQuery(2)), Url: "u2", ResumeKey: "key", Done: true},
+		{PageHash: pageHash("whatever url", pageQuery(3)), Url: "u3", ResumeKey: "key"},
+	}
+	var deleted []string
+	var saved []CollectorCheckpoint
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Delete", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		deleted = append(deleted, fmt.Sprintf("%T %v", args.Get(0), args.Get(1)))
+	}).Return(nil)
+	mockDal.On("All", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*[]CollectorCheckpoint) = checkpoints
+	}).Return(nil).Once()
+	mockDal.On("CreateOrUpdate", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, *args.Get(0).(*CollectorCheckpoint))
+	}).Return(nil)
+	// only the rows of page 3 are saved again
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
+
+	var requested []string
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		page := args.Get(1).(url.Values).Get("page")
+		requested = append(requested, page)
+		res := &http.Response{
+			Request: &http.Request{
+				URL: &url.URL{Path: "u" + page},
+			},
+			Body: ioutil.NopCloser(bytes.NewBufferString(`{"items":[1,2],"total":3}`)),
+		}
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	})
+	mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+		handler := args.Get(0).(func() errors.Error)
+		assert.Nil(t, handler())
+	})
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    unithelper.DummySubTaskContext(mockDal),
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:   mockApi,
+		UrlTemplate: "whatever url",
+		PageSize:    2,
+		ResumeKey:   "key",
+		Query: func(reqData *RequestData) (url.Values, errors.Error) {
+			return pageQuery(reqData.Pager.Page), nil
+		},
+		GetTotalPages: func(res *http.Response, args *ApiCollectorArgs) (int, errors.Error) {
+			return 3, nil
+		},
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			body := &struct {
+				Items []json.RawMessage `json:"items"`
+			}{}
+			err := UnmarshalResponse(res, body)
+			return body.Items, err
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+
+	// the first page is requested for the total pages, but only the interrupted page gets collected again
+	assert.Equal(t, []string{"1", "3"}, requested)
+	if assert.Equal(t, 2, len(saved)) {
+		assert.Equal(t, "u3", saved[0].Url)
+		assert.False(t, saved[0].Done)
+		assert.True(t, saved[1].Done)
+	}
+	// stale checkpoints, the raw rows of the interrupted page and its checkpoint are deleted, the collected raw rows
+	// are kept though the collection is not incremental, and the checkpoints are cleared once completed
+	if assert.Equal(t, 4, len(deleted)) {
+		assert.Contains(t, deleted[0], "*helper.CollectorCheckpoint")
+		assert.Contains(t, deleted[0], "resume_key != ?")
+		assert.Contains(t, deleted[1], "*helper.RawData")
+		assert.Contains(t, deleted[1], "[u3]")
+		assert.Contains(t, deleted[2], "done = ?")
+		assert.Contains(t, deleted[3], "*helper.CollectorCheckpoint")
+		assert.NotContains(t, deleted[3], "done = ?")
+	}
+	mockDal.AssertExpectations(t)
+	mockApi.AssertExpectations(t)
+}
+
+func TestShutdownFlushesCollectedPages(t *testing.T) {
+	var saved []CollectorCheckpoint
+	var deleted []string
+	var inserted []*RawData
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("All", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Delete", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		deleted = append(deleted, fmt.Sprintf("%T %v", args.Get(0), args.Get(1)))
+	}).Return(nil)
+	mockDal.On("CreateOrUpdate", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, *args.Get(0).(*CollectorCheckpoint))
+	}).Return(nil)
+	mockDal.On("CreateIfNotExist", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		inserted = append(inserted, args.Get(0).([]*RawData)...)
+	}).Return(nil)
+
+	shutdown := make(chan struct{})
+	mockCtx := new(mocks.SubTaskContext)
+	mockCtx.On("GetDal").Return(mockDal)
+	mockCtx.On("GetLogger").Return(unithelper.DummyLogger())
+	mockCtx.On("SetProgress", mock.Anything, mock.Anything)
+	mockCtx.On("IncProgress", mock.Anything, mock.Anything)
+	mockCtx.On("GetName").Return("test")
+	mockCtx.On("GetContext").Return(core.WithShutdown(context.Background(), shutdown))
+
+	var requested []string
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		page := args.Get(1).(url.Values).Get("page")
+		requested = append(requested, page)
+		res := &http.Response{
+			Request: &http.Request{URL: &url.URL{Path: "u" + page}},
+			Body:    ioutil.NopCloser(bytes.NewBufferString(`{"items":[1,2],"last":false}`)),
+		}
+		// the process is asked to shut down while the first page is in flight
+		close(shutdown)
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	}).Once()
+	mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+		handler := args.Get(0).(func() errors.Error)
+		assert.Nil(t, handler())
+	})
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:    mockApi,
+		UrlTemplate:  "whatever url",
+		PageSize:     2,
+		ResumeKey:    "key",
+		RawBatchSize: 100,
+		Query: func(reqData *RequestData) (url.Values, errors.Error) {
+			return url.Values{"page": {strconv.Itoa(reqData.Pager.Page)}}, nil
+		},
+		IsLastPage: func(res *http.Response, args *ApiCollectorArgs) (bool, errors.Error) {
+			return false, nil
+		},
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			body := &struct {
+				Items []json.RawMessage `json:"items"`
+			}{}
+			err := UnmarshalResponse(res, body)
+			return body.Items, err
+		},
+	})
+	assert.Nil(t, err)
+	err = collector.Execute()
+	assert.Equal(t, ErrCollectionInterrupted, err)
+
+	// no page is requested after the signal, while the records of the page in flight are flushed and checkpointed
+	assert.Equal(t, []string{"1"}, requested)
+	assert.Equal(t, 2, len(inserted))
+	if assert.Equal(t, 2, len(saved)) {
+		assert.False(t, saved[0].Done)
+		assert.True(t, saved[1].Done)
+	}
+	// only the stale checkpoints and the raw rows of the previous collection are deleted, the checkpoints are kept
+	// for the next run to resume from
+	if assert.Equal(t, 2, len(deleted)) {
+		assert.Contains(t, deleted[0], "resume_key != ?")
+		assert.Contains(t, deleted[1], "*helper.RawData")
+	}
+	mockApi.AssertExpectations(t)
+}
+
+func TestCollectorUpsertsByPrimaryKey(t *testing.T) {
+	// the raw table is kept in memory
+	var table []*RawData
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		table = append(table, args.Get(0).([]*RawData)...)
+	}).Return(nil)
+	mockDal.On("Delete", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		where := args.Get(1).([]dal.Clause)[1].Data.(dal.DalClause)
+		assert.Equal(t, "params = ? AND record_key IN ?", where.Expr)
+		keys := map[string]bool{}
+		for _, key := range where.Params[1].([]string) {
+			keys[key] = true
+		}
+		kept := table[:0]
+		for _, row := range table {
+			if row.Params != where.Params[0] || !keys[row.RecordKey] {
+				kept = append(kept, row)
+			}
+		}
+		table = kept
+	}).Return(nil)
+
+	collect := func(body string) {
+		mockApi := new(mocks.RateLimitedApiClient)
+		mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			handler := args.Get(3).(common.ApiAsyncCallback)
+			assert.Nil(t, handler(&http.Response{
+				Request: &http.Request{URL: &url.URL{}},
+				Body:    ioutil.NopCloser(bytes.NewBufferString(body)),
+			}))
+		}).Once()
+		mockApi.On("WaitAsync").Return(nil)
+		mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+		mockApi.On("SetAfterFunction", mock.Anything).Return()
+		collector, err := NewApiCollector(ApiCollectorArgs{
+			RawDataSubTaskArgs: RawDataSubTaskArgs{
+				Ctx:    unithelper.DummySubTaskContext(mockDal),
+				Table:  "whatever rawtable",
+				Params: "whatever params",
+				PrimaryKeyExtractor: func(msg json.RawMessage) (string, errors.Error) {
+					var record struct {
+						Key string `json:"key"`
+					}
+					return record.Key, errors.Convert(json.Unmarshal(msg, &record))
+				},
+			},
+			ApiClient:      mockApi,
+			UrlTemplate:    "whatever url",
+			Incremental:    true,
+			ResponseParser: GetRawMessageArrayFromResponse,
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, collector.Execute())
+	}
+
+	collect(`[{"key":"K-1","v":1},{"key":"K-2","v":1}]`)
+	// the second run collects K-1 again, which is updated rather than duplicated
+	collect(`[{"key":"K-1","v":2},{"key":"K-3","v":1}]`)
+	data := make([]string, 0, len(table))
+	for _, row := range table {
+		data = append(data, string(row.Data))
+	}
+	assert.ElementsMatch(t, []string{`{"key":"K-2","v":1}`, `{"key":"K-1","v":2}`, `{"key":"K-3","v":1}`}, data)
+}
+
+func TestMaxRecords(t *testing.T) {
+	// 3 pages of 100 records
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	saved := 0
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved += len(args.Get(0).([]*RawData))
+	}).Return(nil)
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+
+	requests := 0
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		requests++
+		page, _ := strconv.Atoi(args.Get(1).(url.Values).Get("page"))
+		items := make([]string, 100)
+		for i := range items {
+			items[i] = strconv.Itoa((page-1)*100 + i)
+		}
+		res := &http.Response{
+			Request: &http.Request{URL: &url.URL{}},
+			Body:    ioutil.NopCloser(bytes.NewBufferString(fmt.Sprintf(`{"total":300,"items":[%s]}`, strings.Join(items, ",")))),
+		}
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	})
+	mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+		handler := args.Get(0).(func() errors.Error)
+		assert.Nil(t, handler())
+	})
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("HasError").Return(false)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	type page struct {
+		Items []json.RawMessage `json:"items"`
+		Total int               `json:"total"`
+	}
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:   mockApi,
+		UrlTemplate: "whatever url",
+		PageSize:    100,
+		Query: func(reqData *RequestData) (url.Values, errors.Error) {
+			return url.Values{"page": {strconv.Itoa(reqData.Pager.Page)}}, nil
+		},
+		GetTotalPages: func(res *http.Response, args *ApiCollectorArgs) (int, errors.Error) {
+			body := &page{}
+			err := UnmarshalResponse(res, body)
+			return (body.Total + args.PageSize - 1) / args.PageSize, err
+		},
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			body := &page{}
+			err := UnmarshalResponse(res, body)
+			return body.Items, err
+		},
+		MaxRecords: 150,
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	assert.Equal(t, 150, saved)
+	assert.Equal(t, 150, collector.GetRecords())
+	// the third page is not requested at all
+	assert.Equal(t, 2, requests)
+}
+
+func TestPaginationStall(t *testing.T) {
+	cases := []struct {
+		name string
+		args func(args *ApiCollectorArgs)
+		// the records returned by the server, the same page is returned all the time if it is nil
+		records  func(request int) string
+		expected string
+	}{
+		{
+			// the server ignores `startAt` and returns the first page again
+			name: "offset ignored",
+			args: func(args *ApiCollectorArgs) {
+				args.IsLastPage = func(res *http.Response, args *ApiCollectorArgs) (bool, errors.Error) {
+					return false, nil
+				}
+			},
+			expected: "page 2 returned the same response as the previous page",
+		},
+		{
+			// the server echoes the cursor it was given back
+			name: "cursor echoed",
+			args: func(args *ApiCollectorArgs) {
+				args.GetNextPageCustomData = func(prevReqData *RequestData, prevPageResponse *http.Response) (interface{}, errors.Error) {
+					return "cursor", nil
+				}
+				args.Query = func(reqData *RequestData) (url.Values, errors.Error) {
+					cursor, _ := reqData.CustomData.(string)
+					return url.Values{"cursor": {cursor}}, nil
+				}
+			},
+			records: func(request int) string {
+				return strconv.Itoa(request)
+			},
+			expected: "page 3 was requested the same as the previous page",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mockDal := new(mocks.Dal)
+			mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+			mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			mockDal.On("Create", mock.Anything, mock.Anything).Return(nil)
+			mockCtx := unithelper.DummySubTaskContext(mockDal)
+
+			requests := 0
+			var handlerErr errors.Error
+			mockApi := new(mocks.RateLimitedApiClient)
+			mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				requests++
+				if requests > 10 {
+					t.Fatal("the collector kept fetching the stalled pages")
+				}
+				records := "1,2,3"
+				if c.records != nil {
+					records = c.records(requests)
+				}
+				res := &http.Response{
+					Request: &http.Request{URL: &url.URL{RawQuery: args.Get(1).(url.Values).Encode()}},
+					Body:    ioutil.NopCloser(bytes.NewBufferString(fmt.Sprintf(`{"items":[%s]}`, records))),
+				}
+				handler := args.Get(3).(common.ApiAsyncCallback)
+				if err := handler(res); err != nil && handlerErr == nil {
+					handlerErr = err
+				}
+			})
+			mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+				if handlerErr != nil {
+					return
+				}
+				handler := args.Get(0).(func() errors.Error)
+				assert.Nil(t, handler())
+			})
+			mockApi.On("WaitAsync").Return(func() errors.Error { return handlerErr })
+			mockApi.On("HasError").Return(func() bool { return handlerErr != nil })
+			mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+			mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+			args := ApiCollectorArgs{
+				RawDataSubTaskArgs: RawDataSubTaskArgs{
+					Ctx:    mockCtx,
+					Table:  "whatever rawtable",
+					Params: "whatever params",
+				},
+				ApiClient:   mockApi,
+				UrlTemplate: "whatever url",
+				PageSize:    3,
+				Query: func(reqData *RequestData) (url.Values, errors.Error) {
+					return url.Values{"startAt": {strconv.Itoa(reqData.Pager.Skip)}}, nil
+				},
+				ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+					body := &struct {
+						Items []json.RawMessage `json:"items"`
+					}{}
+					err := UnmarshalResponse(res, body)
+					return body.Items, err
+				},
+			}
+			c.args(&args)
+			collector, err := NewApiCollector(args)
+			assert.Nil(t, err)
+			err = collector.Execute()
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), c.expected)
+			}
+		})
+	}
+}
+
+func TestRawDataOnly(t *testing.T) {
+	cases := []struct {
+		name      string
+		rows      int64
+		collected bool
+	}{
+		{name: "raw data of the params", rows: 5},
+		// raw tables are shared by the boards, i.e. a newly added board has no raw data of its own
+		{name: "no raw data of the params", rows: 0, collected: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mockDal := new(mocks.Dal)
+			mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+			mockDal.On("Count", mock.Anything, mock.Anything).Return(c.rows, nil).Once()
+			mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+			mockDal.On("Create", mock.Anything, mock.Anything).Return(nil).Maybe()
+			mockCtx := unithelper.DummySubTaskContextWithContext(mockDal, WithRawDataOnly(context.Background()))
+
+			requests := 0
+			mockApi := new(mocks.RateLimitedApiClient)
+			mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				requests++
+				res := &http.Response{
+					Request: &http.Request{URL: &url.URL{}},
+					Body:    ioutil.NopCloser(bytes.NewBufferString(`[1,2,3]`)),
+				}
+				handler := args.Get(3).(common.ApiAsyncCallback)
+				assert.Nil(t, handler(res))
+			}).Maybe()
+			mockApi.On("WaitAsync").Return(nil).Maybe()
+			mockApi.On("HasError").Return(false).Maybe()
+			mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+			mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+			collector, err := NewApiCollector(ApiCollectorArgs{
+				RawDataSubTaskArgs: RawDataSubTaskArgs{
+					Ctx:    mockCtx,
+					Table:  "whatever rawtable",
+					Params: "whatever params",
+				},
+				ApiClient:   mockApi,
+				UrlTemplate: "whatever url",
+				ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+					var items []json.RawMessage
+					err := UnmarshalResponse(res, &items)
+					return items, err
+				},
+			})
+			assert.Nil(t, err)
+			assert.Nil(t, collector.Execute())
+			if c.collected {
+				assert.Equal(t, 1, requests)
+			} else {
+				// the raw data collected before is kept for the extractors
+				assert.Equal(t, 0, requests)
+				mockDal.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRawDataOnlyExtracted(t *testing.T) {
+	db := unithelper.NewMemoryDal()
+	// the raw data collected before by the options of the task
+	db.Insert("_raw_whatever", &RawData{ID: 1, Params: `"whatever params"`, Data: []byte(`{"key":"K-1"}`)},
+		&RawData{ID: 2, Params: `"whatever params"`, Data: []byte(`{"key":"K-2"}`)},
+		// the raw table is shared by other boards, whose data is left out by the extractor
+		&RawData{ID: 3, Params: `"other params"`, Data: []byte(`{"key":"K-3"}`)})
+	taskCtx := unithelper.DummySubTaskContextWithContext(db, WithRawDataOnly(context.Background()))
+	rawDataSubTaskArgs := RawDataSubTaskArgs{
+		Ctx:    taskCtx,
+		Table:  "whatever",
+		Params: "whatever params",
+	}
+
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: rawDataSubTaskArgs,
+		ApiClient:          mockApi,
+		UrlTemplate:        "whatever url",
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			return nil, nil
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	mockApi.AssertNotCalled(t, "DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// the preserved raw data is extracted as if it was just collected
+	var keys []string
+	extractor, err := NewApiExtractor(ApiExtractorArgs{
+		RawDataSubTaskArgs: rawDataSubTaskArgs,
+		Extract: func(row *RawData) ([]interface{}, errors.Error) {
+			var item struct {
+				Key string `json:"key"`
+			}
+			assert.Nil(t, errors.Convert(json.Unmarshal(row.Data, &item)))
+			keys = append(keys, item.Key)
+			return nil, nil
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, extractor.Execute())
+	assert.Equal(t, []string{"K-1", "K-2"}, keys)
+	assert.Len(t, db.Rows("_raw_whatever"), 3)
+}
+
+func TestApiCollectorConcurrencyRestoresAfterResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[1,2,3]`))
+	}))
+	defer server.Close()
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	apiClient.SetLogger(unithelper.DummyLogger())
+	var responses int32
+	afterResponse := func(res *http.Response) errors.Error {
+		atomic.AddInt32(&responses, 1)
+		return nil
+	}
+	apiClient.SetAfterFunction(afterResponse)
+	scheduler, err := NewWorkerScheduler(context.Background(), 2, 100, time.Second, 1, unithelper.DummyLogger())
+	assert.Nil(t, err)
+	asyncClient := &ApiAsyncClient{ApiClient: apiClient, maxRetry: 1, scheduler: scheduler}
+	defer asyncClient.Release()
+
+	// the collectors of a task share the api client, the afterResponse of it stays the same however many run
+	db := unithelper.NewMemoryDal()
+	for i := 0; i < 3; i++ {
+		collector, err := NewApiCollector(ApiCollectorArgs{
+			RawDataSubTaskArgs: RawDataSubTaskArgs{
+				Ctx:    unithelper.DummySubTaskContext(db),
+				Table:  "whatever",
+				Params: fmt.Sprintf("params %d", i),
+			},
+			ApiClient:      asyncClient,
+			UrlTemplate:    "whatever",
+			MaxConcurrency: 2,
+			ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+				var items []json.RawMessage
+				err := UnmarshalResponse(res, &items)
+				return items, err
+			},
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, collector.Execute())
+		assert.Equal(t, reflect.ValueOf(afterResponse).Pointer(), reflect.ValueOf(asyncClient.GetAfterFunction()).Pointer())
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&responses))
+	assert.Len(t, db.Rows("_raw_whatever"), 9)
+}
+
+// wireBody is a response body on the wire, it tells how much of it was read
+type wireBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *wireBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestApiCollectorStreamsResponse(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	var bodies []*wireBody
+	mockApi := new(mocks.RateLimitedApiClient)
+	mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		body := &wireBody{Reader: strings.NewReader(`[1,2,3]`)}
+		bodies = append(bodies, body)
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(&http.Response{StatusCode: http.StatusOK, Request: &http.Request{URL: &url.URL{}}, Body: body}))
+	}).Twice()
+	mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+		assert.Nil(t, args.Get(0).(func() errors.Error)())
+	})
+	mockApi.On("HasError").Return(false)
+	mockApi.On("WaitAsync").Return(nil)
+	mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+	mockApi.On("SetAfterFunction", mock.Anything).Return()
+
+	var unread []int
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    unithelper.DummySubTaskContext(mockDal),
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:   mockApi,
+		UrlTemplate: "whatever url",
+		PageSize:    3,
+		Query: func(reqData *RequestData) (url.Values, errors.Error) {
+			return url.Values{"page": {strconv.Itoa(reqData.Pager.Page)}}, nil
+		},
+		GetTotalPages: func(res *http.Response, args *ApiCollectorArgs) (int, errors.Error) {
+			return 2, nil
+		},
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			unread = append(unread, bodies[len(bodies)-1].Len())
+			var items []json.RawMessage
+			err := UnmarshalResponse(res, &items)
+			return items, err
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	// the first page is buffered for counting the pages, the second one is streamed to the parser
+	assert.Equal(t, []int{0, len(`[1,2,3]`)}, unread)
+	for _, body := range bodies {
+		assert.True(t, body.closed)
+	}
+	assert.Equal(t, int64(2*len(`[1,2,3]`)), atomic.LoadInt64(&collector.bytes))
+	assert.Equal(t, 6, collector.GetRecords())
+}
@@ -0,0 +1,80 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pageWatchdog cancels the requests of an ApiCollector if no page was completed within the timeout, the countdown
+// restarts on every completed page, so it doesn't limit the total duration of a collection
+type pageWatchdog struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	timeout  time.Duration
+	mu       sync.Mutex
+	timer    *time.Timer
+	timedOut bool
+}
+
+func newPageWatchdog(parent context.Context, timeout time.Duration) *pageWatchdog {
+	ctx, cancel := context.WithCancel(parent)
+	w := &pageWatchdog{
+		ctx:     ctx,
+		cancel:  cancel,
+		timeout: timeout,
+	}
+	w.timer = time.AfterFunc(timeout, w.expire)
+	return w
+}
+
+func (w *pageWatchdog) expire() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	w.cancel()
+}
+
+// pageDone restarts the countdown
+func (w *pageWatchdog) pageDone() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut {
+		w.timer.Reset(w.timeout)
+	}
+}
+
+// hasTimedOut tells if the requests were canceled due to the timeout
+func (w *pageWatchdog) hasTimedOut() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.timedOut
+}
+
+func (w *pageWatchdog) stop() {
+	w.timer.Stop()
+	w.cancel()
+}
@@ -0,0 +1,109 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newPageTimeoutCollector creates a collector of 3 pages, the response of every page is delayed by `delays`
+func newPageTimeoutCollector(t *testing.T, delays []time.Duration, pageTimeout time.Duration) (*ApiCollector, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		select {
+		case <-time.After(delays[page-1]):
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf(`[{"page":%d}]`, page)))
+	}))
+
+	taskCtx := new(mocks.TaskContext)
+	taskCtx.On("GetConfig", mock.Anything).Return("")
+	taskCtx.On("GetLogger").Return(unithelper.DummyLogger())
+	taskCtx.On("GetContext").Return(context.Background())
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	asyncClient, err := CreateAsyncApiClient(taskCtx, apiClient, &ApiRateLimitCalculator{UserRateLimitPerHour: 360000})
+	assert.Nil(t, err)
+
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil)
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+	mockCtx.On("GetContext").Return(context.Background())
+
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:   asyncClient,
+		PageSize:    1,
+		UrlTemplate: "whatever",
+		Query: func(reqData *RequestData) (url.Values, errors.Error) {
+			return url.Values{"page": {strconv.Itoa(reqData.Pager.Page)}}, nil
+		},
+		// pages are fetched one after another, so the total duration is the sum of the delays
+		GetNextPageCustomData: func(prevReqData *RequestData, prevPageResponse *http.Response) (interface{}, errors.Error) {
+			if prevReqData.Pager.Page >= len(delays) {
+				return nil, ErrFinishCollect
+			}
+			return nil, nil
+		},
+		ResponseParser: GetRawMessageArrayFromResponse,
+		PageTimeout:    pageTimeout,
+	})
+	assert.Nil(t, err)
+	return collector, func() {
+		asyncClient.Release()
+		server.Close()
+	}
+}
+
+func TestPageTimeoutResetOnEveryPage(t *testing.T) {
+	// the collection takes longer than the timeout in total, while every page is completed within it
+	collector, cleanup := newPageTimeoutCollector(t, []time.Duration{0, 200 * time.Millisecond, 200 * time.Millisecond}, 300*time.Millisecond)
+	defer cleanup()
+	assert.Nil(t, collector.Execute())
+}
+
+func TestPageTimeoutAbortsStalledCollection(t *testing.T) {
+	collector, cleanup := newPageTimeoutCollector(t, []time.Duration{0, 10 * time.Second, 0}, 300*time.Millisecond)
+	defer cleanup()
+	start := time.Now()
+	err := collector.Execute()
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.Timeout, err.GetType())
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -18,9 +18,10 @@ limitations under the License.
 package helper
 
 import (
-	"github.com/apache/incubator-devlake/errors"
+	"fmt"
 	"reflect"
 
+	"github.com/apache/incubator-devlake/errors"
 	"github.com/apache/incubator-devlake/models/common"
 
 	"github.com/apache/incubator-devlake/plugins/core"
@@ -33,6 +34,11 @@ type ApiExtractorArgs struct {
 	Params    interface{}
 	Extract   func(row *RawData) ([]interface{}, errors.Error)
 	BatchSize int
+	// StrictPrimaryKey fails the extraction on the records of which any primary key field is left zero. Records are
+	// upserted by their primary keys, so the extraction re-run over the same raw data leaves the same rows, which
+	// doesn't hold for the keys left unset by `Extract`: they collapse distinct records into one, or are filled by
+	// auto increment with a new row every run
+	StrictPrimaryKey bool
 }
 
 // ApiExtractor helps you extract Raw Data from api responses to Tool Layer Data
@@ -103,6 +109,10 @@ func (extractor *ApiExtractor) Execute() errors.Error {
 		if err != nil {
 			return errors.Default.Wrap(err, "error fetching row")
 		}
+		err = row.decompress()
+		if err != nil {
+			return err
+		}
 
 		results, err := extractor.args.Extract(row)
 		if err != nil {
@@ -115,6 +125,11 @@ func (extractor *ApiExtractor) Execute() errors.Error {
 			if err != nil {
 				return errors.Default.Wrap(err, "error getting batch from result")
 			}
+			if extractor.args.StrictPrimaryKey {
+				if field := batch.zeroPrimaryKeyField(result); field != "" {
+					return errors.Default.New(fmt.Sprintf("%T extracted from raw row %d has no value for its primary key field %s", result, row.ID, field))
+				}
+			}
 			// set raw data origin field
 			origin := reflect.ValueOf(result).Elem().FieldByName(RAW_DATA_ORIGIN)
 			if origin.IsValid() && origin.IsZero() {
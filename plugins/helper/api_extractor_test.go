@@ -0,0 +1,128 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/models/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type extractedRecord struct {
+	ConnectionId uint64 `gorm:"primaryKey"`
+	Key          string `gorm:"primaryKey"`
+	Value        int
+	common.NoPKModel
+}
+
+func (extractedRecord) TableName() string {
+	return "_tool_whatever"
+}
+
+func newRecordExtractor(t *testing.T, db *unithelper.MemoryDal, strict bool) *ApiExtractor {
+	extractor, err := NewApiExtractor(ApiExtractorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    unithelper.DummySubTaskContext(db),
+			Table:  "whatever",
+			Params: "whatever params",
+		},
+		Extract: func(row *RawData) ([]interface{}, errors.Error) {
+			record := &extractedRecord{ConnectionId: 1}
+			err := errors.Convert(json.Unmarshal(row.Data, record))
+			return []interface{}{record}, err
+		},
+		StrictPrimaryKey: strict,
+	})
+	assert.Nil(t, err)
+	return extractor
+}
+
+func TestApiExtractorIdempotent(t *testing.T) {
+	db := unithelper.NewMemoryDal()
+	db.Insert("_raw_whatever",
+		&RawData{ID: 1, Params: `"whatever params"`, Data: []byte(`{"key":"K-1","value":1}`)},
+		&RawData{ID: 2, Params: `"whatever params"`, Data: []byte(`{"key":"K-2","value":2}`)},
+		// the record collected again replaces the one collected before
+		&RawData{ID: 3, Params: `"whatever params"`, Data: []byte(`{"key":"K-1","value":3}`)},
+		&RawData{ID: 4, Params: `"other params"`, Data: []byte(`{"key":"K-4","value":4}`)},
+	)
+	extractor := newRecordExtractor(t, db, true)
+	unithelper.AssertIdempotent(t, db, extractor.Execute, "_tool_whatever")
+	rows := db.Rows("_tool_whatever")
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, 3, rows[0].(*extractedRecord).Value)
+		assert.Equal(t, uint64(3), rows[0].(*extractedRecord).RawDataId)
+		assert.Equal(t, "K-2", rows[1].(*extractedRecord).Key)
+	}
+}
+
+func TestApiExtractorStrictPrimaryKey(t *testing.T) {
+	db := unithelper.NewMemoryDal()
+	db.Insert("_raw_whatever", &RawData{ID: 1, Params: `"whatever params"`, Data: []byte(`{"value":1}`)})
+	err := newRecordExtractor(t, db, true).Execute()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "*helper.extractedRecord extracted from raw row 1 has no value for its primary key field Key")
+	assert.Empty(t, db.Rows("_tool_whatever"))
+
+	// the records are saved as they are if not strict
+	assert.Nil(t, newRecordExtractor(t, db, false).Execute())
+	assert.Len(t, db.Rows("_tool_whatever"), 1)
+}
+
+func TestApiExtractorCompressedRawData(t *testing.T) {
+	db := unithelper.NewMemoryDal()
+	// the row saved before compression was enabled
+	db.Insert("_raw_whatever", &RawData{ID: 1, Params: `"whatever params"`, Data: []byte(`{"key":"K-1","value":1}`)})
+	large := []byte(fmt.Sprintf(`{"key":"K-2","value":2,"description":"%s"}`, strings.Repeat("lorem ipsum ", 100000)))
+	writer := newRawDataWriter(db, "_raw_whatever", `"whatever params"`, 0)
+	writer.compress()
+	row := &RawData{ID: 2, Params: `"whatever params"`, Data: append([]byte(nil), large...)}
+	assert.Nil(t, writer.write([]*RawData{row}, func() errors.Error { return nil }))
+
+	saved := db.Rows("_raw_whatever")
+	if assert.Len(t, saved, 2) {
+		assert.False(t, saved[0].(*RawData).Compressed)
+		assert.True(t, saved[1].(*RawData).Compressed)
+		assert.Less(t, len(saved[1].(*RawData).Data), len(large)/10)
+	}
+
+	var extracted [][]byte
+	extractor, err := NewApiExtractor(ApiExtractorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    unithelper.DummySubTaskContext(db),
+			Table:  "whatever",
+			Params: "whatever params",
+		},
+		Extract: func(row *RawData) ([]interface{}, errors.Error) {
+			extracted = append(extracted, row.Data)
+			return nil, nil
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, extractor.Execute())
+	if assert.Len(t, extracted, 2) {
+		assert.Equal(t, `{"key":"K-1","value":1}`, string(extracted[0]))
+		assert.Equal(t, large, extracted[1])
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"sync"
+
+	"github.com/apache/incubator-devlake/errors"
+)
+
+// FairScheduler shares a number of workers among the api clients of the process by their keys, i.e. their
+// connections, so a connection collecting a huge backfill wouldn't starve the incremental collections of the others.
+// A free worker is granted to the key holding the fewest workers relative to its weight, the earliest waiter first
+// among the keys of the same share. Workers left idle by a key are granted to the others, so a single busy key gets
+// all of them
+type FairScheduler struct {
+	mu       sync.Mutex
+	capacity int
+	inUse    int
+	seq      uint64
+	keys     map[string]*fairSchedulerKey
+}
+
+// fairSchedulerKey is the workers held and the requests waiting of a key, the key is dropped once it has neither
+type fairSchedulerKey struct {
+	weight  int
+	inUse   int
+	waiters []*fairSchedulerWaiter
+}
+
+type fairSchedulerWaiter struct {
+	seq     uint64
+	granted bool
+	ready   chan struct{}
+}
+
+// NewFairScheduler creates a scheduler of `capacity` workers
+func NewFairScheduler(capacity int) (*FairScheduler, errors.Error) {
+	scheduler := &FairScheduler{keys: make(map[string]*fairSchedulerKey)}
+	err := scheduler.SetCapacity(capacity)
+	if err != nil {
+		return nil, err
+	}
+	return scheduler, nil
+}
+
+// SetCapacity changes the number of workers, the workers granted already are not taken back
+func (s *FairScheduler) SetCapacity(capacity int) errors.Error {
+	if capacity <= 0 {
+		return errors.Default.New("capacity less than 1")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.capacity = capacity
+	s.dispatch()
+	return nil
+}
+
+// Acquire blocks till a worker is granted to the key, or returns the error of `ctx` if it is done in the meantime.
+// The weight, 1 if less than 1, is updated by every call, so the latest configuration of the connection takes effect
+func (s *FairScheduler) Acquire(ctx context.Context, key string, weight int) errors.Error {
+	if weight < 1 {
+		weight = 1
+	}
+	s.mu.Lock()
+	k := s.keys[key]
+	if k == nil {
+		k = &fairSchedulerKey{}
+		s.keys[key] = k
+	}
+	k.weight = weight
+	s.seq++
+	waiter := &fairSchedulerWaiter{seq: s.seq, ready: make(chan struct{})}
+	k.waiters = append(k.waiters, waiter)
+	s.dispatch()
+	s.mu.Unlock()
+
+	// requests might not be bound to any context
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-done:
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if waiter.granted {
+		// the worker was granted right as `ctx` was done
+		s.release(key)
+		return errors.Convert(ctx.Err())
+	}
+	for i, w := range k.waiters {
+		if w == waiter {
+			k.waiters = append(k.waiters[:i], k.waiters[i+1:]...)
+			break
+		}
+	}
+	s.drop(key)
+	return errors.Convert(ctx.Err())
+}
+
+// Release gives back a worker granted to the key
+func (s *FairScheduler) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.release(key)
+}
+
+func (s *FairScheduler) release(key string) {
+	k := s.keys[key]
+	if k == nil || k.inUse == 0 {
+		return
+	}
+	k.inUse--
+	s.inUse--
+	s.drop(key)
+	s.dispatch()
+}
+
+// drop forgets the key if it holds no worker and has no waiter
+func (s *FairScheduler) drop(key string) {
+	if k := s.keys[key]; k != nil && k.inUse == 0 && len(k.waiters) == 0 {
+		delete(s.keys, key)
+	}
+}
+
+// dispatch grants the free workers one by one to the waiting key of the least share
+func (s *FairScheduler) dispatch() {
+	for s.inUse < s.capacity {
+		var next *fairSchedulerKey
+		for _, k := range s.keys {
+			if len(k.waiters) == 0 {
+				continue
+			}
+			// k.inUse/k.weight < next.inUse/next.weight
+			if next == nil || k.inUse*next.weight < next.inUse*k.weight ||
+				(k.inUse*next.weight == next.inUse*k.weight && k.waiters[0].seq < next.waiters[0].seq) {
+				next = k
+			}
+		}
+		if next == nil {
+			return
+		}
+		waiter := next.waiters[0]
+		next.waiters = next.waiters[1:]
+		next.inUse++
+		s.inUse++
+		waiter.granted = true
+		close(waiter.ready)
+	}
+}
+
+var sharedFairScheduler struct {
+	sync.Mutex
+	scheduler *FairScheduler
+}
+
+// GetFairScheduler returns the scheduler of the process, it is created on the first call and its capacity is updated
+// by the following ones
+func GetFairScheduler(capacity int) (*FairScheduler, errors.Error) {
+	sharedFairScheduler.Lock()
+	defer sharedFairScheduler.Unlock()
+	if sharedFairScheduler.scheduler != nil {
+		return sharedFairScheduler.scheduler, sharedFairScheduler.scheduler.SetCapacity(capacity)
+	}
+	scheduler, err := NewFairScheduler(capacity)
+	if err != nil {
+		return nil, err
+	}
+	sharedFairScheduler.scheduler = scheduler
+	return scheduler, nil
+}
@@ -0,0 +1,130 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// simulateCollection runs `pages` requests of the key through the scheduler, all pages are requested at once like
+// the async client does, and every page takes `latency` to be handled
+func simulateCollection(scheduler *FairScheduler, key string, weight int, pages int, latency time.Duration, done *int32) *sync.WaitGroup {
+	wg := &sync.WaitGroup{}
+	for i := 0; i < pages; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if scheduler.Acquire(context.Background(), key, weight) != nil {
+				return
+			}
+			time.Sleep(latency)
+			atomic.AddInt32(done, 1)
+			scheduler.Release(key)
+		}()
+	}
+	return wg
+}
+
+func TestFairSchedulerSmallCollectionsProgress(t *testing.T) {
+	scheduler, err := NewFairScheduler(4)
+	assert.Nil(t, err)
+	var large int32
+	backfill := simulateCollection(scheduler, "jira:1", 1, 200, 5*time.Millisecond, &large)
+	// the backfill holds all workers by now, the incremental collections come after
+	time.Sleep(20 * time.Millisecond)
+	small := make([]int32, 3)
+	var incremental sync.WaitGroup
+	for i := range small {
+		incremental.Add(1)
+		go func(i int) {
+			defer incremental.Done()
+			simulateCollection(scheduler, fmt.Sprintf("jira:%d", i+2), 1, 10, 5*time.Millisecond, &small[i]).Wait()
+		}(i)
+	}
+	incremental.Wait()
+	// the small collections were done while the backfill was still far from it
+	assert.Less(t, atomic.LoadInt32(&large), int32(150))
+	for i := range small {
+		assert.Equal(t, int32(10), atomic.LoadInt32(&small[i]))
+	}
+	backfill.Wait()
+	assert.Equal(t, int32(200), large)
+	assert.Empty(t, scheduler.keys)
+	assert.Equal(t, 0, scheduler.inUse)
+}
+
+func TestFairSchedulerWeights(t *testing.T) {
+	scheduler, err := NewFairScheduler(3)
+	assert.Nil(t, err)
+	// the workers are held by another key till both keys are waiting
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, scheduler.Acquire(context.Background(), "jira:0", 1))
+	}
+	// the workers are held till the release of the test, so the grants tell the shares
+	granted := make(chan string, 6)
+	for i := 0; i < 6; i++ {
+		for _, key := range []string{"jira:1", "jira:2"} {
+			weight := 1
+			if key == "jira:2" {
+				weight = 2
+			}
+			go func(key string) {
+				if scheduler.Acquire(context.Background(), key, weight) == nil {
+					granted <- key
+				}
+			}(key)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		scheduler.Release("jira:0")
+	}
+	counts := map[string]int{}
+	for i := 0; i < 3; i++ {
+		counts[<-granted]++
+	}
+	assert.Equal(t, map[string]int{"jira:1": 1, "jira:2": 2}, counts)
+	// a released worker goes to the key of the least share
+	scheduler.Release("jira:2")
+	assert.Equal(t, "jira:2", <-granted)
+	scheduler.Release("jira:1")
+	assert.Equal(t, "jira:1", <-granted)
+}
+
+func TestFairSchedulerContext(t *testing.T) {
+	scheduler, err := NewFairScheduler(1)
+	assert.Nil(t, err)
+	assert.Nil(t, scheduler.Acquire(context.Background(), "jira:1", 1))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.NotNil(t, scheduler.Acquire(ctx, "jira:2", 1))
+	// the waiter given up is forgotten, the worker goes to the next one
+	scheduler.Release("jira:1")
+	assert.Nil(t, scheduler.Acquire(context.Background(), "jira:3", 1))
+	scheduler.Release("jira:3")
+	assert.Empty(t, scheduler.keys)
+
+	assert.NotNil(t, scheduler.SetCapacity(0))
+}
@@ -18,9 +18,12 @@ limitations under the License.
 package helper
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"github.com/apache/incubator-devlake/errors"
+	"io"
 	"time"
 
 	"github.com/apache/incubator-devlake/plugins/core"
@@ -29,12 +32,71 @@ import (
 
 // RawData is raw data structure in DB storage
 type RawData struct {
-	ID        uint64 `gorm:"primaryKey"`
-	Params    string `gorm:"type:varchar(255);index"`
-	Data      []byte
-	Url       string
-	Input     datatypes.JSON
-	CreatedAt time.Time
+	ID     uint64 `gorm:"primaryKey"`
+	Params string `gorm:"type:varchar(255);index"`
+	Data   []byte
+	Url    string
+	Input  datatypes.JSON
+	// Hash is the md5 of the input and the data, it is filled only by collectors skipping unchanged records
+	Hash string `gorm:"type:varchar(32)"`
+	// RecordKey is the natural key of the record extracted by `PrimaryKeyExtractor`, unique among the rows of the
+	// same params
+	RecordKey string `gorm:"type:varchar(255);index"`
+	// Compressed tells the data is gzipped, it is set only by collectors compressing their raw rows, so the rows
+	// saved before are read as they are
+	Compressed bool
+	CreatedAt  time.Time
+}
+
+// compress gzips the data of the row, unless it was compressed already
+func (r *RawData) compress() errors.Error {
+	if r.Compressed {
+		return nil
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write(r.Data)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("error compressing raw row %d", r.ID))
+	}
+	r.Data = buf.Bytes()
+	r.Compressed = true
+	return nil
+}
+
+// decompress gunzips the data of the row if it was compressed, rows not compressed are left as they are
+func (r *RawData) decompress() errors.Error {
+	if !r.Compressed {
+		return nil
+	}
+	data, err := r.GetData()
+	if err != nil {
+		return err
+	}
+	r.Data = data
+	r.Compressed = false
+	return nil
+}
+
+// GetData returns the data of the row decompressed, i.e. for the rows handed over by `AfterSaveRawData`, which
+// are the ones saved and thus compressed if the collector compresses its raw rows
+func (r *RawData) GetData() ([]byte, errors.Error) {
+	if !r.Compressed {
+		return r.Data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(r.Data))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("error decompressing raw row %d", r.ID))
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("error decompressing raw row %d", r.ID))
+	}
+	return data, nil
 }
 
 // RawDataSubTaskArgs FIXME ...
@@ -47,6 +109,10 @@ type RawDataSubTaskArgs struct {
 	//	This struct will be JSONEncoded and stored into database along with raw data itself, to identity minimal
 	//	set of data to be process, for example, we process JiraIssues by Board
 	Params interface{} `comment:"To identify a set of records with same UrlTemplate, i.e. {ConnectionId, BoardId} for jira entities"`
+
+	// PrimaryKeyExtractor extracts the natural key of a record, i.e. the key of a jira issue. Records of the same key
+	// and params are upserted by collectors, a record collected again replaces the row saved before
+	PrimaryKeyExtractor func(msg json.RawMessage) (string, errors.Error)
 }
 
 // RawDataSubTask is Common features for raw data sub-tasks
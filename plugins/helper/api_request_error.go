@@ -0,0 +1,126 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	goerrors "errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/apache/incubator-devlake/errors"
+)
+
+// maxErrorQueryValueLength is the max length of a query value quoted by the message of a request error, i.e. a long
+// JQL, the full value is kept in RequestErrorData
+const maxErrorQueryValueLength = 200
+
+// sensitiveQueryParams are redacted from request errors since they carry credentials
+var sensitiveQueryParams = map[string]bool{
+	"access_token":  true,
+	"api_key":       true,
+	"apikey":        true,
+	"client_secret": true,
+	"password":      true,
+	"private_token": true,
+	"token":         true,
+}
+
+// RequestErrorData is attached to the errors of failed requests by `errors.WithData`, so the request could be
+// reproduced. Headers are left out and credentials in the url are redacted, so nothing sensitive leaks
+type RequestErrorData struct {
+	Method     string     `json:"method"`
+	Url        string     `json:"url"`
+	Query      url.Values `json:"query"`
+	StatusCode int        `json:"statusCode,omitempty"`
+}
+
+// GetRequestErrorData returns the RequestErrorData attached to `err` or any error wrapped by it, nil is returned if
+// there is none
+func GetRequestErrorData(err error) *RequestErrorData {
+	for err != nil {
+		if lakeErr := errors.AsLakeErrorType(err); lakeErr != nil {
+			if data, ok := lakeErr.GetData().(*RequestErrorData); ok {
+				return data
+			}
+		}
+		err = goerrors.Unwrap(err)
+	}
+	return nil
+}
+
+// newRequestErrorData describes the request by the response if there is one, or by the path and query otherwise
+func newRequestErrorData(method, path string, query url.Values, res *http.Response) *RequestErrorData {
+	data := &RequestErrorData{Method: method}
+	var u *url.URL
+	if res != nil && res.Request != nil && res.Request.URL != nil {
+		data.StatusCode = res.StatusCode
+		if res.Request.Method != "" {
+			data.Method = res.Request.Method
+		}
+		copied := *res.Request.URL
+		u = &copied
+		query = u.Query()
+	} else {
+		u = &url.URL{Path: path}
+	}
+	if data.Method == "" {
+		data.Method = http.MethodGet
+	}
+	u.User = nil
+	u.RawQuery = ""
+	data.Url = u.String()
+	data.Query = url.Values{}
+	for key, values := range query {
+		if sensitiveQueryParams[strings.ToLower(key)] {
+			data.Query[key] = []string{"REDACTED"}
+			continue
+		}
+		data.Query[key] = append([]string(nil), values...)
+	}
+	return data
+}
+
+// String formats the request for error messages, query values are left unescaped to be readable and the long ones
+// are truncated
+func (d *RequestErrorData) String() string {
+	keys := make([]string, 0, len(d.Query))
+	for key := range d.Query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, key := range keys {
+		for _, value := range d.Query[key] {
+			if len(value) > maxErrorQueryValueLength {
+				value = value[:maxErrorQueryValueLength] + "...(truncated)"
+			}
+			params = append(params, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	s := fmt.Sprintf("%s %s", d.Method, d.Url)
+	if len(params) > 0 {
+		s = fmt.Sprintf("%s?%s", s, strings.Join(params, "&"))
+	}
+	if d.StatusCode > 0 {
+		s = fmt.Sprintf("%s (status %d)", s, d.StatusCode)
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRequestErrorData(t *testing.T) {
+	res := &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Request: &http.Request{
+			Method: http.MethodGet,
+			URL: &url.URL{
+				Scheme:   "https",
+				User:     url.UserPassword("user", "secret"),
+				Host:     "jira.example.com",
+				Path:     "/rest/api/2/search",
+				RawQuery: url.Values{"jql": {strings.Repeat("a", 300)}, "startAt": {"100"}, "access_token": {"secret"}}.Encode(),
+			},
+		},
+	}
+	data := newRequestErrorData(http.MethodGet, "api/2/search", nil, res)
+	assert.Equal(t, "https://jira.example.com/rest/api/2/search", data.Url)
+	assert.Equal(t, http.StatusBadRequest, data.StatusCode)
+	// the full query is kept apart from the credentials
+	assert.Equal(t, strings.Repeat("a", 300), data.Query.Get("jql"))
+	assert.Equal(t, "100", data.Query.Get("startAt"))
+	assert.Equal(t, "REDACTED", data.Query.Get("access_token"))
+	// while the message is truncated
+	message := data.String()
+	assert.Contains(t, message, "GET https://jira.example.com/rest/api/2/search?")
+	assert.Contains(t, message, "jql="+strings.Repeat("a", maxErrorQueryValueLength)+"...(truncated)")
+	assert.Contains(t, message, "startAt=100")
+	assert.Contains(t, message, "(status 400)")
+	assert.NotContains(t, message, "secret")
+
+	// the path and query are used if the request was not sent at all
+	data = newRequestErrorData("", "api/2/search", url.Values{"startAt": {"0"}}, nil)
+	assert.Equal(t, "GET api/2/search?startAt=0", data.String())
+}
+
+func TestCollectorErrorCarriesRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	taskCtx := new(mocks.TaskContext)
+	taskCtx.On("GetConfig", "API_RETRY").Return("0")
+	taskCtx.On("GetConfig", mock.Anything).Return("")
+	taskCtx.On("GetLogger").Return(unithelper.DummyLogger())
+	taskCtx.On("GetContext").Return(context.Background())
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, map[string]string{"Authorization": "Basic c2VjcmV0"}, 10*time.Second)
+	asyncClient, err := CreateAsyncApiClient(taskCtx, apiClient, &ApiRateLimitCalculator{UserRateLimitPerHour: 360000})
+	assert.Nil(t, err)
+
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Delete", mock.Anything, mock.Anything).Return(nil)
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    unithelper.DummySubTaskContext(mockDal),
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:   asyncClient,
+		UrlTemplate: "api/2/search",
+		Query: func(reqData *RequestData) (url.Values, errors.Error) {
+			return url.Values{"jql": {"project = K"}, "startAt": {"0"}}, nil
+		},
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			return nil, nil
+		},
+	})
+	assert.Nil(t, err)
+	err = collector.Execute()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "GET "+server.URL+"/api/2/search?jql=project = K&startAt=0 (status 400)")
+		assert.NotContains(t, err.Error(), "c2VjcmV0")
+		data := GetRequestErrorData(err)
+		if assert.NotNil(t, data) {
+			assert.Equal(t, http.MethodGet, data.Method)
+			assert.Equal(t, "project = K", data.Query.Get("jql"))
+			assert.Equal(t, http.StatusBadRequest, data.StatusCode)
+		}
+	}
+}
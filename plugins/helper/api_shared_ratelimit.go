@@ -0,0 +1,113 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+)
+
+// SharedRateLimiter paces the requests of all api clients sharing it, i.e. clients of the same connection created by
+// tasks running in parallel, so their aggregate rate respects a single limit instead of each client having its own
+// budget. Requests are granted in the order they arrive, so no client could starve the others
+type SharedRateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewSharedRateLimiter creates a limiter granting `requests` requests per `duration`
+func NewSharedRateLimiter(requests int, duration time.Duration) (*SharedRateLimiter, errors.Error) {
+	limiter := &SharedRateLimiter{}
+	err := limiter.SetRate(requests, duration)
+	if err != nil {
+		return nil, err
+	}
+	return limiter, nil
+}
+
+// SetRate changes the rate of the limiter, the requests granted already are not affected
+func (l *SharedRateLimiter) SetRate(requests int, duration time.Duration) errors.Error {
+	if requests <= 0 {
+		return errors.Default.New("requests less than 1")
+	}
+	if duration <= 0 {
+		return errors.Default.New("duration less than 1")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.interval = duration / time.Duration(requests)
+	return nil
+}
+
+// Wait blocks till the request is granted, or returns the error of `ctx` if it is done in the meantime. Every call
+// reserves the slot after the latest granted one, the slot is not given back if `ctx` is done before it comes
+func (l *SharedRateLimiter) Wait(ctx context.Context) errors.Error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	// requests might not be bound to any context
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return errors.Convert(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+var sharedRateLimiters = struct {
+	sync.Mutex
+	limiters map[string]*SharedRateLimiter
+}{limiters: make(map[string]*SharedRateLimiter)}
+
+// GetSharedRateLimiter returns the limiter of `key` within the process, i.e. `jira:1` for the connection 1 of jira,
+// it is created on the first call and its rate is updated by the following ones, so the latest configuration of the
+// connection takes effect
+func GetSharedRateLimiter(key string, requests int, duration time.Duration) (*SharedRateLimiter, errors.Error) {
+	sharedRateLimiters.Lock()
+	defer sharedRateLimiters.Unlock()
+	limiter, ok := sharedRateLimiters.limiters[key]
+	if ok {
+		return limiter, limiter.SetRate(requests, duration)
+	}
+	limiter, err := NewSharedRateLimiter(requests, duration)
+	if err != nil {
+		return nil, err
+	}
+	sharedRateLimiters.limiters[key] = limiter
+	return limiter, nil
+}
@@ -0,0 +1,103 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedRateLimiter(t *testing.T) {
+	// 20 requests per 100ms, i.e. a request per 5ms
+	limiter, err := NewSharedRateLimiter(20, 100*time.Millisecond)
+	assert.Nil(t, err)
+	start := time.Now()
+	var wg sync.WaitGroup
+	// clients sharing the limiter are paced together
+	for client := 0; client < 4; client++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				assert.Nil(t, limiter.Wait(context.Background()))
+			}
+		}()
+	}
+	wg.Wait()
+	// the first request is granted right away
+	assert.GreaterOrEqual(t, time.Since(start), 19*5*time.Millisecond)
+}
+
+func TestSharedRateLimiterFairness(t *testing.T) {
+	limiter, err := NewSharedRateLimiter(1, 10*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Nil(t, limiter.Wait(context.Background()))
+	// requests are granted in the order they arrive
+	var mu sync.Mutex
+	var granted []int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.Nil(t, limiter.Wait(context.Background()))
+			mu.Lock()
+			granted = append(granted, i)
+			mu.Unlock()
+		}(i)
+		// let the request arrive before the next one
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, granted)
+}
+
+func TestSharedRateLimiterCanceled(t *testing.T) {
+	limiter, err := NewSharedRateLimiter(1, time.Hour)
+	assert.Nil(t, err)
+	assert.Nil(t, limiter.Wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = limiter.Wait(ctx)
+	if assert.NotNil(t, err) {
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+}
+
+func TestGetSharedRateLimiter(t *testing.T) {
+	a, err := GetSharedRateLimiter("test:1", 100, time.Hour)
+	assert.Nil(t, err)
+	b, err := GetSharedRateLimiter("test:1", 200, time.Hour)
+	assert.Nil(t, err)
+	c, err := GetSharedRateLimiter("test:2", 100, time.Hour)
+	assert.Nil(t, err)
+	assert.Same(t, a, b)
+	assert.NotSame(t, a, c)
+	// the latest rate takes effect
+	assert.Equal(t, time.Hour/200, a.interval)
+
+	_, err = GetSharedRateLimiter("test:3", 0, time.Hour)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.Default, err.GetType())
+	}
+}
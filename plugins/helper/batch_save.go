@@ -129,6 +129,17 @@ func (c *BatchSave) Close() errors.Error {
 	return nil
 }
 
+// zeroPrimaryKeyField returns the name of the first primary key field of the slot left zero, empty if there is none
+func (c *BatchSave) zeroPrimaryKeyField(slot interface{}) string {
+	ifv := reflect.Indirect(reflect.ValueOf(slot))
+	for _, key := range c.primaryKey {
+		if ifv.FieldByName(key.Name).IsZero() {
+			return key.Name
+		}
+	}
+	return ""
+}
+
 func getKeyValue(iface interface{}, primaryKey []reflect.StructField) string {
 	var ss []string
 	ifv := reflect.ValueOf(iface)
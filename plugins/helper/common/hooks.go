@@ -28,5 +28,8 @@ type ApiAsyncCallback func(*http.Response) errors.Error
 // ApiClientBeforeRequest FIXME ...
 type ApiClientBeforeRequest func(req *http.Request) errors.Error
 
+// ApiClientRequestSigner computes the headers of a request right before it is sent, i.e. a signature over it
+type ApiClientRequestSigner func(req *http.Request) errors.Error
+
 // ApiClientAfterResponse FIXME ...
 type ApiClientAfterResponse func(res *http.Response) errors.Error
@@ -0,0 +1,56 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+)
+
+// CursorToSlice drains `cursor` into a slice and closes it, rows are fetched into values of `elemType`, and T is
+// either `elemType` or the pointer to it. It is meant for small result sets, use DalCursorIterator for big ones
+func CursorToSlice[T any](db dal.Dal, cursor dal.Rows, elemType reflect.Type) (result []T, err errors.Error) {
+	defer func() {
+		e := cursor.Close()
+		if e != nil && err == nil {
+			result, err = nil, errors.Default.Wrap(e, "failed to close the cursor")
+		}
+	}()
+	resultType := reflect.TypeOf((*T)(nil)).Elem()
+	asPointer := resultType == reflect.PtrTo(elemType)
+	if !asPointer && resultType != elemType {
+		return nil, errors.Default.New(fmt.Sprintf("unable to fetch rows of %s into %s", elemType, resultType))
+	}
+	result = []T{}
+	for cursor.Next() {
+		elem := reflect.New(elemType)
+		err = db.Fetch(cursor, elem.Interface())
+		if err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to fetch row %d of the cursor", len(result)+1))
+		}
+		if asPointer {
+			result = append(result, elem.Interface().(T))
+		} else {
+			result = append(result, elem.Elem().Interface().(T))
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type cursorRow struct {
+	Key string
+}
+
+// newFakeCursor returns a cursor of `keys` fetched by the dal, fetching the row `failAt` fails
+func newFakeCursor(failAt int, keys ...string) (*mocks.Dal, *mocks.Rows) {
+	cursor := new(mocks.Rows)
+	for range keys {
+		cursor.On("Next").Return(true).Once()
+	}
+	cursor.On("Next").Return(false).Maybe()
+	cursor.On("Close").Return(nil).Once()
+	db := new(mocks.Dal)
+	fetched := 0
+	db.On("Fetch", cursor, mock.Anything).Return(func(rows dal.Rows, dst interface{}) errors.Error {
+		fetched++
+		if fetched == failAt {
+			return errors.Default.New("connection reset")
+		}
+		dst.(*cursorRow).Key = keys[fetched-1]
+		return nil
+	})
+	return db, cursor
+}
+
+func TestCursorToSlice(t *testing.T) {
+	db, cursor := newFakeCursor(0, "K-1", "K-2", "K-3")
+	rows, err := CursorToSlice[cursorRow](db, cursor, reflect.TypeOf(cursorRow{}))
+	assert.Nil(t, err)
+	assert.Equal(t, []cursorRow{{"K-1"}, {"K-2"}, {"K-3"}}, rows)
+	cursor.AssertExpectations(t)
+
+	db, cursor = newFakeCursor(0, "K-1")
+	pointers, err := CursorToSlice[*cursorRow](db, cursor, reflect.TypeOf(cursorRow{}))
+	assert.Nil(t, err)
+	assert.Equal(t, []*cursorRow{{"K-1"}}, pointers)
+	cursor.AssertExpectations(t)
+
+	db, cursor = newFakeCursor(0)
+	rows, err = CursorToSlice[cursorRow](db, cursor, reflect.TypeOf(cursorRow{}))
+	assert.Nil(t, err)
+	assert.Empty(t, rows)
+	cursor.AssertExpectations(t)
+}
+
+func TestCursorToSliceFailure(t *testing.T) {
+	db, cursor := newFakeCursor(2, "K-1", "K-2", "K-3")
+	rows, err := CursorToSlice[cursorRow](db, cursor, reflect.TypeOf(cursorRow{}))
+	assert.Nil(t, rows)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "failed to fetch row 2 of the cursor")
+		assert.Contains(t, err.Error(), "connection reset")
+	}
+	// the cursor is closed even though it was not drained
+	cursor.AssertCalled(t, "Close")
+
+	db, cursor = newFakeCursor(0, "K-1")
+	_, err = CursorToSlice[string](db, cursor, reflect.TypeOf(cursorRow{}))
+	assert.NotNil(t, err)
+	cursor.AssertCalled(t, "Close")
+}
@@ -159,7 +159,7 @@ func (c *defaultExecContext) fork(name string) *defaultExecContext {
 	return newDefaultExecContext(
 		c.ctx,
 		c.cfg,
-		c.logger.Nested(name),
+		c.logger.Nested(name).WithFields(map[string]interface{}{"subtask": name}),
 		c.db,
 		name,
 		c.data,
@@ -191,6 +191,8 @@ type DefaultSubTaskContext struct {
 	*defaultExecContext
 	taskCtx          *DefaultTaskContext
 	LastProgressTime time.Time
+	collectorStats   []core.CollectorStats
+	result           *core.SubTaskResult
 }
 
 // SetProgress FIXME ...
@@ -212,6 +214,69 @@ func (c *DefaultSubTaskContext) IncProgress(quantity int) {
 	}
 }
 
+// ReportCollectorProgress sends the detailed progress of a collector to the progress channel
+func (c *DefaultSubTaskContext) ReportCollectorProgress(rawTable string, pages int, totalPages int, records int) {
+	if c.progress != nil {
+		c.progress <- core.RunningProgress{
+			Type:        core.CollectorProgress,
+			Current:     pages,
+			Total:       totalPages,
+			SubTaskName: c.name,
+			RawTable:    rawTable,
+			Records:     records,
+		}
+	}
+}
+
+// ReportCollectorStats adds up the stats of a finished collector to the stats of its raw table
+func (c *DefaultSubTaskContext) ReportCollectorStats(stats core.CollectorStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.collectorStats {
+		if c.collectorStats[i].RawTable == stats.RawTable {
+			c.collectorStats[i].Requests += stats.Requests
+			c.collectorStats[i].Bytes += stats.Bytes
+			c.collectorStats[i].Duration += stats.Duration
+			c.collectorStats[i].InputBatches += stats.InputBatches
+			c.collectorStats[i].InputDuration += stats.InputDuration
+			return
+		}
+	}
+	c.collectorStats = append(c.collectorStats, stats)
+}
+
+// GetCollectorStats returns the stats of collectors reported so far, one per raw table
+func (c *DefaultSubTaskContext) GetCollectorStats() []core.CollectorStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]core.CollectorStats(nil), c.collectorStats...)
+}
+
+// ReportSubTaskResult adds up the result to the ones reported before
+func (c *DefaultSubTaskContext) ReportSubTaskResult(result core.SubTaskResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.result == nil {
+		c.result = &core.SubTaskResult{}
+	}
+	c.result.Records += result.Records
+	c.result.Pages += result.Pages
+	c.result.DryRunRequests += result.DryRunRequests
+	c.result.Warnings = append(c.result.Warnings, result.Warnings...)
+}
+
+// GetSubTaskResult returns the result reported by the subtask, nil is returned if it reported nothing
+func (c *DefaultSubTaskContext) GetSubTaskResult() *core.SubTaskResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.result == nil {
+		return nil
+	}
+	result := *c.result
+	result.Warnings = append([]string(nil), c.result.Warnings...)
+	return &result
+}
+
 // NewDefaultTaskContext FIXME ...
 func NewDefaultTaskContext(
 	ctx context.Context,
@@ -241,6 +306,8 @@ func (c *DefaultTaskContext) SubTaskContext(subtask string) (core.SubTaskContext
 					c.defaultExecContext.fork(subtask),
 					c,
 					time.Time{},
+					nil,
+					nil,
 				}
 			}
 			c.defaultExecContext.mu.Unlock()
@@ -266,9 +333,11 @@ func NewStandaloneSubTaskContext(
 	data interface{},
 ) core.SubTaskContext {
 	return &DefaultSubTaskContext{
-		newDefaultExecContext(ctx, cfg, logger, db, name, data, nil),
+		newDefaultExecContext(ctx, cfg, logger.WithFields(map[string]interface{}{"subtask": name}), db, name, data, nil),
 		nil,
 		time.Time{},
+		nil,
+		nil,
 	}
 }
 
@@ -288,3 +357,6 @@ func (c *DefaultSubTaskContext) TaskContext() core.TaskContext {
 }
 
 var _ core.SubTaskContext = (*DefaultSubTaskContext)(nil)
+var _ core.CollectorProgressReporter = (*DefaultSubTaskContext)(nil)
+var _ core.CollectorStatsReporter = (*DefaultSubTaskContext)(nil)
+var _ core.SubTaskResultReporter = (*DefaultSubTaskContext)(nil)
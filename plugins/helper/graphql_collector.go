@@ -124,12 +124,16 @@ func NewGraphqlCollector(args GraphqlCollectorArgs) (*GraphqlCollector, errors.E
 
 // Execute api collection
 func (collector *GraphqlCollector) Execute() errors.Error {
+	skip, err := collector.skipCollection()
+	if err != nil || skip {
+		return err
+	}
 	logger := collector.args.Ctx.GetLogger()
 	logger.Info("start graphql collection")
 
 	// make sure table is created
 	db := collector.args.Ctx.GetDal()
-	err := db.AutoMigrate(&RawData{}, dal.From(collector.table))
+	err = db.AutoMigrate(&RawData{}, dal.From(collector.table))
 	if err != nil {
 		return errors.Default.Wrap(err, "error running auto-migrate")
 	}
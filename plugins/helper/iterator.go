@@ -18,6 +18,7 @@ limitations under the License.
 package helper
 
 import (
+	"context"
 	"reflect"
 	"time"
 
@@ -35,6 +36,7 @@ type Iterator interface {
 
 // DalCursorIterator FIXME ...
 type DalCursorIterator struct {
+	ctx       context.Context
 	db        dal.Dal
 	cursor    dal.Rows
 	elemType  reflect.Type
@@ -48,7 +50,20 @@ func NewDalCursorIterator(db dal.Dal, cursor dal.Rows, elemType reflect.Type) (*
 
 // NewBatchedDalCursorIterator FIXME ...
 func NewBatchedDalCursorIterator(db dal.Dal, cursor dal.Rows, elemType reflect.Type, batchSize int) (*DalCursorIterator, errors.Error) {
+	return NewBatchedDalCursorIteratorWithContext(context.Background(), db, cursor, elemType, batchSize)
+}
+
+// NewBatchedDalCursorIteratorWithContext creates a batched iterator which stops reading the cursor once `ctx` is
+// cancelled, the cancellation is returned by the following Fetch
+func NewBatchedDalCursorIteratorWithContext(
+	ctx context.Context,
+	db dal.Dal,
+	cursor dal.Rows,
+	elemType reflect.Type,
+	batchSize int,
+) (*DalCursorIterator, errors.Error) {
 	return &DalCursorIterator{
+		ctx:       ctx,
 		db:        db,
 		cursor:    cursor,
 		elemType:  elemType,
@@ -57,13 +72,21 @@ func NewBatchedDalCursorIterator(db dal.Dal, cursor dal.Rows, elemType reflect.T
 }
 
 // HasNext increments the row curser. If we're at the end, it'll return false.
+// It returns true without touching the cursor if the context was cancelled, so that Fetch would report the cancellation
+// instead of the iteration ending silently
 func (c *DalCursorIterator) HasNext() bool {
+	if c.ctx.Err() != nil {
+		return true
+	}
 	return c.cursor.Next()
 }
 
 // Fetch if batching is disabled, it'll read a single row, otherwise it'll read as many rows up to the batch size, and the
 // runtime return type will be []interface{}. Note, HasNext needs to have been called before invoking this.
 func (c *DalCursorIterator) Fetch() (interface{}, errors.Error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, errors.Convert(err)
+	}
 	if c.batchSize > 0 {
 		return c.batchedFetch()
 	}
@@ -81,13 +104,16 @@ func (c *DalCursorIterator) Fetch() (interface{}, errors.Error) {
 func (c *DalCursorIterator) batchedFetch() (interface{}, errors.Error) {
 	var elems []interface{}
 	for i := 1; ; i++ {
+		if err := c.ctx.Err(); err != nil {
+			return nil, errors.Convert(err)
+		}
 		elem := reflect.New(c.elemType).Interface()
 		err := c.cursor.Scan(elem)
 		if err != nil {
 			return nil, errors.Convert(err)
 		}
 		elems = append(elems, elem)
-		if i == c.batchSize || !c.HasNext() {
+		if i == c.batchSize || !c.cursor.Next() {
 			break
 		}
 	}
@@ -177,3 +203,64 @@ func NewQueueIterator() *QueueIterator {
 		queue: NewQueue(),
 	}
 }
+
+// MultiIterator chains iterators into one, the elements of an iterator are fetched once the previous one was
+// exhausted. The iteration stops at the first error returned by any of them
+type MultiIterator struct {
+	iterators []Iterator
+	current   int
+	err       errors.Error
+}
+
+// NewMultiIterator creates an iterator fetching the elements of `iterators` one iterator after another
+func NewMultiIterator(iterators ...Iterator) *MultiIterator {
+	return &MultiIterator{iterators: iterators}
+}
+
+// HasNext moves onto the next iterator if the current one was exhausted, it returns false once an error was returned
+// by Fetch
+func (m *MultiIterator) HasNext() bool {
+	if m.err != nil {
+		return false
+	}
+	for m.current < len(m.iterators) {
+		if m.iterators[m.current].HasNext() {
+			return true
+		}
+		m.current++
+	}
+	return false
+}
+
+// Fetch returns the next element of the current iterator, HasNext needs to have been called before invoking this
+func (m *MultiIterator) Fetch() (interface{}, errors.Error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.current >= len(m.iterators) {
+		return nil, errors.Default.New("all iterators were exhausted")
+	}
+	elem, err := m.iterators[m.current].Fetch()
+	if err != nil {
+		m.err = err
+		return nil, err
+	}
+	return elem, nil
+}
+
+// Close closes all the iterators, even if some of them failed to close
+func (m *MultiIterator) Close() errors.Error {
+	var errs []error
+	for _, iterator := range m.iterators {
+		err := iterator.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Default.Combine(errs)
+	}
+	return nil
+}
+
+var _ Iterator = (*MultiIterator)(nil)
@@ -0,0 +1,110 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatchedDalCursorIteratorCancellation(t *testing.T) {
+	// an endless cursor
+	cursor := new(mocks.Rows)
+	cursor.On("Next").Return(true)
+	cursor.On("Scan", mock.Anything).Return(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	iterator, err := NewBatchedDalCursorIteratorWithContext(ctx, nil, cursor, reflect.TypeOf(""), 10)
+	assert.Nil(t, err)
+
+	assert.True(t, iterator.HasNext())
+	batch, err := iterator.Fetch()
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(batch.([]interface{})))
+	scanned := len(cursor.Calls)
+
+	cancel()
+	assert.True(t, iterator.HasNext())
+	_, err = iterator.Fetch()
+	assert.ErrorIs(t, err, context.Canceled)
+	// the cursor is no longer read
+	assert.Equal(t, scanned, len(cursor.Calls))
+}
+
+// newMockIterator iterates `elems`, Close returns `closeErr`
+func newMockIterator(closeErr errors.Error, elems ...interface{}) *mocks.Iterator {
+	iterator := new(mocks.Iterator)
+	for _, elem := range elems {
+		iterator.On("HasNext").Return(true).Once()
+		iterator.On("Fetch").Return(elem, nil).Once()
+	}
+	iterator.On("HasNext").Return(false)
+	iterator.On("Close").Return(closeErr).Once()
+	return iterator
+}
+
+func TestMultiIterator(t *testing.T) {
+	a := newMockIterator(nil, 1, 2)
+	b := newMockIterator(nil)
+	c := newMockIterator(errors.Default.New("failed to close"), 3)
+	iterator := NewMultiIterator(a, b, c)
+	var elems []interface{}
+	for iterator.HasNext() {
+		elem, err := iterator.Fetch()
+		assert.Nil(t, err)
+		elems = append(elems, elem)
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, elems)
+	_, err := iterator.Fetch()
+	assert.NotNil(t, err)
+	// all iterators are closed though one of them failed
+	err = iterator.Close()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "failed to close")
+	}
+	a.AssertExpectations(t)
+	b.AssertExpectations(t)
+	c.AssertExpectations(t)
+}
+
+func TestMultiIteratorStopsOnError(t *testing.T) {
+	failing := new(mocks.Iterator)
+	failing.On("HasNext").Return(true)
+	failing.On("Fetch").Return(nil, errors.Default.New("failed to fetch")).Once()
+	failing.On("Close").Return(nil).Once()
+	// the iterators after the failing one are never read from
+	untouched := new(mocks.Iterator)
+	untouched.On("Close").Return(nil).Once()
+	iterator := NewMultiIterator(failing, untouched)
+
+	assert.True(t, iterator.HasNext())
+	_, err := iterator.Fetch()
+	assert.NotNil(t, err)
+	assert.False(t, iterator.HasNext())
+	_, err = iterator.Fetch()
+	assert.NotNil(t, err)
+	assert.Nil(t, iterator.Close())
+	failing.AssertExpectations(t)
+	untouched.AssertExpectations(t)
+}
@@ -0,0 +1,219 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+// DecodeJsonArrayField decodes the elements of the array `field` of the json object read from `r` one by one, so
+// the reader is consumed without buffering the whole document as `io.ReadAll` does. Other fields are skipped, and
+// nil is returned if the field is missing or null
+func DecodeJsonArrayField(r io.Reader, field string) ([]json.RawMessage, errors.Error) {
+	return decodeJsonArrayField(r, field, nil)
+}
+
+// DecodeJsonArrayFieldLeniently decodes the array `field` like DecodeJsonArrayField, except that an element which
+// is not valid json is passed to `skip` and left out, rather than failing the whole array, i.e. a record mangled by
+// a proxy. The elements are told apart by their nesting and strings, so the array itself must stay well-formed
+func DecodeJsonArrayFieldLeniently(
+	r io.Reader,
+	field string,
+	skip func(index int, element []byte, err errors.Error),
+) ([]json.RawMessage, errors.Error) {
+	return decodeJsonArrayField(r, field, skip)
+}
+
+func decodeJsonArrayField(r io.Reader, field string, skip func(index int, element []byte, err errors.Error)) ([]json.RawMessage, errors.Error) {
+	decoder := json.NewDecoder(r)
+	err := expectJsonDelim(decoder, '{')
+	if err != nil {
+		return nil, err
+	}
+	for decoder.More() {
+		token, e := decoder.Token()
+		if e != nil {
+			return nil, errors.Convert(e)
+		}
+		if key, ok := token.(string); !ok || key != field {
+			// skip the value of other fields
+			var skipped json.RawMessage
+			e = decoder.Decode(&skipped)
+			if e != nil {
+				return nil, errors.Convert(e)
+			}
+			continue
+		}
+		token, e = decoder.Token()
+		if e != nil {
+			return nil, errors.Convert(e)
+		}
+		if token == nil {
+			return nil, nil
+		}
+		if token != json.Delim('[') {
+			return nil, errors.Default.New(fmt.Sprintf("field %s is expected to be an array, got %v", field, token))
+		}
+		if skip != nil {
+			return scanJsonArrayElements(bufio.NewReader(io.MultiReader(decoder.Buffered(), r)), skip)
+		}
+		elements := make([]json.RawMessage, 0)
+		for decoder.More() {
+			var element json.RawMessage
+			e = decoder.Decode(&element)
+			if e != nil {
+				return nil, errors.Convert(e)
+			}
+			elements = append(elements, element)
+		}
+		// the rest of the document is of no interest
+		return elements, expectJsonDelim(decoder, ']')
+	}
+	return nil, nil
+}
+
+// DecodeJsonArrayFieldFromResponse decodes the array `field` of the response body by DecodeJsonArrayField
+func DecodeJsonArrayFieldFromResponse(res *http.Response, field string) ([]json.RawMessage, errors.Error) {
+	defer res.Body.Close()
+	elements, err := DecodeJsonArrayField(res.Body, field)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("error decoding %s of response from %s", field, res.Request.URL.String()))
+	}
+	return elements, nil
+}
+
+// DecodeJsonArrayFieldFromResponseLeniently decodes the array `field` of the response body by
+// DecodeJsonArrayFieldLeniently, the elements left out are logged as warnings
+func DecodeJsonArrayFieldFromResponseLeniently(res *http.Response, field string, logger core.Logger) ([]json.RawMessage, errors.Error) {
+	defer res.Body.Close()
+	elements, err := DecodeJsonArrayFieldLeniently(res.Body, field, func(index int, element []byte, err errors.Error) {
+		logger.Warn(err, "skipping the malformed element %d of %s of response from %s: %s", index, field, res.Request.URL.String(), element)
+	})
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("error decoding %s of response from %s", field, res.Request.URL.String()))
+	}
+	return elements, nil
+}
+
+// scanJsonArrayElements reads the elements of an array whose `[` was consumed already, up to the closing `]`. The
+// bytes of every element are collected by tracking the brackets and the strings only, and validated on their own
+func scanJsonArrayElements(r *bufio.Reader, skip func(index int, element []byte, err errors.Error)) ([]json.RawMessage, errors.Error) {
+	elements := make([]json.RawMessage, 0)
+	for index := 0; ; index++ {
+		element, err := scanJsonArrayElement(r)
+		if err != nil {
+			return nil, err
+		}
+		if element == nil {
+			return elements, nil
+		}
+		if !json.Valid(element) {
+			var v interface{}
+			skip(index, element, errors.Convert(json.Unmarshal(element, &v)))
+			continue
+		}
+		elements = append(elements, element)
+	}
+}
+
+// scanJsonArrayElement returns the bytes of the next element of the array, nil is returned once the array is closed
+func scanJsonArrayElement(r *bufio.Reader) ([]byte, errors.Error) {
+	var element bytes.Buffer
+	depth := 0
+	inString, escaped := false, false
+	for {
+		c, e := r.ReadByte()
+		if e != nil {
+			return nil, errors.Default.Wrap(errors.Convert(e), "unexpected end of json array")
+		}
+		if inString {
+			element.WriteByte(c)
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			if depth == 0 && element.Len() > 0 {
+				return element.Bytes(), nil
+			}
+			if depth > 0 {
+				element.WriteByte(c)
+			}
+		case ',':
+			if depth == 0 {
+				if element.Len() > 0 {
+					return element.Bytes(), nil
+				}
+				// the separator following the previous element
+				continue
+			}
+			element.WriteByte(c)
+		case '"':
+			inString = true
+			element.WriteByte(c)
+		case '{', '[':
+			depth++
+			element.WriteByte(c)
+		case '}', ']':
+			if depth == 0 && element.Len() == 0 {
+				if c == ']' {
+					return nil, nil
+				}
+				// a stray `}` makes an element of its own
+				return []byte{c}, nil
+			}
+			if depth == 0 {
+				// the closing `]` of the array is left to the next call
+				_ = r.UnreadByte()
+				return element.Bytes(), nil
+			}
+			depth--
+			element.WriteByte(c)
+			if depth == 0 {
+				return element.Bytes(), nil
+			}
+		default:
+			element.WriteByte(c)
+		}
+	}
+}
+
+func expectJsonDelim(decoder *json.Decoder, delim json.Delim) errors.Error {
+	token, err := decoder.Token()
+	if err != nil {
+		return errors.Convert(err)
+	}
+	if token != delim {
+		return errors.Default.New(fmt.Sprintf("expected %v, got %v", delim, token))
+	}
+	return nil
+}
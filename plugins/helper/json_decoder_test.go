@@ -0,0 +1,94 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJsonArrayField(t *testing.T) {
+	elements, err := DecodeJsonArrayField(strings.NewReader(
+		`{"total":2,"names":{"issues":"skipped"},"issues":[{"id":"1","issues":[]}, {"id":"2"}],"isLast":true}`,
+	), "issues")
+	assert.Nil(t, err)
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`{"id":"1","issues":[]}`), json.RawMessage(`{"id":"2"}`)}, elements)
+
+	elements, err = DecodeJsonArrayField(strings.NewReader(`{"issues":[]}`), "issues")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(elements))
+
+	elements, err = DecodeJsonArrayField(strings.NewReader(`{"total":0}`), "issues")
+	assert.Nil(t, err)
+	assert.Nil(t, elements)
+
+	elements, err = DecodeJsonArrayField(strings.NewReader(`{"issues":null}`), "issues")
+	assert.Nil(t, err)
+	assert.Nil(t, elements)
+
+	_, err = DecodeJsonArrayField(strings.NewReader(`{"issues":{}}`), "issues")
+	assert.NotNil(t, err)
+
+	_, err = DecodeJsonArrayField(strings.NewReader(`[]`), "issues")
+	assert.NotNil(t, err)
+
+	_, err = DecodeJsonArrayField(strings.NewReader(`{"issues":[{"id":"1"},`), "issues")
+	assert.NotNil(t, err)
+}
+
+func TestDecodeJsonArrayFieldLeniently(t *testing.T) {
+	var skipped []int
+	skip := func(index int, element []byte, err errors.Error) {
+		assert.NotNil(t, err)
+		skipped = append(skipped, index)
+	}
+	elements, err := DecodeJsonArrayFieldLeniently(strings.NewReader(
+		`{"total":5,"issues":[{"id":"1","fields":{"summary":"a \"quoted\" ] }"}}, {"id":"2","fields":{"summary":}}, {"id":"3"},{"id":"4","fields":[1,2,]}, {"id":"5"}],"isLast":true}`,
+	), "issues", skip)
+	assert.Nil(t, err)
+	assert.Equal(t, []json.RawMessage{
+		json.RawMessage(`{"id":"1","fields":{"summary":"a \"quoted\" ] }"}}`),
+		json.RawMessage(`{"id":"3"}`),
+		json.RawMessage(`{"id":"5"}`),
+	}, elements)
+	assert.Equal(t, []int{1, 3}, skipped)
+
+	// scalars are told apart by the separators
+	skipped = nil
+	elements, err = DecodeJsonArrayFieldLeniently(strings.NewReader(`{"values":[1, "two" ,tree,null]}`), "values", skip)
+	assert.Nil(t, err)
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`"two"`), json.RawMessage(`null`)}, elements)
+	assert.Equal(t, []int{2}, skipped)
+
+	elements, err = DecodeJsonArrayFieldLeniently(strings.NewReader(`{"issues":[]}`), "issues", skip)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(elements))
+
+	elements, err = DecodeJsonArrayFieldLeniently(strings.NewReader(`{"issues":null}`), "issues", skip)
+	assert.Nil(t, err)
+	assert.Nil(t, elements)
+
+	// a truncated array can't be told apart from a malformed element
+	_, err = DecodeJsonArrayFieldLeniently(strings.NewReader(`{"issues":[{"id":"1"},{"id":"2"`), "issues", skip)
+	assert.NotNil(t, err)
+}
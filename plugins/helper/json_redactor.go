@@ -0,0 +1,135 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/apache/incubator-devlake/errors"
+)
+
+const (
+	// RedactionNull replaces the redacted values by null
+	RedactionNull = "null"
+	// RedactionHash replaces the redacted values by their sha256 digests, so they could still be told apart
+	RedactionHash = "hash"
+)
+
+// JsonRedactor redacts the values at the given paths of JSON records. A path is made of the keys of nested objects
+// joined by dots, i.e. `fields.reporter.emailAddress`, `*` matches any key, and arrays are walked through
+// transparently, so `fields.comment.comments.author.emailAddress` redacts the author of every comment. Paths missing
+// from a record are left alone, so the records lacking them are saved as they are
+type JsonRedactor struct {
+	paths [][]string
+	hash  bool
+}
+
+// NewJsonRedactor creates a redactor of the paths by the mode, which is RedactionNull by default. Nil is returned if
+// there is no path to redact
+func NewJsonRedactor(paths []string, mode string) (*JsonRedactor, errors.Error) {
+	if mode != "" && mode != RedactionNull && mode != RedactionHash {
+		return nil, errors.BadInput.New(fmt.Sprintf("invalid redaction mode %s, it must be either %s or %s", mode, RedactionNull, RedactionHash))
+	}
+	redactor := &JsonRedactor{hash: mode == RedactionHash}
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		keys := strings.Split(path, ".")
+		for _, key := range keys {
+			if key == "" {
+				return nil, errors.BadInput.New(fmt.Sprintf("invalid redacted field %s", path))
+			}
+		}
+		redactor.paths = append(redactor.paths, keys)
+	}
+	if len(redactor.paths) == 0 {
+		return nil, nil
+	}
+	return redactor, nil
+}
+
+// Redact returns the record with the values at the paths redacted, the record is returned untouched when none of
+// the paths is present
+func (r *JsonRedactor) Redact(msg json.RawMessage) (json.RawMessage, errors.Error) {
+	decoder := json.NewDecoder(bytes.NewReader(msg))
+	// numbers are kept as they are instead of being turned into floats
+	decoder.UseNumber()
+	var record interface{}
+	err := decoder.Decode(&record)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to decode the record to be redacted")
+	}
+	redacted := false
+	for _, path := range r.paths {
+		if r.redact(record, path) {
+			redacted = true
+		}
+	}
+	if !redacted {
+		return msg, nil
+	}
+	blob, err := json.Marshal(record)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to encode the redacted record")
+	}
+	return blob, nil
+}
+
+// redact redacts the values at the path under the node, it tells if anything was redacted
+func (r *JsonRedactor) redact(node interface{}, path []string) bool {
+	redacted := false
+	switch value := node.(type) {
+	case []interface{}:
+		for _, elem := range value {
+			if r.redact(elem, path) {
+				redacted = true
+			}
+		}
+	case map[string]interface{}:
+		for key, child := range value {
+			if path[0] != "*" && path[0] != key {
+				continue
+			}
+			if len(path) > 1 {
+				if r.redact(child, path[1:]) {
+					redacted = true
+				}
+				continue
+			}
+			value[key] = r.redactValue(child)
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+func (r *JsonRedactor) redactValue(value interface{}) interface{} {
+	if !r.hash || value == nil {
+		return nil
+	}
+	blob, _ := json.Marshal(value)
+	digest := sha256.Sum256(blob)
+	return "sha256:" + hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,118 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonRedactorNestedPaths(t *testing.T) {
+	redactor, err := NewJsonRedactor([]string{
+		"fields.reporter.emailAddress",
+		"fields.comment.comments.author.emailAddress",
+		"fields.*.displayName",
+		"fields.nonexistent.field",
+	}, RedactionNull)
+	assert.Nil(t, err)
+
+	redacted, err := redactor.Redact(json.RawMessage(`{
+		"id": "10001",
+		"fields": {
+			"summary": "an epic",
+			"storyPoints": 12345678901234567890,
+			"reporter": {"accountId": "1", "emailAddress": "a@example.com", "displayName": "A"},
+			"assignee": null,
+			"comment": {"comments": [
+				{"body": "lgtm", "author": {"emailAddress": "b@example.com"}},
+				{"body": "no author"}
+			]}
+		}
+	}`))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"id": "10001",
+		"fields": {
+			"summary": "an epic",
+			"storyPoints": 12345678901234567890,
+			"reporter": {"accountId": "1", "emailAddress": null, "displayName": null},
+			"assignee": null,
+			"comment": {"comments": [
+				{"body": "lgtm", "author": {"emailAddress": null}},
+				{"body": "no author"}
+			]}
+		}
+	}`, string(redacted))
+	// big numbers are not turned into floats
+	assert.Contains(t, string(redacted), "12345678901234567890")
+}
+
+func TestJsonRedactorHash(t *testing.T) {
+	redactor, err := NewJsonRedactor([]string{"author.emailAddress"}, RedactionHash)
+	assert.Nil(t, err)
+	first, err := redactor.Redact(json.RawMessage(`[{"author":{"emailAddress":"a@example.com"}},{"author":{"emailAddress":null}}]`))
+	assert.Nil(t, err)
+	second, err := redactor.Redact(json.RawMessage(`[{"author":{"emailAddress":"a@example.com"}}]`))
+	assert.Nil(t, err)
+	var records []struct {
+		Author struct {
+			EmailAddress *string `json:"emailAddress"`
+		} `json:"author"`
+	}
+	assert.Nil(t, json.Unmarshal(first, &records))
+	if assert.Len(t, records, 2) && assert.NotNil(t, records[0].Author.EmailAddress) {
+		// the same values are hashed the same, so they could still be told apart
+		assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, *records[0].Author.EmailAddress)
+		assert.Contains(t, string(second), *records[0].Author.EmailAddress)
+		assert.Nil(t, records[1].Author.EmailAddress)
+	}
+}
+
+func TestJsonRedactorUntouched(t *testing.T) {
+	redactor, err := NewJsonRedactor([]string{"fields.reporter.emailAddress"}, "")
+	assert.Nil(t, err)
+	// the records lacking the paths are kept byte by byte
+	msg := json.RawMessage(`{"id": 1, "fields": {"reporter": "not an object"}}`)
+	redacted, err := redactor.Redact(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, string(msg), string(redacted))
+
+	_, err = redactor.Redact(json.RawMessage(`{malformed`))
+	assert.NotNil(t, err)
+}
+
+func TestNewJsonRedactor(t *testing.T) {
+	redactor, err := NewJsonRedactor(nil, "")
+	assert.Nil(t, err)
+	assert.Nil(t, redactor)
+	redactor, err = NewJsonRedactor([]string{" "}, RedactionHash)
+	assert.Nil(t, err)
+	assert.Nil(t, redactor)
+
+	_, err = NewJsonRedactor([]string{"fields..emailAddress"}, "")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.BadInput, err.GetType())
+	}
+	_, err = NewJsonRedactor([]string{"emailAddress"}, "mask")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.BadInput, err.GetType())
+	}
+}
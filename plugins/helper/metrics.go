@@ -0,0 +1,212 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// MetricCollectorPageDuration is the histogram of the time taken to collect a page, from its request being
+	// enqueued till its records were saved, in seconds
+	MetricCollectorPageDuration = "devlake_collector_page_duration_seconds"
+	// MetricCollectorDuration is the histogram of the duration of the collections by the subtasks, in seconds
+	MetricCollectorDuration = "devlake_collector_duration_seconds"
+	// MetricLabelPlugin and MetricLabelSubtask label the metrics of the collectors by their plugin and subtask
+	MetricLabelPlugin  = "plugin"
+	MetricLabelSubtask = "subtask"
+)
+
+// MetricsRegistry records the metrics of the collectors, it is no-op by default, see SetMetricsRegistry
+type MetricsRegistry interface {
+	// Enabled tells if the metrics are recorded at all, so the labels don't have to be computed otherwise
+	Enabled() bool
+	// ObserveHistogram records a sample of the histogram by its labels
+	ObserveHistogram(name string, labels map[string]string, value float64)
+}
+
+type noopMetricsRegistry struct{}
+
+func (noopMetricsRegistry) Enabled() bool {
+	return false
+}
+
+func (noopMetricsRegistry) ObserveHistogram(string, map[string]string, float64) {}
+
+var metricsRegistry struct {
+	sync.RWMutex
+	registry MetricsRegistry
+}
+
+// SetMetricsRegistry sets the registry the metrics of the process are recorded into, nil disables the metrics
+func SetMetricsRegistry(registry MetricsRegistry) {
+	metricsRegistry.Lock()
+	defer metricsRegistry.Unlock()
+	metricsRegistry.registry = registry
+}
+
+// GetMetricsRegistry returns the registry set by SetMetricsRegistry, or the no-op one if the metrics are disabled
+func GetMetricsRegistry() MetricsRegistry {
+	metricsRegistry.RLock()
+	defer metricsRegistry.RUnlock()
+	if metricsRegistry.registry == nil {
+		return noopMetricsRegistry{}
+	}
+	return metricsRegistry.registry
+}
+
+// DefaultLatencyBuckets are the upper bounds of the buckets of the histograms of latencies, in seconds
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// DefaultDurationBuckets are the upper bounds of the buckets of the histograms of the durations of collections
+var DefaultDurationBuckets = []float64{1, 10, 30, 60, 300, 600, 1800, 3600, 7200, 14400}
+
+// histogramDesc describes a histogram registered to a PrometheusRegistry
+type histogramDesc struct {
+	help    string
+	labels  []string
+	buckets []float64
+}
+
+// histogramSeries is a series of a histogram, its counts are per bucket rather than cumulative
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// PrometheusRegistry keeps histograms in memory and exposes them in the text format of Prometheus by ServeHTTP,
+// i.e. at the `/metrics` endpoint. Samples of unregistered histograms are dropped
+type PrometheusRegistry struct {
+	mu     sync.Mutex
+	descs  map[string]*histogramDesc
+	series map[string]map[string]*histogramSeries
+}
+
+// NewPrometheusRegistry creates a registry with the histograms of the collectors registered
+func NewPrometheusRegistry() *PrometheusRegistry {
+	registry := &PrometheusRegistry{
+		descs:  make(map[string]*histogramDesc),
+		series: make(map[string]map[string]*histogramSeries),
+	}
+	collectorLabels := []string{MetricLabelPlugin, MetricLabelSubtask}
+	registry.RegisterHistogram(MetricCollectorPageDuration, "Time taken to collect a page by the api collectors.", collectorLabels, DefaultLatencyBuckets)
+	registry.RegisterHistogram(MetricCollectorDuration, "Duration of the collections by the api collectors.", collectorLabels, DefaultDurationBuckets)
+	return registry
+}
+
+// RegisterHistogram registers the histogram of the labels and the ascending upper bounds of its buckets, the
+// bucket of +Inf is implied
+func (r *PrometheusRegistry) RegisterHistogram(name string, help string, labels []string, buckets []float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.descs[name] = &histogramDesc{help: help, labels: labels, buckets: buckets}
+	r.series[name] = make(map[string]*histogramSeries)
+}
+
+// Enabled returns true since the samples are recorded
+func (r *PrometheusRegistry) Enabled() bool {
+	return true
+}
+
+// ObserveHistogram counts the sample into the buckets of the series of the labels, labels not registered for the
+// histogram are ignored and the missing ones are empty
+func (r *PrometheusRegistry) ObserveHistogram(name string, labels map[string]string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	desc, ok := r.descs[name]
+	if !ok {
+		return
+	}
+	labelValues := make([]string, len(desc.labels))
+	for i, label := range desc.labels {
+		labelValues[i] = labels[label]
+	}
+	key := strings.Join(labelValues, "\x00")
+	series, ok := r.series[name][key]
+	if !ok {
+		series = &histogramSeries{labelValues: labelValues, counts: make([]uint64, len(desc.buckets))}
+		r.series[name][key] = series
+	}
+	for i, upperBound := range desc.buckets {
+		if value <= upperBound {
+			series.counts[i]++
+			break
+		}
+	}
+	series.count++
+	series.sum += value
+}
+
+// WriteTo writes all histograms in the text format of Prometheus, sorted by their names and labels
+func (r *PrometheusRegistry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sb strings.Builder
+	names := make([]string, 0, len(r.descs))
+	for name := range r.descs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		desc := r.descs[name]
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s histogram\n", name, desc.help, name)
+		keys := make([]string, 0, len(r.series[name]))
+		for key := range r.series[name] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			series := r.series[name][key]
+			labels := formatMetricLabels(desc.labels, series.labelValues)
+			cumulative := uint64(0)
+			for i, upperBound := range desc.buckets {
+				cumulative += series.counts[i]
+				fmt.Fprintf(&sb, "%s_bucket{%sle=\"%s\"} %d\n", name, labels, strconv.FormatFloat(upperBound, 'g', -1, 64), cumulative)
+			}
+			fmt.Fprintf(&sb, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, series.count)
+			fmt.Fprintf(&sb, "%s_sum{%s} %s\n", name, strings.TrimSuffix(labels, ","), strconv.FormatFloat(series.sum, 'g', -1, 64))
+			fmt.Fprintf(&sb, "%s_count{%s} %d\n", name, strings.TrimSuffix(labels, ","), series.count)
+		}
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// ServeHTTP exposes the histograms to the scraping of Prometheus
+func (r *PrometheusRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+// formatMetricLabels formats the labels as `name="value",` pairs, the values escaped as Prometheus requires
+func formatMetricLabels(names []string, values []string) string {
+	var sb strings.Builder
+	for i, name := range names {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(values[i])
+		fmt.Fprintf(&sb, "%s=\"%s\",", name, value)
+	}
+	return sb.String()
+}
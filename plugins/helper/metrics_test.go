@@ -0,0 +1,149 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/helper/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPrometheusRegistry(t *testing.T) {
+	registry := NewPrometheusRegistry()
+	labels := map[string]string{MetricLabelPlugin: "jira", MetricLabelSubtask: "collectEpics"}
+	registry.ObserveHistogram(MetricCollectorPageDuration, labels, 0.07)
+	registry.ObserveHistogram(MetricCollectorPageDuration, labels, 0.3)
+	registry.ObserveHistogram(MetricCollectorPageDuration, labels, 100)
+	registry.ObserveHistogram(MetricCollectorDuration, map[string]string{MetricLabelPlugin: `a"b`, "unknown": "x"}, 12)
+	// samples of unregistered histograms are dropped
+	registry.ObserveHistogram("unknown_seconds", labels, 1)
+
+	// the names and the labels are scraped by the dashboards, they must not be changed
+	res := httptest.NewRecorder()
+	registry.ServeHTTP(res, nil)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP devlake_collector_duration_seconds Duration of the collections by the api collectors.
+# TYPE devlake_collector_duration_seconds histogram
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="1"} 0
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="10"} 0
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="30"} 1
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="60"} 1
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="300"} 1
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="600"} 1
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="1800"} 1
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="3600"} 1
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="7200"} 1
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="14400"} 1
+devlake_collector_duration_seconds_bucket{plugin="a\"b",subtask="",le="+Inf"} 1
+devlake_collector_duration_seconds_sum{plugin="a\"b",subtask=""} 12
+devlake_collector_duration_seconds_count{plugin="a\"b",subtask=""} 1
+# HELP devlake_collector_page_duration_seconds Time taken to collect a page by the api collectors.
+# TYPE devlake_collector_page_duration_seconds histogram
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="0.05"} 0
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="0.1"} 1
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="0.25"} 1
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="0.5"} 2
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="1"} 2
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="2.5"} 2
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="5"} 2
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="10"} 2
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="30"} 2
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="60"} 2
+devlake_collector_page_duration_seconds_bucket{plugin="jira",subtask="collectEpics",le="+Inf"} 3
+devlake_collector_page_duration_seconds_sum{plugin="jira",subtask="collectEpics"} 100.37
+devlake_collector_page_duration_seconds_count{plugin="jira",subtask="collectEpics"} 3
+`, res.Body.String())
+}
+
+func TestApiCollectorMetrics(t *testing.T) {
+	registry := NewPrometheusRegistry()
+	SetMetricsRegistry(registry)
+	defer SetMetricsRegistry(nil)
+
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil)
+	mockTaskCtx := new(mocks.TaskContext)
+	mockTaskCtx.On("GetName").Return("jira")
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+	mockCtx.On("TaskContext").Return(mockTaskCtx)
+	bodies := map[string]string{
+		"0": `{"total":3,"issues":[{"id":1},{"id":2}]}`,
+		"2": `{"total":3,"issues":[{"id":3}]}`,
+	}
+	apiClient := new(mocks.RateLimitedApiClient)
+	apiClient.On("DoGetAsync", "api/2/search", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		query := args.Get(1).(url.Values)
+		res := &http.Response{
+			StatusCode: http.StatusOK,
+			Request:    &http.Request{URL: &url.URL{Path: "api/2/search", RawQuery: query.Encode()}},
+			Body:       ioutil.NopCloser(strings.NewReader(bodies[query.Get("startAt")])),
+		}
+		handler := args.Get(3).(common.ApiAsyncCallback)
+		assert.Nil(t, handler(res))
+	}).Twice()
+	apiClient.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+		handler := args.Get(0).(func() errors.Error)
+		assert.Nil(t, handler())
+	})
+	apiClient.On("HasError").Return(false)
+	apiClient.On("WaitAsync").Return(nil)
+	apiClient.On("GetAfterFunction", mock.Anything).Return(nil)
+	apiClient.On("SetAfterFunction", mock.Anything).Return()
+	collector, err := NewApiCollector(ApiCollectorArgs{
+		RawDataSubTaskArgs: RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  "whatever rawtable",
+			Params: "whatever params",
+		},
+		ApiClient:   apiClient,
+		UrlTemplate: "api/2/search",
+		PageSize:    2,
+		Query: func(reqData *RequestData) (url.Values, errors.Error) {
+			return url.Values{"startAt": {strconv.Itoa(reqData.Pager.Skip)}}, nil
+		},
+		GetTotalPages: func(res *http.Response, args *ApiCollectorArgs) (int, errors.Error) {
+			return 2, nil
+		},
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			return DecodeJsonArrayFieldFromResponse(res, "issues")
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+
+	var sb strings.Builder
+	_, e := registry.WriteTo(&sb)
+	assert.Nil(t, e)
+	// the subtask of the dummy context is named `test`
+	assert.Contains(t, sb.String(), `devlake_collector_page_duration_seconds_count{plugin="jira",subtask="test"} 2`)
+	assert.Contains(t, sb.String(), `devlake_collector_duration_seconds_count{plugin="jira",subtask="test"} 1`)
+}
@@ -0,0 +1,82 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/helper/common"
+	"golang.org/x/oauth2"
+)
+
+// NewOAuth2RefreshTokenSource returns a TokenSource issuing OAuth 2.0 access tokens by the refresh token grant.
+// It is safe for concurrent use: an access token is shared till it is about to expire, and it is refreshed by one
+// goroutine at a time. `onRotated` is called with the new refresh token whenever the server rotates it
+func NewOAuth2RefreshTokenSource(
+	ctx context.Context,
+	config *oauth2.Config,
+	refreshToken string,
+	onRotated func(refreshToken string) errors.Error,
+) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &oauth2RefreshTokenSource{
+		ctx:          ctx,
+		config:       config,
+		refreshToken: refreshToken,
+		onRotated:    onRotated,
+	})
+}
+
+// oauth2RefreshTokenSource refreshes the access token on every call, it relies on oauth2.ReuseTokenSource to be
+// called only when the token expired, and one goroutine at a time
+type oauth2RefreshTokenSource struct {
+	ctx          context.Context
+	config       *oauth2.Config
+	refreshToken string
+	onRotated    func(refreshToken string) errors.Error
+}
+
+func (s *oauth2RefreshTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.config.TokenSource(s.ctx, &oauth2.Token{RefreshToken: s.refreshToken}).Token()
+	if err != nil {
+		return nil, errors.Unauthorized.Wrap(err, "failed to refresh OAuth2 access token")
+	}
+	if token.RefreshToken != "" && token.RefreshToken != s.refreshToken {
+		s.refreshToken = token.RefreshToken
+		if s.onRotated != nil {
+			err = s.onRotated(token.RefreshToken)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return token, nil
+}
+
+// OAuth2BeforeRequest returns a BeforeRequest hook which authorizes requests with access tokens of the TokenSource
+func OAuth2BeforeRequest(tokenSource oauth2.TokenSource) common.ApiClientBeforeRequest {
+	return func(req *http.Request) errors.Error {
+		token, err := tokenSource.Token()
+		if err != nil {
+			return errors.Unauthorized.Wrap(err, "failed to get OAuth2 access token")
+		}
+		token.SetAuthHeader(req)
+		return nil
+	}
+}
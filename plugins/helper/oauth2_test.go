@@ -0,0 +1,99 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestOAuth2RefreshTokenSource(t *testing.T) {
+	// the first access token is about to expire, so it is refreshed right away
+	var refreshedBy []string
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "refresh_token", r.Form.Get("grant_type"))
+		refreshedBy = append(refreshedBy, r.Form.Get("refresh_token"))
+		n := len(refreshedBy)
+		expiresIn := 3600
+		if n == 1 {
+			expiresIn = 1
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"access%d","refresh_token":"refresh%d","token_type":"Bearer","expires_in":%d}`, n, n+1, expiresIn)
+	}))
+	defer tokenServer.Close()
+
+	var mu sync.Mutex
+	authorizations := map[string]int{}
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		authorizations[r.Header.Get("Authorization")]++
+	}))
+	defer apiServer.Close()
+
+	var rotated []string
+	tokenSource := NewOAuth2RefreshTokenSource(
+		context.Background(),
+		&oauth2.Config{
+			ClientID:     "client",
+			ClientSecret: "secret",
+			Endpoint:     oauth2.Endpoint{TokenURL: tokenServer.URL, AuthStyle: oauth2.AuthStyleInParams},
+		},
+		"refresh1",
+		func(refreshToken string) errors.Error {
+			rotated = append(rotated, refreshToken)
+			return nil
+		},
+	)
+	apiClient := &ApiClient{}
+	apiClient.Setup(apiServer.URL, nil, 10*time.Second)
+	apiClient.SetBeforeFunction(OAuth2BeforeRequest(tokenSource))
+
+	// the first request triggers the refresh
+	res, err := apiClient.Get("whatever", nil, nil)
+	assert.Nil(t, err)
+	res.Body.Close()
+
+	// the rest of them are sent by concurrent workers sharing the client
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := apiClient.Get("whatever", nil, nil)
+			assert.Nil(t, err)
+			res.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"refresh1", "refresh2"}, refreshedBy)
+	assert.Equal(t, []string{"refresh2", "refresh3"}, rotated)
+	assert.Equal(t, map[string]int{"Bearer access1": 1, "Bearer access2": 10}, authorizations)
+}
@@ -0,0 +1,59 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"crypto/md5"
+	"fmt"
+
+	"github.com/apache/incubator-devlake/errors"
+)
+
+// PageStallDetector detects a pagination fetching pages one after another which doesn't advance, i.e. a cursor
+// echoed back by the server or an offset ignored by it, where the same page would be fetched over and over again.
+// It is fed with the pages of a single chain in order, and is not safe to be shared by concurrent chains
+type PageStallDetector struct {
+	pages       int
+	lastRequest string
+	lastBody    [md5.Size]byte
+}
+
+// NewPageStallDetector creates a PageStallDetector for a new chain of pages
+func NewPageStallDetector() *PageStallDetector {
+	return &PageStallDetector{}
+}
+
+// Check returns an error if the page is requested the same way as the previous page, or the server responded to it
+// with the same body, `request` identifies the request of the page, i.e. by its url and query
+func (d *PageStallDetector) Check(request string, body []byte) errors.Error {
+	d.pages++
+	sum := md5.Sum(body)
+	defer func() {
+		d.lastRequest, d.lastBody = request, sum
+	}()
+	if d.pages == 1 {
+		return nil
+	}
+	if request == d.lastRequest {
+		return errors.Default.New(fmt.Sprintf("page %d was requested the same as the previous page, the pagination doesn't advance", d.pages))
+	}
+	if sum == d.lastBody {
+		return errors.Default.New(fmt.Sprintf("page %d returned the same response as the previous page, the pagination doesn't advance", d.pages))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+)
+
+// RawDataOnlyOption is the task option which re-runs a task against the raw data collected before, i.e. to run the
+// extractors again once a bug of them was fixed. Collectors are skipped where raw data of their params exists, so
+// the api is not hit again
+const RawDataOnlyOption = "rawDataOnly"
+
+type rawDataOnlyKey struct{}
+
+// WithRawDataOnly returns a context telling the collectors running with it to collect nothing but what is missing in
+// the raw tables, it is set by the runner for tasks with RawDataOnlyOption
+func WithRawDataOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawDataOnlyKey{}, true)
+}
+
+// IsRawDataOnly tells if the context was returned by WithRawDataOnly
+func IsRawDataOnly(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	rawDataOnly, _ := ctx.Value(rawDataOnlyKey{}).(bool)
+	return rawDataOnly
+}
+
+// skipCollection tells if the collection is to be skipped in the raw data only mode since the raw table holds data
+// of the params. Raw tables are shared by the boards and connections, so the ones holding no data of the params are
+// collected as usual, i.e. for a newly added board, the extractors only read the data of their params anyway
+func (r *RawDataSubTask) skipCollection() (bool, errors.Error) {
+	if !IsRawDataOnly(r.args.Ctx.GetContext()) {
+		return false, nil
+	}
+	db := r.args.Ctx.GetDal()
+	logger := r.args.Ctx.GetLogger()
+	err := db.AutoMigrate(&RawData{}, dal.From(r.table))
+	if err != nil {
+		return false, errors.Default.Wrap(err, fmt.Sprintf("error auto-migrating %s", r.table))
+	}
+	rows, err := db.Count(dal.From(r.table), dal.Where("params = ?", r.params))
+	if err != nil {
+		return false, errors.Default.Wrap(err, fmt.Sprintf("error counting the raw data of %s", r.table))
+	}
+	if rows > 0 {
+		logger.Info("skipping the collection of %s for the raw data only mode, %d rows of params %s were collected before", r.table, rows, r.params)
+		return true, nil
+	}
+	logger.Info("%s holds no raw data of params %s, collecting it despite the raw data only mode", r.table, r.params)
+	return false, nil
+}
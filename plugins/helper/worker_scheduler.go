@@ -86,6 +86,10 @@ func (s *WorkerScheduler) SubmitBlocking(task func() errors.Error) {
 	s.waitGroup.Add(1)
 	s.checkError(s.pool.Submit(func() {
 		defer s.waitGroup.Done()
+		// the error is recorded before Done, so it is seen by Wait, the panic handler of the pool is called after
+		defer func() {
+			s.checkError(recover())
+		}()
 
 		id := atomic.AddInt32(&s.counter, 1)
 		s.logger.Debug("schedulerJob >>> %d started", id)
@@ -133,6 +137,8 @@ func (s *WorkerScheduler) checkError(err interface{}) {
 
 // HasError return if any error occurred
 func (s *WorkerScheduler) HasError() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return len(s.workerErrors) > 0
 }
 
@@ -148,9 +154,15 @@ func (s *WorkerScheduler) NextTick(task func() errors.Error) {
 	}()
 }
 
-// Wait blocks current go-routine until all workers returned
+// Wait blocks current go-routine until all workers returned, a single error is returned as is, so the type and data
+// of it are kept
 func (s *WorkerScheduler) Wait() errors.Error {
 	s.waitGroup.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.workerErrors) == 1 {
+		return errors.Convert(s.workerErrors[0])
+	}
 	if len(s.workerErrors) > 0 {
 		return errors.Default.Combine(s.workerErrors)
 	}
@@ -62,3 +62,19 @@ func TestWorkerSchedulerQpsControl(t *testing.T) {
 	}
 	cancel()
 }
+
+func TestWorkerSchedulerWaitReturnsTaskError(t *testing.T) {
+	s, _ := NewWorkerScheduler(context.Background(), 5, 100, time.Second, 0, unithelper.DummyLogger())
+	defer s.Release()
+	taskErr := errors.NotFound.New("task failed")
+	for i := 0; i < 20; i++ {
+		s.SubmitBlocking(func() errors.Error {
+			return taskErr
+		})
+		// the error of the task is recorded by the time it is waited for
+		err := s.Wait()
+		assert.Equal(t, taskErr, err)
+		assert.True(t, s.HasError())
+		s.workerErrors = nil
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/apache/incubator-devlake/plugins/jira/tasks"
+)
+
+// @Summary get the incremental state of the collection of a jira board
+// @Description Get the state the next collection of the board by the subtask picks its time range from, `collectEpics` by default
+// @Tags plugins/jira
+// @Param subtask query string false "subtask name"
+// @Success 200  {object} tasks.CollectionState
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internel Error"
+// @Router /plugins/jira/connections/{connectionId}/boards/{boardId}/collection-state [GET]
+func GetCollectionState(input *core.ApiResourceInput) (*core.ApiResourceOutput, errors.Error) {
+	connectionId, boardId, subtaskName, err := getCollectionStateScope(input)
+	if err != nil {
+		return nil, err
+	}
+	state, err := tasks.GetCollectionState(basicRes.GetDal(), connectionId, boardId, subtaskName)
+	if err != nil {
+		return nil, err
+	}
+	return &core.ApiResourceOutput{Body: state}, nil
+}
+
+// @Summary reset the incremental state of the collection of a jira board
+// @Description Mark the collection of the board by the subtask, `collectEpics` by default, reset and delete its checkpoints and watermark, so the next collection is a full one. The raw data is kept till the next collection replaces it
+// @Tags plugins/jira
+// @Param subtask query string false "subtask name"
+// @Success 200
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internel Error"
+// @Router /plugins/jira/connections/{connectionId}/boards/{boardId}/collection-state [DELETE]
+func ResetCollectionState(input *core.ApiResourceInput) (*core.ApiResourceOutput, errors.Error) {
+	connectionId, boardId, subtaskName, err := getCollectionStateScope(input)
+	if err != nil {
+		return nil, err
+	}
+	db := basicRes.GetDal()
+	err = tasks.ResetCollectionState(context.TODO(), db, basicRes.GetLogger(), helper.NewDalScopeLock(db), connectionId, boardId, subtaskName)
+	if err != nil {
+		return nil, err
+	}
+	return &core.ApiResourceOutput{Status: http.StatusOK}, nil
+}
+
+func getCollectionStateScope(input *core.ApiResourceInput) (uint64, uint64, string, errors.Error) {
+	connection := &models.JiraConnection{}
+	err := connectionHelper.First(connection, input.Params)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	boardId, e := strconv.ParseUint(input.Params["boardId"], 10, 64)
+	if e != nil || boardId == 0 {
+		return 0, 0, "", errors.BadInput.New(fmt.Sprintf("invalid boardId %s", input.Params["boardId"]))
+	}
+	subtaskName := input.Query.Get("subtask")
+	if subtaskName == "" {
+		subtaskName = tasks.CollectEpicsMeta.Name
+	}
+	return connection.ID, boardId, subtaskName, nil
+}
@@ -22,14 +22,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/apache/incubator-devlake/errors"
 
 	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
 	"github.com/apache/incubator-devlake/plugins/helper"
 	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/apache/incubator-devlake/plugins/jira/tasks"
 )
 
 // @Summary test jira connection
@@ -62,8 +65,12 @@ func TestConnection(input *core.ApiResourceInput) (*core.ApiResourceOutput, erro
 	if err != nil {
 		return nil, errors.Convert(err)
 	}
+	err = apiClient.SetTLSConfig(connection.CaCertificate, connection.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
 	// serverInfo checking
-	res, err := apiClient.Get("api/2/serverInfo", nil, nil)
+	res, err := apiClient.Get(tasks.JiraApiV2.Path("serverInfo"), nil, nil)
 	if err != nil {
 		return nil, errors.Convert(err)
 	}
@@ -153,6 +160,8 @@ func PatchConnection(input *core.ApiResourceInput) (*core.ApiResourceOutput, err
 	if err != nil {
 		return nil, err
 	}
+	// the credential might be fixed, let the connection be tried again
+	helper.ResetCircuitBreaker(tasks.CircuitBreakerKey(connection.ID))
 	return &core.ApiResourceOutput{Body: connection}, nil
 }
 
@@ -170,6 +179,11 @@ func DeleteConnection(input *core.ApiResourceInput) (*core.ApiResourceOutput, er
 		return nil, err
 	}
 	err = connectionHelper.Delete(connection)
+	if err != nil {
+		return nil, err
+	}
+	// the credentials of the bucket are not left behind
+	err = basicRes.GetDal().Delete(&models.JiraRawExport{}, dal.Where("connection_id = ?", connection.ID))
 	return &core.ApiResourceOutput{Body: connection}, err
 }
 
@@ -204,3 +218,115 @@ func GetConnection(input *core.ApiResourceInput) (*core.ApiResourceOutput, error
 	}
 	return &core.ApiResourceOutput{Body: connection}, err
 }
+
+// OAuth2CodeRequest is the authorization code returned to the redirect uri of the OAuth2 app
+type OAuth2CodeRequest struct {
+	Code        string `mapstructure:"code" json:"code" validate:"required"`
+	RedirectUri string `mapstructure:"redirectUri" json:"redirectUri" validate:"required"`
+}
+
+// @Summary authorize jira connection by OAuth2
+// @Description Exchange the authorization code of the OAuth 2.0 (3LO) flow for a refresh token, and save it to the connection
+// @Tags plugins/jira
+// @Param body body OAuth2CodeRequest true "json body"
+// @Success 200  {object} models.JiraConnection
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internel Error"
+// @Router /plugins/jira/connections/{connectionId}/oauth2/token [POST]
+func ExchangeOAuth2Code(input *core.ApiResourceInput) (*core.ApiResourceOutput, errors.Error) {
+	var request OAuth2CodeRequest
+	err := helper.Decode(input.Body, &request, vld)
+	if err != nil {
+		return nil, err
+	}
+	connection := &models.JiraConnection{}
+	err = connectionHelper.First(connection, input.Params)
+	if err != nil {
+		return nil, err
+	}
+	if !connection.IsOAuth2() {
+		return nil, errors.BadInput.New("the connection does not authenticate by OAuth2")
+	}
+	token, e := connection.GetOAuth2Config(request.RedirectUri).Exchange(context.TODO(), request.Code)
+	if e != nil {
+		return nil, errors.BadInput.Wrap(e, "failed to exchange the OAuth2 authorization code")
+	}
+	if token.RefreshToken == "" {
+		return nil, errors.BadInput.New("no refresh token was granted, please make sure the offline_access scope was requested")
+	}
+	err = connectionHelper.Patch(connection, &core.ApiResourceInput{
+		Params: input.Params,
+		Body:   map[string]interface{}{"oauth2RefreshToken": token.RefreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &core.ApiResourceOutput{Body: connection}, nil
+}
+
+// @Summary diagnose jira connection
+// @Description Probe the credential, permissions and board scope of a Jira connection, and report what worked
+// @Tags plugins/jira
+// @Param boardId query []int false "ids of the boards to be checked"
+// @Success 200  {object} tasks.ConnectionDiagnostics
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internel Error"
+// @Router /plugins/jira/connections/{connectionId}/diagnostics [GET]
+func DiagnoseConnection(input *core.ApiResourceInput) (*core.ApiResourceOutput, errors.Error) {
+	connection := &models.JiraConnection{}
+	err := connectionHelper.First(connection, input.Params)
+	if err != nil {
+		return nil, err
+	}
+	var boardIds []uint64
+	for _, v := range input.Query["boardId"] {
+		boardId, e := strconv.ParseUint(v, 10, 64)
+		if e != nil {
+			return nil, errors.BadInput.Wrap(e, fmt.Sprintf("invalid boardId %s", v))
+		}
+		boardIds = append(boardIds, boardId)
+	}
+	// a manual test closes the circuit breaker tripped by repeated authentication failures, it trips again soon
+	// enough if the credential is still rejected
+	helper.ResetCircuitBreaker(tasks.CircuitBreakerKey(connection.ID))
+	apiClient, err := tasks.NewJiraSyncApiClient(context.TODO(), basicRes, connection, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	// the checks are sent in the version the collectors would use, v2 if the server info is not available, in which
+	// case the checks tell why
+	serverInfo, _, _ := tasks.GetJiraServerInfo(apiClient)
+	diagnostics, err := tasks.DiagnoseConnection(apiClient, tasks.GetJiraApiVersion(serverInfo), boardIds)
+	if err != nil {
+		return nil, err
+	}
+	return &core.ApiResourceOutput{Body: diagnostics}, nil
+}
+
+// @Summary preflight the epics of a jira board
+// @Description Count the epic keys of the board in the tool tables and the epics Jira resolves by them, so a board yielding no epic could be told before a full pipeline runs
+// @Tags plugins/jira
+// @Success 200  {object} tasks.BoardPreflight
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internel Error"
+// @Router /plugins/jira/connections/{connectionId}/boards/{boardId}/preflight [GET]
+func PreflightBoard(input *core.ApiResourceInput) (*core.ApiResourceOutput, errors.Error) {
+	connection := &models.JiraConnection{}
+	err := connectionHelper.First(connection, input.Params)
+	if err != nil {
+		return nil, err
+	}
+	boardId, e := strconv.ParseUint(input.Params["boardId"], 10, 64)
+	if e != nil || boardId == 0 {
+		return nil, errors.BadInput.New(fmt.Sprintf("invalid boardId %s", input.Params["boardId"]))
+	}
+	apiClient, err := tasks.NewJiraSyncApiClient(context.TODO(), basicRes, connection, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	preflight, err := tasks.PreflightBoardEpics(context.TODO(), basicRes.GetDal(), apiClient, connection.ID, boardId)
+	if err != nil {
+		return nil, err
+	}
+	return &core.ApiResourceOutput{Body: preflight}, nil
+}
@@ -19,14 +19,13 @@ package api
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"time"
 
 	"github.com/apache/incubator-devlake/errors"
 	"github.com/apache/incubator-devlake/plugins/core"
-	"github.com/apache/incubator-devlake/plugins/helper"
 	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/apache/incubator-devlake/plugins/jira/tasks"
 )
 
 func Proxy(input *core.ApiResourceInput) (*core.ApiResourceOutput, errors.Error) {
@@ -35,16 +34,7 @@ func Proxy(input *core.ApiResourceInput) (*core.ApiResourceOutput, errors.Error)
 	if err != nil {
 		return nil, err
 	}
-	apiClient, err := helper.NewApiClient(
-		context.TODO(),
-		connection.Endpoint,
-		map[string]string{
-			"Authorization": fmt.Sprintf("Basic %v", connection.GetEncodedToken()),
-		},
-		30*time.Second,
-		connection.Proxy,
-		basicRes,
-	)
+	apiClient, err := tasks.NewJiraSyncApiClient(context.TODO(), basicRes, connection, 30*time.Second)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,113 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/apache/incubator-devlake/plugins/jira/tasks"
+)
+
+// @Summary put the raw export of jira connection
+// @Description Configure the bucket the raw epics collected by the connection are exported to
+// @Tags plugins/jira
+// @Param body body models.JiraRawExport true "json body"
+// @Success 200  {object} models.JiraRawExport
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internel Error"
+// @Router /plugins/jira/connections/{connectionId}/raw-export [PUT]
+func PutRawExport(input *core.ApiResourceInput) (*core.ApiResourceOutput, errors.Error) {
+	connection := &models.JiraConnection{}
+	err := connectionHelper.First(connection, input.Params)
+	if err != nil {
+		return nil, err
+	}
+	export := &models.JiraRawExport{}
+	err = helper.Decode(input.Body, export, vld)
+	if err != nil {
+		return nil, err
+	}
+	export.ConnectionId = connection.ID
+	// the bucket is checked by the provider before it is saved
+	_, err = tasks.NewObjectStore(export)
+	if err != nil {
+		return nil, err
+	}
+	encKey := basicRes.GetConfig(core.EncodeKeyEnvStr)
+	saved := *export
+	err = helper.UpdateEncryptFields(&saved, func(plaintext string) (string, errors.Error) {
+		return core.Encrypt(encKey, plaintext)
+	})
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to encrypt the raw export")
+	}
+	err = basicRes.GetDal().CreateOrUpdate(&saved)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to save the raw export")
+	}
+	return &core.ApiResourceOutput{Body: export, Status: http.StatusOK}, nil
+}
+
+// @Summary get the raw export of jira connection
+// @Description Get the bucket the raw epics collected by the connection are exported to
+// @Tags plugins/jira
+// @Success 200  {object} models.JiraRawExport
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internel Error"
+// @Router /plugins/jira/connections/{connectionId}/raw-export [GET]
+func GetRawExport(input *core.ApiResourceInput) (*core.ApiResourceOutput, errors.Error) {
+	connection := &models.JiraConnection{}
+	err := connectionHelper.First(connection, input.Params)
+	if err != nil {
+		return nil, err
+	}
+	export, err := tasks.LoadRawExport(basicRes, connection.ID)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil {
+		return nil, errors.NotFound.New("the raw export is not configured for the connection")
+	}
+	return &core.ApiResourceOutput{Body: export}, nil
+}
+
+// @Summary delete the raw export of jira connection
+// @Description Stop exporting the raw epics collected by the connection
+// @Tags plugins/jira
+// @Success 200
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internel Error"
+// @Router /plugins/jira/connections/{connectionId}/raw-export [DELETE]
+func DeleteRawExport(input *core.ApiResourceInput) (*core.ApiResourceOutput, errors.Error) {
+	connection := &models.JiraConnection{}
+	err := connectionHelper.First(connection, input.Params)
+	if err != nil {
+		return nil, err
+	}
+	err = basicRes.GetDal().Delete(&models.JiraRawExport{}, dal.Where("connection_id = ?", connection.ID))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to delete the raw export")
+	}
+	return &core.ApiResourceOutput{Status: http.StatusOK}, nil
+}
@@ -35,8 +35,23 @@ type JiraBlueprintSetting []struct {
 		Scope        []struct {
 			Transformation tasks.TransformationRules `json:"transformation"`
 			Options        struct {
-				BoardId uint64 `json:"boardId"`
-				Since   string `json:"since"`
+				BoardId                   uint64                      `json:"boardId"`
+				BoardNames                []string                    `json:"boardNames"`
+				Since                     string                      `json:"since"`
+				TimeAfter                 string                      `json:"timeAfter"`
+				ConnectionScopedRawTables bool                        `json:"connectionScopedRawTables"`
+				EpicOrderBy               string                      `json:"epicOrderBy"`
+				EpicFields                []string                    `json:"epicFields"`
+				IncludeArchived           bool                        `json:"includeArchived"`
+				LookbackDays              int                         `json:"lookbackDays"`
+				Labels                    []string                    `json:"labels"`
+				StatusCategories          []string                    `json:"statusCategories"`
+				EpicWindowDays            int                         `json:"epicWindowDays"`
+				FederatedConnections      []tasks.FederatedConnection `json:"federatedConnections"`
+				Limit                     int                         `json:"limit"`
+				EpicShardCount            int                         `json:"epicShardCount"`
+				EpicShardIndex            int                         `json:"epicShardIndex"`
+				EmptyEpicsWarningKeys     int                         `json:"emptyEpicsWarningKeys"`
 			} `json:"options"`
 			Entities []string `json:"entities"`
 		} `json:"scope"`
@@ -55,8 +70,23 @@ type JiraPipelinePlan [][]struct {
 	Plugin   string   `json:"plugin"`
 	Subtasks []string `json:"subtasks"`
 	Options  struct {
-		BoardID             int                       `json:"boardId"`
-		ConnectionID        int                       `json:"connectionId"`
-		TransformationRules tasks.TransformationRules `json:"transformationRules"`
+		BoardID                   int                         `json:"boardId"`
+		BoardNames                []string                    `json:"boardNames"`
+		ConnectionID              int                         `json:"connectionId"`
+		TransformationRules       tasks.TransformationRules   `json:"transformationRules"`
+		TimeAfter                 string                      `json:"timeAfter"`
+		ConnectionScopedRawTables bool                        `json:"connectionScopedRawTables"`
+		EpicOrderBy               string                      `json:"epicOrderBy"`
+		EpicFields                []string                    `json:"epicFields"`
+		IncludeArchived           bool                        `json:"includeArchived"`
+		LookbackDays              int                         `json:"lookbackDays"`
+		Labels                    []string                    `json:"labels"`
+		StatusCategories          []string                    `json:"statusCategories"`
+		EpicWindowDays            int                         `json:"epicWindowDays"`
+		FederatedConnections      []tasks.FederatedConnection `json:"federatedConnections"`
+		Limit                     int                         `json:"limit"`
+		EpicShardCount            int                         `json:"epicShardCount"`
+		EpicShardIndex            int                         `json:"epicShardIndex"`
+		EmptyEpicsWarningKeys     int                         `json:"emptyEpicsWarningKeys"`
 	} `json:"options"`
 }
@@ -75,7 +75,7 @@ func TestEpicDataflow(t *testing.T) {
 	)
 	t.Run("batch_single", func(t *testing.T) {
 		// run the part of the collector that queries tools data
-		iter, err := tasks.GetEpicKeysIterator(ctx.GetDal(), taskData, 1)
+		iter, err := tasks.GetEpicKeysIterator(ctx, taskData.Options.BoardId, 1)
 		require.NoError(t, err)
 		require.True(t, iter.HasNext())
 		e1, err := iter.Fetch()
@@ -95,7 +95,7 @@ func TestEpicDataflow(t *testing.T) {
 	})
 	t.Run("batch_multiple", func(t *testing.T) {
 		// run the part of the collector that queries tools data
-		iter, err := tasks.GetEpicKeysIterator(ctx.GetDal(), taskData, 2)
+		iter, err := tasks.GetEpicKeysIterator(ctx, taskData.Options.BoardId, 2)
 		require.NoError(t, err)
 		require.True(t, iter.HasNext())
 		e, err := iter.Fetch()
@@ -109,6 +109,19 @@ func TestEpicDataflow(t *testing.T) {
 		require.Contains(t, epicKeys, "K5-1")
 		require.Contains(t, epicKeys, "K5-4")
 	})
+	t.Run("collected_boards", func(t *testing.T) {
+		// epics shared with a board collected before are left out
+		iter, err := tasks.GetUncollectedEpicKeysIterator(ctx, taskData.Options.BoardId, []uint64{taskData.Options.BoardId}, 2)
+		require.NoError(t, err)
+		require.False(t, iter.HasNext())
+		// while boards sharing no epics don't affect the result
+		iter, err = tasks.GetUncollectedEpicKeysIterator(ctx, taskData.Options.BoardId, []uint64{1}, 2)
+		require.NoError(t, err)
+		require.True(t, iter.HasNext())
+		e, err := iter.Fetch()
+		require.NoError(t, err)
+		require.Equal(t, 2, len(e.([]interface{})))
+	})
 
 	require.NoError(t, tasks.ExtractEpicsMeta.EntryPoint(ctx))
 
@@ -20,7 +20,6 @@ package impl
 import (
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/apache/incubator-devlake/errors"
 
@@ -42,6 +41,8 @@ var _ core.PluginModel = (*Jira)(nil)
 var _ core.PluginMigration = (*Jira)(nil)
 var _ core.PluginBlueprintV100 = (*Jira)(nil)
 var _ core.CloseablePluginTask = (*Jira)(nil)
+var _ core.SubTaskValidator = (*Jira)(nil)
+var _ core.SubTaskFilter = (*Jira)(nil)
 
 type Jira struct{}
 
@@ -57,7 +58,19 @@ func (plugin Jira) GetTablesInfo() []core.Tabler {
 		&models.JiraBoard{},
 		&models.JiraBoardIssue{},
 		&models.JiraBoardSprint{},
+		&models.JiraCollectionQuery{},
+		&models.JiraCollectionReset{},
 		&models.JiraConnection{},
+		&models.JiraDeletedIssue{},
+		&models.JiraEpicAttachment{},
+		&models.JiraEpicChangelogState{},
+		&models.JiraEpicChangelogWatermark{},
+		&models.JiraEpicCommentStat{},
+		&models.JiraEpicLink{},
+		&models.JiraEpicRemotelink{},
+		&models.JiraEpicStatusChangelog{},
+		&models.JiraEpicVote{},
+		&models.JiraEpicWatch{},
 		&models.JiraIssue{},
 		&models.JiraIssueChangelogItems{},
 		&models.JiraIssueChangelogs{},
@@ -65,6 +78,7 @@ func (plugin Jira) GetTablesInfo() []core.Tabler {
 		&models.JiraIssueLabel{},
 		&models.JiraIssueType{},
 		&models.JiraProject{},
+		&models.JiraRawExport{},
 		&models.JiraRemotelink{},
 		&models.JiraServerInfo{},
 		&models.JiraSprint{},
@@ -80,6 +94,9 @@ func (plugin Jira) Description() string {
 
 func (plugin Jira) SubTaskMetas() []core.SubTaskMeta {
 	return []core.SubTaskMeta{
+		tasks.DiscoverBoardsMeta,
+		tasks.DiagnoseConnectionHealthMeta,
+
 		tasks.CollectStatusMeta,
 		tasks.ExtractStatusMeta,
 
@@ -127,10 +144,76 @@ func (plugin Jira) SubTaskMetas() []core.SubTaskMeta {
 		tasks.ConvertAccountsMeta,
 
 		tasks.CollectEpicsMeta,
+		tasks.CollectFederatedEpicsMeta,
 		tasks.ExtractEpicsMeta,
+		tasks.ExtractEpicChangelogsMeta,
+		tasks.CollectEpicChangelogDetailsMeta,
+		tasks.ConvertEpicsMeta,
+		tasks.ReconcileDeletedEpicsMeta,
+		tasks.CollectEpicChildrenMeta,
+		tasks.CollectEpicSprintsMeta,
+		tasks.ExtractEpicSprintsMeta,
+		tasks.ConvertEpicSprintsMeta,
+		tasks.CollectEpicEngagementMeta,
+		tasks.ExtractEpicEngagementMeta,
+		tasks.CollectEpicLinksMeta,
+		tasks.ExtractEpicLinksMeta,
+		tasks.CollectEpicCommentsMeta,
+		tasks.ExtractEpicCommentsMeta,
+		tasks.ExtractEpicAttachmentsMeta,
+		tasks.CollectEpicRemotelinksMeta,
+		tasks.ExtractEpicRemotelinksMeta,
 	}
 }
 
+// serviceManagementExcludedSubTasks are the subtasks of epics, which are left out for JSM connections by default.
+// Their epics are replaced by the requests of the service desks, which are collected by collectEpics
+var serviceManagementExcludedSubTasks = []*core.SubTaskMeta{
+	&tasks.CollectFederatedEpicsMeta,
+	&tasks.ExtractEpicsMeta,
+	&tasks.ExtractEpicChangelogsMeta,
+	&tasks.CollectEpicChangelogDetailsMeta,
+	&tasks.ConvertEpicsMeta,
+	&tasks.ReconcileDeletedEpicsMeta,
+	&tasks.CollectEpicChildrenMeta,
+	&tasks.CollectEpicSprintsMeta,
+	&tasks.ExtractEpicSprintsMeta,
+	&tasks.ConvertEpicSprintsMeta,
+	&tasks.CollectEpicEngagementMeta,
+	&tasks.ExtractEpicEngagementMeta,
+	&tasks.CollectEpicLinksMeta,
+	&tasks.ExtractEpicLinksMeta,
+	&tasks.CollectEpicCommentsMeta,
+	&tasks.ExtractEpicCommentsMeta,
+	&tasks.ExtractEpicAttachmentsMeta,
+	&tasks.CollectEpicRemotelinksMeta,
+	&tasks.ExtractEpicRemotelinksMeta,
+}
+
+// GetConnectionType returns the type of the connection the task data was prepared for
+func (plugin Jira) GetConnectionType(taskData interface{}) string {
+	return taskData.(*tasks.JiraTaskData).ConnectionType
+}
+
+// FilterSubTasks leaves the subtasks of epics out for JSM connections
+func (plugin Jira) FilterSubTasks(connectionType string) []core.SubTaskMeta {
+	subtaskMetas := plugin.SubTaskMetas()
+	if connectionType != models.ConnectionTypeJSM {
+		return subtaskMetas
+	}
+	excluded := make(map[string]bool, len(serviceManagementExcludedSubTasks))
+	for _, subtaskMeta := range serviceManagementExcludedSubTasks {
+		excluded[subtaskMeta.Name] = true
+	}
+	filtered := make([]core.SubTaskMeta, 0, len(subtaskMetas))
+	for _, subtaskMeta := range subtaskMetas {
+		if !excluded[subtaskMeta.Name] {
+			filtered = append(filtered, subtaskMeta)
+		}
+	}
+	return filtered
+}
+
 func (plugin Jira) PrepareTaskData(taskCtx core.TaskContext, options map[string]interface{}) (interface{}, errors.Error) {
 	var op tasks.JiraOptions
 	var err error
@@ -140,8 +223,14 @@ func (plugin Jira) PrepareTaskData(taskCtx core.TaskContext, options map[string]
 	if err != nil {
 		return nil, errors.Default.Wrap(err, "could not decode Jira options")
 	}
-	if op.ConnectionId == 0 {
-		return nil, errors.BadInput.New("jira connectionId is invalid")
+	// the options are validated as a whole, so that all problems within them are reported at once
+	e := op.ValidateOptions()
+	if e != nil {
+		return nil, e
+	}
+	e = op.ValidateRawTableOverrides(taskCtx.GetConfig("MODE"))
+	if e != nil {
+		return nil, e
 	}
 	connection := &models.JiraConnection{}
 	connectionHelper := helper.NewConnectionHelper(
@@ -156,12 +245,22 @@ func (plugin Jira) PrepareTaskData(taskCtx core.TaskContext, options map[string]
 		return nil, errors.Default.Wrap(err, "unable to get Jira connection")
 	}
 
-	var since time.Time
-	if op.Since != "" {
-		since, err = time.Parse("2006-01-02T15:04:05Z", op.Since)
-		if err != nil {
-			return nil, errors.BadInput.Wrap(err, "invalid value for `since`")
-		}
+	since, e := op.GetSince()
+	if e != nil {
+		return nil, e
+	}
+	timeAfter, e := op.GetTimeAfter()
+	if e != nil {
+		return nil, e
+	}
+	pageTimeout, e := op.GetPageTimeout()
+	if e != nil {
+		return nil, e
+	}
+	clock := tasks.SystemClock
+	lookbackSince, e := op.GetLookbackSince(clock.Now())
+	if e != nil {
+		return nil, e
 	}
 	jiraApiClient, err := tasks.NewJiraApiClient(taskCtx, connection)
 	if err != nil {
@@ -171,18 +270,74 @@ func (plugin Jira) PrepareTaskData(taskCtx core.TaskContext, options map[string]
 	if err != nil || code != http.StatusOK || info == nil {
 		return nil, errors.HttpStatus(code).Wrap(err, "fail to get Jira server info")
 	}
+	timeZone, err := tasks.GetJiraTimeZone(jiraApiClient, info)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "fail to get the time zone of the Jira user")
+	}
+	if timeZone == nil {
+		logger.Warn(nil, "the time zone of the Jira user is unknown, the time of JQL is formatted as it is")
+	}
+	e = op.ValidateUpdatedBy(info.DeploymentType)
+	if e != nil {
+		return nil, e
+	}
+	rawExporter, e := tasks.LoadRawExporter(taskCtx, op.ConnectionId)
+	if e != nil {
+		return nil, e
+	}
+	err = tasks.VerifyJql(jiraApiClient, tasks.GetJiraApiVersion(info).SearchPath(), op.Jql)
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	// fields might be configured by names, which are resolved to the ids of this instance once for all subtasks
+	fieldResolver := tasks.NewFieldResolver(jiraApiClient, tasks.GetJiraApiVersion(info))
+	sprintField := connection.SprintField
+	e = fieldResolver.ResolveAll(
+		&op.TransformationRules.EpicKeyField,
+		&op.TransformationRules.StoryPointField,
+		&sprintField,
+	)
+	if e != nil {
+		return nil, e
+	}
 	taskData := &tasks.JiraTaskData{
 		Options:        &op,
 		ApiClient:      jiraApiClient,
 		JiraServerInfo: *info,
+		TimeZone:       timeZone,
+		Concurrency:    tasks.GetCollectorConcurrency(connection),
+		SprintField:    sprintField,
+		ConnectionType: connection.Type,
+		FieldResolver:  fieldResolver,
+		RawExporter:    rawExporter,
+		PageTimeout:    pageTimeout,
+		// pages larger than the instance accepts would be shrunk silently
+		MaxResultsPerPage: connection.MaxResultsPerPage,
+		Clock:             clock,
 	}
-	if !since.IsZero() {
-		taskData.Since = &since
+	tasks.WarnMaxResultsPerPage(logger, connection)
+	if since != nil {
+		taskData.Since = since
 		logger.Debug("collect data updated since %s", since)
 	}
+	if timeAfter != nil {
+		taskData.TimeAfter = timeAfter
+		logger.Info("timeAfter %s overrides the incremental state of the collectors", timeAfter)
+	}
+	if lookbackSince != nil {
+		taskData.LookbackSince = lookbackSince
+		logger.Info("collect epics updated within the last %d days, since %s", op.LookbackDays, lookbackSince)
+	}
 	return taskData, nil
 }
 
+// ValidateSubTasks fails the task if any enabled extractor consumes a raw field not requested by its collector
+func (plugin Jira) ValidateSubTasks(taskData interface{}, subtasks map[string]bool) errors.Error {
+	data := taskData.(*tasks.JiraTaskData)
+	data.EnabledSubtasks = subtasks
+	return tasks.ValidateRawFields(data, subtasks)
+}
+
 func (plugin Jira) MakePipelinePlan(connectionId uint64, scope []*core.BlueprintScopeV100) (core.PipelinePlan, errors.Error) {
 	return api.MakePipelinePlan(plugin.SubTaskMetas(), connectionId, scope)
 }
@@ -214,6 +369,24 @@ func (plugin Jira) ApiResources() map[string]map[string]core.ApiResourceHandler
 			"DELETE": api.DeleteConnection,
 			"GET":    api.GetConnection,
 		},
+		"connections/:connectionId/diagnostics": {
+			"GET": api.DiagnoseConnection,
+		},
+		"connections/:connectionId/boards/:boardId/preflight": {
+			"GET": api.PreflightBoard,
+		},
+		"connections/:connectionId/boards/:boardId/collection-state": {
+			"GET":    api.GetCollectionState,
+			"DELETE": api.ResetCollectionState,
+		},
+		"connections/:connectionId/oauth2/token": {
+			"POST": api.ExchangeOAuth2Code,
+		},
+		"connections/:connectionId/raw-export": {
+			"PUT":    api.PutRawExport,
+			"GET":    api.GetRawExport,
+			"DELETE": api.DeleteRawExport,
+		},
 		"connections/:connectionId/proxy/rest/*path": {
 			"GET": api.Proxy,
 		},
@@ -0,0 +1,47 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package impl
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/apache/incubator-devlake/plugins/jira/tasks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterSubTasks(t *testing.T) {
+	names := func(subtaskMetas []core.SubTaskMeta) []string {
+		var names []string
+		for _, subtaskMeta := range subtaskMetas {
+			names = append(names, subtaskMeta.Name)
+		}
+		return names
+	}
+	plugin := Jira{}
+	assert.Equal(t, models.ConnectionTypeJSM, plugin.GetConnectionType(&tasks.JiraTaskData{ConnectionType: models.ConnectionTypeJSM}))
+	assert.Equal(t, names(plugin.SubTaskMetas()), names(plugin.FilterSubTasks(models.ConnectionTypeJira)))
+
+	// the requests of the service desks are collected by collectEpics, the epics are left out
+	filtered := names(plugin.FilterSubTasks(models.ConnectionTypeJSM))
+	assert.Contains(t, filtered, tasks.CollectEpicsMeta.Name)
+	assert.Contains(t, filtered, tasks.CollectIssuesMeta.Name)
+	assert.NotContains(t, filtered, tasks.ExtractEpicsMeta.Name)
+	assert.NotContains(t, filtered, tasks.CollectEpicSprintsMeta.Name)
+}
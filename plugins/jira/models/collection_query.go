@@ -0,0 +1,42 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+)
+
+// JiraCollectionQuery records the JQL a collection was run with, so it could be explained long afterward why an issue
+// was or wasn't collected. It is kept for every collection rather than overwritten, and never holds credentials
+type JiraCollectionQuery struct {
+	ID           uint64 `gorm:"primaryKey"`
+	ConnectionId uint64 `gorm:"index"`
+	BoardId      uint64 `gorm:"index"`
+	SubtaskName  string `gorm:"type:varchar(255)"`
+	RawTable     string `gorm:"type:varchar(255)"`
+	Params       string `gorm:"type:varchar(255)"`
+	// Jql is stored in full, every request of the collection narrows it down to a batch of keys or a time window
+	Jql         string `gorm:"type:text"`
+	Since       *time.Time
+	Incremental bool
+	CreatedAt   time.Time `gorm:"index"`
+}
+
+func (JiraCollectionQuery) TableName() string {
+	return "_tool_jira_collection_queries"
+}
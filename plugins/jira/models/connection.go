@@ -19,8 +19,22 @@ package models
 
 import (
 	"github.com/apache/incubator-devlake/plugins/helper"
+	"golang.org/x/oauth2"
 )
 
+const (
+	AuthMethodBasic  = "BasicAuth"
+	AuthMethodOAuth2 = "OAuth2"
+)
+
+const (
+	ConnectionTypeJira = "Jira"
+	ConnectionTypeJSM  = "JSM"
+)
+
+// defaultOAuth2TokenUrl is the token endpoint of Atlassian Cloud OAuth 2.0 (3LO) apps
+const defaultOAuth2TokenUrl = "https://auth.atlassian.com/oauth/token"
+
 type EpicResponse struct {
 	Id    int
 	Title string
@@ -31,6 +45,7 @@ type TestConnectionRequest struct {
 	Endpoint         string `json:"endpoint"`
 	Proxy            string `json:"proxy"`
 	helper.BasicAuth `mapstructure:",squash"`
+	JiraTLS          `mapstructure:",squash"`
 }
 
 type BoardResponse struct {
@@ -39,9 +54,83 @@ type BoardResponse struct {
 	Value string
 }
 
+// JiraAuth holds the credentials of a connection, either a username along with a password/api token, or an OAuth 2.0
+// (3LO) app along with the refresh token granted to it by the authorization code flow
+type JiraAuth struct {
+	AuthMethod         string `mapstructure:"authMethod" json:"authMethod" validate:"omitempty,oneof=BasicAuth OAuth2" comment:"BasicAuth by default, or OAuth2"`
+	Username           string `mapstructure:"username" validate:"required_unless=AuthMethod OAuth2" json:"username"`
+	Password           string `mapstructure:"password" validate:"required_unless=AuthMethod OAuth2" json:"password" encrypt:"yes"`
+	OAuth2ClientId     string `mapstructure:"oauth2ClientId" validate:"required_if=AuthMethod OAuth2" json:"oauth2ClientId" gorm:"column:oauth2_client_id"`
+	OAuth2ClientSecret string `mapstructure:"oauth2ClientSecret" validate:"required_if=AuthMethod OAuth2" json:"oauth2ClientSecret" gorm:"column:oauth2_client_secret" encrypt:"yes"`
+	// OAuth2RefreshToken is rotated by Jira on every refresh, the latest one is written back to the connection
+	OAuth2RefreshToken string `mapstructure:"oauth2RefreshToken" json:"oauth2RefreshToken" gorm:"column:oauth2_refresh_token" encrypt:"yes"`
+	OAuth2TokenUrl     string `mapstructure:"oauth2TokenUrl" json:"oauth2TokenUrl" gorm:"column:oauth2_token_url" comment:"Atlassian token endpoint by default"`
+}
+
+// GetEncodedToken returns the base64 encoded username and password for basic auth
+func (auth JiraAuth) GetEncodedToken() string {
+	return helper.BasicAuth{Username: auth.Username, Password: auth.Password}.GetEncodedToken()
+}
+
+// IsOAuth2 tells if the connection authenticates by OAuth 2.0 access tokens
+func (auth JiraAuth) IsOAuth2() bool {
+	return auth.AuthMethod == AuthMethodOAuth2
+}
+
+// GetOAuth2Config returns the config of the OAuth 2.0 app for exchanging and refreshing tokens
+func (auth JiraAuth) GetOAuth2Config(redirectUrl string) *oauth2.Config {
+	tokenUrl := auth.OAuth2TokenUrl
+	if tokenUrl == "" {
+		tokenUrl = defaultOAuth2TokenUrl
+	}
+	return &oauth2.Config{
+		ClientID:     auth.OAuth2ClientId,
+		ClientSecret: auth.OAuth2ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			TokenURL:  tokenUrl,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		RedirectURL: redirectUrl,
+	}
+}
+
 type JiraConnection struct {
 	helper.RestConnection `mapstructure:",squash"`
-	helper.BasicAuth      `mapstructure:",squash"`
+	JiraAuth              `mapstructure:",squash"`
+	Concurrency           int `mapstructure:"concurrency" json:"concurrency" comment:"max number of concurrent requests of a collector"`
+	// SprintField is the id or name of the custom field holding the sprints of issues, Jira assigns the id per instance
+	SprintField string `mapstructure:"sprintField" json:"sprintField" gorm:"type:varchar(255)" comment:"e.g. customfield_10020"`
+	JiraTLS     `mapstructure:",squash"`
+	// Type tells the collectors which flavor of Jira the connection is pointed at, the epics of a Jira Service
+	// Management connection are collected as the customer requests of its service desks
+	Type          string `mapstructure:"type" json:"type" gorm:"type:varchar(20)" validate:"omitempty,oneof=Jira JSM" comment:"Jira by default, or JSM"`
+	JiraRedaction `mapstructure:",squash"`
+	// MaxResultsPerPage caps the page size requested by the collectors, for instances known to cap `maxResults` below
+	// the size requested, which would be shrunk silently otherwise. 0 means no cap
+	MaxResultsPerPage int `mapstructure:"maxResultsPerPage" json:"maxResultsPerPage" validate:"omitempty,gt=0" comment:"max page size accepted by the instance"`
+	// Priority is the weight of the connection in the share of the workers of the process, a connection of priority
+	// 2 gets twice the workers of one of priority 1 while both are collecting, see API_SHARED_WORKERS. 1 if omitted
+	Priority int `mapstructure:"priority" json:"priority" validate:"omitempty,gt=0" comment:"weight in the share of the workers"`
+}
+
+// IsServiceManagement tells if the connection is pointed at Jira Service Management
+func (connection JiraConnection) IsServiceManagement() bool {
+	return connection.Type == ConnectionTypeJSM
+}
+
+// JiraTLS holds the TLS settings of a connection, a self-hosted instance might be signed by a private CA
+type JiraTLS struct {
+	// CaCertificate is the PEM encoded bundle of CA certificates trusted on top of the system roots
+	CaCertificate      string `mapstructure:"caCertificate" json:"caCertificate" gorm:"type:text"`
+	InsecureSkipVerify bool   `mapstructure:"insecureSkipVerify" json:"insecureSkipVerify" comment:"skip the verification of the server certificate"`
+}
+
+// JiraRedaction tells which fields of the collected records are redacted before they are saved into the raw tables,
+// so the personal data could be kept out of the database by configuration. The fields are JSON paths like
+// `fields.reporter.emailAddress`, see helper.JsonRedactor for the syntax
+type JiraRedaction struct {
+	RedactedFields []string `mapstructure:"redactedFields" json:"redactedFields" gorm:"type:text;serializer:json"`
+	RedactionMode  string   `mapstructure:"redactionMode" json:"redactionMode" gorm:"type:varchar(20)" validate:"omitempty,oneof=null hash" comment:"null by default, or hash"`
 }
 
 func (JiraConnection) TableName() string {
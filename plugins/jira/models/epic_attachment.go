@@ -0,0 +1,44 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/models/common"
+)
+
+// JiraEpicAttachment is the metadata of a file attached to an epic, the file itself is not downloaded. The author
+// is identified by the account id on Jira Cloud and by the username on Jira Server and Data Center
+type JiraEpicAttachment struct {
+	common.NoPKModel
+	ConnectionId      uint64 `gorm:"primaryKey"`
+	AttachmentId      uint64 `gorm:"primaryKey"`
+	EpicId            uint64 `gorm:"index"`
+	EpicKey           string `gorm:"type:varchar(255)"`
+	Filename          string `gorm:"type:varchar(255)"`
+	Size              int64
+	MimeType          string `gorm:"type:varchar(255)"`
+	AuthorAccountId   string `gorm:"type:varchar(255)"`
+	AuthorDisplayName string `gorm:"type:varchar(255)"`
+	Created           *time.Time
+}
+
+func (JiraEpicAttachment) TableName() string {
+	return "_tool_jira_epic_attachments"
+}
@@ -0,0 +1,59 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/models/common"
+)
+
+// JiraEpicStatusChangelog is a status transition of an epic, extracted from the changelog expanded along with the epic
+type JiraEpicStatusChangelog struct {
+	common.NoPKModel
+	ConnectionId      uint64    `gorm:"primaryKey"`
+	ChangelogId       uint64    `gorm:"primaryKey"`
+	EpicId            uint64    `gorm:"index"`
+	AuthorAccountId   string    `gorm:"type:varchar(255)"`
+	AuthorDisplayName string    `gorm:"type:varchar(255)"`
+	FromStatusId      string    `gorm:"type:varchar(255)"`
+	FromStatus        string    `gorm:"type:varchar(255)"`
+	ToStatusId        string    `gorm:"type:varchar(255)"`
+	ToStatus          string    `gorm:"type:varchar(255)"`
+	Created           time.Time `gorm:"index"`
+}
+
+func (JiraEpicStatusChangelog) TableName() string {
+	return "_tool_jira_epic_status_changelogs"
+}
+
+// JiraEpicChangelogState tells how much of the changelog of an epic was returned along with it, Jira truncates the
+// changelog expanded in search responses, so epics `Truncated` are left for a follow-up collector to fetch the rest
+type JiraEpicChangelogState struct {
+	common.NoPKModel
+	ConnectionId uint64 `gorm:"primaryKey"`
+	EpicId       uint64 `gorm:"primaryKey"`
+	EpicUpdated  *time.Time
+	Returned     int
+	Total        int
+	Truncated    bool `gorm:"index"`
+}
+
+func (JiraEpicChangelogState) TableName() string {
+	return "_tool_jira_epic_changelog_states"
+}
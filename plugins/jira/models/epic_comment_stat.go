@@ -0,0 +1,42 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/models/common"
+)
+
+// JiraEpicCommentStat is the summary of the comments of an epic for telling the epics under discussion, the epic
+// is kept under the first board it was collected by. The author is identified by the account id on Jira Cloud and
+// by the username on Jira Server and Data Center, the latest comment is empty if the epic has none
+type JiraEpicCommentStat struct {
+	common.NoPKModel
+	ConnectionId        uint64 `gorm:"primaryKey"`
+	BoardId             uint64 `gorm:"primaryKey"`
+	IssueId             uint64 `gorm:"primaryKey"`
+	EpicKey             string `gorm:"type:varchar(255)"`
+	CommentCount        int
+	LatestCommentAuthor string `gorm:"type:varchar(255)"`
+	LatestCommentedAt   *time.Time
+}
+
+func (JiraEpicCommentStat) TableName() string {
+	return "_tool_jira_epic_comment_stats"
+}
@@ -0,0 +1,49 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"github.com/apache/incubator-devlake/models/common"
+)
+
+// JiraEpicWatch is the number of users watching an epic, as a signal of the interest in it
+type JiraEpicWatch struct {
+	common.NoPKModel
+	ConnectionId uint64 `gorm:"primaryKey"`
+	EpicKey      string `gorm:"primaryKey;type:varchar(255)"`
+	WatchCount   int
+}
+
+func (JiraEpicWatch) TableName() string {
+	return "_tool_jira_epic_watches"
+}
+
+// JiraEpicVote is the number of votes for an epic along with the voters, which are identified by their account ids
+// on Jira Cloud and by their usernames on Jira Server and Data Center. The voters are left empty if the user of the
+// connection isn't allowed to view them
+type JiraEpicVote struct {
+	common.NoPKModel
+	ConnectionId uint64 `gorm:"primaryKey"`
+	EpicKey      string `gorm:"primaryKey;type:varchar(255)"`
+	VoteCount    int
+	Voters       []string `gorm:"type:text;serializer:json"`
+}
+
+func (JiraEpicVote) TableName() string {
+	return "_tool_jira_epic_votes"
+}
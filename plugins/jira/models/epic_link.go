@@ -0,0 +1,43 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"github.com/apache/incubator-devlake/models/common"
+)
+
+// JiraEpicLink is an issue link of an epic, i.e. `blocks` or `relates to`, seen from the side of the epic: a link
+// between two epics shows up once on each of them with the opposite directions. Direction is `outward` if the epic
+// is the source of the link, i.e. the epic blocks the linked issue, and `inward` otherwise
+type JiraEpicLink struct {
+	common.NoPKModel
+	ConnectionId   uint64 `gorm:"primaryKey"`
+	EpicId         uint64 `gorm:"primaryKey"`
+	LinkId         uint64 `gorm:"primaryKey"`
+	EpicKey        string `gorm:"type:varchar(255)"`
+	LinkTypeId     string `gorm:"type:varchar(255)"`
+	LinkTypeName   string `gorm:"type:varchar(255)"`
+	Direction      string `gorm:"type:varchar(20)"`
+	Description    string `gorm:"type:varchar(255)"`
+	LinkedIssueId  uint64
+	LinkedIssueKey string `gorm:"type:varchar(255)"`
+}
+
+func (JiraEpicLink) TableName() string {
+	return "_tool_jira_epic_links"
+}
@@ -0,0 +1,41 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"github.com/apache/incubator-devlake/models/common"
+)
+
+// JiraEpicRemotelink is a link of an epic to an object outside of Jira, i.e. a Confluence page or a GitHub pull
+// request. The application tells the kind of the object, it is empty for the links added by url only
+type JiraEpicRemotelink struct {
+	common.NoPKModel
+	ConnectionId    uint64 `gorm:"primaryKey"`
+	RemotelinkId    uint64 `gorm:"primaryKey"`
+	EpicKey         string `gorm:"type:varchar(255);index"`
+	GlobalId        string
+	Title           string
+	Url             string
+	Relationship    string `gorm:"type:varchar(255)"`
+	ApplicationType string `gorm:"type:varchar(255)"`
+	ApplicationName string `gorm:"type:varchar(255)"`
+}
+
+func (JiraEpicRemotelink) TableName() string {
+	return "_tool_jira_epic_remotelinks"
+}
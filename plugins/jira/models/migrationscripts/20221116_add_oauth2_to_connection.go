@@ -0,0 +1,49 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+type jiraConnection20221116 struct {
+	AuthMethod         string `gorm:"type:varchar(20)"`
+	OAuth2ClientId     string `gorm:"column:oauth2_client_id;type:varchar(255)"`
+	OAuth2ClientSecret string `gorm:"column:oauth2_client_secret"`
+	OAuth2RefreshToken string `gorm:"column:oauth2_refresh_token"`
+	OAuth2TokenUrl     string `gorm:"column:oauth2_token_url;type:varchar(255)"`
+}
+
+func (jiraConnection20221116) TableName() string {
+	return "_tool_jira_connections"
+}
+
+type addOAuth2ToConnection20221116 struct{}
+
+func (*addOAuth2ToConnection20221116) Up(basicRes core.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(&jiraConnection20221116{})
+}
+
+func (*addOAuth2ToConnection20221116) Version() uint64 {
+	return 20221116000001
+}
+
+func (*addOAuth2ToConnection20221116) Name() string {
+	return "add oauth2 columns at _tool_jira_connections"
+}
@@ -0,0 +1,77 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+	"github.com/apache/incubator-devlake/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+type jiraEpicStatusChangelog20221118 struct {
+	archived.NoPKModel
+	ConnectionId      uint64    `gorm:"primaryKey"`
+	ChangelogId       uint64    `gorm:"primaryKey"`
+	EpicId            uint64    `gorm:"index"`
+	AuthorAccountId   string    `gorm:"type:varchar(255)"`
+	AuthorDisplayName string    `gorm:"type:varchar(255)"`
+	FromStatusId      string    `gorm:"type:varchar(255)"`
+	FromStatus        string    `gorm:"type:varchar(255)"`
+	ToStatusId        string    `gorm:"type:varchar(255)"`
+	ToStatus          string    `gorm:"type:varchar(255)"`
+	Created           time.Time `gorm:"index"`
+}
+
+func (jiraEpicStatusChangelog20221118) TableName() string {
+	return "_tool_jira_epic_status_changelogs"
+}
+
+type jiraEpicChangelogState20221118 struct {
+	archived.NoPKModel
+	ConnectionId uint64 `gorm:"primaryKey"`
+	EpicId       uint64 `gorm:"primaryKey"`
+	EpicUpdated  *time.Time
+	Returned     int
+	Total        int
+	Truncated    bool `gorm:"index"`
+}
+
+func (jiraEpicChangelogState20221118) TableName() string {
+	return "_tool_jira_epic_changelog_states"
+}
+
+type addEpicChangelogTables20221118 struct{}
+
+func (*addEpicChangelogTables20221118) Up(basicRes core.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(
+		basicRes,
+		&jiraEpicStatusChangelog20221118{},
+		&jiraEpicChangelogState20221118{},
+	)
+}
+
+func (*addEpicChangelogTables20221118) Version() uint64 {
+	return 20221118000001
+}
+
+func (*addEpicChangelogTables20221118) Name() string {
+	return "add _tool_jira_epic_status_changelogs and _tool_jira_epic_changelog_states"
+}
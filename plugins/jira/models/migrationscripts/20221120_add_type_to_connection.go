@@ -0,0 +1,45 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+type jiraConnection20221120 struct {
+	Type string `gorm:"type:varchar(20)" comment:"Jira by default, or JSM"`
+}
+
+func (jiraConnection20221120) TableName() string {
+	return "_tool_jira_connections"
+}
+
+type addTypeToConnection20221120 struct{}
+
+func (*addTypeToConnection20221120) Up(basicRes core.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(&jiraConnection20221120{})
+}
+
+func (*addTypeToConnection20221120) Version() uint64 {
+	return 20221120000001
+}
+
+func (*addTypeToConnection20221120) Name() string {
+	return "add column `type` at _tool_jira_connections"
+}
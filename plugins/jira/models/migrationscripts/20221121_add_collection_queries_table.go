@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+type jiraCollectionQuery20221121 struct {
+	ID           uint64 `gorm:"primaryKey"`
+	ConnectionId uint64 `gorm:"index"`
+	BoardId      uint64 `gorm:"index"`
+	SubtaskName  string `gorm:"type:varchar(255)"`
+	RawTable     string `gorm:"type:varchar(255)"`
+	Params       string `gorm:"type:varchar(255)"`
+	Jql          string `gorm:"type:text"`
+	Since        *time.Time
+	Incremental  bool
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+func (jiraCollectionQuery20221121) TableName() string {
+	return "_tool_jira_collection_queries"
+}
+
+type addCollectionQueriesTable20221121 struct{}
+
+func (*addCollectionQueriesTable20221121) Up(basicRes core.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &jiraCollectionQuery20221121{})
+}
+
+func (*addCollectionQueriesTable20221121) Version() uint64 {
+	return 20221121000001
+}
+
+func (*addCollectionQueriesTable20221121) Name() string {
+	return "add _tool_jira_collection_queries"
+}
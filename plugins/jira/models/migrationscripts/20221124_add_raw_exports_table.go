@@ -0,0 +1,55 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+	"github.com/apache/incubator-devlake/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+type jiraRawExport20221124 struct {
+	archived.NoPKModel
+	ConnectionId    uint64 `gorm:"primaryKey"`
+	Provider        string `gorm:"type:varchar(20)"`
+	Endpoint        string `gorm:"type:varchar(255)"`
+	Region          string `gorm:"type:varchar(100)"`
+	Bucket          string `gorm:"type:varchar(255)"`
+	Prefix          string `gorm:"type:varchar(255)"`
+	AccessKeyId     string `gorm:"type:varchar(255)"`
+	SecretAccessKey string
+}
+
+func (jiraRawExport20221124) TableName() string {
+	return "_tool_jira_raw_exports"
+}
+
+type addRawExportsTable20221124 struct{}
+
+func (*addRawExportsTable20221124) Up(basicRes core.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &jiraRawExport20221124{})
+}
+
+func (*addRawExportsTable20221124) Version() uint64 {
+	return 20221124000001
+}
+
+func (*addRawExportsTable20221124) Name() string {
+	return "add _tool_jira_raw_exports"
+}
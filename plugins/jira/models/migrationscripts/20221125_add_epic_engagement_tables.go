@@ -0,0 +1,62 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+	"github.com/apache/incubator-devlake/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+type jiraEpicWatch20221125 struct {
+	archived.NoPKModel
+	ConnectionId uint64 `gorm:"primaryKey"`
+	EpicKey      string `gorm:"primaryKey;type:varchar(255)"`
+	WatchCount   int
+}
+
+func (jiraEpicWatch20221125) TableName() string {
+	return "_tool_jira_epic_watches"
+}
+
+type jiraEpicVote20221125 struct {
+	archived.NoPKModel
+	ConnectionId uint64 `gorm:"primaryKey"`
+	EpicKey      string `gorm:"primaryKey;type:varchar(255)"`
+	VoteCount    int
+	Voters       string `gorm:"type:text"`
+}
+
+func (jiraEpicVote20221125) TableName() string {
+	return "_tool_jira_epic_votes"
+}
+
+type addEpicEngagementTables20221125 struct{}
+
+func (*addEpicEngagementTables20221125) Up(basicRes core.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &jiraEpicWatch20221125{}, &jiraEpicVote20221125{})
+}
+
+func (*addEpicEngagementTables20221125) Version() uint64 {
+	return 20221125000001
+}
+
+func (*addEpicEngagementTables20221125) Name() string {
+	return "add _tool_jira_epic_watches and _tool_jira_epic_votes"
+}
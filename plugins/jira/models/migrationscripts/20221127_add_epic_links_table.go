@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+	"github.com/apache/incubator-devlake/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+type jiraEpicLink20221127 struct {
+	archived.NoPKModel
+	ConnectionId   uint64 `gorm:"primaryKey"`
+	EpicId         uint64 `gorm:"primaryKey"`
+	LinkId         uint64 `gorm:"primaryKey"`
+	EpicKey        string `gorm:"type:varchar(255)"`
+	LinkTypeId     string `gorm:"type:varchar(255)"`
+	LinkTypeName   string `gorm:"type:varchar(255)"`
+	Direction      string `gorm:"type:varchar(20)"`
+	Description    string `gorm:"type:varchar(255)"`
+	LinkedIssueId  uint64
+	LinkedIssueKey string `gorm:"type:varchar(255)"`
+}
+
+func (jiraEpicLink20221127) TableName() string {
+	return "_tool_jira_epic_links"
+}
+
+type addEpicLinksTable20221127 struct{}
+
+func (*addEpicLinksTable20221127) Up(basicRes core.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &jiraEpicLink20221127{})
+}
+
+func (*addEpicLinksTable20221127) Version() uint64 {
+	return 20221127000001
+}
+
+func (*addEpicLinksTable20221127) Name() string {
+	return "add _tool_jira_epic_links"
+}
@@ -0,0 +1,56 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+	"github.com/apache/incubator-devlake/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+type jiraEpicRemotelink20221202 struct {
+	archived.NoPKModel
+	ConnectionId    uint64 `gorm:"primaryKey"`
+	RemotelinkId    uint64 `gorm:"primaryKey"`
+	EpicKey         string `gorm:"type:varchar(255);index"`
+	GlobalId        string
+	Title           string
+	Url             string
+	Relationship    string `gorm:"type:varchar(255)"`
+	ApplicationType string `gorm:"type:varchar(255)"`
+	ApplicationName string `gorm:"type:varchar(255)"`
+}
+
+func (jiraEpicRemotelink20221202) TableName() string {
+	return "_tool_jira_epic_remotelinks"
+}
+
+type addEpicRemotelinksTable20221202 struct{}
+
+func (*addEpicRemotelinksTable20221202) Up(basicRes core.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &jiraEpicRemotelink20221202{})
+}
+
+func (*addEpicRemotelinksTable20221202) Version() uint64 {
+	return 20221202000001
+}
+
+func (*addEpicRemotelinksTable20221202) Name() string {
+	return "add _tool_jira_epic_remotelinks"
+}
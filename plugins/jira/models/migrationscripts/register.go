@@ -27,5 +27,24 @@ func All() []core.MigrationScript {
 		new(addSourceTable20220407),
 		new(renameSourceTable20220505),
 		new(addInitTables20220716),
+		new(addConcurrencyToConnection20221115),
+		new(addOAuth2ToConnection20221116),
+		new(addSprintFieldToConnection20221117),
+		new(addEpicChangelogTables20221118),
+		new(addTLSConfigToConnection20221119),
+		new(addTypeToConnection20221120),
+		new(addCollectionQueriesTable20221121),
+		new(addDeletedIssuesTable20221122),
+		new(addRedactionToConnection20221123),
+		new(addRawExportsTable20221124),
+		new(addEpicEngagementTables20221125),
+		new(addMaxResultsPerPageToConnection20221126),
+		new(addEpicLinksTable20221127),
+		new(addEpicCommentStatsTable20221128),
+		new(addEpicChangelogWatermarksTable20221129),
+		new(addEpicAttachmentsTable20221130),
+		new(addPriorityToConnection20221201),
+		new(addEpicRemotelinksTable20221202),
+		new(addCollectionResetsTable20221203),
 	}
 }
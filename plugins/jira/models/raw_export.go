@@ -0,0 +1,47 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"github.com/apache/incubator-devlake/models/common"
+)
+
+const (
+	RawExportProviderS3  = "s3"
+	RawExportProviderGCS = "gcs"
+)
+
+// JiraRawExport is the bucket the raw epics collected by a connection are exported to, it is kept by the side of the
+// connection so the credentials of the bucket are managed apart from those of Jira. Both AWS S3 and Google Cloud
+// Storage are spoken to by the S3 api, the latter by the HMAC keys of a service account
+type JiraRawExport struct {
+	common.NoPKModel
+	ConnectionId uint64 `gorm:"primaryKey" json:"connectionId"`
+	Provider     string `mapstructure:"provider" json:"provider" gorm:"type:varchar(20)" validate:"required,oneof=s3 gcs" comment:"s3 or gcs"`
+	// Endpoint defaults to the one of the provider, it is set for S3 compatible storages like MinIO
+	Endpoint        string `mapstructure:"endpoint" json:"endpoint" gorm:"type:varchar(255)"`
+	Region          string `mapstructure:"region" json:"region" gorm:"type:varchar(100)" comment:"us-east-1 by default"`
+	Bucket          string `mapstructure:"bucket" json:"bucket" gorm:"type:varchar(255)" validate:"required"`
+	Prefix          string `mapstructure:"prefix" json:"prefix" gorm:"type:varchar(255)" comment:"prepended to the keys of the objects"`
+	AccessKeyId     string `mapstructure:"accessKeyId" json:"accessKeyId" gorm:"type:varchar(255)" validate:"required"`
+	SecretAccessKey string `mapstructure:"secretAccessKey" json:"secretAccessKey" validate:"required" encrypt:"yes"`
+}
+
+func (JiraRawExport) TableName() string {
+	return "_tool_jira_raw_exports"
+}
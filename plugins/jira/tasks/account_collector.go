@@ -58,7 +58,7 @@ func CollectAccounts(taskCtx core.SubTaskContext) errors.Error {
 		return err
 	}
 	queryKey := "accountId"
-	urlTemplate := "api/2/user"
+	urlTemplate := data.ApiVersion().Path("user")
 	if data.JiraServerInfo.DeploymentType == models.DeploymentServer {
 		queryKey = "key"
 	}
@@ -70,7 +70,7 @@ func CollectAccounts(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_USERS_TABLE,
+			Table: data.Options.RawTable(RAW_USERS_TABLE),
 		},
 		ApiClient:   data.ApiClient,
 		Input:       iterator,
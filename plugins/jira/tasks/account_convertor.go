@@ -64,7 +64,7 @@ func ConvertAccounts(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: connectionId,
 				BoardId:      boardId,
 			},
-			Table: RAW_USERS_TABLE,
+			Table: data.Options.RawTable(RAW_USERS_TABLE),
 		},
 		InputRowType: reflect.TypeOf(models.JiraAccount{}),
 		Input:        cursor,
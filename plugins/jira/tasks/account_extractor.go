@@ -44,7 +44,7 @@ func ExtractAccounts(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_USERS_TABLE,
+			Table: data.Options.RawTable(RAW_USERS_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			var user apiv2models.Account
@@ -18,22 +18,22 @@ limitations under the License.
 package tasks
 
 import (
+	"context"
 	"fmt"
 	"github.com/apache/incubator-devlake/errors"
+	"math"
 	"net/http"
+	"time"
 
 	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
 	"github.com/apache/incubator-devlake/plugins/helper"
 	"github.com/apache/incubator-devlake/plugins/jira/models"
 )
 
 func NewJiraApiClient(taskCtx core.TaskContext, connection *models.JiraConnection) (*helper.ApiAsyncClient, errors.Error) {
 	// create synchronize api client so we can calculate api rate limit dynamically
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Basic %v", connection.GetEncodedToken()),
-	}
-
-	apiClient, err := helper.NewApiClient(taskCtx.GetContext(), connection.Endpoint, headers, 0, connection.Proxy, taskCtx)
+	apiClient, err := NewJiraSyncApiClient(taskCtx.GetContext(), taskCtx, connection, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -50,18 +50,129 @@ func NewJiraApiClient(taskCtx core.TaskContext, connection *models.JiraConnectio
 	if err != nil {
 		return nil, err
 	}
+	// tasks of the connection running in parallel share the rate limit, which is per tenant on Jira's side
+	err = asyncApiClient.ShareRateLimit(fmt.Sprintf("jira:%d", connection.ID))
+	if err != nil {
+		return nil, err
+	}
+	// a connection collecting a backfill shouldn't hold back the collections of the other connections
+	err = asyncApiClient.ShareWorkers(fmt.Sprintf("jira:%d", connection.ID), connection.Priority)
+	if err != nil {
+		return nil, err
+	}
 
 	return asyncApiClient, nil
 }
 
+// NewJiraSyncApiClient creates an api client authenticated by the credentials of the connection, access tokens of
+// OAuth2 connections are refreshed transparently, and the rotated refresh token is saved back to the connection
+func NewJiraSyncApiClient(
+	ctx context.Context,
+	basicRes core.BasicRes,
+	connection *models.JiraConnection,
+	timeout time.Duration,
+) (*helper.ApiClient, errors.Error) {
+	headers := map[string]string{}
+	if !connection.IsOAuth2() {
+		headers["Authorization"] = fmt.Sprintf("Basic %v", connection.GetEncodedToken())
+	}
+	apiClient, err := helper.NewApiClient(ctx, connection.Endpoint, headers, timeout, connection.Proxy, basicRes)
+	if err != nil {
+		return nil, err
+	}
+	err = apiClient.SetTLSConfig(connection.CaCertificate, connection.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	apiClient.ShareCircuitBreaker(CircuitBreakerKey(connection.ID), helper.DefaultCircuitBreakerThreshold)
+	redactor, err := helper.NewJsonRedactor(connection.RedactedFields, connection.RedactionMode)
+	if err != nil {
+		return nil, err
+	}
+	apiClient.SetRedactor(redactor)
+	if connection.IsOAuth2() {
+		if connection.OAuth2RefreshToken == "" {
+			return nil, errors.Unauthorized.New("the connection was not authorized by OAuth2 yet")
+		}
+		tokenSource := helper.NewOAuth2RefreshTokenSource(
+			ctx,
+			connection.GetOAuth2Config(""),
+			connection.OAuth2RefreshToken,
+			func(refreshToken string) errors.Error {
+				return saveOAuth2RefreshToken(basicRes, connection, refreshToken)
+			},
+		)
+		apiClient.SetBeforeFunction(helper.OAuth2BeforeRequest(tokenSource))
+	}
+	return apiClient, nil
+}
+
+// CircuitBreakerKey returns the key of the circuit breaker shared by the api clients of the connection
+func CircuitBreakerKey(connectionId uint64) string {
+	return fmt.Sprintf("jira:%d", connectionId)
+}
+
+// saveOAuth2RefreshToken writes the refresh token back to the connection, Jira invalidates the previous one once it
+// is rotated
+func saveOAuth2RefreshToken(basicRes core.BasicRes, connection *models.JiraConnection, refreshToken string) errors.Error {
+	encrypted, err := core.Encrypt(basicRes.GetConfig(core.EncodeKeyEnvStr), refreshToken)
+	if err != nil {
+		return err
+	}
+	err = basicRes.GetDal().UpdateColumn(
+		&models.JiraConnection{},
+		"oauth2_refresh_token",
+		encrypted,
+		dal.Where("id = ?", connection.ID),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to save the rotated OAuth2 refresh token")
+	}
+	connection.OAuth2RefreshToken = refreshToken
+	return nil
+}
+
+const defaultConcurrency = 10
+
+// expectedResponseTime is how long a Jira request is assumed to take when translating the rate limit into concurrency
+const expectedResponseTime = 5 * time.Second
+
+// GetCollectorConcurrency returns the number of concurrent requests a collector may issue for the connection.
+// The rate limit caps the concurrency, requests beyond what can be sent during the expected response time would only
+// pile up in the scheduler
+func GetCollectorConcurrency(connection *models.JiraConnection) int {
+	concurrency := connection.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	if connection.RateLimitPerHour > 0 {
+		maxConcurrency := int(math.Ceil(float64(connection.RateLimitPerHour) * expectedResponseTime.Seconds() / time.Hour.Seconds()))
+		if concurrency > maxConcurrency {
+			concurrency = maxConcurrency
+		}
+	}
+	return concurrency
+}
+
+// jiraMaxResultsPerPage is the page size Jira honors by default, also the largest size requested by the collectors
+const jiraMaxResultsPerPage = 100
+
+// WarnMaxResultsPerPage warns about a cap of the page size which would never be reached, since the collectors don't
+// request more than jiraMaxResultsPerPage at a time anyway
+func WarnMaxResultsPerPage(logger core.Logger, connection *models.JiraConnection) {
+	if connection.MaxResultsPerPage > jiraMaxResultsPerPage {
+		logger.Warn(nil, "maxResultsPerPage %d of connection %d exceeds the jira default of %d, which is requested at most", connection.MaxResultsPerPage, connection.ID, jiraMaxResultsPerPage)
+	}
+}
+
 type JiraPagination struct {
 	StartAt    int `json:"startAt"`
 	MaxResults int `json:"maxResults"`
 	Total      int `json:"total"`
 }
 
-func GetJiraServerInfo(client *helper.ApiAsyncClient) (*models.JiraServerInfo, int, errors.Error) {
-	res, err := client.Get("api/2/serverInfo", nil, nil)
+func GetJiraServerInfo(client helper.ApiClientGetter) (*models.JiraServerInfo, int, errors.Error) {
+	res, err := client.Get(JiraApiV2.Path("serverInfo"), nil, nil)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -76,6 +187,44 @@ func GetJiraServerInfo(client *helper.ApiAsyncClient) (*models.JiraServerInfo, i
 	return serverInfo, res.StatusCode, nil
 }
 
+// jiraServerTimeLayout is the layout of the `serverTime` of the server info, i.e. `2022-11-21T10:18:04.566+0100`
+const jiraServerTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// GetJiraTimeZone returns the time zone the dates of JQL are interpreted in, which is the one of the user the api
+// client authenticates as rather than UTC. The offset of the server time is taken if the time zone of the user is
+// unknown, nil is returned if neither is, and dates are formatted as they are
+func GetJiraTimeZone(client helper.ApiClientGetter, serverInfo *models.JiraServerInfo) (*time.Location, errors.Error) {
+	res, err := client.Get(GetJiraApiVersion(serverInfo).Path("myself"), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	myself := &struct {
+		TimeZone string `json:"timeZone"`
+	}{}
+	if res.StatusCode == http.StatusOK {
+		err = helper.UnmarshalResponse(res, myself)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		res.Body.Close()
+	}
+	if myself.TimeZone != "" {
+		location, e := time.LoadLocation(myself.TimeZone)
+		if e == nil {
+			return location, nil
+		}
+	}
+	if serverInfo != nil && serverInfo.ServerTime != "" {
+		serverTime, e := time.Parse(jiraServerTimeLayout, serverInfo.ServerTime)
+		if e == nil {
+			_, offset := serverTime.Zone()
+			return time.FixedZone(serverTime.Format("-0700"), offset), nil
+		}
+	}
+	return nil, nil
+}
+
 func ignoreHTTPStatus404(res *http.Response) errors.Error {
 	if res.StatusCode == http.StatusUnauthorized {
 		return errors.Unauthorized.New("authentication failed, please check your AccessToken")
@@ -0,0 +1,135 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetCollectorConcurrency(t *testing.T) {
+	newConnection := func(concurrency, rateLimitPerHour int) *models.JiraConnection {
+		connection := &models.JiraConnection{Concurrency: concurrency}
+		connection.RateLimitPerHour = rateLimitPerHour
+		return connection
+	}
+	assert.Equal(t, 10, GetCollectorConcurrency(newConnection(0, 0)))
+	assert.Equal(t, 30, GetCollectorConcurrency(newConnection(30, 0)))
+	assert.Equal(t, 30, GetCollectorConcurrency(newConnection(30, 36000)))
+	// 1440 requests per hour allow 2 requests every 5 seconds
+	assert.Equal(t, 2, GetCollectorConcurrency(newConnection(0, 1440)))
+	assert.Equal(t, 2, GetCollectorConcurrency(newConnection(30, 1440)))
+	assert.Equal(t, 1, GetCollectorConcurrency(newConnection(0, 10)))
+}
+
+func TestWarnMaxResultsPerPage(t *testing.T) {
+	logger := new(mocks.Logger)
+	logger.On("Warn", mock.Anything, mock.Anything, mock.Anything).Return()
+	WarnMaxResultsPerPage(logger, &models.JiraConnection{MaxResultsPerPage: 0})
+	WarnMaxResultsPerPage(logger, &models.JiraConnection{MaxResultsPerPage: 50})
+	WarnMaxResultsPerPage(logger, &models.JiraConnection{MaxResultsPerPage: 100})
+	logger.AssertNotCalled(t, "Warn", mock.Anything, mock.Anything, mock.Anything)
+	WarnMaxResultsPerPage(logger, &models.JiraConnection{MaxResultsPerPage: 500})
+	logger.AssertNumberOfCalls(t, "Warn", 1)
+}
+
+func TestNewJiraSyncApiClientTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	basicRes := new(mocks.BasicRes)
+	basicRes.On("GetConfig", mock.Anything).Return("")
+
+	newConnection := func(caCertificate string) *models.JiraConnection {
+		connection := &models.JiraConnection{}
+		connection.Endpoint = server.URL + "/"
+		connection.CaCertificate = caCertificate
+		return connection
+	}
+	get := func(connection *models.JiraConnection) error {
+		apiClient, err := NewJiraSyncApiClient(context.Background(), basicRes, connection, 10*time.Second)
+		if err != nil {
+			return err
+		}
+		_, err = apiClient.Get("api/2/serverInfo", nil, nil)
+		return err
+	}
+
+	// a connection without the CA rejects the self-signed certificate, regardless of the one trusting it
+	trusted := newConnection(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+	assert.Nil(t, get(trusted))
+	err := get(newConnection(""))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "certificate")
+	}
+	assert.Nil(t, get(trusted))
+}
+
+func TestGetJiraTimeZone(t *testing.T) {
+	apiClient := mocks.NewApiClientGetter(t)
+	mockDiagnosticResponse(apiClient, "api/2/myself", http.StatusOK, `{"accountId":"1","timeZone":"Europe/Berlin"}`)
+	location, err := GetJiraTimeZone(apiClient, &models.JiraServerInfo{ServerTime: "2022-11-21T10:18:04.566+0530"})
+	assert.Nil(t, err)
+	if assert.NotNil(t, location) {
+		assert.Equal(t, "Europe/Berlin", location.String())
+	}
+
+	// the offset of the server time is taken without the time zone of the user
+	apiClient = mocks.NewApiClientGetter(t)
+	mockDiagnosticResponse(apiClient, "api/2/myself", http.StatusOK, `{"accountId":"1"}`)
+	location, err = GetJiraTimeZone(apiClient, &models.JiraServerInfo{ServerTime: "2022-11-21T10:18:04.566+0530"})
+	assert.Nil(t, err)
+	if assert.NotNil(t, location) {
+		_, offset := time.Date(2022, 11, 1, 0, 0, 0, 0, location).Zone()
+		assert.Equal(t, 5*3600+30*60, offset)
+	}
+
+	apiClient = mocks.NewApiClientGetter(t)
+	mockDiagnosticResponse(apiClient, "api/2/myself", http.StatusForbidden, ``)
+	location, err = GetJiraTimeZone(apiClient, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, location)
+}
+
+func TestUpdatedSinceCriteriaInTimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.Nil(t, err)
+	since := time.Date(2022, 11, 1, 7, 30, 0, 0, time.UTC)
+	// Jira interprets the time in the time zone of the user, 07:30 UTC is 08:30 in Berlin
+	assert.Equal(t, "updated >= '2022/11/01 08:30'", updatedSinceCriteria(&since, berlin))
+	// the daylight saving time is honored, 07:30 UTC is 09:30 in Berlin in summer
+	summer := time.Date(2022, 7, 1, 7, 30, 0, 0, time.UTC)
+	assert.Equal(t, "updated >= '2022/07/01 09:30'", updatedSinceCriteria(&summer, berlin))
+	// the time is formatted as it is without the time zone
+	assert.Equal(t, "updated >= '2022/11/01 07:30'", updatedSinceCriteria(&since, nil))
+	assert.Equal(t, "", updatedSinceCriteria(nil, berlin))
+
+	end := since.Add(7 * 24 * time.Hour)
+	window := &timeWindow{Start: since, End: &end}
+	assert.Equal(t, "updated >= '2022/11/01 08:30' AND updated < '2022/11/08 08:30'", window.updatedCriteria(berlin))
+}
@@ -0,0 +1,58 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+// JiraApiVersion is the version of the REST api of Jira the collectors talk to
+type JiraApiVersion string
+
+const (
+	JiraApiV2 JiraApiVersion = "2"
+	JiraApiV3 JiraApiVersion = "3"
+)
+
+// GetJiraApiVersion resolves the version of the REST api by the deployment of the instance, Jira Cloud is on v3
+// while Jira Server and Data Center only serve v2. Instances of unknown deployment, i.e. the federated ones, are
+// talked to in v2, which is served by both. The only request pinned to v2 is the one of the server info, which
+// tells the deployment in the first place, all the others are sent in the version it resolves to
+func GetJiraApiVersion(serverInfo *models.JiraServerInfo) JiraApiVersion {
+	if serverInfo != nil && serverInfo.DeploymentType == models.DeploymentCloud {
+		return JiraApiV3
+	}
+	return JiraApiV2
+}
+
+// Path returns the path of the resource in the version, i.e. `api/3/issuetype`
+func (v JiraApiVersion) Path(resource string) string {
+	return fmt.Sprintf("api/%s/%s", v, resource)
+}
+
+// SearchPath returns the path of the issue search api in the version
+func (v JiraApiVersion) SearchPath() string {
+	return v.Path("search")
+}
+
+// ApiVersion returns the version of the REST api of the instance the task is collecting from
+func (data *JiraTaskData) ApiVersion() JiraApiVersion {
+	return GetJiraApiVersion(&data.JiraServerInfo)
+}
@@ -0,0 +1,41 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJiraApiVersion(t *testing.T) {
+	cloud := &JiraTaskData{JiraServerInfo: models.JiraServerInfo{DeploymentType: models.DeploymentCloud}}
+	assert.Equal(t, JiraApiV3, cloud.ApiVersion())
+	assert.Equal(t, "api/3/search", cloud.ApiVersion().SearchPath())
+	assert.Equal(t, "api/3/issuetype", cloud.ApiVersion().Path("issuetype"))
+
+	// Data Center reports itself as a Server deployment
+	dataCenter := &JiraTaskData{JiraServerInfo: models.JiraServerInfo{DeploymentType: models.DeploymentServer}}
+	assert.Equal(t, JiraApiV2, dataCenter.ApiVersion())
+	assert.Equal(t, "api/2/search", dataCenter.ApiVersion().SearchPath())
+
+	// the federated connections don't know their deployments
+	assert.Equal(t, "api/2/search", GetJiraApiVersion(&models.JiraServerInfo{}).SearchPath())
+	assert.Equal(t, JiraApiV2, GetJiraApiVersion(nil))
+}
@@ -23,3 +23,9 @@ type Input struct {
 	IssueId    uint64    `json:"issue_id"`
 	UpdateTime time.Time `json:"update_time"`
 }
+
+// EpicInput is the input of collectors fetching the details of epics one by one
+type EpicInput struct {
+	IssueId  uint64 `json:"issue_id"`
+	IssueKey string `json:"issue_key"`
+}
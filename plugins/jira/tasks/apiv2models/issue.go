@@ -114,8 +114,8 @@ type Issue struct {
 				Name      string `json:"name"`
 			} `json:"statusCategory"`
 		} `json:"status"`
-		Timeoriginalestimate *int64      `json:"timeoriginalestimate"`
-		Description          interface{} `json:"description"`
+		Timeoriginalestimate *int64   `json:"timeoriginalestimate"`
+		Description          RichText `json:"description"`
 		Timetracking         *struct {
 			RemainingEstimate        string `json:"remainingEstimate"`
 			TimeSpent                string `json:"timeSpent"`
@@ -132,7 +132,7 @@ type Issue struct {
 			Progress int `json:"progress"`
 			Total    int `json:"total"`
 		} `json:"aggregateprogress"`
-		Environment interface{} `json:"environment"`
+		Environment RichText    `json:"environment"`
 		Duedate     interface{} `json:"duedate"`
 		Progress    struct {
 			Progress int `json:"progress"`
@@ -0,0 +1,96 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiv2models
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// RichText is a rich text field of Jira, v2 of the REST api returns it as a string in wiki markup while v3 returns an
+// Atlassian Document Format document, which is flattened into plain text so both versions are parsed the same. It
+// is used by the fields of the issues searched, including their worklogs, and of the worklogs collected, all of which
+// are fetched from v3 on Jira Cloud
+type RichText string
+
+// adfNode is a node of an Atlassian Document Format document, the text is carried by the leaf nodes
+type adfNode struct {
+	Type    string    `json:"type"`
+	Text    string    `json:"text"`
+	Content []adfNode `json:"content"`
+}
+
+// adfBlockTypes are the nodes whose text is put onto lines of its own
+var adfBlockTypes = map[string]bool{
+	"paragraph":   true,
+	"heading":     true,
+	"codeBlock":   true,
+	"blockquote":  true,
+	"listItem":    true,
+	"tableRow":    true,
+	"mediaSingle": true,
+	"rule":        true,
+	"panel":       true,
+}
+
+// UnmarshalJSON accepts either a string or a document
+func (t *RichText) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		*t = ""
+		return nil
+	}
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*t = RichText(s)
+		return nil
+	}
+	var doc adfNode
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	var lines []string
+	var line strings.Builder
+	doc.flatten(&lines, &line)
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	*t = RichText(strings.Join(lines, "\n"))
+	return nil
+}
+
+func (n *adfNode) flatten(lines *[]string, line *strings.Builder) {
+	switch n.Type {
+	case "text":
+		line.WriteString(n.Text)
+	case "hardBreak":
+		*lines = append(*lines, line.String())
+		line.Reset()
+	}
+	for i := range n.Content {
+		n.Content[i].flatten(lines, line)
+	}
+	if adfBlockTypes[n.Type] && line.Len() > 0 {
+		*lines = append(*lines, line.String())
+		line.Reset()
+	}
+}
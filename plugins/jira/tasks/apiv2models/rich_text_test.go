@@ -0,0 +1,86 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiv2models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRichTextUnmarshalJSON(t *testing.T) {
+	cases := map[string]struct {
+		json string
+		want RichText
+	}{
+		"v2 string": {`"fixed *the* bug"`, "fixed *the* bug"},
+		"null":      {`null`, ""},
+		"v3 document": {`{
+			"type": "doc",
+			"version": 1,
+			"content": [
+				{"type": "paragraph", "content": [
+					{"type": "text", "text": "fixed "},
+					{"type": "text", "text": "the", "marks": [{"type": "strong"}]},
+					{"type": "text", "text": " bug"},
+					{"type": "hardBreak"},
+					{"type": "text", "text": "for real"}
+				]},
+				{"type": "bulletList", "content": [
+					{"type": "listItem", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "one"}]}]},
+					{"type": "listItem", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "two"}]}]}
+				]}
+			]
+		}`, "fixed the bug\nfor real\none\ntwo"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var worklog struct {
+				Comment RichText `json:"comment"`
+			}
+			err := json.Unmarshal([]byte(`{"comment":`+c.json+`}`), &worklog)
+			assert.Nil(t, err)
+			assert.Equal(t, c.want, worklog.Comment)
+		})
+	}
+}
+
+func TestIssueUnmarshalV3(t *testing.T) {
+	// an epic searched in v3, the rich text fields of the epic and its worklogs are documents
+	blob := `{"id":"10001","key":"K-1","fields":{
+		"summary":"an epic",
+		"description":{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"the goal"}]}]},
+		"environment":null,
+		"worklog":{"total":1,"maxResults":20,"worklogs":[{"id":"1","issueId":"10001","timeSpentSeconds":1800,
+			"author":{"accountId":"a1"},
+			"comment":{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"reviewed"}]}]}
+		}]}
+	}}`
+	var issue Issue
+	err := json.Unmarshal([]byte(blob), &issue)
+	assert.Nil(t, err)
+	assert.Equal(t, RichText("the goal"), issue.Fields.Description)
+	assert.Equal(t, RichText(""), issue.Fields.Environment)
+	if assert.Len(t, issue.Fields.Worklog.Worklogs, 1) {
+		assert.Equal(t, RichText("reviewed"), issue.Fields.Worklog.Worklogs[0].Comment)
+	}
+	_, jiraIssue, worklogs, _, _, _ := issue.ExtractEntities(1)
+	assert.Equal(t, "an epic", jiraIssue.Summary)
+	assert.Len(t, worklogs, 1)
+}
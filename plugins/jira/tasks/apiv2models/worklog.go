@@ -29,7 +29,7 @@ type Worklog struct {
 	Self             string             `json:"self"`
 	Author           *Account           `json:"author"`
 	UpdateAuthor     *Account           `json:"updateAuthor"`
-	Comment          string             `json:"comment"`
+	Comment          RichText           `json:"comment"`
 	Created          string             `json:"created"`
 	Updated          helper.Iso8601Time `json:"updated"`
 	Started          helper.Iso8601Time `json:"started"`
@@ -50,12 +50,13 @@ func CollectBoard(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_BOARD_TABLE,
+			Table: data.Options.RawTable(RAW_BOARD_TABLE),
 		},
 		ApiClient:     data.ApiClient,
 		UrlTemplate:   "agile/1.0/board/{{ .Params.BoardId }}",
 		GetTotalPages: GetTotalPagesFromResponse,
-		Concurrency:   10,
+		IsLastPage:    IsLastPageFromResponse,
+		Concurrency:   data.Concurrency,
 		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
 			blob, err := io.ReadAll(res.Body)
 			if err != nil {
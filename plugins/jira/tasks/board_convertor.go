@@ -61,7 +61,7 @@ func ConvertBoard(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_BOARD_TABLE,
+			Table: data.Options.RawTable(RAW_BOARD_TABLE),
 		},
 		InputRowType: reflect.TypeOf(models.JiraBoard{}),
 		Input:        cursor,
@@ -0,0 +1,166 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/apache/incubator-devlake/plugins/jira/tasks/apiv2models"
+)
+
+// boardDiscoveryPageSize is the page size requested from `/board`, Jira might cap it silently
+const boardDiscoveryPageSize = 50
+
+var _ core.SubTaskEntryPoint = DiscoverBoards
+
+var DiscoverBoardsMeta = core.SubTaskMeta{
+	Name:             "discoverBoards",
+	EntryPoint:       DiscoverBoards,
+	EnabledByDefault: true,
+	Description:      "list the boards visible to the connection and resolve the boards selected by names",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+}
+
+// DiscoverBoards saves the boards visible to the connection into _tool_jira_boards, and resolves the ids of the
+// boards selected by `boardNames` for the subtasks afterward. It does nothing if no board was selected by name
+func DiscoverBoards(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	if len(data.Options.BoardNames) == 0 {
+		logger.Info("no board was selected by name, skip discovering boards")
+		return nil
+	}
+	boards, err := listBoards(data.ApiClient, data.Options.ConnectionId)
+	if err != nil {
+		return err
+	}
+	logger.Info("%d boards are visible to connection %d", len(boards), data.Options.ConnectionId)
+	if len(boards) > 0 {
+		err = taskCtx.GetDal().CreateOrUpdate(boards)
+		if err != nil {
+			return errors.Default.Wrap(err, "failed to save the discovered boards")
+		}
+	}
+	return resolveBoardNames(data.Options, boards)
+}
+
+// listBoards lists all boards visible to the credential of the connection page by page
+func listBoards(apiClient helper.ApiClientGetter, connectionId uint64) ([]*models.JiraBoard, errors.Error) {
+	var boards []*models.JiraBoard
+	for {
+		query := url.Values{
+			"startAt":    {strconv.Itoa(len(boards))},
+			"maxResults": {strconv.Itoa(boardDiscoveryPageSize)},
+		}
+		res, err := apiClient.Get("agile/1.0/board", query, nil)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "failed to list jira boards")
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to list jira boards, unexpected status code: %d", res.StatusCode))
+		}
+		var page struct {
+			IsLast bool                `json:"isLast"`
+			Values []apiv2models.Board `json:"values"`
+		}
+		err = helper.UnmarshalResponse(res, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, board := range page.Values {
+			boards = append(boards, board.ToToolLayer(connectionId))
+		}
+		// the next page starts right after the boards returned, which might be less than requested
+		if page.IsLast || len(page.Values) == 0 {
+			return boards, nil
+		}
+	}
+}
+
+// resolveBoardNames adds the ids of the boards selected by `boardNames` to `boardIds`, names are matched
+// case-insensitively. A name matching none or several of the boards is rejected
+func resolveBoardNames(op *JiraOptions, boards []*models.JiraBoard) errors.Error {
+	if len(op.BoardNames) == 0 {
+		return nil
+	}
+	if len(boards) == 0 {
+		return errors.BadInput.New(fmt.Sprintf("no jira board is visible to connection %d, boards %s could not be found", op.ConnectionId, strings.Join(op.BoardNames, ", ")))
+	}
+	ids := make(map[string][]uint64, len(boards))
+	for _, board := range boards {
+		name := strings.ToLower(board.Name)
+		ids[name] = append(ids[name], board.BoardId)
+	}
+	boardIds := op.GetBoardIds()
+	if op.BoardId == 0 && len(op.BoardIds) == 0 {
+		boardIds = nil
+	}
+	for _, name := range op.BoardNames {
+		matched := ids[strings.ToLower(strings.TrimSpace(name))]
+		switch len(matched) {
+		case 0:
+			return errors.BadInput.New(fmt.Sprintf("jira board %s does not exist or is not visible to connection %d", name, op.ConnectionId))
+		case 1:
+			if !containsBoardId(boardIds, matched[0]) {
+				boardIds = append(boardIds, matched[0])
+			}
+		default:
+			sort.Slice(matched, func(i, j int) bool { return matched[i] < matched[j] })
+			return errors.BadInput.New(fmt.Sprintf("jira board %s is ambiguous, please select one of the boards %v by id instead", name, matched))
+		}
+	}
+	op.BoardIds = boardIds
+	// subtasks of a single board work on the first one
+	if op.BoardId == 0 {
+		op.BoardId = boardIds[0]
+	}
+	return nil
+}
+
+// resolveBoardNamesFromDb resolves the boards selected by `boardNames` with the boards saved by discoverBoards
+func resolveBoardNamesFromDb(db dal.Dal, op *JiraOptions) errors.Error {
+	if len(op.BoardNames) == 0 {
+		return nil
+	}
+	var boards []*models.JiraBoard
+	err := db.All(&boards, dal.Where("connection_id = ?", op.ConnectionId))
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load the discovered boards")
+	}
+	return resolveBoardNames(op, boards)
+}
+
+func containsBoardId(boardIds []uint64, boardId uint64) bool {
+	for _, id := range boardIds {
+		if id == boardId {
+			return true
+		}
+	}
+	return false
+}
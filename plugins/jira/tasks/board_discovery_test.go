@@ -0,0 +1,105 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListBoards(t *testing.T) {
+	respond := func(body string) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Request:    &http.Request{URL: &url.URL{}},
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+		}
+	}
+	pageAt := func(startAt string) interface{} {
+		return mock.MatchedBy(func(query url.Values) bool { return query.Get("startAt") == startAt })
+	}
+	apiClient := mocks.NewApiClientGetter(t)
+	// the page size is capped to 2 by the server
+	apiClient.On("Get", "agile/1.0/board", pageAt("0"), mock.Anything).Return(respond(`{"isLast":false,"values":[
+		{"id":1,"name":"Team A","type":"scrum","location":{"projectId":10}},
+		{"id":2,"name":"Team B","type":"kanban"}
+	]}`), nil).Once()
+	apiClient.On("Get", "agile/1.0/board", pageAt("2"), mock.Anything).Return(respond(`{"isLast":true,"values":[
+		{"id":3,"name":"Team C","type":"scrum"}
+	]}`), nil).Once()
+
+	boards, err := listBoards(apiClient, 7)
+	assert.Nil(t, err)
+	if assert.Len(t, boards, 3) {
+		assert.Equal(t, &models.JiraBoard{ConnectionId: 7, BoardId: 1, Name: "Team A", Type: "scrum", ProjectId: 10}, boards[0])
+		assert.Equal(t, uint64(3), boards[2].BoardId)
+	}
+
+	// a credential seeing no board at all
+	apiClient = mocks.NewApiClientGetter(t)
+	apiClient.On("Get", "agile/1.0/board", pageAt("0"), mock.Anything).Return(respond(`{"isLast":true,"values":[]}`), nil).Once()
+	boards, err = listBoards(apiClient, 7)
+	assert.Nil(t, err)
+	assert.Empty(t, boards)
+}
+
+func TestResolveBoardNames(t *testing.T) {
+	boards := []*models.JiraBoard{
+		{ConnectionId: 7, BoardId: 1, Name: "Team A"},
+		{ConnectionId: 7, BoardId: 2, Name: "Team B"},
+		{ConnectionId: 7, BoardId: 3, Name: "Shared"},
+		{ConnectionId: 7, BoardId: 4, Name: "shared"},
+	}
+
+	op := &JiraOptions{ConnectionId: 7, BoardNames: []string{"team b", "Team A"}}
+	assert.Nil(t, resolveBoardNames(op, boards))
+	assert.Equal(t, []uint64{2, 1}, op.BoardIds)
+	assert.Equal(t, uint64(2), op.BoardId)
+	// resolving again changes nothing
+	assert.Nil(t, resolveBoardNames(op, boards))
+	assert.Equal(t, []uint64{2, 1}, op.BoardIds)
+
+	// boards selected by id are kept
+	op = &JiraOptions{ConnectionId: 7, BoardId: 9, BoardNames: []string{"Team A"}}
+	assert.Nil(t, resolveBoardNames(op, boards))
+	assert.Equal(t, []uint64{9, 1}, op.BoardIds)
+	assert.Equal(t, uint64(9), op.BoardId)
+
+	for _, names := range [][]string{{"Missing"}, {"Shared"}} {
+		op = &JiraOptions{ConnectionId: 7, BoardNames: names}
+		err := resolveBoardNames(op, boards)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, errors.BadInput, err.GetType())
+		}
+	}
+
+	op = &JiraOptions{ConnectionId: 7, BoardNames: []string{"Team A"}}
+	err := resolveBoardNames(op, nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "no jira board is visible")
+	}
+}
@@ -44,7 +44,7 @@ func ExtractBoard(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_BOARD_TABLE,
+			Table: data.Options.RawTable(RAW_BOARD_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			var board apiv2models.Board
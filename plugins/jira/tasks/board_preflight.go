@@ -0,0 +1,130 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+// BoardPreflight tells whether collecting the epics of a board would yield any epic, by comparing the epic keys
+// referenced by the issues of the board in the tool tables with the epics Jira could find by them
+type BoardPreflight struct {
+	BoardId uint64 `json:"boardId"`
+	// EpicKeys is the number of distinct epic keys referenced by the issues of the board collected before
+	EpicKeys int `json:"epicKeys"`
+	// ResolvableEpics is the number of epics Jira finds by the keys
+	ResolvableEpics int    `json:"resolvableEpics"`
+	Ok              bool   `json:"ok"`
+	Message         string `json:"message"`
+}
+
+// PreflightBoardEpics runs the query of the epic keys of the board the epic collector runs, and searches Jira with
+// `issue in (...)` and `maxResults=0` for the number of epics resolvable by them, i.e. without fetching any. Keys
+// reported nonexistent by Jira are dropped from the batch, which is searched again without them. Fewer resolvable
+// epics than keys usually means the tool tables are stale relative to Jira
+func PreflightBoardEpics(ctx context.Context, db dal.Dal, apiClient helper.ApiClientGetter, connectionId uint64, boardId uint64) (*BoardPreflight, errors.Error) {
+	cursor, err := db.Cursor(uncollectedEpicKeysClauses(connectionId, boardId, nil)...)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "unable to query for epic keys")
+	}
+	batches, err := helper.NewBatchedDalCursorIteratorWithContext(ctx, db, cursor, reflect.TypeOf(""), defaultEpicKeysBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	iterator := newJqlLimitedEpicKeysIterator(batches, maxEpicJqlLength)
+	defer iterator.Close()
+	preflight := &BoardPreflight{BoardId: boardId}
+	for iterator.HasNext() {
+		batch, err := iterator.Fetch()
+		if err != nil {
+			return nil, err
+		}
+		var epicKeys []string
+		for _, e := range batch.([]interface{}) {
+			epicKeys = append(epicKeys, *e.(*string))
+		}
+		preflight.EpicKeys += len(epicKeys)
+		resolvable, err := countResolvableEpics(apiClient, epicKeys)
+		if err != nil {
+			return nil, err
+		}
+		preflight.ResolvableEpics += resolvable
+	}
+	switch {
+	case preflight.EpicKeys == 0:
+		preflight.Message = fmt.Sprintf("no epic key is found in the issues of board %d, please collect the issues of the board first", boardId)
+	case preflight.ResolvableEpics == 0:
+		preflight.Message = fmt.Sprintf("0 epics resolvable for board %d, none of the %d epic keys is found by Jira", boardId, preflight.EpicKeys)
+	case preflight.ResolvableEpics < preflight.EpicKeys:
+		preflight.Message = fmt.Sprintf("%d of %d epic keys of board %d are not found by Jira, the issues of the board might be stale", preflight.EpicKeys-preflight.ResolvableEpics, preflight.EpicKeys, boardId)
+	}
+	preflight.Ok = preflight.ResolvableEpics > 0
+	return preflight, nil
+}
+
+// countResolvableEpics returns the number of epics Jira finds by the keys, the search is repeated without the keys
+// reported nonexistent till it succeeds
+func countResolvableEpics(apiClient helper.ApiClientGetter, epicKeys []string) (int, errors.Error) {
+	for len(epicKeys) > 0 {
+		query := url.Values{
+			"jql":        {buildEpicJql("", epicKeys, "", "")},
+			"maxResults": {"0"},
+		}
+		res, err := apiClient.Get(JiraApiV2.SearchPath(), query, nil)
+		if err != nil {
+			return 0, errors.Default.Wrap(err, "failed to search for the epics")
+		}
+		if res.StatusCode == http.StatusOK {
+			body := &JiraPagination{}
+			err = helper.UnmarshalResponse(res, body)
+			if err != nil {
+				return 0, err
+			}
+			return body.Total, nil
+		}
+		blob, e := io.ReadAll(res.Body)
+		res.Body.Close()
+		if e != nil {
+			return 0, errors.Convert(e)
+		}
+		nonexistentKeys := getNonexistentIssueKeys(blob)
+		if res.StatusCode != http.StatusBadRequest || len(nonexistentKeys) == 0 {
+			return 0, errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to search for the epics, unexpected status code: %d", res.StatusCode))
+		}
+		var remainingKeys []string
+		for _, key := range epicKeys {
+			if !nonexistentKeys[key] {
+				remainingKeys = append(remainingKeys, key)
+			}
+		}
+		if len(remainingKeys) == len(epicKeys) {
+			return 0, errors.Default.New(fmt.Sprintf("Jira reported epics not being searched as nonexistent: %s", string(blob)))
+		}
+		epicKeys = remainingKeys
+	}
+	return 0, nil
+}
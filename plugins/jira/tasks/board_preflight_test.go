@@ -0,0 +1,90 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockEpicKeyRows(epicKeys ...string) *mocks.Rows {
+	rows := new(mocks.Rows)
+	if len(epicKeys) > 0 {
+		rows.On("Next").Return(true).Times(len(epicKeys))
+	}
+	rows.On("Next").Return(false)
+	for _, epicKey := range epicKeys {
+		epicKey := epicKey
+		rows.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).([]interface{})[0].(*string) = epicKey
+		}).Return(nil).Once()
+	}
+	rows.On("Close").Return(nil)
+	return rows
+}
+
+func TestPreflightBoardEpics(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("Cursor", mock.Anything).Return(mockEpicKeyRows("K-1", "K-2", "K-3"), nil).Once()
+	apiClient := mocks.NewApiClientGetter(t)
+	mockDiagnosticResponse(apiClient, "api/2/search", http.StatusBadRequest, `{"errorMessages":["An issue with key 'K-2' does not exist for field 'issue'."]}`)
+	mockDiagnosticResponse(apiClient, "api/2/search", http.StatusOK, `{"startAt":0,"maxResults":0,"total":2,"issues":[]}`)
+
+	preflight, err := PreflightBoardEpics(context.Background(), mockDal, apiClient, 1, 8)
+	assert.Nil(t, err)
+	assert.True(t, preflight.Ok)
+	assert.Equal(t, 3, preflight.EpicKeys)
+	assert.Equal(t, 2, preflight.ResolvableEpics)
+	assert.Equal(t, "1 of 3 epic keys of board 8 are not found by Jira, the issues of the board might be stale", preflight.Message)
+	// the nonexistent key is left out of the second search
+	assert.Equal(t, `issue in ("K-1","K-3")`, apiClient.Calls[1].Arguments.Get(1).(url.Values).Get("jql"))
+	mockDal.AssertExpectations(t)
+}
+
+func TestPreflightBoardEpicsWithoutEpics(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("Cursor", mock.Anything).Return(mockEpicKeyRows(), nil).Once()
+	apiClient := mocks.NewApiClientGetter(t)
+
+	preflight, err := PreflightBoardEpics(context.Background(), mockDal, apiClient, 1, 8)
+	assert.Nil(t, err)
+	assert.False(t, preflight.Ok)
+	assert.Equal(t, 0, preflight.EpicKeys)
+	assert.Contains(t, preflight.Message, "no epic key is found in the issues of board 8")
+}
+
+func TestCountResolvableEpics(t *testing.T) {
+	apiClient := mocks.NewApiClientGetter(t)
+	mockDiagnosticResponse(apiClient, "api/2/search", http.StatusBadRequest, `{"errorMessages":["An issue with key 'K-1' does not exist for field 'issue'.","An issue with key 'K-2' does not exist for field 'issue'."]}`)
+	resolvable, err := countResolvableEpics(apiClient, []string{"K-1", "K-2"})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, resolvable)
+
+	mockDiagnosticResponse(apiClient, "api/2/search", http.StatusForbidden, ``)
+	_, err = countResolvableEpics(apiClient, []string{"K-1"})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.Forbidden, err.GetType())
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+// changelogFallback collects the issues without their changelogs once Jira forbids `expand=changelog`, which some
+// permission schemes do even though the fields of the issues are readable. A page forbidden with the changelogs is
+// requested again without them, the following pages are requested without them right away
+type changelogFallback struct {
+	logger    core.Logger
+	apiClient helper.ApiClientGetter
+	path      string
+	message   string
+	forbidden atomic.Bool
+}
+
+// newChangelogFallback returns a fallback for the requests of `path` made by `apiClient`, `message` is logged as
+// a warning once the changelogs are skipped
+func newChangelogFallback(logger core.Logger, apiClient helper.ApiClientGetter, path string, message string) *changelogFallback {
+	return &changelogFallback{
+		logger:    logger,
+		apiClient: apiClient,
+		path:      path,
+		message:   message,
+	}
+}
+
+// SetQuery asks for the changelogs unless they were forbidden
+func (f *changelogFallback) SetQuery(query url.Values) {
+	if !f.forbidden.Load() {
+		query.Set("expand", "changelog")
+	}
+}
+
+// AfterResponse requests a page forbidden along with the changelogs again without them, and replaces the response
+// by the one returned. It must be the last of the AfterResponse callbacks, since the response requested again has
+// been through all of them already
+func (f *changelogFallback) AfterResponse(res *http.Response) errors.Error {
+	if res.StatusCode != http.StatusForbidden || res.Request == nil {
+		return nil
+	}
+	query := res.Request.URL.Query()
+	if query.Get("expand") != "changelog" {
+		return nil
+	}
+	query.Del("expand")
+	fallback, err := f.apiClient.Get(f.path, query, nil)
+	if err != nil {
+		return err
+	}
+	// the 403 might be caused by something else, which fails the page as usual
+	if fallback.StatusCode == http.StatusOK && f.forbidden.CompareAndSwap(false, true) {
+		f.logger.Warn(nil, f.message)
+	}
+	res.Body.Close()
+	*res = *fallback
+	return nil
+}
@@ -0,0 +1,226 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChangelogFallback(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	// 3 pages of 2 issues, changelogs are forbidden
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.RawQuery)
+		mu.Unlock()
+		if r.URL.Query().Get("expand") == "changelog" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errorMessages":["You do not have the permission to see the specified issue history."]}`))
+			return
+		}
+		startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		_, _ = w.Write([]byte(fmt.Sprintf(
+			`{"startAt":%d,"maxResults":2,"total":6,"issues":[{"id":"%d"},{"id":"%d"}]}`,
+			startAt, startAt, startAt+1,
+		)))
+	}))
+	defer server.Close()
+
+	taskCtx := new(mocks.TaskContext)
+	taskCtx.On("GetConfig", mock.Anything).Return("")
+	taskCtx.On("GetLogger").Return(unithelper.DummyLogger())
+	taskCtx.On("GetContext").Return(context.Background())
+	apiClient := &helper.ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	asyncClient, err := helper.CreateAsyncApiClient(taskCtx, apiClient, &helper.ApiRateLimitCalculator{UserRateLimitPerHour: 360000})
+	assert.Nil(t, err)
+	defer asyncClient.Release()
+
+	var ids []string
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range args.Get(0).([]*helper.RawData) {
+			issue := &struct {
+				Id string `json:"id"`
+			}{}
+			assert.Nil(t, json.Unmarshal(row.Data, issue))
+			ids = append(ids, issue.Id)
+		}
+	}).Return(nil)
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+
+	logger := unithelper.DummyLogger()
+	changelogs := newChangelogFallback(logger, asyncClient, "api/2/search", "changelogs are skipped")
+	pager := searchPager{}
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  RAW_EPIC_TABLE,
+			Params: "whatever params",
+		},
+		ApiClient:   asyncClient,
+		PageSize:    2,
+		UrlTemplate: "api/2/search",
+		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+			query := url.Values{}
+			pager.SetQuery(query, reqData)
+			changelogs.SetQuery(query)
+			return query, nil
+		},
+		GetTotalPages:  pager.GetTotalPages,
+		ResponseParser: pager.ResponseParser,
+		AfterResponse:  chainAfterResponse(ignoreHTTPStatus404, changelogs.AfterResponse),
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+
+	assert.ElementsMatch(t, []string{"0", "1", "2", "3", "4", "5"}, ids)
+	assert.True(t, changelogs.forbidden.Load())
+	// the first page is requested twice, the rest of the pages are requested without changelogs
+	assert.Len(t, requests, 4)
+	assert.Equal(t, "changelog", mustParseQuery(requests[0]).Get("expand"))
+	for _, query := range requests[1:] {
+		assert.Equal(t, "", mustParseQuery(query).Get("expand"))
+	}
+}
+
+func TestChangelogFallbackConcurrentWithCircuitBreaker(t *testing.T) {
+	const pages = 10
+	var forbidden int32
+	allForbidden := make(chan struct{})
+	// the first pages are all requested at once, every one of them is forbidden with the changelogs before any of
+	// them is requested again without
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		if r.URL.Query().Get("expand") == "changelog" {
+			if atomic.AddInt32(&forbidden, 1) == pages {
+				close(allForbidden)
+			}
+			select {
+			case <-allForbidden:
+			case <-time.After(5 * time.Second):
+			}
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errorMessages":["You do not have the permission to see the specified issue history."]}`))
+			return
+		}
+		// a single issue per page, so every page is the last one of its goroutine
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"startAt":%d,"maxResults":2,"issues":[{"id":"%d"}]}`, startAt, startAt/2)))
+	}))
+	defer server.Close()
+
+	key := "test:changelog-fallback"
+	defer helper.ResetCircuitBreaker(key)
+	taskCtx := new(mocks.TaskContext)
+	taskCtx.On("GetConfig", mock.Anything).Return("")
+	taskCtx.On("GetLogger").Return(unithelper.DummyLogger())
+	taskCtx.On("GetContext").Return(context.Background())
+	apiClient := &helper.ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	apiClient.ShareCircuitBreaker(key, helper.DefaultCircuitBreakerThreshold)
+	asyncClient, err := helper.CreateAsyncApiClient(taskCtx, apiClient, &helper.ApiRateLimitCalculator{UserRateLimitPerHour: 360000})
+	assert.Nil(t, err)
+	defer asyncClient.Release()
+
+	var mu sync.Mutex
+	var ids []string
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range args.Get(0).([]*helper.RawData) {
+			issue := &struct {
+				Id string `json:"id"`
+			}{}
+			assert.Nil(t, json.Unmarshal(row.Data, issue))
+			ids = append(ids, issue.Id)
+		}
+	}).Return(nil)
+
+	changelogs := newChangelogFallback(unithelper.DummyLogger(), asyncClient, "api/2/search", "changelogs are skipped")
+	pager := searchPager{}
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx:    unithelper.DummySubTaskContext(mockDal),
+			Table:  RAW_EPIC_TABLE,
+			Params: "whatever params",
+		},
+		ApiClient:   asyncClient,
+		PageSize:    2,
+		Concurrency: pages,
+		UrlTemplate: "api/2/search",
+		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+			query := url.Values{}
+			pager.SetQuery(query, reqData)
+			changelogs.SetQuery(query)
+			return query, nil
+		},
+		ResponseParser: pager.ResponseParser,
+		AfterResponse:  chainAfterResponse(ignoreHTTPStatus404, changelogs.AfterResponse),
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+
+	assert.Equal(t, int32(pages), atomic.LoadInt32(&forbidden))
+	assert.ElementsMatch(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}, ids)
+	// the forbidden changelogs don't tell the credential is revoked
+	assert.False(t, apiClient.GetCircuitBreaker().IsOpen())
+}
+
+func TestChangelogFallbackIgnoresOtherFailures(t *testing.T) {
+	changelogs := newChangelogFallback(unithelper.DummyLogger(), mocks.NewApiClientGetter(t), "api/2/search", "changelogs are skipped")
+	for _, res := range []*http.Response{
+		{StatusCode: http.StatusForbidden, Request: &http.Request{URL: &url.URL{RawQuery: "jql=x"}}},
+		{StatusCode: http.StatusOK, Request: &http.Request{URL: &url.URL{RawQuery: "expand=changelog"}}},
+	} {
+		assert.Nil(t, changelogs.AfterResponse(res))
+	}
+	assert.False(t, changelogs.forbidden.Load())
+}
+
+func mustParseQuery(query string) url.Values {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		panic(err)
+	}
+	return values
+}
@@ -0,0 +1,153 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	goerror "errors"
+	"fmt"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"gorm.io/gorm"
+)
+
+// CollectionState is the incremental state of the collection of a board by a subtask, that is everything the next
+// collection consults to pick its time range. The scope is taken from the query recorded by the latest collection,
+// so the raw table and params are the ones it was actually collected into, i.e. with `rawTableOverrides`
+type CollectionState struct {
+	ConnectionId uint64 `json:"connectionId"`
+	BoardId      uint64 `json:"boardId"`
+	SubtaskName  string `json:"subtaskName"`
+	RawTable     string `json:"rawTable"`
+	Params       string `json:"params"`
+	// Since is the time the latest raw row of the scope was collected, which the next incremental collection starts
+	// from unless it is overridden by the options of the task. Nil makes the next collection a full one
+	Since *time.Time `json:"since"`
+	// ResetAt is the time the collection was reset by ResetCollectionState, nil if it never was
+	ResetAt *time.Time `json:"resetAt,omitempty"`
+	// ResumeKey is of the interrupted collection to be resumed by the next one with the same options, which keeps
+	// the time range of the interrupted one, empty if the latest collection was completed
+	ResumeKey string `json:"resumeKey,omitempty"`
+	// ChangelogWatermark is the highest changelog id collected for the board by the epic collector, see
+	// `changelogDeltaSync`, 0 if it was not tracked
+	ChangelogWatermark uint64 `json:"changelogWatermark,omitempty"`
+	// LastQuery is the query recorded by the latest collection, along with the time range it picked
+	LastQuery *models.JiraCollectionQuery `json:"lastQuery"`
+}
+
+// GetCollectionState loads the incremental state of the collection of the board by the subtask, a NotFound error is
+// returned if no collection of the board by the subtask was recorded
+func GetCollectionState(db dal.Dal, connectionId uint64, boardId uint64, subtaskName string) (*CollectionState, errors.Error) {
+	query := &models.JiraCollectionQuery{}
+	err := db.First(query,
+		dal.Where("connection_id = ? AND board_id = ? AND subtask_name = ?", connectionId, boardId, subtaskName),
+		dal.Orderby("id DESC"),
+	)
+	if err != nil {
+		if goerror.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NotFound.New(fmt.Sprintf("no collection of board %d by %s was recorded", boardId, subtaskName))
+		}
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to load the latest collection query of board %d", boardId))
+	}
+	state := &CollectionState{
+		ConnectionId: connectionId,
+		BoardId:      boardId,
+		SubtaskName:  subtaskName,
+		RawTable:     query.RawTable,
+		Params:       query.Params,
+		LastQuery:    query,
+	}
+	state.Since, err = getLatestCollectedOf(db, query.RawTable, query.Params)
+	if err != nil {
+		return nil, err
+	}
+	reset := &models.JiraCollectionReset{}
+	err = db.First(reset, dal.Where("raw_table = ? AND params = ?", query.RawTable, query.Params))
+	if err != nil && !goerror.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to load the reset of the collection of board %d", boardId))
+	}
+	if err == nil {
+		state.ResetAt = &reset.ResetAt
+	}
+	state.ResumeKey, err = helper.GetTableResumeKey(db, query.RawTable, query.Params)
+	if err != nil {
+		return nil, err
+	}
+	if subtaskName != CollectEpicsMeta.Name {
+		return state, nil
+	}
+	watermark := &models.JiraEpicChangelogWatermark{}
+	err = db.First(watermark, dal.Where("connection_id = ? AND board_id = ?", connectionId, boardId))
+	if err != nil && !goerror.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to load the changelog watermark of board %d", boardId))
+	}
+	if err == nil {
+		state.ChangelogWatermark = watermark.ChangelogId
+	}
+	return state, nil
+}
+
+// ResetCollectionState resets the incremental state of the collection of the board by the subtask, so the next
+// collection is a full one: the scope is marked reset, which makes the raw rows collected so far disregarded till the
+// next collection replaces them, and the checkpoints of the interrupted collection and the changelog watermark are
+// deleted. The raw rows and the recorded queries are kept. The scope is locked meanwhile, it fails with
+// helper.ErrScopeLocked if the board is being collected
+func ResetCollectionState(
+	ctx context.Context,
+	db dal.Dal,
+	logger core.Logger,
+	lock helper.ScopeLock,
+	connectionId uint64,
+	boardId uint64,
+	subtaskName string,
+) errors.Error {
+	state, err := GetCollectionState(db, connectionId, boardId, subtaskName)
+	if err != nil {
+		return err
+	}
+	unlock, err := lock.Lock(ctx, helper.ScopeLockKey{RawTable: state.RawTable, Params: state.Params}, 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			logger.Warn(err, "failed to unlock the scope of %s", state.RawTable)
+		}
+	}()
+	err = db.CreateOrUpdate(&models.JiraCollectionReset{RawTable: state.RawTable, Params: state.Params, ResetAt: time.Now()})
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to mark the collection of board %d reset", boardId))
+	}
+	err = db.Delete(&helper.CollectorCheckpoint{}, dal.Where("raw_table = ? AND params = ?", state.RawTable, state.Params))
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to delete the checkpoints of board %d", boardId))
+	}
+	if subtaskName != CollectEpicsMeta.Name {
+		return nil
+	}
+	err = db.Delete(&models.JiraEpicChangelogWatermark{}, dal.Where("connection_id = ? AND board_id = ?", connectionId, boardId))
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to delete the changelog watermark of board %d", boardId))
+	}
+	return nil
+}
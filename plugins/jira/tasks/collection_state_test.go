@@ -0,0 +1,191 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// collectionStateDal keeps the state of the collection of a board, the rows are looked up by the type of the
+// destination rather than by the clauses. Nothing is migrated, the state is read and reset without DDL
+type collectionStateDal struct {
+	*mocks.Dal
+	query     *models.JiraCollectionQuery
+	collected *time.Time
+	resumeKey string
+	watermark *models.JiraEpicChangelogWatermark
+	reset     *models.JiraCollectionReset
+	deleted   []string
+}
+
+func newCollectionStateDal() *collectionStateDal {
+	d := &collectionStateDal{Dal: new(mocks.Dal)}
+	d.On("First", mock.Anything, mock.Anything).Return(func(dst interface{}, clauses ...dal.Clause) errors.Error {
+		switch dst := dst.(type) {
+		case *models.JiraCollectionQuery:
+			if d.query == nil {
+				return errors.Convert(gorm.ErrRecordNotFound)
+			}
+			*dst = *d.query
+		case *helper.RawData:
+			if d.collected == nil {
+				return errors.Convert(gorm.ErrRecordNotFound)
+			}
+			dst.CreatedAt = *d.collected
+		case *models.JiraEpicChangelogWatermark:
+			if d.watermark == nil {
+				return errors.Convert(gorm.ErrRecordNotFound)
+			}
+			*dst = *d.watermark
+		case *models.JiraCollectionReset:
+			if d.reset == nil {
+				return errors.Convert(gorm.ErrRecordNotFound)
+			}
+			*dst = *d.reset
+		}
+		return nil
+	})
+	d.On("Pluck", "resume_key", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		if d.resumeKey != "" {
+			*args.Get(1).(*[]string) = []string{d.resumeKey}
+		}
+	}).Return(nil)
+	d.On("CreateOrUpdate", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		switch row := args.Get(0).(type) {
+		case *models.JiraEpicChangelogWatermark:
+			d.watermark = row
+		case *models.JiraCollectionReset:
+			d.reset = row
+		}
+	}).Return(nil)
+	d.On("Delete", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		switch args.Get(0).(type) {
+		case *helper.CollectorCheckpoint:
+			d.resumeKey = ""
+			d.deleted = append(d.deleted, "checkpoints")
+		case *models.JiraEpicChangelogWatermark:
+			d.watermark = nil
+			d.deleted = append(d.deleted, "watermark")
+		}
+	}).Return(nil)
+	return d
+}
+
+// scopeLockStub grants the lock unless it is held, the unlocking fails if unlockErr is set
+type scopeLockStub struct {
+	held      bool
+	keys      []helper.ScopeLockKey
+	unlockErr errors.Error
+}
+
+func (l *scopeLockStub) Lock(_ context.Context, key helper.ScopeLockKey, _ time.Duration) (func() errors.Error, errors.Error) {
+	if l.held {
+		return nil, helper.ErrScopeLocked
+	}
+	l.keys = append(l.keys, key)
+	return func() errors.Error { return l.unlockErr }, nil
+}
+
+func TestCollectionState(t *testing.T) {
+	since := time.Date(2022, 12, 1, 8, 0, 0, 0, time.UTC)
+	collected := time.Date(2022, 12, 2, 9, 30, 0, 0, time.UTC)
+	db := newCollectionStateDal()
+	db.query = &models.JiraCollectionQuery{
+		ID:           3,
+		ConnectionId: 1,
+		BoardId:      2,
+		SubtaskName:  "collectEpics",
+		RawTable:     "_raw_jira_api_epics",
+		Params:       `{"ConnectionId":1,"BoardId":2}`,
+		Jql:          "updated >= '2022/12/01 08:00' ORDER BY created ASC, key ASC",
+		Since:        &since,
+		Incremental:  true,
+	}
+	db.collected = &collected
+	db.resumeKey = `{"query":"whatever"}`
+
+	// the watermark saved by the collection is read back along with the rest of the state
+	mockCtx := unithelper.DummySubTaskContext(db)
+	mockCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{ConnectionId: 1, ChangelogDeltaSync: true}})
+	watermark := &epicChangelogWatermark{connectionId: 1, boardId: 2, highest: 120}
+	assert.Nil(t, watermark.save(mockCtx, newEpicLimit(0)))
+
+	state, err := GetCollectionState(db, 1, 2, CollectEpicsMeta.Name)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "_raw_jira_api_epics", state.RawTable)
+		assert.Equal(t, `{"ConnectionId":1,"BoardId":2}`, state.Params)
+		assert.Equal(t, &collected, state.Since)
+		assert.Equal(t, `{"query":"whatever"}`, state.ResumeKey)
+		assert.Nil(t, state.ResetAt)
+		assert.Equal(t, uint64(120), state.ChangelogWatermark)
+		assert.Equal(t, &since, state.LastQuery.Since)
+		assert.True(t, state.LastQuery.Incremental)
+	}
+
+	// nothing is reset while the board is being collected
+	err = ResetCollectionState(context.Background(), db, unithelper.DummyLogger(), &scopeLockStub{held: true}, 1, 2, CollectEpicsMeta.Name)
+	assert.Equal(t, helper.ErrScopeLocked, err)
+	assert.Empty(t, db.deleted)
+	assert.Nil(t, db.reset)
+
+	// the failure of unlocking is logged rather than failing the reset done
+	lock := &scopeLockStub{unlockErr: errors.Default.New("lost the lock")}
+	assert.Nil(t, ResetCollectionState(context.Background(), db, unithelper.DummyLogger(), lock, 1, 2, CollectEpicsMeta.Name))
+	assert.Equal(t, []helper.ScopeLockKey{{RawTable: "_raw_jira_api_epics", Params: `{"ConnectionId":1,"BoardId":2}`}}, lock.keys)
+	assert.Equal(t, []string{"checkpoints", "watermark"}, db.deleted)
+	// the raw rows are kept, the reset makes them disregarded
+	assert.Equal(t, &collected, db.collected)
+	if assert.NotNil(t, db.reset) {
+		assert.Equal(t, "_raw_jira_api_epics", db.reset.RawTable)
+		assert.Equal(t, `{"ConnectionId":1,"BoardId":2}`, db.reset.Params)
+	}
+	state, err = GetCollectionState(db, 1, 2, CollectEpicsMeta.Name)
+	if assert.Nil(t, err) {
+		// the next collection is a full one
+		assert.Nil(t, state.Since)
+		assert.Equal(t, &db.reset.ResetAt, state.ResetAt)
+		assert.Empty(t, state.ResumeKey)
+		assert.Zero(t, state.ChangelogWatermark)
+		assert.Equal(t, uint64(3), state.LastQuery.ID)
+	}
+}
+
+func TestCollectionStateNotRecorded(t *testing.T) {
+	db := newCollectionStateDal()
+	_, err := GetCollectionState(db, 1, 2, CollectEpicsMeta.Name)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.NotFound, err.GetType())
+	}
+	err = ResetCollectionState(context.Background(), db, unithelper.DummyLogger(), &scopeLockStub{}, 1, 2, CollectEpicsMeta.Name)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.NotFound, err.GetType())
+	}
+	assert.Empty(t, db.deleted)
+}
@@ -0,0 +1,167 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+// DiagnosticCheck is the outcome of probing a single endpoint with the credential of the connection
+type DiagnosticCheck struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	StatusCode int    `json:"statusCode"`
+	Ok         bool   `json:"ok"`
+	Message    string `json:"message"`
+}
+
+// ConnectionDiagnostics tells which parts of the Jira api are reachable by the connection, so a failing 401 or 403
+// could be attributed to the credential, the permissions or the board scope
+type ConnectionDiagnostics struct {
+	Ok     bool               `json:"ok"`
+	Checks []*DiagnosticCheck `json:"checks"`
+}
+
+// FailedChecks returns the names of the checks that didn't pass
+func (d *ConnectionDiagnostics) FailedChecks() []string {
+	var names []string
+	for _, check := range d.Checks {
+		if !check.Ok {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
+
+// DiagnoseConnection probes the endpoints the collectors rely on: `myself` verifies the credential, `mypermissions`
+// verifies the user is allowed to browse projects, a search with `maxResults=0` verifies issues are searchable
+// without fetching any of them, and every board of `boardIds` is checked to be visible to the credential.
+// All checks are performed even if some of them have failed, only the failure of sending requests is returned as error
+func DiagnoseConnection(apiClient helper.ApiClientGetter, version JiraApiVersion, boardIds []uint64) (*ConnectionDiagnostics, errors.Error) {
+	diagnostics := &ConnectionDiagnostics{Ok: true}
+	probe := func(name, path string, query url.Values, explain func(res *http.Response) string) errors.Error {
+		res, err := apiClient.Get(path, query, nil)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to probe %s", path))
+		}
+		defer res.Body.Close()
+		check := &DiagnosticCheck{
+			Name:       name,
+			Path:       path,
+			StatusCode: res.StatusCode,
+			Ok:         res.StatusCode == http.StatusOK,
+		}
+		if check.Ok && explain != nil {
+			check.Message = explain(res)
+			check.Ok = check.Message == ""
+		}
+		if !check.Ok && check.Message == "" {
+			check.Message = explainDiagnosticStatus(res.StatusCode)
+		}
+		diagnostics.Ok = diagnostics.Ok && check.Ok
+		diagnostics.Checks = append(diagnostics.Checks, check)
+		return nil
+	}
+
+	err := probe("credential", version.Path("myself"), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = probe("permissions", version.Path("mypermissions"), url.Values{"permissions": {"BROWSE_PROJECTS"}}, func(res *http.Response) string {
+		body := &struct {
+			Permissions map[string]struct {
+				HavePermission bool `json:"havePermission"`
+			} `json:"permissions"`
+		}{}
+		if helper.UnmarshalResponse(res, body) != nil {
+			return "unrecognized response of mypermissions"
+		}
+		if !body.Permissions["BROWSE_PROJECTS"].HavePermission {
+			return "the user is not allowed to browse any project"
+		}
+		return ""
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = probe("search", version.SearchPath(), url.Values{"maxResults": {"0"}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, boardId := range boardIds {
+		err = probe(fmt.Sprintf("board %d", boardId), fmt.Sprintf("agile/1.0/board/%d", boardId), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return diagnostics, nil
+}
+
+func explainDiagnosticStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusOK:
+		return ""
+	case http.StatusUnauthorized:
+		return "the credential was rejected, please check the username/password or the token"
+	case http.StatusForbidden:
+		return "the credential is valid but lacks the permission"
+	case http.StatusNotFound:
+		return "not found, please check the endpoint url, or whether the resource is visible to the credential"
+	default:
+		return fmt.Sprintf("unexpected status code: %d", statusCode)
+	}
+}
+
+var _ core.SubTaskEntryPoint = DiagnoseConnectionHealth
+
+var DiagnoseConnectionHealthMeta = core.SubTaskMeta{
+	Name:             "diagnoseConnectionHealth",
+	EntryPoint:       DiagnoseConnectionHealth,
+	EnabledByDefault: false,
+	Description:      "check the credential, permissions and board scope of the connection before collecting",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET, core.DOMAIN_TYPE_CROSS},
+}
+
+// DiagnoseConnectionHealth fails the task upfront with a report of the failed checks, instead of letting collectors
+// fail later with a bare status code
+func DiagnoseConnectionHealth(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	diagnostics, err := DiagnoseConnection(data.ApiClient, data.ApiVersion(), data.Options.GetBoardIds())
+	if err != nil {
+		return err
+	}
+	for _, check := range diagnostics.Checks {
+		if check.Ok {
+			logger.Info("diagnostic check %s passed", check.Name)
+		} else {
+			logger.Error(nil, "diagnostic check %s failed on %s with status %d: %s", check.Name, check.Path, check.StatusCode, check.Message)
+		}
+	}
+	if !diagnostics.Ok {
+		return errors.BadInput.New(fmt.Sprintf("connection diagnostics failed: %s", strings.Join(diagnostics.FailedChecks(), ", ")))
+	}
+	return nil
+}
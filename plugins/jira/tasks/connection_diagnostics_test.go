@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func mockDiagnosticResponse(apiClient *mocks.ApiClientGetter, path string, status int, body string) {
+	res := &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(body))}
+	apiClient.On("Get", path, mock.Anything, mock.Anything).Return(res, nil).Once()
+}
+
+func TestDiagnoseConnection(t *testing.T) {
+	apiClient := mocks.NewApiClientGetter(t)
+	mockDiagnosticResponse(apiClient, "api/2/myself", http.StatusOK, `{"accountId":"1"}`)
+	mockDiagnosticResponse(apiClient, "api/2/mypermissions", http.StatusOK, `{"permissions":{"BROWSE_PROJECTS":{"havePermission":true}}}`)
+	mockDiagnosticResponse(apiClient, "api/2/search", http.StatusOK, `{"total":10,"issues":[]}`)
+	mockDiagnosticResponse(apiClient, "agile/1.0/board/1", http.StatusOK, `{"id":1}`)
+	mockDiagnosticResponse(apiClient, "agile/1.0/board/2", http.StatusNotFound, ``)
+
+	diagnostics, err := DiagnoseConnection(apiClient, JiraApiV2, []uint64{1, 2})
+	assert.Nil(t, err)
+	assert.False(t, diagnostics.Ok)
+	assert.Len(t, diagnostics.Checks, 5)
+	assert.Equal(t, []string{"board 2"}, diagnostics.FailedChecks())
+	assert.Equal(t, http.StatusNotFound, diagnostics.Checks[4].StatusCode)
+}
+
+func TestDiagnoseConnectionWithoutPermission(t *testing.T) {
+	apiClient := mocks.NewApiClientGetter(t)
+	mockDiagnosticResponse(apiClient, "api/3/myself", http.StatusOK, `{"accountId":"1"}`)
+	mockDiagnosticResponse(apiClient, "api/3/mypermissions", http.StatusOK, `{"permissions":{"BROWSE_PROJECTS":{"havePermission":false}}}`)
+	mockDiagnosticResponse(apiClient, "api/3/search", http.StatusForbidden, ``)
+
+	diagnostics, err := DiagnoseConnection(apiClient, JiraApiV3, nil)
+	assert.Nil(t, err)
+	assert.False(t, diagnostics.Ok)
+	assert.Equal(t, []string{"permissions", "search"}, diagnostics.FailedChecks())
+	assert.Equal(t, "the user is not allowed to browse any project", diagnostics.Checks[1].Message)
+}
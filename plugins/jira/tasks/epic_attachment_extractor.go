@@ -0,0 +1,123 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+var _ core.SubTaskEntryPoint = ExtractEpicAttachments
+
+var ExtractEpicAttachmentsMeta = core.SubTaskMeta{
+	Name:             "extractEpicAttachments",
+	EntryPoint:       ExtractEpicAttachments,
+	EnabledByDefault: false,
+	Description:      "extract the metadata of the attachments of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+	DependsOn:        []string{"collectEpics"},
+}
+
+// ExtractEpicAttachments extracts the attachments from the raw epics, the `attachment` field is requested by the
+// epic collector only while this subtask is enabled
+func ExtractEpicAttachments(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	for _, boardId := range data.Options.GetBoardIds() {
+		err := extractBoardEpicAttachments(taskCtx, boardId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractBoardEpicAttachments(taskCtx core.SubTaskContext, boardId uint64) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	connectionId := data.Options.ConnectionId
+	params, err := getEpicRawDataParams(taskCtx.GetDal(), data, boardId)
+	if err != nil {
+		return err
+	}
+	extractor, err := helper.NewApiExtractor(helper.ApiExtractorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx:    taskCtx,
+			Params: params,
+			Table:  data.Options.RawTable(RAW_EPIC_TABLE),
+		},
+		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
+			return extractEpicAttachments(connectionId, row.Data)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return extractor.Execute()
+}
+
+// extractEpicAttachments extracts the metadata of the attachments of a raw epic, the author is the account id, or
+// the username where there is none
+func extractEpicAttachments(connectionId uint64, blob json.RawMessage) ([]interface{}, errors.Error) {
+	var epic struct {
+		ID     uint64 `json:"id,string"`
+		Key    string `json:"key"`
+		Fields struct {
+			Attachment []struct {
+				ID       uint64 `json:"id,string"`
+				Filename string `json:"filename"`
+				Author   *struct {
+					AccountId   string `json:"accountId"`
+					Name        string `json:"name"`
+					DisplayName string `json:"displayName"`
+				} `json:"author"`
+				Created  *helper.Iso8601Time `json:"created"`
+				Size     int64               `json:"size"`
+				MimeType string              `json:"mimeType"`
+			} `json:"attachment"`
+		} `json:"fields"`
+	}
+	err := errors.Convert(json.Unmarshal(blob, &epic))
+	if err != nil {
+		return nil, err
+	}
+	var results []interface{}
+	for _, attachment := range epic.Fields.Attachment {
+		result := &models.JiraEpicAttachment{
+			ConnectionId: connectionId,
+			AttachmentId: attachment.ID,
+			EpicId:       epic.ID,
+			EpicKey:      epic.Key,
+			Filename:     attachment.Filename,
+			Size:         attachment.Size,
+			MimeType:     attachment.MimeType,
+			Created:      attachment.Created.ToNullableTime(),
+		}
+		if attachment.Author != nil {
+			result.AuthorAccountId = attachment.Author.AccountId
+			if result.AuthorAccountId == "" {
+				result.AuthorAccountId = attachment.Author.Name
+			}
+			result.AuthorDisplayName = attachment.Author.DisplayName
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
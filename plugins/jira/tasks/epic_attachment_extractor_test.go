@@ -0,0 +1,71 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractEpicAttachments(t *testing.T) {
+	results, err := extractEpicAttachments(1, []byte(`{"id":"10001","key":"K-1","fields":{"attachment":[
+		{"id":"20001","filename":"roadmap.pdf","author":{"accountId":"a1","displayName":"Alice"},
+			"created":"2022-11-01T10:00:00.000+0000","size":1024,"mimeType":"application/pdf",
+			"content":"https://jira.example.com/secure/attachment/20001/roadmap.pdf"},
+		{"id":"20002","filename":"mock.png","author":{"name":"bob","displayName":"Bob"},"size":2048,"mimeType":"image/png"}
+	]}}`))
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+	attachment := results[0].(*models.JiraEpicAttachment)
+	assert.Equal(t, uint64(20001), attachment.AttachmentId)
+	assert.Equal(t, uint64(10001), attachment.EpicId)
+	assert.Equal(t, "K-1", attachment.EpicKey)
+	assert.Equal(t, "roadmap.pdf", attachment.Filename)
+	assert.Equal(t, int64(1024), attachment.Size)
+	assert.Equal(t, "application/pdf", attachment.MimeType)
+	assert.Equal(t, "a1", attachment.AuthorAccountId)
+	assert.Equal(t, "Alice", attachment.AuthorDisplayName)
+	assert.True(t, time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC).Equal(*attachment.Created))
+	// the author is identified by the username on Jira Server
+	attachment = results[1].(*models.JiraEpicAttachment)
+	assert.Equal(t, "bob", attachment.AuthorAccountId)
+	assert.Nil(t, attachment.Created)
+
+	results, err = extractEpicAttachments(1, []byte(`{"id":"10001","key":"K-1","fields":{}}`))
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+}
+
+func TestEpicAttachmentFieldIsOptional(t *testing.T) {
+	data := &JiraTaskData{Options: &JiraOptions{}}
+	assert.NotContains(t, strings.Split(getEpicFields(data), ","), "attachment")
+
+	data.EnabledSubtasks = map[string]bool{"collectEpics": true, "extractEpicAttachments": true}
+	assert.Contains(t, strings.Split(getEpicFields(data), ","), "attachment")
+	assert.Nil(t, ValidateRawFields(data, data.EnabledSubtasks))
+
+	// the field left out of EpicFields fails the task
+	data.Options.EpicFields = []string{"summary"}
+	err := ValidateRawFields(data, data.EnabledSubtasks)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "collectEpics doesn't request field attachment needed by extractEpicAttachments")
+}
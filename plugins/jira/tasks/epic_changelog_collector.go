@@ -0,0 +1,144 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/apache/incubator-devlake/plugins/jira/tasks/apiv2models"
+)
+
+const RAW_EPIC_CHANGELOG_TABLE = "jira_api_epic_changelogs"
+
+var _ core.SubTaskEntryPoint = CollectEpicChangelogDetails
+
+var CollectEpicChangelogDetailsMeta = core.SubTaskMeta{
+	Name:             "collectEpicChangelogDetails",
+	EntryPoint:       CollectEpicChangelogDetails,
+	EnabledByDefault: true,
+	Description:      "collect the complete changelogs of Jira epics whose changelogs were truncated in search responses",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+}
+
+// CollectEpicChangelogDetails fetches the complete changelogs of the epics flagged as truncated by
+// ExtractEpicChangelogs, the rest of the epics are left alone since their changelogs were returned in full
+func CollectEpicChangelogDetails(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	// the changelog api is not available on Jira Server, same as CollectIssueChangelogs
+	if data.JiraServerInfo.DeploymentType == models.DeploymentServer {
+		return nil
+	}
+	for _, boardId := range data.Options.GetBoardIds() {
+		err := collectBoardEpicChangelogDetails(taskCtx, boardId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectBoardEpicChangelogDetails(taskCtx core.SubTaskContext, boardId uint64) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	iterator, err := GetTruncatedEpicsIterator(taskCtx, boardId)
+	if err != nil {
+		return err
+	}
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: data.Options.ConnectionId,
+				BoardId:      boardId,
+			},
+			Table: data.Options.RawTable(RAW_EPIC_CHANGELOG_TABLE),
+		},
+		ApiClient:     data.ApiClient,
+		PageSize:      data.PageSize(100),
+		GetTotalPages: GetTotalPagesFromResponse,
+		GetPageSize:   GetPageSizeFromResponse,
+		IsLastPage:    IsLastPageFromResponse,
+		Input:         iterator,
+		UrlTemplate:   data.ApiVersion().Path("issue/{{ .Input.IssueKey }}/changelog"),
+		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+			query := url.Values{}
+			query.Set("startAt", fmt.Sprintf("%v", reqData.Pager.Skip))
+			query.Set("maxResults", fmt.Sprintf("%v", reqData.Pager.Size))
+			return query, nil
+		},
+		Concurrency: data.Concurrency,
+		PageTimeout: data.PageTimeout,
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			var data struct {
+				Values []json.RawMessage
+			}
+			err := helper.UnmarshalResponse(res, &data)
+			if err != nil {
+				return nil, err
+			}
+			return data.Values, nil
+		},
+		AfterResponse: ignoreHTTPStatus404,
+	})
+	if err != nil {
+		return err
+	}
+	return collector.Execute()
+}
+
+// GetTruncatedEpicsIterator iterates the epics of the board whose changelogs were truncated. Like
+// GetUncollectedEpicKeysIterator, an epic shared by several boards is iterated once only: the changelog state of an
+// epic is extracted from the raw row it was collected into, so the state belongs to a single board. Epics are
+// iterated one by one rather than in batches since the changelog api takes a single issue
+func GetTruncatedEpicsIterator(taskCtx core.SubTaskContext, boardId uint64) (helper.Iterator, errors.Error) {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*JiraTaskData)
+	rawEpics, err := helper.NewRawDataSubTask(helper.RawDataSubTaskArgs{
+		Ctx: taskCtx,
+		Params: JiraApiParams{
+			ConnectionId: data.Options.ConnectionId,
+			BoardId:      boardId,
+		},
+		Table: data.Options.RawTable(RAW_EPIC_TABLE),
+	})
+	if err != nil {
+		return nil, err
+	}
+	clauses := []dal.Clause{
+		dal.Select("s.epic_id AS issue_id, i.issue_key"),
+		dal.From("_tool_jira_epic_changelog_states s"),
+		dal.Join("JOIN _tool_jira_issues i ON (i.connection_id = s.connection_id AND i.issue_id = s.epic_id)"),
+		dal.Where(
+			"s.connection_id = ? AND s.truncated = ? AND s._raw_data_table = ? AND s._raw_data_params = ?",
+			data.Options.ConnectionId, true, rawEpics.GetTable(), rawEpics.GetParams(),
+		),
+	}
+	cursor, err := db.Cursor(clauses...)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "unable to query for epics with truncated changelogs")
+	}
+	return helper.NewBatchedDalCursorIteratorWithContext(taskCtx.GetContext(), db, cursor, reflect.TypeOf(apiv2models.EpicInput{}), -1)
+}
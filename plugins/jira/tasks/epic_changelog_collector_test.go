@@ -0,0 +1,51 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetTruncatedEpicsIterator(t *testing.T) {
+	var where dal.DalClause
+	mockDal := new(mocks.Dal)
+	mockDal.On("Cursor", mock.Anything).Run(func(args mock.Arguments) {
+		for _, clause := range args.Get(0).([]dal.Clause) {
+			if clause.Type == dal.WhereClause {
+				where = clause.Data.(dal.DalClause)
+			}
+		}
+	}).Return(new(mocks.Rows), nil).Once()
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+	mockCtx.On("GetContext").Return(context.Background())
+	mockCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{ConnectionId: 2}})
+
+	iterator, err := GetTruncatedEpicsIterator(mockCtx, 8)
+	assert.Nil(t, err)
+	assert.NotNil(t, iterator)
+	// only the truncated epics extracted from the raw epics of the board are targeted
+	assert.Equal(t, []interface{}{uint64(2), true, "_raw_jira_api_epics", `{"ConnectionId":2,"BoardId":8}`}, where.Params)
+	mockDal.AssertExpectations(t)
+}
@@ -0,0 +1,132 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/apache/incubator-devlake/plugins/jira/tasks/apiv2models"
+)
+
+var _ core.SubTaskEntryPoint = ExtractEpicChangelogs
+
+var ExtractEpicChangelogsMeta = core.SubTaskMeta{
+	Name:             "extractEpicChangelogs",
+	EntryPoint:       ExtractEpicChangelogs,
+	EnabledByDefault: true,
+	Description:      "extract the status transitions of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+}
+
+// epicWithChangelog is the part of a raw epic needed for extracting its status transitions
+type epicWithChangelog struct {
+	ID     uint64 `json:"id,string"`
+	Fields struct {
+		Updated helper.Iso8601Time `json:"updated"`
+	} `json:"fields"`
+	Changelog *struct {
+		StartAt    int                     `json:"startAt"`
+		MaxResults int                     `json:"maxResults"`
+		Total      int                     `json:"total"`
+		Histories  []apiv2models.Changelog `json:"histories"`
+	} `json:"changelog"`
+}
+
+func ExtractEpicChangelogs(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	for _, boardId := range data.Options.GetBoardIds() {
+		err := extractBoardEpicChangelogs(taskCtx, boardId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractBoardEpicChangelogs(taskCtx core.SubTaskContext, boardId uint64) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	connectionId := data.Options.ConnectionId
+	extractor, err := helper.NewApiExtractor(helper.ApiExtractorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: connectionId,
+				BoardId:      boardId,
+			},
+			Table: data.Options.RawTable(RAW_EPIC_TABLE),
+		},
+		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
+			return extractEpicChangelog(connectionId, row.Data)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return extractor.Execute()
+}
+
+// extractEpicChangelog extracts the status transitions from the changelog of a raw epic, along with the state telling
+// if the changelog was truncated by Jira. Epics without the changelog expanded produce nothing
+func extractEpicChangelog(connectionId uint64, blob json.RawMessage) ([]interface{}, errors.Error) {
+	epic := &epicWithChangelog{}
+	err := errors.Convert(json.Unmarshal(blob, epic))
+	if err != nil {
+		return nil, err
+	}
+	if epic.Changelog == nil {
+		return nil, nil
+	}
+	var results []interface{}
+	for _, changelog := range epic.Changelog.Histories {
+		// the tool layer changelog resolves the author across Jira Cloud and Server
+		cl, _ := changelog.ToToolLayer(connectionId, epic.ID, nil)
+		for _, item := range changelog.Items {
+			if item.Field != "status" {
+				continue
+			}
+			results = append(results, &models.JiraEpicStatusChangelog{
+				ConnectionId:      connectionId,
+				ChangelogId:       cl.ChangelogId,
+				EpicId:            epic.ID,
+				AuthorAccountId:   cl.AuthorAccountId,
+				AuthorDisplayName: cl.AuthorDisplayName,
+				FromStatusId:      item.FromValue,
+				FromStatus:        item.FromString,
+				ToStatusId:        item.ToValue,
+				ToStatus:          item.ToString,
+				Created:           cl.Created,
+			})
+			// a changelog entry changes the status at most once
+			break
+		}
+	}
+	returned := len(epic.Changelog.Histories)
+	results = append(results, &models.JiraEpicChangelogState{
+		ConnectionId: connectionId,
+		EpicId:       epic.ID,
+		EpicUpdated:  epic.Fields.Updated.ToNullableTime(),
+		Returned:     returned,
+		Total:        epic.Changelog.Total,
+		Truncated:    epic.Changelog.StartAt+returned < epic.Changelog.Total,
+	})
+	return results, nil
+}
@@ -0,0 +1,196 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExtractEpicChangelog(t *testing.T) {
+	blob := `{
+		"id": "10001",
+		"key": "EPIC-1",
+		"fields": {"updated": "2022-11-10T08:00:00.000+0000"},
+		"changelog": {
+			"startAt": 0,
+			"maxResults": 2,
+			"total": 150,
+			"histories": [
+				{
+					"id": "201",
+					"author": {"accountId": "acc-1", "displayName": "Alice"},
+					"created": "2022-11-01T08:00:00.000+0000",
+					"items": [
+						{"field": "assignee", "fieldtype": "jira", "from": null, "to": "acc-1"},
+						{"field": "status", "fieldtype": "jira", "from": "1", "fromString": "To Do", "to": "3", "toString": "In Progress"}
+					]
+				},
+				{
+					"id": "202",
+					"author": {"accountId": "acc-2", "displayName": "Bob"},
+					"created": "2022-11-02T08:00:00.000+0000",
+					"items": [
+						{"field": "summary", "fieldtype": "jira", "fromString": "a", "toString": "b"}
+					]
+				}
+			]
+		}
+	}`
+	results, err := extractEpicChangelog(1, json.RawMessage(blob))
+	assert.Nil(t, err)
+	if assert.Len(t, results, 2) {
+		transition := results[0].(*models.JiraEpicStatusChangelog)
+		assert.Equal(t, uint64(201), transition.ChangelogId)
+		assert.Equal(t, uint64(10001), transition.EpicId)
+		assert.Equal(t, "acc-1", transition.AuthorAccountId)
+		assert.Equal(t, "To Do", transition.FromStatus)
+		assert.Equal(t, "3", transition.ToStatusId)
+		assert.Equal(t, "In Progress", transition.ToStatus)
+		assert.Equal(t, "2022-11-01T08:00:00Z", transition.Created.UTC().Format("2006-01-02T15:04:05Z07:00"))
+
+		state := results[1].(*models.JiraEpicChangelogState)
+		assert.Equal(t, uint64(10001), state.EpicId)
+		assert.Equal(t, 2, state.Returned)
+		assert.Equal(t, 150, state.Total)
+		assert.True(t, state.Truncated)
+	}
+
+	// the changelog is complete
+	results, err = extractEpicChangelog(1, json.RawMessage(`{"id":"10002","changelog":{"startAt":0,"total":0,"histories":[]}}`))
+	assert.Nil(t, err)
+	if assert.Len(t, results, 1) {
+		assert.False(t, results[0].(*models.JiraEpicChangelogState).Truncated)
+	}
+
+	// the changelog was not expanded
+	results, err = extractEpicChangelog(1, json.RawMessage(`{"id":"10003"}`))
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+}
+
+// newRawTablesDal mocks a database holding the given raw tables, rows are read by the table and the params they
+// were collected with, the same way the database filters them. Extracted records are appended to `saved`
+func newRawTablesDal(rawTables map[string][]*helper.RawData, saved *[]interface{}) *mocks.Dal {
+	selectRows := func(clauses []dal.Clause) []*helper.RawData {
+		var table, params string
+		for _, clause := range clauses {
+			switch clause.Type {
+			case dal.FromClause:
+				table = clause.Data.(string)
+			case dal.WhereClause:
+				params = clause.Data.(dal.DalClause).Params[0].(string)
+			}
+		}
+		var rows []*helper.RawData
+		for _, row := range rawTables[table] {
+			if row.Params == params {
+				rows = append(rows, row)
+			}
+		}
+		return rows
+	}
+	mockDal := new(mocks.Dal)
+	var selected []*helper.RawData
+	mockDal.On("Count", mock.Anything).Return(func(clauses ...dal.Clause) (int64, errors.Error) {
+		return int64(len(selectRows(clauses))), nil
+	})
+	rows := new(mocks.Rows)
+	rows.On("Next").Return(func() bool {
+		return len(selected) > 0
+	})
+	rows.On("Close").Return(nil)
+	mockDal.On("Cursor", mock.Anything).Run(func(args mock.Arguments) {
+		selected = selectRows(args.Get(0).([]dal.Clause))
+	}).Return(rows, nil)
+	mockDal.On("Fetch", rows, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(1).(*helper.RawData) = *selected[0]
+		selected = selected[1:]
+	}).Return(nil)
+	mockDal.On("GetPrimaryKeyFields", mock.Anything).Return([]reflect.StructField{{Name: "ConnectionId"}, {Name: "EpicId"}})
+	mockDal.On("Delete", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("CreateOrUpdate", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		records := reflect.ValueOf(args.Get(0))
+		for i := 0; i < records.Len(); i++ {
+			*saved = append(*saved, records.Index(i).Interface())
+		}
+	}).Return(nil)
+	return mockDal
+}
+
+func TestExtractEpicChangelogsIsolatesConnections(t *testing.T) {
+	rawEpic := func(connectionId uint64, epicId int) *helper.RawData {
+		params, _ := json.Marshal(JiraApiParams{ConnectionId: connectionId, BoardId: 8})
+		return &helper.RawData{
+			Params: string(params),
+			Data:   []byte(fmt.Sprintf(`{"id":"%d","changelog":{"startAt":0,"total":0,"histories":[]}}`, epicId)),
+		}
+	}
+	cases := map[string]struct {
+		scoped    bool
+		rawTables map[string][]*helper.RawData
+	}{
+		"shared raw table": {
+			rawTables: map[string][]*helper.RawData{
+				"_raw_jira_api_epics": {rawEpic(1, 101), rawEpic(2, 201), rawEpic(1, 102)},
+			},
+		},
+		"connection scoped raw tables": {
+			scoped: true,
+			rawTables: map[string][]*helper.RawData{
+				// rows of other connections are never read even if they were collected with the same params
+				"_raw_jira_api_epics":   {rawEpic(1, 901)},
+				"_raw_jira_api_epics_1": {rawEpic(1, 101), rawEpic(1, 102)},
+				"_raw_jira_api_epics_2": {rawEpic(1, 201), rawEpic(2, 202)},
+			},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var saved []interface{}
+			mockCtx := unithelper.DummySubTaskContext(newRawTablesDal(c.rawTables, &saved))
+			mockCtx.On("GetContext").Return(context.Background())
+			mockCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{
+				ConnectionId:              1,
+				BoardId:                   8,
+				ConnectionScopedRawTables: c.scoped,
+			}})
+
+			assert.Nil(t, ExtractEpicChangelogs(mockCtx))
+			var epicIds []uint64
+			for _, record := range saved {
+				state := record.(*models.JiraEpicChangelogState)
+				assert.Equal(t, uint64(1), state.ConnectionId)
+				epicIds = append(epicIds, state.EpicId)
+			}
+			assert.Equal(t, []uint64{101, 102}, epicIds)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	goerror "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"gorm.io/gorm"
+)
+
+// changelogDeltaOverlap is how far an incremental collection in delta mode reaches back before `since`, the epics
+// updated around the boundary are searched again and those without new changelog entries are dropped by the
+// watermark
+const changelogDeltaOverlap = time.Hour
+
+// epicWithChangelogIds is the part of a raw epic needed for comparing its changelog with the watermark
+type epicWithChangelogIds struct {
+	Changelog *struct {
+		StartAt   int `json:"startAt"`
+		Total     int `json:"total"`
+		Histories []struct {
+			ID uint64 `json:"id,string"`
+		} `json:"histories"`
+	} `json:"changelog"`
+}
+
+// epicChangelogWatermark drops the epics without changelog entries beyond the watermark of a board from an
+// incremental collection, and tracks the highest changelog id collected for the next one, see ChangelogDeltaSync.
+// Epics whose changelog was not returned, or was truncated by Jira, are kept since they can't be told apart. A nil
+// watermark, as returned with the option off, does nothing
+type epicChangelogWatermark struct {
+	connectionId uint64
+	boardId      uint64
+	previous     uint64
+	filter       bool
+	mu           sync.Mutex
+	highest      uint64
+	skipped      int
+}
+
+// loadEpicChangelogWatermark loads the watermark of the board if the options ask for the delta mode, the epics are
+// filtered by it in incremental mode only
+func loadEpicChangelogWatermark(db dal.Dal, op *JiraOptions, boardId uint64, incremental bool) (*epicChangelogWatermark, errors.Error) {
+	if !op.ChangelogDeltaSync {
+		return nil, nil
+	}
+	watermark := &epicChangelogWatermark{
+		connectionId: op.ConnectionId,
+		boardId:      boardId,
+		filter:       incremental,
+	}
+	saved := &models.JiraEpicChangelogWatermark{}
+	err := db.First(saved, dal.Where("connection_id = ? AND board_id = ?", op.ConnectionId, boardId))
+	if err != nil {
+		if goerror.Is(err, gorm.ErrRecordNotFound) {
+			return watermark, nil
+		}
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to load the changelog watermark of board %d", boardId))
+	}
+	watermark.previous = saved.ChangelogId
+	watermark.highest = saved.ChangelogId
+	return watermark, nil
+}
+
+// since widens the time range of an incremental collection by changelogDeltaOverlap
+func (w *epicChangelogWatermark) since(since *time.Time) *time.Time {
+	if w == nil || !w.filter || since == nil || w.previous == 0 {
+		return since
+	}
+	widened := since.Add(-changelogDeltaOverlap)
+	return &widened
+}
+
+// apply makes the collector pass the epics through the watermark
+func (w *epicChangelogWatermark) apply(args *helper.ApiCollectorArgs) {
+	if w == nil {
+		return
+	}
+	args.ResponseTransformer = w.transform
+}
+
+// transform returns nil for an epic without changelog entries beyond the watermark, the epic is returned as it is
+// otherwise
+func (w *epicChangelogWatermark) transform(msg json.RawMessage) (json.RawMessage, errors.Error) {
+	epic := &epicWithChangelogIds{}
+	err := errors.Convert(json.Unmarshal(msg, epic))
+	if err != nil {
+		return nil, err
+	}
+	if epic.Changelog == nil || len(epic.Changelog.Histories) == 0 {
+		return msg, nil
+	}
+	var latest uint64
+	for _, history := range epic.Changelog.Histories {
+		if history.ID > latest {
+			latest = history.ID
+		}
+	}
+	truncated := epic.Changelog.StartAt+len(epic.Changelog.Histories) < epic.Changelog.Total
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if latest > w.highest {
+		w.highest = latest
+	}
+	if w.filter && !truncated && latest <= w.previous {
+		w.skipped++
+		return nil, nil
+	}
+	return msg, nil
+}
+
+// save stores the highest changelog id collected as the watermark of the board. Nothing is stored in DryRun mode
+// or once the limit was reached, the epics of the board were not collected as a whole then
+func (w *epicChangelogWatermark) save(taskCtx core.SubTaskContext, limit *epicLimit) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	if w == nil || data.Options.DryRun || limit.reached() {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.filter {
+		taskCtx.GetLogger().Info("%d epics of board %d without changelog entries beyond %d were skipped", w.skipped, w.boardId, w.previous)
+	}
+	if w.highest <= w.previous {
+		return nil
+	}
+	err := taskCtx.GetDal().CreateOrUpdate(&models.JiraEpicChangelogWatermark{
+		ConnectionId: w.connectionId,
+		BoardId:      w.boardId,
+		ChangelogId:  w.highest,
+	})
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to save the changelog watermark of board %d", w.boardId))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestEpicChangelogWatermark(t *testing.T) {
+	op := &JiraOptions{ConnectionId: 1, ChangelogDeltaSync: true}
+	mockDal := new(mocks.Dal)
+	mockDal.On("First", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.JiraEpicChangelogWatermark).ChangelogId = 100
+	}).Return(nil).Once()
+	watermark, err := loadEpicChangelogWatermark(mockDal, op, 2, true)
+	assert.Nil(t, err)
+	mockDal.AssertExpectations(t)
+
+	epics := map[string]bool{
+		// changed beyond the watermark
+		`{"key":"EP-1","changelog":{"startAt":0,"total":2,"histories":[{"id":"90"},{"id":"120"}]}}`: true,
+		// changed before the watermark only
+		`{"key":"EP-2","changelog":{"startAt":0,"total":2,"histories":[{"id":"99"},{"id":"100"}]}}`: false,
+		// the changelog is truncated, newer entries might be left out
+		`{"key":"EP-3","changelog":{"startAt":0,"total":3,"histories":[{"id":"50"},{"id":"60"}]}}`: true,
+		// the changelog was not returned at all
+		`{"key":"EP-4"}`: true,
+		`{"key":"EP-5","changelog":{"startAt":0,"total":0,"histories":[]}}`: true,
+	}
+	for epic, kept := range epics {
+		msg, err := watermark.transform(json.RawMessage(epic))
+		assert.Nil(t, err)
+		assert.Equal(t, kept, msg != nil, epic)
+	}
+	assert.Equal(t, uint64(120), watermark.highest)
+	assert.Equal(t, 1, watermark.skipped)
+
+	since := time.Date(2022, 11, 29, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, since.Add(-changelogDeltaOverlap), *watermark.since(&since))
+	assert.Nil(t, watermark.since(nil))
+}
+
+func TestEpicChangelogWatermarkFullMode(t *testing.T) {
+	op := &JiraOptions{ConnectionId: 1, ChangelogDeltaSync: true}
+	mockDal := new(mocks.Dal)
+	mockDal.On("First", mock.Anything, mock.Anything).Return(errors.Convert(gorm.ErrRecordNotFound)).Once()
+	watermark, err := loadEpicChangelogWatermark(mockDal, op, 2, false)
+	assert.Nil(t, err)
+	// nothing is dropped by a full collection, the watermark is tracked all the same
+	msg, err := watermark.transform(json.RawMessage(`{"key":"EP-1","changelog":{"startAt":0,"total":1,"histories":[{"id":"7"}]}}`))
+	assert.Nil(t, err)
+	assert.NotNil(t, msg)
+	assert.Equal(t, uint64(7), watermark.highest)
+	since := time.Now()
+	assert.Equal(t, &since, watermark.since(&since))
+
+	// the delta mode is off
+	watermark, err = loadEpicChangelogWatermark(mockDal, &JiraOptions{ConnectionId: 1}, 2, true)
+	assert.Nil(t, err)
+	assert.Nil(t, watermark)
+	assert.Equal(t, &since, watermark.since(&since))
+	mockDal.AssertExpectations(t)
+
+	assert.NotNil(t, (&JiraOptions{ChangelogDeltaSync: true, EpicShardCount: 2}).ValidateChangelogDeltaSync())
+	assert.NotNil(t, (&JiraOptions{ChangelogDeltaSync: true, Sample: &SampleOptions{Every: 10}}).ValidateChangelogDeltaSync())
+	assert.Nil(t, (&JiraOptions{ChangelogDeltaSync: true}).ValidateChangelogDeltaSync())
+}
@@ -0,0 +1,139 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+const RAW_EPIC_CHILDREN_TABLE = "jira_api_epic_children"
+
+var _ core.SubTaskEntryPoint = CollectEpicChildren
+
+var CollectEpicChildrenMeta = core.SubTaskMeta{
+	Name:             "collectEpicChildren",
+	EntryPoint:       CollectEpicChildren,
+	EnabledByDefault: true,
+	Description:      "collect the child issues of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET, core.DOMAIN_TYPE_CROSS},
+}
+
+// CollectEpicChildren collects the issues linked to the epics of the board, only the fields linking them to their
+// epics are requested, so every raw row maps a child issue to its epic
+func CollectEpicChildren(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	boardIds := data.Options.GetBoardIds()
+	for i, boardId := range boardIds {
+		err := collectBoardEpicChildren(taskCtx, boardId, boardIds[:i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectBoardEpicChildren(taskCtx core.SubTaskContext, boardId uint64, collectedBoardIds []uint64) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	rawDataSubTaskArgs := helper.RawDataSubTaskArgs{
+		Ctx: taskCtx,
+		Params: JiraApiParams{
+			ConnectionId: data.Options.ConnectionId,
+			BoardId:      boardId,
+		},
+		Table: data.Options.RawTable(RAW_EPIC_CHILDREN_TABLE),
+	}
+	// the incremental state is loaded from the raw table
+	since, incremental, err := getCollectionSince(logger, data, func() (*time.Time, errors.Error) {
+		return getLatestCollected(db, rawDataSubTaskArgs)
+	})
+	if err != nil {
+		return err
+	}
+	if incremental {
+		logger.Info("collect epic children of board %d in incremental mode, since %s", boardId, since)
+	} else {
+		logger.Info("collect epic children of board %d in full mode", boardId)
+	}
+	// see CollectEpics for why the boundary is inclusive
+	updatedCriteria := updatedSinceCriteria(since, data.TimeZone)
+	batchSize := data.Options.EpicKeysBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEpicKeysBatchSize
+	}
+	epicIterator, err := GetUncollectedEpicKeysIterator(taskCtx, boardId, collectedBoardIds, batchSize)
+	if err != nil {
+		return err
+	}
+	overhead := len(buildEpicChildrenJql(nil, updatedCriteria)) - len(epicKeysCriteria(nil))
+	limitedIterator := newJqlLimitedEpicKeysIterator(epicIterator, maxEpicJqlLength-overhead)
+	fields := []string{"parent"}
+	if data.Options.TransformationRules.EpicKeyField != "" {
+		fields = append(fields, data.Options.TransformationRules.EpicKeyField)
+	}
+	pager := searchPager{logger: logger}
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: rawDataSubTaskArgs,
+		ApiClient:          data.ApiClient,
+		PageSize:           data.PageSize(100),
+		Incremental:        incremental,
+		UrlTemplate:        data.ApiVersion().SearchPath(),
+		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+			query := url.Values{}
+			epicKeys := []string{}
+			for _, e := range reqData.Input.([]interface{}) {
+				epicKeys = append(epicKeys, *e.(*string))
+			}
+			query.Set("jql", buildEpicChildrenJql(epicKeys, updatedCriteria))
+			query.Set("fields", strings.Join(fields, ","))
+			pager.SetQuery(query, reqData)
+			return query, nil
+		},
+		Input:                 limitedIterator,
+		GetTotalPages:         pager.GetTotalPages,
+		GetPageSize:           GetPageSizeFromResponse,
+		GetNextPageCustomData: pager.GetNextPageCustomData,
+		DryRun:                data.Options.DryRun,
+		PageTimeout:           data.PageTimeout,
+		Concurrency:           data.Concurrency,
+		AfterResponse:         ignoreNonexistentEpics(logger, limitedIterator),
+		ResponseParser:        pager.ResponseParser,
+	})
+	if err != nil {
+		return err
+	}
+	return collector.Execute()
+}
+
+func buildEpicChildrenJql(epicKeys []string, updatedCriteria string) string {
+	return buildJql("created ASC", epicChildrenCriteria(epicKeys), updatedCriteria)
+}
+
+// epicChildrenCriteria matches issues linked to the given epics, `"Epic Link"` is understood by both Jira Cloud and
+// Data Center, unlike `childIssuesOf` which takes a single issue
+func epicChildrenCriteria(epicKeys []string) string {
+	return fmt.Sprintf(`"Epic Link" in (%s)`, jqlValues(epicKeys))
+}
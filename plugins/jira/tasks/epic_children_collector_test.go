@@ -0,0 +1,42 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildEpicChildrenJql(t *testing.T) {
+	jql := buildEpicChildrenJql([]string{"K-1", "K-2"}, "updated >= '2022/11/01 08:00'")
+	assert.Equal(t, `"Epic Link" in ("K-1","K-2") AND updated >= '2022/11/01 08:00' ORDER BY created ASC`, jql)
+	// nonexistent epics are located the same way as in the epic collector
+	matches := epicKeysCriteriaPattern.FindStringSubmatch(jql)
+	if assert.NotNil(t, matches) {
+		assert.Equal(t, `"K-1","K-2"`, matches[1])
+	}
+}
+
+func TestEpicKeysCriteriaPatternQuotedKeys(t *testing.T) {
+	keys := []string{"K-1) OR (", `O"Brien`}
+	matches := epicKeysCriteriaPattern.FindStringSubmatch(buildEpicChildrenJql(keys, ""))
+	if assert.NotNil(t, matches) {
+		assert.Equal(t, keys, parseJqlValues(matches[1]))
+	}
+}
@@ -18,9 +18,14 @@ limitations under the License.
 package tasks
 
 import (
+	"bytes"
+	goerror "errors"
 	"fmt"
 	"reflect"
-	"strings"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/apache/incubator-devlake/errors"
 	"github.com/apache/incubator-devlake/plugins/core"
@@ -30,87 +35,621 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 
 	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/helper/common"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"gorm.io/gorm"
 )
 
 const RAW_EPIC_TABLE = "jira_api_epics"
 
+const defaultEpicKeysBatchSize = 100
+
+// defaultEmptyEpicsWarningKeys warns about a board of which no epic was collected as soon as any epic key was searched
+const defaultEmptyEpicsWarningKeys = 1
+
+// defaultEpicOrderBy orders the epics by their creation, so pages wouldn't shift as epics get updated during the
+// collection, epics created in the same second are ordered by their keys
+const defaultEpicOrderBy = "created ASC, " + epicOrderByTieBreaker
+
+// epicOrderByTieBreaker is appended to the ordering of the epics unless they are ordered by their keys already.
+// Neither `created` nor `updated` is unique, epics of the same second could swap places between the requests of
+// two pages otherwise, so a deep pagination would collect some of them twice and skip the others
+const epicOrderByTieBreaker = "key ASC"
+
+// maxEpicJqlLength is the max length of the JQL generated by the epic collector, Jira rejects requests with JQL
+// that is too long
+const maxEpicJqlLength = 3000
+
+// epicScopeLockWait is how long the epic collector of a board waits for the collection of the same board by another
+// pipeline, i.e. of another blueprint sharing the board, before it fails
+const epicScopeLockWait = 10 * time.Minute
+
+// epicRawBatchSize is the number of raw epics inserted at a time, that is 10 pages of 100 epics
+const epicRawBatchSize = 1000
+
 var _ core.SubTaskEntryPoint = CollectEpics
 
 var CollectEpicsMeta = core.SubTaskMeta{
 	Name:             "collectEpics",
 	EntryPoint:       CollectEpics,
 	EnabledByDefault: true,
-	Description:      "collect Jira epics from all boards",
+	Description:      "collect Jira epics from all boards, or requests of the service desks for JSM connections",
 	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET, core.DOMAIN_TYPE_CROSS},
 }
 
 func CollectEpics(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	// boards selected by names are resolved by discoverBoards, or by the boards it saved if it was not selected
+	err := resolveBoardNamesFromDb(taskCtx.GetDal(), data.Options)
+	if err != nil {
+		return err
+	}
+	err = data.Options.ValidateBoardScope()
+	if err != nil {
+		return err
+	}
+	if data.ConnectionType == models.ConnectionTypeJSM {
+		return collectServiceDeskRequests(taskCtx)
+	}
+	boardIds := data.Options.GetBoardIds()
+	err = checkEpicScope(taskCtx, boardIds)
+	if err != nil {
+		return err
+	}
+	limit := newEpicLimit(data.Options.Limit)
+	for i, boardId := range boardIds {
+		if limit.reached() {
+			taskCtx.GetLogger().Info("%d epics were collected, the rest of the boards are skipped by the limit", data.Options.Limit)
+			break
+		}
+		// epics shared with the boards before were collected along with them
+		err = collectBoardEpics(taskCtx, boardId, boardIds[:i], limit)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectBoardEpics collects the epics of a single board except those shared with `collectedBoardIds`, the board is
+// recorded in the params of the raw rows, so they could be extracted board by board
+func collectBoardEpics(taskCtx core.SubTaskContext, boardId uint64, collectedBoardIds []uint64, limit *epicLimit) errors.Error {
 	db := taskCtx.GetDal()
 	data := taskCtx.GetData().(*JiraTaskData)
-	epicIterator, err := GetEpicKeysIterator(db, data, 100)
+	logger := taskCtx.GetLogger()
+	params, err := getEpicRawDataParams(db, data, boardId)
 	if err != nil {
 		return err
 	}
-	since := data.Since
-	jql := "ORDER BY created ASC"
-	if since != nil {
-		// prepend a time range criteria if `since` was specified, either by user or from database
-		jql = fmt.Sprintf("updated >= '%s' %s", since.Format("2006/01/02 15:04"), jql)
+	rawDataSubTaskArgs := helper.RawDataSubTaskArgs{
+		Ctx:    taskCtx,
+		Params: params,
+		Table:  data.Options.RawTable(RAW_EPIC_TABLE),
+		// an epic collected again by an incremental collection replaces the one collected before
+		PrimaryKeyExtractor: extractEpicKey,
 	}
-	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
-		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
-			Ctx: taskCtx,
-			Params: JiraApiParams{
-				ConnectionId: data.Options.ConnectionId,
-				BoardId:      data.Options.BoardId,
-			},
-			Table: RAW_EPIC_TABLE,
-		},
-		ApiClient:   data.ApiClient,
-		PageSize:    100,
-		Incremental: false,
-		UrlTemplate: "api/2/search",
+	orderBy, err := data.Options.GetEpicOrderBy()
+	if err != nil {
+		return err
+	}
+	userCriteria := epicFilterCriteria(data.Options)
+	fields := getEpicFields(data)
+	// the incremental state is loaded from the raw table, an interrupted collection is resumed with its time range
+	// as long as the rest of the query stays the same
+	query := url.Values{"jql": {buildJql(orderBy, userCriteria)}, "fields": {fields}}.Encode()
+	since, incremental, resumeKey, err := getResumableCollectionSince(logger, data, rawDataSubTaskArgs, query, func() (*time.Time, errors.Error) {
+		// the epics left out by the previous sample would never be collected incrementally
+		if data.Options.SampleEvery() > 0 {
+			return nil, nil
+		}
+		return getLatestCollected(db, rawDataSubTaskArgs)
+	})
+	if err != nil {
+		return err
+	}
+	watermark, err := loadEpicChangelogWatermark(db, data.Options, boardId, incremental)
+	if err != nil {
+		return err
+	}
+	since = watermark.since(since)
+	if incremental {
+		logger.Info("collect epics of board %d in incremental mode, since %s", boardId, since)
+	} else {
+		logger.Info("collect epics of board %d in full mode", boardId)
+	}
+	if data.Options.EpicWindowDays > 0 {
+		err = collectBoardEpicsByTimeWindows(taskCtx, rawDataSubTaskArgs, boardId, since, incremental, resumeKey, orderBy, userCriteria, fields, limit, watermark)
+	} else {
+		err = collectBoardEpicsByKeys(taskCtx, rawDataSubTaskArgs, boardId, collectedBoardIds, since, incremental, resumeKey, orderBy, userCriteria, fields, limit, watermark)
+	}
+	if err == nil {
+		err = watermark.save(taskCtx, limit)
+	}
+	// the epics left out by a sample are not archived, they are not searched for one by one either
+	if err != nil || !data.Options.IncludeArchived || data.Options.DryRun || data.Options.SampleEvery() > 0 || limit.reached() {
+		return err
+	}
+	return collectArchivedEpics(taskCtx, rawDataSubTaskArgs, boardId, collectedBoardIds, fields, limit)
+}
+
+// collectBoardEpicsByKeys collects the epics of the board by batches of their keys, which are taken from the issues
+// of the board collected before
+func collectBoardEpicsByKeys(
+	taskCtx core.SubTaskContext,
+	rawDataSubTaskArgs helper.RawDataSubTaskArgs,
+	boardId uint64,
+	collectedBoardIds []uint64,
+	since *time.Time,
+	incremental bool,
+	resumeKey string,
+	orderBy string,
+	userCriteria string,
+	fields string,
+	limit *epicLimit,
+	watermark *epicChangelogWatermark,
+) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	updatedCriteria := updatedSinceCriteria(since, data.TimeZone)
+	err := recordCollectionQuery(taskCtx, rawDataSubTaskArgs, boardId, buildJql(orderBy, updatedCriteria, userCriteria), since, incremental)
+	if err != nil {
+		return err
+	}
+	batchSize := data.Options.EpicKeysBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEpicKeysBatchSize
+	}
+	epicIterator, err := getEpicKeysIterator(taskCtx, boardId, collectedBoardIds, batchSize)
+	if err != nil {
+		return err
+	}
+	epicIterator = newEpicShardIterator(epicIterator, data.Options, func(elem interface{}) string {
+		return *elem.(*string)
+	})
+	epicIterator = newSampledEpicKeysIterator(epicIterator, data.Options)
+	keysIterator := &countingEpicKeysIterator{Iterator: epicIterator}
+	epicIterator = keysIterator
+	// long epic keys could make the JQL exceed what Jira accepts, split the batches further when necessary
+	overhead := len(buildEpicJql(orderBy, nil, updatedCriteria, userCriteria)) - len(epicKeysCriteria(nil))
+	limitedIterator := newJqlLimitedEpicKeysIterator(epicIterator, maxEpicJqlLength-overhead)
+	pager := searchPager{logger: logger}
+	changelogs := newEpicChangelogFallback(logger, data, boardId)
+	args := helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: rawDataSubTaskArgs,
+		ApiClient:          data.ApiClient,
+		AfterSaveRawData:   data.RawExporter.AfterSave(taskCtx.GetContext(), boardId),
+		PageSize:           data.PageSize(100),
+		Incremental:        incremental,
+		UrlTemplate:        data.ApiVersion().SearchPath(),
 		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
 			query := url.Values{}
 			epicKeys := []string{}
 			for _, e := range reqData.Input.([]interface{}) {
 				epicKeys = append(epicKeys, *e.(*string))
 			}
-			localJQL := fmt.Sprintf("issue in (%s) %s", strings.Join(epicKeys, ","), jql)
-			query.Set("jql", localJQL)
-			query.Set("startAt", fmt.Sprintf("%v", reqData.Pager.Skip))
-			query.Set("maxResults", fmt.Sprintf("%v", reqData.Pager.Size))
-			query.Set("expand", "changelog")
+			query.Set("jql", buildEpicJql(orderBy, epicKeys, updatedCriteria, userCriteria))
+			pager.SetQuery(query, reqData)
+			query.Set("fields", fields)
+			changelogs.SetQuery(query)
+			return query, nil
+		},
+		Input:                 limitedIterator,
+		GetTotalPages:         pager.GetTotalPages,
+		GetPageSize:           GetPageSizeFromResponse,
+		GetNextPageCustomData: pager.GetNextPageCustomData,
+		DryRun:                data.Options.DryRun,
+		PageTimeout:           data.PageTimeout,
+		EstimateTotalPages: func(reqData *helper.RequestData) (int, errors.Error) {
+			// every epic key matches one issue at most
+			keys := len(reqData.Input.([]interface{}))
+			return (keys + reqData.Pager.Size - 1) / reqData.Pager.Size, nil
+		},
+		Concurrency:    data.Concurrency,
+		MinConcurrency: data.Options.MinConcurrency,
+		MaxConcurrency: data.Options.MaxConcurrency,
+		// epics might have been deleted since their keys were collected, which fails the whole batch
+		AfterResponse: chainAfterResponse(
+			newRateLimitObserver(logger).AfterResponse,
+			ignoreNonexistentEpics(logger, limitedIterator),
+			changelogs.AfterResponse,
+		),
+		ResponseParser: pager.ResponseParser,
+		ResumeKey:      resumeKey,
+		RawBatchSize:   epicRawBatchSize,
+		// epics updated right at `since` are collected again by every incremental collection
+		SkipUnchangedRecords: incremental,
+		CompressRawData:      data.Options.CompressRawData,
+		MaxRecords:           limit.maxRecords(),
+		ScopeLock:            helper.NewDalScopeLock(taskCtx.GetDal()),
+		ScopeLockWait:        epicScopeLockWait,
+	}
+	sampleEpicPages(&args, data.Options)
+	watermark.apply(&args)
+	collector, err := helper.NewApiCollector(args)
+	if err != nil {
+		return err
+	}
+	err = limit.execute(collector)
+	if err != nil || since != nil || data.Options.DryRun {
+		return err
+	}
+	warnEmptyEpics(taskCtx, boardId, keysIterator.keys, collector.GetRecords())
+	return nil
+}
+
+// countingEpicKeysIterator counts the epic keys fetched from the underlying iterator
+type countingEpicKeysIterator struct {
+	helper.Iterator
+	keys int
+}
+
+func (it *countingEpicKeysIterator) Fetch() (interface{}, errors.Error) {
+	batch, err := it.Iterator.Fetch()
+	if err == nil {
+		it.keys += len(batch.([]interface{}))
+	}
+	return batch, err
+}
+
+// warnEmptyEpics warns about a full collection of a board which searched epic keys but collected no epic, since it
+// is usually caused by a wrong board, a permission lost by the connection or too strict filters rather than epics
+// being gone. The warning is reported as a result of the subtask as well, without failing it
+func warnEmptyEpics(taskCtx core.SubTaskContext, boardId uint64, keys int, records int) {
+	data := taskCtx.GetData().(*JiraTaskData)
+	threshold := data.Options.EmptyEpicsWarningKeys
+	if threshold == 0 {
+		threshold = defaultEmptyEpicsWarningKeys
+	}
+	if threshold < 0 || keys < threshold || records > 0 {
+		return
+	}
+	warning := fmt.Sprintf("no epic of board %d was collected though %d epic keys were searched, please check the board, the permissions of the connection and the filters", boardId, keys)
+	taskCtx.GetLogger().Warn(nil, warning)
+	if reporter, ok := taskCtx.(core.SubTaskResultReporter); ok {
+		reporter.ReportSubTaskResult(core.SubTaskResult{Warnings: []string{warning}})
+	}
+}
+
+// collectBoardEpicsByTimeWindows collects the epics of the project of the board by a JQL search per window of
+// EpicWindowDays, so the pagination of every search stays shallow. The windows start from `since`, or the epic
+// updated the earliest for a full collection
+func collectBoardEpicsByTimeWindows(
+	taskCtx core.SubTaskContext,
+	rawDataSubTaskArgs helper.RawDataSubTaskArgs,
+	boardId uint64,
+	since *time.Time,
+	incremental bool,
+	resumeKey string,
+	orderBy string,
+	userCriteria string,
+	fields string,
+	limit *epicLimit,
+	watermark *epicChangelogWatermark,
+) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	projectId, err := getBoardProjectId(taskCtx.GetDal(), data.Options.ConnectionId, boardId)
+	if err != nil {
+		return err
+	}
+	if projectId == 0 {
+		return errors.Default.New(fmt.Sprintf("the project of board %d is unknown, which is required by `epicWindowDays`", boardId))
+	}
+	scopeCriteria := projectEpicsCriteria(projectId)
+	err = recordCollectionQuery(taskCtx, rawDataSubTaskArgs, boardId, buildJql(orderBy, scopeCriteria, updatedSinceCriteria(since, data.TimeZone), userCriteria), since, incremental)
+	if err != nil {
+		return err
+	}
+	start := since
+	if start == nil {
+		start, err = getEarliestUpdated(data.ApiClient, data.ApiVersion().SearchPath(), buildJql("", scopeCriteria, userCriteria))
+		if err != nil {
+			return err
+		}
+	}
+	if start == nil {
+		logger.Info("no epic is found in the project of board %d", boardId)
+	}
+	pager := searchPager{logger: logger}
+	changelogs := newEpicChangelogFallback(logger, data, boardId)
+	args := helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: rawDataSubTaskArgs,
+		ApiClient:          data.ApiClient,
+		AfterSaveRawData:   data.RawExporter.AfterSave(taskCtx.GetContext(), boardId),
+		PageSize:           data.PageSize(100),
+		Incremental:        incremental,
+		UrlTemplate:        data.ApiVersion().SearchPath(),
+		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+			query := url.Values{}
+			window := reqData.Input.(*timeWindow)
+			query.Set("jql", buildJql(orderBy, scopeCriteria, window.updatedCriteria(data.TimeZone), userCriteria))
+			pager.SetQuery(query, reqData)
+			query.Set("fields", fields)
+			changelogs.SetQuery(query)
 			return query, nil
 		},
-		Input:         epicIterator,
-		GetTotalPages: GetTotalPagesFromResponse,
-		Concurrency:   10,
+		Input:                 newEpicTimeWindowIterator(data, start),
+		GetTotalPages:         pager.GetTotalPages,
+		GetPageSize:           GetPageSizeFromResponse,
+		GetNextPageCustomData: pager.GetNextPageCustomData,
+		DryRun:                data.Options.DryRun,
+		PageTimeout:           data.PageTimeout,
+		Concurrency:           data.Concurrency,
+		MinConcurrency:        data.Options.MinConcurrency,
+		MaxConcurrency:        data.Options.MaxConcurrency,
+		AfterResponse: chainAfterResponse(
+			newRateLimitObserver(logger).AfterResponse,
+			ignoreHTTPStatus404,
+			changelogs.AfterResponse,
+		),
+		ResponseParser:       pager.ResponseParser,
+		ResumeKey:            resumeKey,
+		RawBatchSize:         epicRawBatchSize,
+		SkipUnchangedRecords: incremental,
+		CompressRawData:      data.Options.CompressRawData,
+		MaxRecords:           limit.maxRecords(),
+		ScopeLock:            helper.NewDalScopeLock(taskCtx.GetDal()),
+		ScopeLockWait:        epicScopeLockWait,
+	}
+	sampleEpicPages(&args, data.Options)
+	watermark.apply(&args)
+	collector, err := helper.NewApiCollector(args)
+	if err != nil {
+		return err
+	}
+	return limit.execute(collector)
+}
+
+// newEpicChangelogFallback returns the fallback of the epic search of the board, see `changelogFallback`
+func newEpicChangelogFallback(logger core.Logger, data *JiraTaskData, boardId uint64) *changelogFallback {
+	return newChangelogFallback(logger, data.ApiClient, data.ApiVersion().SearchPath(), fmt.Sprintf(
+		"expanding changelogs is not permitted for connection %d, epics of board %d are collected without their changelogs",
+		data.Options.ConnectionId, boardId,
+	))
+}
+
+// projectEpicsCriteria returns the criteria matching the epics of the project
+func projectEpicsCriteria(projectId uint) string {
+	return fmt.Sprintf("issuetype = %s AND project = %d", epicIssueType, projectId)
+}
+
+// getEarliestUpdated returns the time the issue matched by the JQL earliest updated was updated, nil is returned if
+// the JQL matches nothing
+func getEarliestUpdated(apiClient helper.ApiClientGetter, searchPath string, jql string) (*time.Time, errors.Error) {
+	query := url.Values{
+		"jql":        {buildJql("updated ASC", jql)},
+		"maxResults": {"1"},
+		"fields":     {"updated"},
+	}
+	res, err := apiClient.Get(searchPath, query, nil)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to search for the earliest updated epic")
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to search for the earliest updated epic, unexpected status code: %d", res.StatusCode))
+	}
+	var body struct {
+		Issues []struct {
+			Fields struct {
+				Updated helper.Iso8601Time `json:"updated"`
+			} `json:"fields"`
+		} `json:"issues"`
+	}
+	err = helper.UnmarshalResponse(res, &body)
+	if err != nil {
+		return nil, err
+	}
+	if len(body.Issues) == 0 {
+		return nil, nil
+	}
+	return body.Issues[0].Fields.Updated.ToNullableTime(), nil
+}
+
+// updatedSinceCriteria returns the time range criteria of `since` if it was specified, either by user or from
+// database. Jira only accepts minute precision, the formatting truncates `since` down to the minute and `>=` keeps
+// the boundary inclusive, so epics updated at the boundary get re-collected instead of skipped, and the duplicated
+// raw rows are resolved by the extractor which processes them in id order. `since` is formatted in `location`, the
+// time zone of the Jira user, see formatJqlTime
+func updatedSinceCriteria(since *time.Time, location *time.Location) string {
+	if since == nil {
+		return ""
+	}
+	return fmt.Sprintf("updated >= '%s'", formatJqlTime(*since, location))
+}
+
+// recordCollectionQuery saves the JQL of a collection for auditing, the batches of epic keys and the time windows are
+// left out since they are derived from the data collected before. Nothing is recorded in DryRun mode
+func recordCollectionQuery(
+	taskCtx core.SubTaskContext,
+	args helper.RawDataSubTaskArgs,
+	boardId uint64,
+	jql string,
+	since *time.Time,
+	incremental bool,
+) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	if data.Options.DryRun {
+		return nil
+	}
+	rawDataSubTask, err := helper.NewRawDataSubTask(args)
+	if err != nil {
+		return err
+	}
+	err = taskCtx.GetDal().Create(&models.JiraCollectionQuery{
+		ConnectionId: data.Options.ConnectionId,
+		BoardId:      boardId,
+		SubtaskName:  taskCtx.GetName(),
+		RawTable:     rawDataSubTask.GetTable(),
+		Params:       rawDataSubTask.GetParams(),
+		Jql:          jql,
+		Since:        since,
+		Incremental:  incremental,
+	})
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to record the query of the collection")
+	}
+	return nil
+}
+
+// epicLimit is what is left of the Limit of the epic collector, it is shared by the collections of all boards
+type epicLimit struct {
+	limited bool
+	left    int
+}
+
+func newEpicLimit(limit int) *epicLimit {
+	return &epicLimit{limited: limit > 0, left: limit}
+}
+
+// reached tells if the limit was used up
+func (l *epicLimit) reached() bool {
+	return l.limited && l.left <= 0
+}
+
+// maxRecords returns the `MaxRecords` of the next collection, 0 if there is no limit
+func (l *epicLimit) maxRecords() int {
+	if !l.limited {
+		return 0
+	}
+	return l.left
+}
+
+// execute runs the collector and takes the records it collected off the limit
+func (l *epicLimit) execute(collector *helper.ApiCollector) errors.Error {
+	err := collector.Execute()
+	l.left -= collector.GetRecords()
+	return err
+}
+
+// archivedEpicInput is the key of an epic left out by the search
+type archivedEpicInput struct {
+	EpicKey string
+}
+
+// collectArchivedEpics collects the epics of the board left out by the search one by one by their keys. Archived
+// issues are excluded from JQL search by Jira Data Center (8.1+) and Jira Cloud Premium/Enterprise, while the issue
+// api still returns them to users allowed to browse them. Where they are not returned, i.e. Jira Server without
+// archiving or with the permission missing, the epics are skipped with a warning, as if the option was off
+func collectArchivedEpics(
+	taskCtx core.SubTaskContext,
+	rawDataSubTaskArgs helper.RawDataSubTaskArgs,
+	boardId uint64,
+	collectedBoardIds []uint64,
+	fields string,
+	limit *epicLimit,
+) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	rawDataSubTask, err := helper.NewRawDataSubTask(rawDataSubTaskArgs)
+	if err != nil {
+		return err
+	}
+	clauses := append(
+		uncollectedEpicKeysClauses(data.Options.ConnectionId, boardId, collectedBoardIds),
+		dal.Where(fmt.Sprintf(`
+			NOT EXISTS (
+				SELECT 1 FROM %s r WHERE r.params = ? AND r.record_key = i.epic_key
+			)
+		`, rawDataSubTask.GetTable()), rawDataSubTask.GetParams()),
+	)
+	cursor, err := db.Cursor(clauses...)
+	if err != nil {
+		return errors.Default.Wrap(err, "unable to query for archived epics")
+	}
+	iterator, err := helper.NewBatchedDalCursorIteratorWithContext(taskCtx.GetContext(), db, cursor, reflect.TypeOf(archivedEpicInput{}), -1)
+	if err != nil {
+		return err
+	}
+	// the epics of other shards are not in the raw rows of this one either
+	shardIterator := newEpicShardIterator(iterator, data.Options, func(elem interface{}) string {
+		return elem.(*archivedEpicInput).EpicKey
+	})
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: rawDataSubTaskArgs,
+		ApiClient:          data.ApiClient,
+		AfterSaveRawData:   data.RawExporter.AfterSave(taskCtx.GetContext(), boardId),
+		// the epics collected by the search are kept
+		Incremental: true,
+		UrlTemplate: data.ApiVersion().Path("issue/{{ .Input.EpicKey }}"),
+		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+			return url.Values{"fields": {fields}, "expand": {"changelog"}}, nil
+		},
+		Input:       shardIterator,
+		Concurrency: data.Concurrency,
+		PageTimeout: data.PageTimeout,
+		AfterResponse: chainAfterResponse(
+			newRateLimitObserver(logger).AfterResponse,
+			ignoreUnavailableEpic(logger, boardId),
+		),
 		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
-			var data struct {
-				Issues []json.RawMessage `json:"issues"`
-			}
-			blob, err := io.ReadAll(res.Body)
-			if err != nil {
-				return nil, errors.Convert(err)
-			}
-			err = json.Unmarshal(blob, &data)
+			var epic json.RawMessage
+			err := helper.UnmarshalResponse(res, &epic)
 			if err != nil {
-				return nil, errors.Convert(err)
+				return nil, err
 			}
-			return data.Issues, nil
+			return []json.RawMessage{epic}, nil
 		},
+		SkipUnchangedRecords: true,
+		CompressRawData:      data.Options.CompressRawData,
+		MaxRecords:           limit.maxRecords(),
+		ScopeLock:            helper.NewDalScopeLock(taskCtx.GetDal()),
+		ScopeLockWait:        epicScopeLockWait,
 	})
 	if err != nil {
 		return err
 	}
-	return collector.Execute()
+	return limit.execute(collector)
+}
+
+// ignoreUnavailableEpic skips the epics not returned by the issue api, since they are not archived but deleted, or
+// the edition doesn't return archived issues
+func ignoreUnavailableEpic(logger core.Logger, boardId uint64) common.ApiClientAfterResponse {
+	return func(res *http.Response) errors.Error {
+		if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusForbidden {
+			logger.Warn(nil, "epic %s of board %d is not available, skipping it", path.Base(res.Request.URL.Path), boardId)
+			return helper.ErrIgnoreAndContinue
+		}
+		return ignoreHTTPStatus404(res)
+	}
 }
 
-func GetEpicKeysIterator(db dal.Dal, data *JiraTaskData, batchSize int) (helper.Iterator, errors.Error) {
-	cursor, err := db.Cursor(
+func GetEpicKeysIterator(taskCtx core.SubTaskContext, boardId uint64, batchSize int) (helper.Iterator, errors.Error) {
+	return GetUncollectedEpicKeysIterator(taskCtx, boardId, nil, batchSize)
+}
+
+// GetUncollectedEpicKeysIterator iterates the epic keys of the board, leaving out the epics shared with any board of
+// `collectedBoardIds`, so an epic appearing under several boards of the connection is collected once only.
+// Epics are put onto boards by their child issues rather than by the raw rows they were collected into, so leaving
+// them out doesn't affect the board membership. The deduplication is done by the database to keep the memory bounded.
+// The iteration stops once the subtask is cancelled, rather than reading the cursor till the end
+func GetUncollectedEpicKeysIterator(
+	taskCtx core.SubTaskContext,
+	boardId uint64,
+	collectedBoardIds []uint64,
+	batchSize int,
+) (helper.Iterator, errors.Error) {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*JiraTaskData)
+	clauses := uncollectedEpicKeysClauses(data.Options.ConnectionId, boardId, collectedBoardIds)
+	cursor, err := db.Cursor(clauses...)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "unable to query for external epics")
+	}
+	iter, err := helper.NewBatchedDalCursorIteratorWithContext(taskCtx.GetContext(), db, cursor, reflect.TypeOf(""), batchSize)
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// uncollectedEpicKeysClauses queries the epic keys of the board except the epics shared with `collectedBoardIds`
+func uncollectedEpicKeysClauses(connectionId uint64, boardId uint64, collectedBoardIds []uint64) []dal.Clause {
+	clauses := []dal.Clause{
 		dal.Select("DISTINCT epic_key"),
 		dal.From("_tool_jira_issues i"),
 		dal.Join(`
@@ -125,15 +664,253 @@ func GetEpicKeysIterator(db dal.Dal, data *JiraTaskData, batchSize int) (helper.
 			bi.board_id = ?
 			AND
 			i.epic_key != ''
-		`, data.Options.ConnectionId, data.Options.BoardId,
+		`, connectionId, boardId,
 		),
+	}
+	if len(collectedBoardIds) > 0 {
+		clauses = append(clauses, dal.Where(`
+			NOT EXISTS (
+				SELECT 1 FROM _tool_jira_issues ci
+				JOIN _tool_jira_board_issues cbi ON (
+					cbi.connection_id = ci.connection_id
+					AND
+					cbi.issue_id = ci.issue_id
+				)
+				WHERE ci.connection_id = i.connection_id AND ci.epic_key = i.epic_key AND cbi.board_id IN ?
+			)
+		`, collectedBoardIds))
+	}
+	return clauses
+}
+
+// epicFilterCriteria AND-s the filters selected by the options, i.e. the labels, the status categories, the users
+// and the user-supplied JQL, they stay the same across the batches of epic keys
+func epicFilterCriteria(op *JiraOptions) string {
+	return buildJql(
+		"",
+		labelsCriteria(op.Labels),
+		statusCategoriesCriteria(op.StatusCategories),
+		updatedByCriteria(op.UpdatedBy),
+		userJqlCriteria(op.Jql),
 	)
-	if err != nil {
-		return nil, errors.Default.Wrap(err, "unable to query for external epics")
+}
+
+func buildEpicJql(orderBy string, epicKeys []string, updatedCriteria, userCriteria string) string {
+	return buildJql(orderBy, epicKeysCriteria(epicKeys), updatedCriteria, userCriteria)
+}
+
+func epicKeysCriteria(epicKeys []string) string {
+	return fmt.Sprintf("issue in (%s)", jqlValues(epicKeys))
+}
+
+// jqlLimitedEpicKeysIterator splits batches of epic keys, so that the `issue in (...)` criteria generated for a batch
+// wouldn't exceed the given length. Batches pushed back by `Retry` are fetched before the underlying iterator
+type jqlLimitedEpicKeysIterator struct {
+	helper.Iterator
+	maxLength int
+	mu        sync.Mutex
+	pending   [][]interface{}
+}
+
+func newJqlLimitedEpicKeysIterator(iterator helper.Iterator, maxLength int) *jqlLimitedEpicKeysIterator {
+	return &jqlLimitedEpicKeysIterator{
+		Iterator:  iterator,
+		maxLength: maxLength,
+	}
+}
+
+// HasNext returns true if there are split batches pending or the underlying iterator has more
+func (it *jqlLimitedEpicKeysIterator) HasNext() bool {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return len(it.pending) > 0 || it.Iterator.HasNext()
+}
+
+// Fetch returns a batch of epic keys which fits into the length limit
+func (it *jqlLimitedEpicKeysIterator) Fetch() (interface{}, errors.Error) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if len(it.pending) == 0 {
+		batch, err := it.Iterator.Fetch()
+		if err != nil {
+			return nil, err
+		}
+		it.pending = splitEpicKeys(batch.([]interface{}), it.maxLength)
+	}
+	next := it.pending[0]
+	it.pending = it.pending[1:]
+	return next, nil
+}
+
+// Retry pushes a batch of epic keys back to be collected again, it is safe to be called from the api workers
+func (it *jqlLimitedEpicKeysIterator) Retry(epicKeys []string) {
+	batch := make([]interface{}, len(epicKeys))
+	for i := range epicKeys {
+		batch[i] = &epicKeys[i]
+	}
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	it.pending = append(it.pending, batch)
+}
+
+func splitEpicKeys(epicKeys []interface{}, maxLength int) [][]interface{} {
+	var batches [][]interface{}
+	var batch []interface{}
+	length := len(epicKeysCriteria(nil))
+	for _, e := range epicKeys {
+		keyLength := len(jqlValue(*e.(*string)))
+		if len(batch) > 0 {
+			// the comma separator
+			keyLength++
+		}
+		// a key is put into a batch alone if it doesn't fit into the limit by itself
+		if len(batch) > 0 && length+keyLength > maxLength {
+			batches = append(batches, batch)
+			batch = nil
+			length = len(epicKeysCriteria(nil))
+			keyLength--
+		}
+		batch = append(batch, e)
+		length += keyLength
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+var nonexistentIssuePatterns = []*regexp.Regexp{
+	// Jira Cloud
+	regexp.MustCompile(`An issue with key '([^']+)' does not exist`),
+	// Jira Server/Data Center
+	regexp.MustCompile(`The issue key '([^']+)' for field '[^']+' is invalid`),
+}
+
+// epicKeysCriteriaPattern matches the criteria generated by `epicKeysCriteria` and `epicChildrenCriteria`
+var epicKeysCriteriaPattern = regexp.MustCompile(`(?:issue|"Epic Link") in \(((?:"(?:[^"\\]|\\.)*"|[^)"])*)\)`)
+
+// ignoreNonexistentEpics returns an AfterResponse handler which classifies 400 responses caused by nonexistent epic
+// keys as skippable, the offending keys get logged and the rest of the batch is pushed back to be collected again.
+// Other failures are left to the api client as usual
+func ignoreNonexistentEpics(logger core.Logger, iterator *jqlLimitedEpicKeysIterator) common.ApiClientAfterResponse {
+	return func(res *http.Response) errors.Error {
+		if res.StatusCode == http.StatusUnauthorized {
+			return errors.Unauthorized.New("authentication failed, please check your AccessToken")
+		}
+		if res.StatusCode != http.StatusBadRequest {
+			return nil
+		}
+		blob, err := io.ReadAll(res.Body)
+		if err != nil {
+			return errors.Convert(err)
+		}
+		res.Body.Close()
+		res.Body = io.NopCloser(bytes.NewBuffer(blob))
+		nonexistentKeys := getNonexistentIssueKeys(blob)
+		if len(nonexistentKeys) == 0 {
+			return nil
+		}
+		var remainingKeys []string
+		if matches := epicKeysCriteriaPattern.FindStringSubmatch(res.Request.URL.Query().Get("jql")); matches != nil {
+			for _, key := range parseJqlValues(matches[1]) {
+				if !nonexistentKeys[key] {
+					remainingKeys = append(remainingKeys, key)
+				}
+			}
+		}
+		missing := make([]string, 0, len(nonexistentKeys))
+		for key := range nonexistentKeys {
+			missing = append(missing, key)
+		}
+		sort.Strings(missing)
+		logger.Warn(nil, "epics %v do not exist anymore, skipping them", missing)
+		if len(remainingKeys) > 0 {
+			iterator.Retry(remainingKeys)
+		}
+		return helper.ErrIgnoreAndContinue
+	}
+}
+
+// getNonexistentIssueKeys returns the keys mentioned by the error messages of the response body, nil is returned if
+// any of the error messages is about something else
+func getNonexistentIssueKeys(body []byte) map[string]bool {
+	var errorResponse struct {
+		ErrorMessages []string `json:"errorMessages"`
 	}
-	iter, err := helper.NewBatchedDalCursorIterator(db, cursor, reflect.TypeOf(""), batchSize)
+	if json.Unmarshal(body, &errorResponse) != nil || len(errorResponse.ErrorMessages) == 0 {
+		return nil
+	}
+	keys := make(map[string]bool)
+	for _, message := range errorResponse.ErrorMessages {
+		matched := false
+		for _, pattern := range nonexistentIssuePatterns {
+			if matches := pattern.FindStringSubmatch(message); matches != nil {
+				keys[matches[1]] = true
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+	}
+	return keys
+}
+
+// getLatestCollected returns the time the latest raw row was collected into the raw table for the given scope, nil is
+// returned when nothing was collected before, or since the collection was reset, so the collector would fall back to
+// a full collection
+func getLatestCollected(db dal.Dal, args helper.RawDataSubTaskArgs) (*time.Time, errors.Error) {
+	rawDataSubTask, err := helper.NewRawDataSubTask(args)
 	if err != nil {
 		return nil, err
 	}
-	return iter, nil
+	// make sure the raw table exists for the very first collection
+	err = db.AutoMigrate(&helper.RawData{}, dal.From(rawDataSubTask.GetTable()))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("error auto-migrating raw table %s", rawDataSubTask.GetTable()))
+	}
+	return getLatestCollectedOf(db, rawDataSubTask.GetTable(), rawDataSubTask.GetParams())
+}
+
+// getLatestCollectedOf is getLatestCollected by the raw table and the params as they are saved, the raw table is
+// expected to exist. The rows collected till the reset of the collection, see ResetCollectionState, don't count
+func getLatestCollectedOf(db dal.Dal, table string, params string) (*time.Time, errors.Error) {
+	var latestCollected helper.RawData
+	err := db.First(
+		&latestCollected,
+		dal.From(table),
+		dal.Where("params = ?", params),
+		dal.Orderby("created_at DESC"),
+	)
+	if err != nil {
+		if goerror.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to get latest collected row of %s", table))
+	}
+	reset := &models.JiraCollectionReset{}
+	err = db.First(reset, dal.Where("raw_table = ? AND params = ?", table, params))
+	if err != nil && !goerror.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to get the reset of the collection into %s", table))
+	}
+	if err == nil && !latestCollected.CreatedAt.After(reset.ResetAt) {
+		return nil, nil
+	}
+	return &latestCollected.CreatedAt, nil
+}
+
+// extractEpicKey extracts the key of an epic, which identifies it along with the connection and the board
+func extractEpicKey(msg json.RawMessage) (string, errors.Error) {
+	var epic struct {
+		Key string `json:"key"`
+	}
+	err := json.Unmarshal(msg, &epic)
+	if err != nil {
+		return "", errors.Default.Wrap(err, "failed to parse the epic")
+	}
+	if epic.Key == "" {
+		return "", errors.Default.New("the epic has no key")
+	}
+	return epic.Key, nil
 }
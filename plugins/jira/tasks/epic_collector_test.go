@@ -0,0 +1,566 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// batchesIterator yields the given batches in order
+type batchesIterator struct {
+	batches [][]interface{}
+}
+
+func (it *batchesIterator) HasNext() bool {
+	return len(it.batches) > 0
+}
+
+func (it *batchesIterator) Fetch() (interface{}, errors.Error) {
+	next := it.batches[0]
+	it.batches = it.batches[1:]
+	return next, nil
+}
+
+func (it *batchesIterator) Close() errors.Error {
+	return nil
+}
+
+func TestJqlLimitedEpicKeysIterator(t *testing.T) {
+	// 500 long keys in batches of 100
+	inner := &batchesIterator{}
+	var batch []interface{}
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("AVERYLONGPROJECTKEYFOREPICS-%d", 100000+i)
+		batch = append(batch, &key)
+		if len(batch) == 100 {
+			inner.batches = append(inner.batches, batch)
+			batch = nil
+		}
+	}
+	updatedCriteria := "updated >= '2022/11/01 08:00'"
+	userCriteria := userJqlCriteria("labels = roadmap")
+	overhead := len(buildEpicJql(defaultEpicOrderBy, nil, updatedCriteria, userCriteria)) - len(epicKeysCriteria(nil))
+	iter := newJqlLimitedEpicKeysIterator(inner, maxEpicJqlLength-overhead)
+
+	collected := map[string]bool{}
+	batches := 0
+	for iter.HasNext() {
+		keys, err := iter.Fetch()
+		assert.Nil(t, err)
+		var epicKeys []string
+		for _, e := range keys.([]interface{}) {
+			epicKeys = append(epicKeys, *e.(*string))
+			collected[*e.(*string)] = true
+		}
+		jql := buildEpicJql(defaultEpicOrderBy, epicKeys, updatedCriteria, userCriteria)
+		assert.LessOrEqual(t, len(jql), maxEpicJqlLength)
+		batches++
+	}
+	assert.Equal(t, 500, len(collected))
+	assert.Greater(t, batches, 5)
+}
+
+func TestSplitEpicKeys(t *testing.T) {
+	k1, k2, k3 := "K-1", "K-2", "K-100"
+	// `issue in ("K-1","K-2")` is 22 bytes long
+	batches := splitEpicKeys([]interface{}{&k1, &k2, &k3}, 22)
+	assert.Equal(t, [][]interface{}{{&k1, &k2}, {&k3}}, batches)
+	// a key longer than the limit is put into a batch alone
+	batches = splitEpicKeys([]interface{}{&k1, &k3, &k2}, 5)
+	assert.Equal(t, [][]interface{}{{&k1}, {&k3}, {&k2}}, batches)
+}
+
+func newSearchResponse(statusCode int, jql string, body string) *http.Response {
+	query := url.Values{}
+	query.Set("jql", jql)
+	return &http.Response{
+		StatusCode: statusCode,
+		Request: &http.Request{
+			URL: &url.URL{Path: "api/2/search", RawQuery: query.Encode()},
+		},
+		Body: io.NopCloser(bytes.NewBufferString(body)),
+	}
+}
+
+func TestIgnoreNonexistentEpics(t *testing.T) {
+	iter := newJqlLimitedEpicKeysIterator(&batchesIterator{}, maxEpicJqlLength)
+	logger := new(mocks.Logger)
+	logger.On("Warn", nil, mock.Anything, mock.Anything).Once()
+	handler := ignoreNonexistentEpics(logger, iter)
+	jql := buildEpicJql(defaultEpicOrderBy, []string{"K-1", "K-2", "K-3"}, "", "")
+
+	// nonexistent keys are skipped and the rest of the batch is collected again
+	res := newSearchResponse(http.StatusBadRequest, jql, `{"errorMessages":[
+		"An issue with key 'K-2' does not exist for field 'issue'.",
+		"The issue key 'K-3' for field 'issue' is invalid."
+	],"errors":{}}`)
+	assert.Equal(t, helper.ErrIgnoreAndContinue, handler(res))
+	assert.True(t, iter.HasNext())
+	batch, err := iter.Fetch()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(batch.([]interface{})))
+	assert.Equal(t, "K-1", *batch.([]interface{})[0].(*string))
+	assert.False(t, iter.HasNext())
+
+	// other bad requests are left to the api client, with the body intact
+	body := `{"errorMessages":["Error in the JQL Query: Expecting either 'OR' or 'AND'"],"errors":{}}`
+	res = newSearchResponse(http.StatusBadRequest, jql, body)
+	assert.Nil(t, handler(res))
+	blob, _ := io.ReadAll(res.Body)
+	assert.Equal(t, body, string(blob))
+	assert.False(t, iter.HasNext())
+
+	// authentication failures abort the collection
+	res = newSearchResponse(http.StatusUnauthorized, jql, "")
+	err = handler(res)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.Unauthorized, err.GetType())
+	}
+
+	assert.Nil(t, handler(newSearchResponse(http.StatusOK, jql, `{"issues":[]}`)))
+	logger.AssertExpectations(t)
+}
+
+func TestBuildEpicJqlOrderBy(t *testing.T) {
+	cases := map[string]string{
+		"":              "created ASC, key ASC",
+		"created":       "created ASC, key ASC",
+		"created DESC":  "created DESC, key ASC",
+		"updated ASC":   "updated ASC, key ASC",
+		" Updated desc": "updated DESC, key ASC",
+		"key":           "key ASC",
+		"key DESC":      "key DESC",
+	}
+	for option, orderBy := range cases {
+		op := &JiraOptions{EpicOrderBy: option}
+		actual, err := op.GetEpicOrderBy()
+		assert.Nil(t, err, option)
+		assert.Equal(t,
+			fmt.Sprintf(`issue in ("K-1") AND updated >= '2022/11/01 08:00' ORDER BY %s`, orderBy),
+			buildEpicJql(actual, []string{"K-1"}, "updated >= '2022/11/01 08:00'", ""),
+			option,
+		)
+	}
+	invalid := []string{
+		"summary ASC",
+		"created UP",
+		"created ASC, updated DESC",
+		"created ASC; DROP",
+		"created ASC OR 1=1",
+	}
+	for _, option := range invalid {
+		op := &JiraOptions{EpicOrderBy: option}
+		_, err := op.GetEpicOrderBy()
+		if assert.NotNil(t, err, option) {
+			assert.Equal(t, errors.BadInput, err.GetType(), option)
+		}
+	}
+}
+
+func TestBuildEpicJqlLabels(t *testing.T) {
+	since := "updated >= '2022/11/01 08:00'"
+	op := &JiraOptions{Labels: []string{"roadmap"}}
+	assert.Equal(t,
+		`issue in ("K-1") AND updated >= '2022/11/01 08:00' AND labels in ("roadmap") ORDER BY created ASC`,
+		buildEpicJql("created ASC", []string{"K-1"}, since, epicFilterCriteria(op)),
+	)
+	op = &JiraOptions{Labels: []string{"roadmap", " ", "Q1 2023", `say "hi"`}, Jql: "status = Done OR priority = High"}
+	assert.Equal(t,
+		`issue in ("K-1") AND labels in ("roadmap","Q1 2023","say \"hi\"") AND (status = Done OR priority = High) ORDER BY created ASC`,
+		buildEpicJql("created ASC", []string{"K-1"}, "", epicFilterCriteria(op)),
+	)
+	// all epics are collected without labels
+	assert.Equal(t,
+		`issue in ("K-1") ORDER BY created ASC`,
+		buildEpicJql("created ASC", []string{"K-1"}, "", epicFilterCriteria(&JiraOptions{Labels: []string{""}})),
+	)
+}
+
+func TestBuildEpicJqlStatusCategories(t *testing.T) {
+	since := "updated >= '2022/11/01 08:00'"
+	op := &JiraOptions{StatusCategories: []string{"To Do", "indeterminate"}, Labels: []string{"roadmap"}}
+	assert.Nil(t, op.ValidateStatusCategories())
+	assert.Equal(t,
+		`issue in ("K-1") AND updated >= '2022/11/01 08:00' AND labels in ("roadmap") AND statusCategory in ("To Do","In Progress") ORDER BY created ASC`,
+		buildEpicJql("created ASC", []string{"K-1"}, since, epicFilterCriteria(op)),
+	)
+	op = &JiraOptions{StatusCategories: []string{"done"}, Jql: "priority = High"}
+	assert.Equal(t,
+		`issue in ("K-1") AND statusCategory in ("Done") AND (priority = High) ORDER BY created ASC`,
+		buildEpicJql("created ASC", []string{"K-1"}, "", epicFilterCriteria(op)),
+	)
+	err := (&JiraOptions{StatusCategories: []string{"In Progress", "Closed"}}).ValidateStatusCategories()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `unknown status category "Closed"`)
+}
+
+func TestBuildEpicJqlUpdatedBy(t *testing.T) {
+	op := &JiraOptions{UpdatedBy: []string{"5b10ac8d82e05b22cc7d4ef5", "557058:f58131cb-b67d-43c7-b30d-6b58d40bd077"}, Labels: []string{"roadmap"}, Jql: "priority = High"}
+	assert.Nil(t, op.ValidateUpdatedBy(models.DeploymentCloud))
+	assert.Equal(t,
+		`issue in ("K-1") AND labels in ("roadmap") AND (reporter in ("5b10ac8d82e05b22cc7d4ef5","557058:f58131cb-b67d-43c7-b30d-6b58d40bd077") OR assignee in ("5b10ac8d82e05b22cc7d4ef5","557058:f58131cb-b67d-43c7-b30d-6b58d40bd077")) AND (priority = High) ORDER BY created ASC`,
+		buildEpicJql("created ASC", []string{"K-1"}, "", epicFilterCriteria(op)),
+	)
+	// usernames of Jira Data Center are escaped like any other value
+	op = &JiraOptions{UpdatedBy: []string{"john.doe", `o"brien`}}
+	assert.Nil(t, op.ValidateUpdatedBy(models.DeploymentServer))
+	assert.Equal(t,
+		`issue in ("K-1") AND (reporter in ("john.doe","o\"brien") OR assignee in ("john.doe","o\"brien")) ORDER BY created ASC`,
+		buildEpicJql("created ASC", []string{"K-1"}, "", epicFilterCriteria(op)),
+	)
+	// Jira Cloud doesn't accept usernames or emails in JQL
+	err := (&JiraOptions{UpdatedBy: []string{"john.doe@example.com"}}).ValidateUpdatedBy(models.DeploymentCloud)
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.BadInput, err.GetType())
+	assert.Contains(t, err.Error(), "not an account id")
+	assert.NotNil(t, (&JiraOptions{UpdatedBy: []string{" "}}).ValidateUpdatedBy(models.DeploymentServer))
+	assert.NotNil(t, (&JiraOptions{ConnectionId: 1, BoardId: 8, UpdatedBy: []string{""}}).ValidateOptions())
+}
+
+func TestCollectEpicsRejectsZeroedOptions(t *testing.T) {
+	cases := []struct {
+		options  *JiraOptions
+		expected string
+	}{
+		{&JiraOptions{BoardId: 1}, "jira connectionId is invalid"},
+		{&JiraOptions{ConnectionId: 1}, "jira boardId is invalid"},
+		{&JiraOptions{ConnectionId: 1, BoardIds: []uint64{1, 0}}, "jira boardId is invalid"},
+	}
+	for _, c := range cases {
+		// nothing is queried for invalid options
+		mockCtx := unithelper.DummySubTaskContext(new(mocks.Dal))
+		mockCtx.On("GetData").Return(&JiraTaskData{Options: c.options})
+		err := CollectEpics(mockCtx)
+		if assert.NotNil(t, err, c.expected) {
+			assert.Equal(t, errors.BadInput, err.GetType(), c.expected)
+			assert.Contains(t, err.Error(), c.expected)
+		}
+	}
+}
+
+func TestExtractEpicKey(t *testing.T) {
+	key, err := extractEpicKey(json.RawMessage(`{"id":"10001","key":"EPIC-1","fields":{}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "EPIC-1", key)
+	_, err = extractEpicKey(json.RawMessage(`{"id":"10001"}`))
+	assert.NotNil(t, err)
+	_, err = extractEpicKey(json.RawMessage(`[`))
+	assert.NotNil(t, err)
+}
+
+func TestIgnoreUnavailableEpic(t *testing.T) {
+	logger := new(mocks.Logger)
+	logger.On("Warn", nil, mock.Anything, mock.Anything).Twice()
+	handler := ignoreUnavailableEpic(logger, 1)
+	newResponse := func(statusCode int) *http.Response {
+		return &http.Response{
+			StatusCode: statusCode,
+			Request:    &http.Request{URL: &url.URL{Path: "api/2/issue/EPIC-1"}},
+		}
+	}
+	// an archived epic which is not returned on this edition or to this user
+	assert.Equal(t, helper.ErrIgnoreAndContinue, handler(newResponse(http.StatusNotFound)))
+	assert.Equal(t, helper.ErrIgnoreAndContinue, handler(newResponse(http.StatusForbidden)))
+	assert.Nil(t, handler(newResponse(http.StatusOK)))
+	err := handler(newResponse(http.StatusUnauthorized))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.Unauthorized, err.GetType())
+	}
+	logger.AssertExpectations(t)
+}
+
+func TestCollectArchivedEpicsQuery(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	var clauses []dal.Clause
+	mockDal.On("Cursor", mock.Anything).Run(func(args mock.Arguments) {
+		clauses = args.Get(0).([]dal.Clause)
+	}).Return(nil, errors.Default.New("stop")).Once()
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+	mockCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{ConnectionId: 1, BoardId: 2}})
+
+	err := collectArchivedEpics(mockCtx, helper.RawDataSubTaskArgs{
+		Ctx:    mockCtx,
+		Params: JiraApiParams{ConnectionId: 1, BoardId: 2},
+		Table:  RAW_EPIC_TABLE,
+	}, 2, []uint64{1}, allEpicFields, newEpicLimit(0))
+	assert.NotNil(t, err)
+	// epics saved into the raw table of the board by the search are left out
+	if assert.Len(t, clauses, 6) {
+		where := clauses[5].Data.(dal.DalClause)
+		assert.Contains(t, where.Expr, "FROM _raw_jira_api_epics r WHERE r.params = ? AND r.record_key = i.epic_key")
+		assert.Equal(t, []interface{}{`{"ConnectionId":1,"BoardId":2}`}, where.Params)
+	}
+}
+
+func TestRecordCollectionQuery(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	var recorded *models.JiraCollectionQuery
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(0).(*models.JiraCollectionQuery)
+	}).Return(nil).Once()
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+	mockCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{ConnectionId: 1, BoardId: 2}})
+
+	since := time.Date(2022, 11, 1, 8, 0, 0, 0, time.UTC)
+	// long filters are stored in full
+	jql := buildJql("created ASC", "updated >= '2022/11/01 08:00'", userJqlCriteria(strings.Repeat("labels = roadmap OR ", 200)+"labels = q1"))
+	err := recordCollectionQuery(mockCtx, helper.RawDataSubTaskArgs{
+		Ctx:    mockCtx,
+		Params: JiraApiParams{ConnectionId: 1, BoardId: 2},
+		Table:  RAW_EPIC_TABLE,
+	}, 2, jql, &since, true)
+	assert.Nil(t, err)
+	if assert.NotNil(t, recorded) {
+		assert.Equal(t, jql, recorded.Jql)
+		assert.Equal(t, "_raw_jira_api_epics", recorded.RawTable)
+		assert.Equal(t, `{"ConnectionId":1,"BoardId":2}`, recorded.Params)
+		assert.Equal(t, uint64(2), recorded.BoardId)
+		assert.Equal(t, "test", recorded.SubtaskName)
+		assert.Equal(t, &since, recorded.Since)
+		assert.True(t, recorded.Incremental)
+	}
+}
+
+func TestGetLatestCollectedDecidesMode(t *testing.T) {
+	collected := time.Date(2022, 11, 2, 9, 30, 0, 0, time.UTC)
+	cases := []struct {
+		name                string
+		rows                []*helper.RawData
+		reset               *models.JiraCollectionReset
+		expectedSince       *time.Time
+		expectedIncremental bool
+	}{
+		{name: "nothing collected before makes a full collection"},
+		{name: "rows collected before make an incremental collection", rows: []*helper.RawData{{ID: 1, CreatedAt: collected}}, expectedSince: &collected, expectedIncremental: true},
+		{name: "rows collected before the reset make a full collection", rows: []*helper.RawData{{ID: 1, CreatedAt: collected}}, reset: &models.JiraCollectionReset{ResetAt: collected.Add(time.Hour)}},
+		{name: "rows collected after the reset make an incremental collection", rows: []*helper.RawData{{ID: 1, CreatedAt: collected}}, reset: &models.JiraCollectionReset{ResetAt: collected.Add(-time.Hour)}, expectedSince: &collected, expectedIncremental: true},
+	}
+	for _, c := range cases {
+		mockDal := new(mocks.Dal)
+		mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+		mockDal.On("First", mock.Anything, mock.Anything).Return(func(dst interface{}, clauses ...dal.Clause) errors.Error {
+			switch dst := dst.(type) {
+			case *helper.RawData:
+				if len(c.rows) == 0 {
+					return errors.Convert(gorm.ErrRecordNotFound)
+				}
+				*dst = *c.rows[0]
+			case *models.JiraCollectionReset:
+				if c.reset == nil {
+					return errors.Convert(gorm.ErrRecordNotFound)
+				}
+				*dst = *c.reset
+			}
+			return nil
+		})
+		mockCtx := unithelper.DummySubTaskContext(mockDal)
+		data := &JiraTaskData{Options: &JiraOptions{ConnectionId: 1, BoardId: 2}}
+		since, incremental, err := getCollectionSince(unithelper.DummyLogger(), data, func() (*time.Time, errors.Error) {
+			return getLatestCollected(mockDal, helper.RawDataSubTaskArgs{
+				Ctx:    mockCtx,
+				Params: JiraApiParams{ConnectionId: 1, BoardId: 2},
+				Table:  RAW_EPIC_TABLE,
+			})
+		})
+		assert.Nil(t, err, c.name)
+		assert.Equal(t, c.expectedSince, since, c.name)
+		assert.Equal(t, c.expectedIncremental, incremental, c.name)
+		mockDal.AssertExpectations(t)
+	}
+}
+
+func TestEpicLimit(t *testing.T) {
+	unlimited := newEpicLimit(0)
+	assert.False(t, unlimited.reached())
+	assert.Equal(t, 0, unlimited.maxRecords())
+
+	limit := newEpicLimit(150)
+	assert.False(t, limit.reached())
+	assert.Equal(t, 150, limit.maxRecords())
+	limit.left -= 100
+	assert.Equal(t, 50, limit.maxRecords())
+	limit.left -= 50
+	assert.True(t, limit.reached())
+}
+
+// resultRecordingSubTaskContext records the results reported by the subtask
+type resultRecordingSubTaskContext struct {
+	*mocks.SubTaskContext
+	results []core.SubTaskResult
+}
+
+func (c *resultRecordingSubTaskContext) ReportSubTaskResult(result core.SubTaskResult) {
+	c.results = append(c.results, result)
+}
+
+func TestWarnEmptyEpics(t *testing.T) {
+	cases := []struct {
+		threshold int
+		keys      int
+		records   int
+		warned    bool
+	}{
+		{threshold: 0, keys: 3, records: 0, warned: true},
+		{threshold: 0, keys: 3, records: 2},
+		{threshold: 0, keys: 0, records: 0},
+		{threshold: 5, keys: 3, records: 0},
+		{threshold: 5, keys: 5, records: 0, warned: true},
+		{threshold: -1, keys: 3, records: 0},
+	}
+	for _, c := range cases {
+		mockCtx := unithelper.DummySubTaskContext(new(mocks.Dal))
+		mockCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{EmptyEpicsWarningKeys: c.threshold}})
+		ctx := &resultRecordingSubTaskContext{SubTaskContext: mockCtx}
+		warnEmptyEpics(ctx, 2, c.keys, c.records)
+		if c.warned {
+			if assert.Len(t, ctx.results, 1, "%+v", c) {
+				assert.Equal(t, []string{fmt.Sprintf("no epic of board 2 was collected though %d epic keys were searched, please check the board, the permissions of the connection and the filters", c.keys)}, ctx.results[0].Warnings)
+			}
+		} else {
+			assert.Empty(t, ctx.results, "%+v", c)
+		}
+	}
+}
+
+func TestCountingEpicKeysIterator(t *testing.T) {
+	iterator := &countingEpicKeysIterator{Iterator: &batchesIterator{batches: epicKeyBatches(10, 4)}}
+	for iterator.HasNext() {
+		_, err := iterator.Fetch()
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 10, iterator.keys)
+}
+
+// searchSameSecondEpics pages through epics created in a few seconds like Jira does, the epics are sorted by the
+// ORDER BY of the JQL, while the order of the epics tied by it is arbitrary and changes from request to request
+func searchSameSecondEpics(jql string, startAt, maxResults int, request int64) []string {
+	type epic struct {
+		key     string
+		number  int
+		created int
+	}
+	var epics []epic
+	for i := 1; i <= 250; i++ {
+		epics = append(epics, epic{key: fmt.Sprintf("EPIC-%d", i), number: i, created: i % 3})
+	}
+	rand.New(rand.NewSource(request)).Shuffle(len(epics), func(i, j int) {
+		epics[i], epics[j] = epics[j], epics[i]
+	})
+	terms := strings.Split(jql[strings.Index(jql, "ORDER BY ")+len("ORDER BY "):], ", ")
+	sort.SliceStable(epics, func(i, j int) bool {
+		for _, term := range terms {
+			field, direction := strings.Fields(term)[0], strings.Fields(term)[1]
+			a, b := epics[i].created, epics[j].created
+			if field == "key" {
+				a, b = epics[i].number, epics[j].number
+			}
+			if a != b {
+				return (a < b) == (direction == "ASC")
+			}
+		}
+		return false
+	})
+	var keys []string
+	for i := startAt; i < startAt+maxResults && i < len(epics); i++ {
+		keys = append(keys, epics[i].key)
+	}
+	return keys
+}
+
+func TestEpicOrderByPaginatesDeterministically(t *testing.T) {
+	for _, option := range []string{"", "created DESC", "key DESC"} {
+		orderBy, err := (&JiraOptions{EpicOrderBy: option}).GetEpicOrderBy()
+		assert.Nil(t, err, option)
+		jql := buildJql(orderBy, "issuetype = Epic")
+		collected := map[string]int{}
+		for page := 0; page < 3; page++ {
+			for _, key := range searchSameSecondEpics(jql, page*100, 100, int64(page)) {
+				collected[key]++
+			}
+		}
+		// every epic is collected exactly once across the pages
+		assert.Len(t, collected, 250, option)
+		for key, count := range collected {
+			assert.Equal(t, 1, count, "%s of %s", key, option)
+		}
+	}
+}
+
+func TestCollectEpicsDryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("%s was requested in dry-run mode", r.URL)
+	}))
+	defer server.Close()
+	taskCtx := new(mocks.TaskContext)
+	taskCtx.On("GetConfig", mock.Anything).Return("")
+	taskCtx.On("GetLogger").Return(unithelper.DummyLogger())
+	taskCtx.On("GetContext").Return(context.Background())
+	apiClient := &helper.ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	asyncClient, err := helper.CreateAsyncApiClient(taskCtx, apiClient, &helper.ApiRateLimitCalculator{UserRateLimitPerHour: 360000})
+	assert.Nil(t, err)
+	defer asyncClient.Release()
+
+	// 25 epic keys on the board, searched by batches of 10
+	mockDal := new(mocks.Dal)
+	mockDal.On("All", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Count", mock.Anything).Return(int64(25), nil)
+	mockDal.On("Cursor", mock.Anything).Return(epicKeysCursor(25), nil)
+	mockDal.On("First", mock.Anything, mock.Anything).Return(errors.Convert(gorm.ErrRecordNotFound))
+	mockDal.On("Pluck", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+	mockCtx.On("GetData").Return(&JiraTaskData{
+		Options:   &JiraOptions{ConnectionId: 1, BoardId: 2, DryRun: true, EpicKeysBatchSize: 10},
+		ApiClient: asyncClient,
+	})
+	ctx := &resultRecordingSubTaskContext{SubTaskContext: mockCtx}
+	assert.Nil(t, CollectEpics(ctx))
+
+	// a page of a batch of keys is requested for each batch
+	var requests int
+	for _, result := range ctx.results {
+		requests += result.DryRunRequests
+	}
+	assert.Equal(t, 3, requests)
+	mockDal.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockDal.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,126 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+var _ core.SubTaskEntryPoint = ExtractEpicComments
+
+var ExtractEpicCommentsMeta = core.SubTaskMeta{
+	Name:             "extractEpicComments",
+	EntryPoint:       ExtractEpicComments,
+	EnabledByDefault: false,
+	Description:      "extract the comment counts of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+	DependsOn:        []string{"collectEpicComments"},
+}
+
+func ExtractEpicComments(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	for _, boardId := range data.Options.GetBoardIds() {
+		err := extractBoardEpicComments(taskCtx, boardId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractBoardEpicComments(taskCtx core.SubTaskContext, boardId uint64) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	connectionId := data.Options.ConnectionId
+	extractor, err := helper.NewApiExtractor(helper.ApiExtractorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: connectionId,
+				BoardId:      boardId,
+			},
+			Table: data.Options.RawTable(RAW_EPIC_COMMENT_TABLE),
+		},
+		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
+			stat, err := parseEpicCommentStat(connectionId, boardId, row.Data)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{stat}, nil
+		},
+		StrictPrimaryKey: true,
+	})
+	if err != nil {
+		return err
+	}
+	return extractor.Execute()
+}
+
+// parseEpicCommentStat summarizes the `comment` field of a raw epic. The search returns a page of the comments
+// along with their total, the latest comment is the one created the latest of the page
+func parseEpicCommentStat(connectionId uint64, boardId uint64, blob json.RawMessage) (*models.JiraEpicCommentStat, errors.Error) {
+	var epic struct {
+		ID     uint64 `json:"id,string"`
+		Key    string `json:"key"`
+		Fields struct {
+			Comment *struct {
+				Total    int `json:"total"`
+				Comments []struct {
+					Author *struct {
+						AccountId string `json:"accountId"`
+						Name      string `json:"name"`
+					} `json:"author"`
+					Created helper.Iso8601Time `json:"created"`
+				} `json:"comments"`
+			} `json:"comment"`
+		} `json:"fields"`
+	}
+	err := errors.Convert(json.Unmarshal(blob, &epic))
+	if err != nil {
+		return nil, err
+	}
+	stat := &models.JiraEpicCommentStat{
+		ConnectionId: connectionId,
+		BoardId:      boardId,
+		IssueId:      epic.ID,
+		EpicKey:      epic.Key,
+	}
+	if epic.Fields.Comment == nil {
+		return stat, nil
+	}
+	stat.CommentCount = epic.Fields.Comment.Total
+	for _, comment := range epic.Fields.Comment.Comments {
+		created := comment.Created.ToTime()
+		if stat.LatestCommentedAt != nil && !created.After(*stat.LatestCommentedAt) {
+			continue
+		}
+		stat.LatestCommentedAt = &created
+		stat.LatestCommentAuthor = ""
+		if comment.Author != nil {
+			stat.LatestCommentAuthor = comment.Author.AccountId
+			if stat.LatestCommentAuthor == "" {
+				stat.LatestCommentAuthor = comment.Author.Name
+			}
+		}
+	}
+	return stat, nil
+}
@@ -0,0 +1,62 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEpicCommentStat(t *testing.T) {
+	stat, err := parseEpicCommentStat(1, 2, []byte(`{"id":"10001","key":"K-1","fields":{"comment":{"total":3,"comments":[
+		{"author":{"accountId":"a1","name":"alice"},"created":"2022-11-01T10:00:00.000+0000"},
+		{"author":{"name":"bob"},"created":"2022-11-03T10:00:00.000+0000"},
+		{"author":{"accountId":"a3"},"created":"2022-11-02T10:00:00.000+0000"}
+	]}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10001), stat.IssueId)
+	assert.Equal(t, uint64(2), stat.BoardId)
+	assert.Equal(t, "K-1", stat.EpicKey)
+	assert.Equal(t, 3, stat.CommentCount)
+	// the author is identified by the username on Jira Server
+	assert.Equal(t, "bob", stat.LatestCommentAuthor)
+	assert.True(t, time.Date(2022, 11, 3, 10, 0, 0, 0, time.UTC).Equal(*stat.LatestCommentedAt))
+
+	// the comments searched in v3 have the bodies of documents, and the authors identified by the account id only
+	stat, err = parseEpicCommentStat(1, 2, []byte(`{"id":"10001","key":"K-1","fields":{"comment":{"total":1,"comments":[
+		{"author":{"accountId":"a1"},"created":"2022-11-01T10:00:00.000+0000",
+		 "body":{"type":"doc","version":1,"content":[{"type":"paragraph","content":[{"type":"text","text":"lgtm"}]}]}}
+	]}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, stat.CommentCount)
+	assert.Equal(t, "a1", stat.LatestCommentAuthor)
+
+	// epics without comments have no latest comment
+	for _, blob := range []string{
+		`{"id":"10001","key":"K-1","fields":{"comment":{"total":0,"comments":[]}}}`,
+		`{"id":"10001","key":"K-1","fields":{}}`,
+	} {
+		stat, err = parseEpicCommentStat(1, 2, []byte(blob))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, stat.CommentCount)
+		assert.Empty(t, stat.LatestCommentAuthor)
+		assert.Nil(t, stat.LatestCommentedAt)
+	}
+}
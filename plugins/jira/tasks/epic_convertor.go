@@ -0,0 +1,125 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"reflect"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/models/domainlayer/didgen"
+	"github.com/apache/incubator-devlake/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	jiraModels "github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+var _ core.SubTaskEntryPoint = ConvertEpics
+
+var ConvertEpicsMeta = core.SubTaskMeta{
+	Name:             "convertEpics",
+	EntryPoint:       ConvertEpics,
+	EnabledByDefault: true,
+	Description:      "convert Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET, core.DOMAIN_TYPE_CROSS},
+}
+
+func ConvertEpics(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	for _, boardId := range data.Options.GetBoardIds() {
+		err := convertBoardEpics(taskCtx, boardId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertBoardEpics converts the epics of the issues on the board into domain layer issues, and puts them onto the
+// board, so the `EpicKey` of the child issues could be resolved within the board. Epics which are on the board
+// already have been converted by ConvertIssues
+func convertBoardEpics(taskCtx core.SubTaskContext, boardId uint64) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	logger.Info("convert external epic Issues, connection_id=%d, board_id=%d", data.Options.ConnectionId, boardId)
+
+	clauses := []dal.Clause{
+		dal.Select("i.*"),
+		dal.From("_tool_jira_issues i"),
+		dal.Where(`
+			i.connection_id = ?
+			AND
+			i.issue_key IN (
+				SELECT ci.epic_key FROM _tool_jira_issues ci
+				JOIN _tool_jira_board_issues cbi ON (
+					cbi.connection_id = ci.connection_id
+					AND
+					cbi.issue_id = ci.issue_id
+				)
+				WHERE cbi.connection_id = ? AND cbi.board_id = ? AND ci.epic_key != ''
+			)
+			AND
+			NOT EXISTS (
+				SELECT 1 FROM _tool_jira_board_issues bi
+				WHERE bi.connection_id = i.connection_id AND bi.issue_id = i.issue_id AND bi.board_id = ?
+			)
+		`, data.Options.ConnectionId, data.Options.ConnectionId, boardId, boardId),
+	}
+	cursor, err := db.Cursor(clauses...)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	issueIdGen := didgen.NewDomainIdGenerator(&jiraModels.JiraIssue{})
+	accountIdGen := didgen.NewDomainIdGenerator(&jiraModels.JiraAccount{})
+	boardIdGen := didgen.NewDomainIdGenerator(&jiraModels.JiraBoard{})
+	domainBoardId := boardIdGen.Generate(data.Options.ConnectionId, boardId)
+
+	converter, err := helper.NewDataConverter(helper.DataConverterArgs{
+		InputRowType: reflect.TypeOf(jiraModels.JiraIssue{}),
+		Input:        cursor,
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: data.Options.ConnectionId,
+				BoardId:      boardId,
+			},
+			Table: data.Options.RawTable(RAW_EPIC_TABLE),
+		},
+		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
+			jiraIssue := inputRow.(*jiraModels.JiraIssue)
+			issue := convertIssue(data, jiraIssue, issueIdGen, accountIdGen)
+			issue.Type = ticket.EPIC
+			boardIssue := &ticket.BoardIssue{
+				BoardId: domainBoardId,
+				IssueId: issue.Id,
+			}
+			return []interface{}{
+				issue,
+				boardIssue,
+			}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return converter.Execute()
+}
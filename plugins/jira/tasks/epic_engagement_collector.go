@@ -0,0 +1,120 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+const RAW_EPIC_WATCHER_TABLE = "jira_api_epic_watchers"
+const RAW_EPIC_VOTE_TABLE = "jira_api_epic_votes"
+
+var _ core.SubTaskEntryPoint = CollectEpicEngagement
+
+var CollectEpicEngagementMeta = core.SubTaskMeta{
+	Name:             "collectEpicEngagement",
+	EntryPoint:       CollectEpicEngagement,
+	EnabledByDefault: false,
+	Description:      "collect the watchers and votes of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+}
+
+// CollectEpicEngagement collects the watchers and the votes of the epics, one request per epic for each of them
+// since neither is returned by the search. Like CollectEpicSprints, an epic shared by several boards is collected
+// under the first board only
+func CollectEpicEngagement(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	boardIds := data.Options.GetBoardIds()
+	for i, boardId := range boardIds {
+		taskCtx.GetLogger().Info("collect epic watchers and votes of board %d", boardId)
+		err := collectBoardEpicResponses(taskCtx, boardId, boardIds[:i], "issue/{{ .Input.EpicKey }}/watchers", RAW_EPIC_WATCHER_TABLE)
+		if err != nil {
+			return err
+		}
+		err = collectBoardEpicResponses(taskCtx, boardId, boardIds[:i], "issue/{{ .Input.EpicKey }}/votes", RAW_EPIC_VOTE_TABLE)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectBoardEpicResponses collects the response of the per-epic api of `urlTemplate` for the epics of the board
+// in full mode, i.e. the counts of the engagement change without touching the updated date of the epics, neither do
+// the remote links
+func collectBoardEpicResponses(
+	taskCtx core.SubTaskContext,
+	boardId uint64,
+	collectedBoardIds []uint64,
+	urlTemplate string,
+	table string,
+) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	cursor, err := db.Cursor(uncollectedEpicKeysClauses(data.Options.ConnectionId, boardId, collectedBoardIds)...)
+	if err != nil {
+		return errors.Default.Wrap(err, "unable to query for epic keys")
+	}
+	iterator, err := helper.NewBatchedDalCursorIteratorWithContext(taskCtx.GetContext(), db, cursor, reflect.TypeOf(archivedEpicInput{}), -1)
+	if err != nil {
+		return err
+	}
+	shardIterator := newEpicShardIterator(iterator, data.Options, func(elem interface{}) string {
+		return elem.(*archivedEpicInput).EpicKey
+	})
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: data.Options.ConnectionId,
+				BoardId:      boardId,
+			},
+			Table: data.Options.RawTable(table),
+		},
+		ApiClient:   data.ApiClient,
+		UrlTemplate: data.ApiVersion().Path(urlTemplate),
+		Input:       shardIterator,
+		Concurrency: data.Concurrency,
+		PageTimeout: data.PageTimeout,
+		DryRun:      data.Options.DryRun,
+		// epics deleted since the issues were collected are skipped
+		AfterResponse: chainAfterResponse(
+			newRateLimitObserver(logger).AfterResponse,
+			ignoreHTTPStatus404,
+		),
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			var body json.RawMessage
+			err := helper.UnmarshalResponse(res, &body)
+			if err != nil {
+				return nil, err
+			}
+			return []json.RawMessage{body}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return collector.Execute()
+}
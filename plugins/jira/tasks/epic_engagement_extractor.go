@@ -0,0 +1,141 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+var _ core.SubTaskEntryPoint = ExtractEpicEngagement
+
+var ExtractEpicEngagementMeta = core.SubTaskMeta{
+	Name:             "extractEpicEngagement",
+	EntryPoint:       ExtractEpicEngagement,
+	EnabledByDefault: false,
+	Description:      "extract the watchers and votes of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+}
+
+func ExtractEpicEngagement(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	connectionId := data.Options.ConnectionId
+	for _, boardId := range data.Options.GetBoardIds() {
+		err := extractBoardEpicEngagement(taskCtx, boardId, RAW_EPIC_WATCHER_TABLE, func(epicKey string, body json.RawMessage) (interface{}, errors.Error) {
+			return parseEpicWatch(connectionId, epicKey, body)
+		})
+		if err != nil {
+			return err
+		}
+		err = extractBoardEpicEngagement(taskCtx, boardId, RAW_EPIC_VOTE_TABLE, func(epicKey string, body json.RawMessage) (interface{}, errors.Error) {
+			return parseEpicVote(connectionId, epicKey, body)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractBoardEpicEngagement extracts the raw rows of `table`, the epic of a row is told by the input it was
+// collected by, since neither api returns the key of the issue
+func extractBoardEpicEngagement(
+	taskCtx core.SubTaskContext,
+	boardId uint64,
+	table string,
+	parse func(epicKey string, body json.RawMessage) (interface{}, errors.Error),
+) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	extractor, err := helper.NewApiExtractor(helper.ApiExtractorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: data.Options.ConnectionId,
+				BoardId:      boardId,
+			},
+			Table: data.Options.RawTable(table),
+		},
+		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
+			input := &archivedEpicInput{}
+			err := errors.Convert(json.Unmarshal(row.Input, input))
+			if err != nil {
+				return nil, err
+			}
+			result, err := parse(input.EpicKey, row.Data)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{result}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return extractor.Execute()
+}
+
+// parseEpicWatch parses the response of the watchers api, only the count is kept since the watchers are listed
+// for the users allowed to manage them only
+func parseEpicWatch(connectionId uint64, epicKey string, body json.RawMessage) (*models.JiraEpicWatch, errors.Error) {
+	var watchers struct {
+		WatchCount int `json:"watchCount"`
+	}
+	err := errors.Convert(json.Unmarshal(body, &watchers))
+	if err != nil {
+		return nil, err
+	}
+	return &models.JiraEpicWatch{
+		ConnectionId: connectionId,
+		EpicKey:      epicKey,
+		WatchCount:   watchers.WatchCount,
+	}, nil
+}
+
+// parseEpicVote parses the response of the votes api, the voters are identified by their account ids on Jira Cloud,
+// and by their usernames on Jira Server which has no account ids
+func parseEpicVote(connectionId uint64, epicKey string, body json.RawMessage) (*models.JiraEpicVote, errors.Error) {
+	var votes struct {
+		Votes  int `json:"votes"`
+		Voters []struct {
+			AccountId string `json:"accountId"`
+			Name      string `json:"name"`
+		} `json:"voters"`
+	}
+	err := errors.Convert(json.Unmarshal(body, &votes))
+	if err != nil {
+		return nil, err
+	}
+	voters := []string{}
+	for _, voter := range votes.Voters {
+		if voter.AccountId != "" {
+			voters = append(voters, voter.AccountId)
+		} else if voter.Name != "" {
+			voters = append(voters, voter.Name)
+		}
+	}
+	return &models.JiraEpicVote{
+		ConnectionId: connectionId,
+		EpicKey:      epicKey,
+		VoteCount:    votes.Votes,
+		Voters:       voters,
+	}, nil
+}
@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEpicWatch(t *testing.T) {
+	watch, err := parseEpicWatch(1, "EPIC-1", json.RawMessage(`{"self":"x","isWatching":false,"watchCount":3,"watchers":[]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), watch.ConnectionId)
+	assert.Equal(t, "EPIC-1", watch.EpicKey)
+	assert.Equal(t, 3, watch.WatchCount)
+
+	_, err = parseEpicWatch(1, "EPIC-1", json.RawMessage(`[]`))
+	assert.NotNil(t, err)
+}
+
+func TestParseEpicVote(t *testing.T) {
+	cases := map[string][]string{
+		// jira cloud
+		`{"votes":2,"hasVoted":false,"voters":[{"accountId":"5b10a2844c20165700ede21g"},{"accountId":"557058:f58131cb-b67d-43c7-b30d-6b58d40bd077"}]}`: {"5b10a2844c20165700ede21g", "557058:f58131cb-b67d-43c7-b30d-6b58d40bd077"},
+		// jira server
+		`{"votes":1,"hasVoted":true,"voters":[{"name":"fred","displayName":"Fred F. User"}]}`: {"fred"},
+		// voters are hidden from users without the permission to view them
+		`{"votes":2,"hasVoted":false}`: {},
+	}
+	for raw, expected := range cases {
+		vote, err := parseEpicVote(1, "EPIC-1", json.RawMessage(raw))
+		assert.Nil(t, err, raw)
+		assert.Equal(t, "EPIC-1", vote.EpicKey, raw)
+		assert.Equal(t, expected, vote.Voters, raw)
+	}
+
+	vote, err := parseEpicVote(1, "EPIC-1", json.RawMessage(`{"votes":2}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, vote.VoteCount)
+}
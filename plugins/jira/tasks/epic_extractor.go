@@ -31,31 +31,45 @@ var ExtractEpicsMeta = core.SubTaskMeta{
 	EnabledByDefault: true,
 	Description:      "extract Jira epics from all boards",
 	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET, core.DOMAIN_TYPE_CROSS},
+	DependsOn:        []string{"collectEpics"},
 }
 
 func ExtractEpics(taskCtx core.SubTaskContext) errors.Error {
 	data := taskCtx.GetData().(*JiraTaskData)
 	db := taskCtx.GetDal()
+	mappings, err := getTypeMappings(data, db)
+	if err != nil {
+		return err
+	}
+	for _, boardId := range data.Options.GetBoardIds() {
+		err = extractBoardEpics(taskCtx, mappings, boardId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractBoardEpics(taskCtx core.SubTaskContext, mappings *typeMappings, boardId uint64) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
 	connectionId := data.Options.ConnectionId
-	boardId := data.Options.BoardId
 	logger := taskCtx.GetLogger()
 	logger.Info("extract external epic Issues, connection_id=%d, board_id=%d", connectionId, boardId)
-	mappings, err := getTypeMappings(data, db)
+	params, err := getEpicRawDataParams(taskCtx.GetDal(), data, boardId)
 	if err != nil {
 		return err
 	}
 	extractor, err := helper.NewApiExtractor(helper.ApiExtractorArgs{
 		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
-			Ctx: taskCtx,
-			Params: JiraApiParams{
-				ConnectionId: data.Options.ConnectionId,
-				BoardId:      data.Options.BoardId,
-			},
-			Table: RAW_EPIC_TABLE,
+			Ctx:    taskCtx,
+			Params: params,
+			Table:  data.Options.RawTable(RAW_EPIC_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			return extractIssues(data, mappings, true, row)
 		},
+		// the issues are extracted again and again over the same raw rows, they must be upserted by their keys
+		StrictPrimaryKey: true,
 	})
 	if err != nil {
 		return err
@@ -0,0 +1,64 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractEpicsIdempotent(t *testing.T) {
+	db := unithelper.NewMemoryDal()
+	params := `{"ConnectionId":1,"BoardId":8}`
+	db.Insert("_raw_"+RAW_EPIC_TABLE,
+		&helper.RawData{ID: 1, Params: params, Data: []byte(fullEpic)},
+		&helper.RawData{ID: 2, Params: params, Data: []byte(`{"id":"10002","key":"K-2","fields":{"summary":"another epic","created":"2022-11-01T08:00:00.000+0000","updated":"2022-11-01T08:00:00.000+0000"}}`)},
+		// the epic collected again by an incremental collection
+		&helper.RawData{ID: 3, Params: params, Data: []byte(fullEpic)},
+	)
+	data := &JiraTaskData{Options: &JiraOptions{
+		ConnectionId:        1,
+		BoardId:             8,
+		TransformationRules: TransformationRules{StoryPointField: "customfield_10016"},
+	}}
+	taskCtx := unithelper.DummySubTaskContext(db)
+	taskCtx.On("GetData").Return(data)
+	tables := []string{
+		models.JiraIssue{}.TableName(),
+		models.JiraSprintIssue{}.TableName(),
+		models.JiraWorklog{}.TableName(),
+		models.JiraIssueLabel{}.TableName(),
+		models.JiraAccount{}.TableName(),
+	}
+	unithelper.AssertIdempotent(t, db, func() errors.Error {
+		return extractBoardEpics(taskCtx, &typeMappings{}, 8)
+	}, tables...)
+
+	issues := db.Rows(models.JiraIssue{}.TableName())
+	if assert.Len(t, issues, 2) {
+		assert.Equal(t, uint64(10001), issues[0].(*models.JiraIssue).IssueId)
+		assert.Equal(t, uint64(3), issues[0].(*models.JiraIssue).RawDataId)
+		assert.Equal(t, uint64(10002), issues[1].(*models.JiraIssue).IssueId)
+	}
+	assert.Len(t, db.Rows(models.JiraIssueLabel{}.TableName()), 1)
+}
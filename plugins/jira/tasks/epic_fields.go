@@ -0,0 +1,194 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+// allEpicFields requests all fields of the epics, it is the escape hatch of EpicFields
+const allEpicFields = "*all"
+
+// rawFieldRequirement declares the fields of a raw table consumed by an extractor. Extractors are referred to by
+// their metas, so a requirement couldn't outlive the extractor, while collectors are referred to by their names
+// since their entry points look the requirements up. Fields configured by the task, i.e. the story point field,
+// are returned by `configuredFields`. Optional requirements are only requested while their extractor is enabled,
+// for fields too heavy to be requested by default, i.e. the attachments
+type rawFieldRequirement struct {
+	extractor        *core.SubTaskMeta
+	collector        string
+	fields           []string
+	configuredFields func(data *JiraTaskData) []string
+	optional         bool
+}
+
+// rawFieldRequirements is the registry of the consumers of the raw tables, the epic collector requests the union of
+// the fields required from it by default. Any extractor reading a new field of a raw table must declare it here, or
+// the task would fail once the field is not requested by the collector
+var rawFieldRequirements = []rawFieldRequirement{
+	{
+		// see `apiv2models.Issue.ExtractEntities`
+		extractor: &ExtractEpicsMeta,
+		collector: "collectEpics",
+		fields: []string{
+			"aggregatetimeestimate", "assignee", "closedSprints", "created", "creator", "epic", "issuetype",
+			"labels", "parent", "priority", "project", "reporter", "resolutiondate", "sprint", "status", "summary",
+			"timeoriginalestimate", "timespent", "timetracking", "updated", "worklog",
+		},
+		configuredFields: func(data *JiraTaskData) []string {
+			return []string{data.Options.TransformationRules.StoryPointField}
+		},
+	},
+	{
+		extractor: &ExtractEpicChangelogsMeta,
+		collector: "collectEpics",
+		fields:    []string{"updated"},
+	},
+	{
+		extractor: &ExtractEpicSprintsMeta,
+		collector: "collectEpicSprints",
+		fields:    []string{"created", "resolutiondate"},
+		configuredFields: func(data *JiraTaskData) []string {
+			return []string{data.SprintField}
+		},
+	},
+	{
+		extractor: &ExtractEpicLinksMeta,
+		collector: "collectEpicLinks",
+		fields:    []string{"issuelinks"},
+	},
+	{
+		extractor: &ExtractEpicCommentsMeta,
+		collector: "collectEpicComments",
+		fields:    []string{"comment"},
+	},
+	{
+		extractor: &ExtractEpicAttachmentsMeta,
+		collector: "collectEpics",
+		fields:    []string{"attachment"},
+		optional:  true,
+	},
+}
+
+// rawFieldRequests returns the fields requested by the collectors of rawFieldRequirements
+var rawFieldRequests = map[string]func(data *JiraTaskData) []string{
+	"collectEpics": func(data *JiraTaskData) []string {
+		return strings.Split(getEpicFields(data), ",")
+	},
+	"collectEpicSprints":  getEpicSprintFields,
+	"collectEpicLinks":    getEpicLinkFields,
+	"collectEpicComments": getEpicCommentFields,
+}
+
+// requiredFields returns the fields required by the requirement, the empty ones of the task are left out
+func (requirement rawFieldRequirement) requiredFields(data *JiraTaskData) []string {
+	fields := requirement.fields
+	if requirement.configuredFields != nil {
+		fields = append(append([]string(nil), fields...), requirement.configuredFields(data)...)
+	}
+	required := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field != "" {
+			required = append(required, field)
+		}
+	}
+	return required
+}
+
+// getRequiredFields returns the fields required from the collector by the registered extractors, sorted and
+// deduplicated
+func getRequiredFields(data *JiraTaskData, collector string) []string {
+	set := make(map[string]bool)
+	for _, requirement := range rawFieldRequirements {
+		if requirement.collector != collector || (requirement.optional && !data.EnabledSubtasks[requirement.extractor.Name]) {
+			continue
+		}
+		for _, field := range requirement.requiredFields(data) {
+			set[field] = true
+		}
+	}
+	fields := make([]string, 0, len(set))
+	for field := range set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// getEpicFields returns the `fields` param of the epic collector, the required fields are used if EpicFields was
+// omitted. Fields required but left out of EpicFields fail the task by ValidateRawFields
+func getEpicFields(data *JiraTaskData) string {
+	fields := data.Options.EpicFields
+	if len(fields) == 0 {
+		return strings.Join(getRequiredFields(data, "collectEpics"), ",")
+	}
+	for _, field := range fields {
+		if field == allEpicFields {
+			return allEpicFields
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// getEpicSprintFields returns the `fields` param of the epic sprint collector
+func getEpicSprintFields(data *JiraTaskData) []string {
+	return []string{data.SprintField, "created", "resolutiondate"}
+}
+
+// getEpicLinkFields returns the `fields` param of the epic link collector
+func getEpicLinkFields(data *JiraTaskData) []string {
+	return []string{"issuelinks"}
+}
+
+// getEpicCommentFields returns the `fields` param of the epic comment collector
+func getEpicCommentFields(data *JiraTaskData) []string {
+	return []string{"comment"}
+}
+
+// ValidateRawFields cross-checks the fields requested by the enabled collectors against the ones required by the
+// enabled extractors, the extractors would extract the fields left out as empty silently otherwise. Extractors of
+// disabled collectors are left to the `DependsOn` of their metas, or to the raw data collected before
+func ValidateRawFields(data *JiraTaskData, subtasks map[string]bool) errors.Error {
+	var messages []string
+	for _, requirement := range rawFieldRequirements {
+		if !subtasks[requirement.extractor.Name] || !subtasks[requirement.collector] {
+			continue
+		}
+		requested := make(map[string]bool)
+		for _, field := range rawFieldRequests[requirement.collector](data) {
+			requested[field] = true
+		}
+		if requested[allEpicFields] {
+			continue
+		}
+		for _, field := range requirement.requiredFields(data) {
+			if !requested[field] {
+				messages = append(messages, fmt.Sprintf("%s doesn't request field %s needed by %s", requirement.collector, field, requirement.extractor.Name))
+			}
+		}
+	}
+	if len(messages) > 0 {
+		return errors.BadInput.New(strings.Join(messages, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+const fullEpic = `{"id":"10001","key":"K-1","fields":{
+	"summary":"epic","issuetype":{"id":"10000","name":"Epic"},"parent":{"id":"10000","key":"K-0"},
+	"project":{"id":"10000","key":"K"},"status":{"id":"3","name":"In Progress","statusCategory":{"key":"indeterminate"}},
+	"priority":{"id":"3","name":"Medium"},"created":"2022-11-01T08:00:00.000+0000","updated":"2022-11-02T08:00:00.000+0000",
+	"resolutiondate":"2022-11-03T08:00:00.000+0000","timeoriginalestimate":3600,"aggregatetimeestimate":1800,
+	"timespent":1800,"timetracking":{"remainingEstimateSeconds":1800},
+	"assignee":{"accountId":"a1","displayName":"A"},"creator":{"accountId":"a2"},"reporter":{"accountId":"a3"},
+	"sprint":{"id":1,"name":"S1"},"closedSprints":[{"id":2}],"labels":["roadmap"],
+	"worklog":{"total":1,"maxResults":20,"worklogs":[{"id":"1","issueId":"10001","timeSpentSeconds":1800}]},
+	"customfield_10016":5,"customfield_99999":"unused","description":"a long description"
+}}`
+
+// filterEpicFields keeps the given fields of the raw epic, as Jira does for the `fields` param
+func filterEpicFields(t *testing.T, raw string, fields string) []byte {
+	epic := map[string]json.RawMessage{}
+	assert.Nil(t, json.Unmarshal([]byte(raw), &epic))
+	all := map[string]json.RawMessage{}
+	assert.Nil(t, json.Unmarshal(epic["fields"], &all))
+	selected := map[string]json.RawMessage{}
+	for _, field := range strings.Split(fields, ",") {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	blob, err := json.Marshal(selected)
+	assert.Nil(t, err)
+	epic["fields"] = blob
+	blob, err = json.Marshal(epic)
+	assert.Nil(t, err)
+	return blob
+}
+
+func TestEpicFieldsCoverExtraction(t *testing.T) {
+	data := &JiraTaskData{Options: &JiraOptions{
+		ConnectionId:        1,
+		TransformationRules: TransformationRules{StoryPointField: "customfield_10016"},
+	}}
+	fields := getEpicFields(data)
+	assert.Contains(t, strings.Split(fields, ","), "customfield_10016")
+	assert.NotContains(t, fields, "description")
+
+	mappings := &typeMappings{}
+	full, err := extractIssues(data, mappings, true, &helper.RawData{Data: []byte(fullEpic)})
+	assert.Nil(t, err)
+	reduced, err := extractIssues(data, mappings, true, &helper.RawData{Data: filterEpicFields(t, fullEpic, fields)})
+	assert.Nil(t, err)
+	// the epics collected with the default fields are extracted the same as the complete ones
+	assert.Equal(t, full, reduced)
+}
+
+func TestGetEpicFields(t *testing.T) {
+	data := &JiraTaskData{Options: &JiraOptions{EpicFields: []string{"summary", allEpicFields}}}
+	assert.Equal(t, allEpicFields, getEpicFields(data))
+
+	// the selection is respected, the fields left out are up to ValidateRawFields
+	data = &JiraTaskData{Options: &JiraOptions{
+		EpicFields:          []string{"summary", "updated"},
+		TransformationRules: TransformationRules{StoryPointField: "customfield_10016"},
+	}}
+	assert.Equal(t, "summary,updated", getEpicFields(data))
+}
+
+func TestValidateRawFields(t *testing.T) {
+	subtasks := map[string]bool{
+		"collectEpics":          true,
+		"extractEpics":          true,
+		"extractEpicChangelogs": true,
+		"collectEpicSprints":    true,
+		"extractEpicSprints":    true,
+	}
+	data := &JiraTaskData{
+		Options: &JiraOptions{
+			TransformationRules: TransformationRules{StoryPointField: "customfield_10016"},
+		},
+		SprintField: "customfield_10020",
+	}
+	assert.Nil(t, ValidateRawFields(data, subtasks))
+
+	data.Options.EpicFields = []string{"summary", allEpicFields}
+	assert.Nil(t, ValidateRawFields(data, subtasks))
+}
+
+func TestValidateRawFieldsMismatch(t *testing.T) {
+	subtasks := map[string]bool{"collectEpics": true, "extractEpics": true, "extractEpicChangelogs": true}
+	data := &JiraTaskData{Options: &JiraOptions{
+		EpicFields:          append(getRequiredFields(&JiraTaskData{Options: &JiraOptions{}}, "collectEpics"), "description"),
+		TransformationRules: TransformationRules{StoryPointField: "customfield_10016"},
+	}}
+	err := ValidateRawFields(data, subtasks)
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.BadInput, err.GetType())
+	assert.Contains(t, err.Error(), "collectEpics doesn't request field customfield_10016 needed by extractEpics")
+	assert.NotContains(t, err.Error(), "extractEpicChangelogs")
+
+	data.Options.EpicFields = []string{"summary"}
+	err = ValidateRawFields(data, subtasks)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "collectEpics doesn't request field updated needed by extractEpics")
+	assert.Contains(t, err.Error(), "collectEpics doesn't request field updated needed by extractEpicChangelogs")
+
+	// the fields are not cross-checked for the disabled extractors
+	assert.Nil(t, ValidateRawFields(data, map[string]bool{"collectEpics": true}))
+}
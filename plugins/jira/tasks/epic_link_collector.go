@@ -0,0 +1,51 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+const RAW_EPIC_LINK_TABLE = "jira_api_epic_links"
+
+var _ core.SubTaskEntryPoint = CollectEpicLinks
+
+var CollectEpicLinksMeta = core.SubTaskMeta{
+	Name:             "collectEpicLinks",
+	EntryPoint:       CollectEpicLinks,
+	EnabledByDefault: false,
+	Description:      "collect the issue links of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET, core.DOMAIN_TYPE_CROSS},
+}
+
+// CollectEpicLinks collects the issue links of the epics in full mode by the search, a link removed from an epic
+// doesn't show up in an incremental collection. Like CollectEpicSprints, an epic shared by several boards is
+// collected under the first board only
+func CollectEpicLinks(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	boardIds := data.Options.GetBoardIds()
+	for i, boardId := range boardIds {
+		taskCtx.GetLogger().Info("collect epic links of board %d", boardId)
+		err := collectBoardEpicFields(taskCtx, boardId, boardIds[:i], RAW_EPIC_LINK_TABLE, getEpicLinkFields(data))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+var _ core.SubTaskEntryPoint = ExtractEpicLinks
+
+var ExtractEpicLinksMeta = core.SubTaskMeta{
+	Name:             "extractEpicLinks",
+	EntryPoint:       ExtractEpicLinks,
+	EnabledByDefault: false,
+	Description:      "extract the issue links of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET, core.DOMAIN_TYPE_CROSS},
+	DependsOn:        []string{"collectEpicLinks"},
+}
+
+// epicIssueLink is an element of the `issuelinks` field, the linked issue is set on the side of the link opposite
+// to the epic
+type epicIssueLink struct {
+	ID   string `json:"id"`
+	Type struct {
+		ID      string `json:"id"`
+		Name    string `json:"name"`
+		Inward  string `json:"inward"`
+		Outward string `json:"outward"`
+	} `json:"type"`
+	InwardIssue  *linkedIssue `json:"inwardIssue"`
+	OutwardIssue *linkedIssue `json:"outwardIssue"`
+}
+
+type linkedIssue struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+}
+
+func ExtractEpicLinks(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	for _, boardId := range data.Options.GetBoardIds() {
+		err := extractBoardEpicLinks(taskCtx, boardId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractBoardEpicLinks(taskCtx core.SubTaskContext, boardId uint64) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	connectionId := data.Options.ConnectionId
+	extractor, err := helper.NewApiExtractor(helper.ApiExtractorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: connectionId,
+				BoardId:      boardId,
+			},
+			Table: data.Options.RawTable(RAW_EPIC_LINK_TABLE),
+		},
+		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
+			links, err := parseEpicLinks(connectionId, row.Data)
+			if err != nil {
+				return nil, err
+			}
+			results := make([]interface{}, 0, len(links))
+			for _, link := range links {
+				results = append(results, link)
+			}
+			return results, nil
+		},
+		StrictPrimaryKey: true,
+	})
+	if err != nil {
+		return err
+	}
+	return extractor.Execute()
+}
+
+// parseEpicLinks parses the `issuelinks` of a raw epic, an epic without links yields none, as does a link missing
+// the linked issue which is not visible to the user of the connection
+func parseEpicLinks(connectionId uint64, blob json.RawMessage) ([]*models.JiraEpicLink, errors.Error) {
+	var epic struct {
+		ID     uint64 `json:"id,string"`
+		Key    string `json:"key"`
+		Fields struct {
+			Issuelinks []epicIssueLink `json:"issuelinks"`
+		} `json:"fields"`
+	}
+	err := errors.Convert(json.Unmarshal(blob, &epic))
+	if err != nil {
+		return nil, err
+	}
+	var links []*models.JiraEpicLink
+	for _, issueLink := range epic.Fields.Issuelinks {
+		direction, description, linked := "outward", issueLink.Type.Outward, issueLink.OutwardIssue
+		if linked == nil {
+			direction, description, linked = "inward", issueLink.Type.Inward, issueLink.InwardIssue
+		}
+		if linked == nil {
+			continue
+		}
+		linkId, err := errors.Convert01(strconv.ParseUint(issueLink.ID, 10, 64))
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "failed to parse the id of a link of epic "+epic.Key)
+		}
+		linkedIssueId, err := errors.Convert01(strconv.ParseUint(linked.ID, 10, 64))
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "failed to parse the id of an issue linked to epic "+epic.Key)
+		}
+		links = append(links, &models.JiraEpicLink{
+			ConnectionId:   connectionId,
+			EpicId:         epic.ID,
+			LinkId:         linkId,
+			EpicKey:        epic.Key,
+			LinkTypeId:     issueLink.Type.ID,
+			LinkTypeName:   issueLink.Type.Name,
+			Direction:      direction,
+			Description:    description,
+			LinkedIssueId:  linkedIssueId,
+			LinkedIssueKey: linked.Key,
+		})
+	}
+	return links, nil
+}
@@ -0,0 +1,56 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEpicLinks(t *testing.T) {
+	links, err := parseEpicLinks(1, []byte(`{"id":"10001","key":"K-1","fields":{"issuelinks":[
+		{"id":"20001","type":{"id":"10000","name":"Blocks","inward":"is blocked by","outward":"blocks"},"outwardIssue":{"id":"10002","key":"K-2"}},
+		{"id":"20002","type":{"id":"10003","name":"Relates","inward":"relates to","outward":"relates to"},"inwardIssue":{"id":"10003","key":"K-3"}},
+		{"id":"20003","type":{"id":"10000","name":"Blocks","inward":"is blocked by","outward":"blocks"}}
+	]}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []*models.JiraEpicLink{
+		{
+			ConnectionId: 1, EpicId: 10001, LinkId: 20001, EpicKey: "K-1", LinkTypeId: "10000", LinkTypeName: "Blocks",
+			Direction: "outward", Description: "blocks", LinkedIssueId: 10002, LinkedIssueKey: "K-2",
+		},
+		{
+			ConnectionId: 1, EpicId: 10001, LinkId: 20002, EpicKey: "K-1", LinkTypeId: "10003", LinkTypeName: "Relates",
+			Direction: "inward", Description: "relates to", LinkedIssueId: 10003, LinkedIssueKey: "K-3",
+		},
+	}, links)
+
+	// epics without links yield none
+	links, err = parseEpicLinks(1, []byte(`{"id":"10001","key":"K-1","fields":{"issuelinks":[]}}`))
+	assert.Nil(t, err)
+	assert.Empty(t, links)
+	links, err = parseEpicLinks(1, []byte(`{"id":"10001","key":"K-1","fields":{}}`))
+	assert.Nil(t, err)
+	assert.Empty(t, links)
+
+	_, err = parseEpicLinks(1, []byte(`{"id":"10001","key":"K-1","fields":{"issuelinks":[{"id":"x","outwardIssue":{"id":"10002"}}]}}`))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed to parse the id of a link of epic K-1")
+}
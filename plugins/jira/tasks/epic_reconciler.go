@@ -0,0 +1,251 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/models/domainlayer/didgen"
+	"github.com/apache/incubator-devlake/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+var _ core.SubTaskEntryPoint = ReconcileDeletedEpics
+
+var ReconcileDeletedEpicsMeta = core.SubTaskMeta{
+	Name:             "reconcileDeletedEpics",
+	EntryPoint:       ReconcileDeletedEpics,
+	EnabledByDefault: true,
+	Description:      "mark the epics of the boards which were deleted from Jira as deleted in the domain layer",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+}
+
+// reconcileBatchSize is the number of epics looked up by a single search, it is the max page size of the search api
+const reconcileBatchSize = 100
+
+// boardEpic is an epic of a board known to the tool layer
+type boardEpic struct {
+	IssueId  uint64
+	IssueKey string
+}
+
+// ReconcileDeletedEpics looks the epics of the boards collected before up in Jira, the ones which are gone are marked
+// as deleted in the domain layer rather than being removed, and the ones which showed up again are unmarked.
+// Only the epics known to the tool layer are looked up, so the cost is bounded by the epics of the boards instead of
+// the size of the Jira instance
+func ReconcileDeletedEpics(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	for _, boardId := range data.Options.GetBoardIds() {
+		err := reconcileBoardEpics(taskCtx, boardId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reconcileBoardEpics(taskCtx core.SubTaskContext, boardId uint64) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	var epics []boardEpic
+	err := db.All(&epics, boardEpicsClauses(data.Options.ConnectionId, boardId)...)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to load the epics of board %d", boardId))
+	}
+	if len(epics) == 0 {
+		return nil
+	}
+	existing, err := findExistingEpics(data.ApiClient, data.ApiVersion().SearchPath(), epics, data.PageSize(reconcileBatchSize))
+	if err != nil {
+		return err
+	}
+	// losing the access to the projects looks the same as the epics being deleted, nothing is marked in that case
+	if len(existing) == 0 {
+		logger.Warn(nil, "none of the %d epics of board %d was found in Jira, leaving them as they are since the connection might have lost its access to them", len(epics), boardId)
+		return nil
+	}
+	deleted, restored := diffBoardEpics(epics, existing)
+	logger.Info("%d of the %d epics of board %d were deleted from Jira", len(deleted), len(epics), boardId)
+	if data.Options.DryRun {
+		return nil
+	}
+	return markDeletedEpics(db, data.Options.ConnectionId, deleted, restored, data.Now())
+}
+
+// boardEpicsClauses queries the epics of the board, i.e. the epics of the issues on the board and the epics on the
+// board themselves, the same epics converted by ConvertIssues and ConvertEpics
+func boardEpicsClauses(connectionId uint64, boardId uint64) []dal.Clause {
+	return []dal.Clause{
+		dal.Select("i.issue_id, i.issue_key"),
+		dal.From("_tool_jira_issues i"),
+		dal.Where(`
+			i.connection_id = ?
+			AND (
+				i.issue_key IN (
+					SELECT ci.epic_key FROM _tool_jira_issues ci
+					JOIN _tool_jira_board_issues cbi ON (
+						cbi.connection_id = ci.connection_id
+						AND
+						cbi.issue_id = ci.issue_id
+					)
+					WHERE cbi.connection_id = ? AND cbi.board_id = ? AND ci.epic_key != ''
+				)
+				OR
+				(
+					i.std_type = ?
+					AND
+					EXISTS (
+						SELECT 1 FROM _tool_jira_board_issues bi
+						WHERE bi.connection_id = i.connection_id AND bi.issue_id = i.issue_id AND bi.board_id = ?
+					)
+				)
+			)
+		`, connectionId, connectionId, boardId, ticket.EPIC, boardId),
+		dal.Orderby("i.issue_id"),
+	}
+}
+
+// findExistingEpics looks the epics up in Jira batch by batch, and returns the ids of the ones which still exist. A
+// batch must fit into a page, the epics left out of a shrunk page would be taken as deleted otherwise
+func findExistingEpics(apiClient helper.ApiClientGetter, searchPath string, epics []boardEpic, batchSize int) (map[uint64]bool, errors.Error) {
+	existing := make(map[uint64]bool, len(epics))
+	for start := 0; start < len(epics); start += batchSize {
+		end := start + batchSize
+		if end > len(epics) {
+			end = len(epics)
+		}
+		err := searchExistingIssues(apiClient, searchPath, epics[start:end], existing)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return existing, nil
+}
+
+// searchExistingIssues adds the ids of the issues which still exist in Jira into `existing`. The issues are searched
+// by id, which stays the same when an issue is moved to another project, and the nonexistent ones are reported as
+// warnings by `validateQuery=warn` rather than failing the search
+func searchExistingIssues(apiClient helper.ApiClientGetter, searchPath string, epics []boardEpic, existing map[uint64]bool) errors.Error {
+	ids := make([]string, len(epics))
+	for i, epic := range epics {
+		ids[i] = strconv.FormatUint(epic.IssueId, 10)
+	}
+	query := url.Values{
+		"jql":           {buildJql("", fmt.Sprintf("id in (%s)", jqlValues(ids)))},
+		"fields":        {"key"},
+		"maxResults":    {strconv.Itoa(len(epics))},
+		"validateQuery": {"warn"},
+	}
+	res, err := apiClient.Get(searchPath, query, nil)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to search for the existing epics")
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to search for the existing epics, unexpected status code: %d", res.StatusCode))
+	}
+	var body struct {
+		Issues []struct {
+			Id string `json:"id"`
+		} `json:"issues"`
+	}
+	err = helper.UnmarshalResponse(res, &body)
+	if err != nil {
+		return err
+	}
+	for _, issue := range body.Issues {
+		id, parseErr := strconv.ParseUint(issue.Id, 10, 64)
+		if parseErr != nil {
+			return errors.Default.Wrap(parseErr, fmt.Sprintf("invalid issue id %s", issue.Id))
+		}
+		existing[id] = true
+	}
+	return nil
+}
+
+// diffBoardEpics splits the epics of the board into the ones deleted from Jira and the ones still there, which are
+// restored in case they were marked before
+func diffBoardEpics(epics []boardEpic, existing map[uint64]bool) (deleted []boardEpic, restored []boardEpic) {
+	for _, epic := range epics {
+		if existing[epic.IssueId] {
+			restored = append(restored, epic)
+		} else {
+			deleted = append(deleted, epic)
+		}
+	}
+	return deleted, restored
+}
+
+// markDeletedEpics records the deleted epics in the tool layer and marks their domain issues as deleted. An epic
+// found deleted before keeps the time it was found, so marking it again on every run doesn't move the date
+func markDeletedEpics(db dal.Dal, connectionId uint64, deleted []boardEpic, restored []boardEpic, now time.Time) errors.Error {
+	issueIdGen := didgen.NewDomainIdGenerator(&models.JiraIssue{})
+	for _, epic := range deleted {
+		deletedIssue := &models.JiraDeletedIssue{
+			ConnectionId: connectionId,
+			IssueId:      epic.IssueId,
+			IssueKey:     epic.IssueKey,
+			DeletedAt:    now,
+		}
+		err := db.CreateIfNotExist(deletedIssue)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to record the deletion of epic %s", epic.IssueKey))
+		}
+		err = db.First(deletedIssue, dal.Where("connection_id = ? AND issue_id = ?", connectionId, epic.IssueId))
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to load the deletion of epic %s", epic.IssueKey))
+		}
+		err = db.UpdateColumns(&ticket.Issue{}, []dal.DalSet{
+			{ColumnName: "is_deleted", Value: true},
+			{ColumnName: "deleted_date", Value: deletedIssue.DeletedAt},
+		}, dal.Where("id = ?", issueIdGen.Generate(connectionId, epic.IssueId)))
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to mark epic %s as deleted", epic.IssueKey))
+		}
+	}
+	if len(restored) == 0 {
+		return nil
+	}
+	issueIds := make([]uint64, len(restored))
+	domainIds := make([]string, len(restored))
+	for i, epic := range restored {
+		issueIds[i] = epic.IssueId
+		domainIds[i] = issueIdGen.Generate(connectionId, epic.IssueId)
+	}
+	err := db.Delete(&models.JiraDeletedIssue{}, dal.Where("connection_id = ? AND issue_id IN ?", connectionId, issueIds))
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to remove the deletions of the restored epics")
+	}
+	err = db.UpdateColumns(&ticket.Issue{}, []dal.DalSet{
+		{ColumnName: "is_deleted", Value: false},
+		{ColumnName: "deleted_date", Value: nil},
+	}, dal.Where("id IN ? AND is_deleted = ?", domainIds, true))
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to unmark the restored epics")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSearchExistingIssues(t *testing.T) {
+	apiClient := mocks.NewApiClientGetter(t)
+	apiClient.On("Get", "api/2/search", mock.MatchedBy(func(query url.Values) bool {
+		return query.Get("jql") == `id in ("10001","10002")` &&
+			query.Get("validateQuery") == "warn" &&
+			query.Get("maxResults") == "2"
+	}), mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body: io.NopCloser(bytes.NewBufferString(
+			`{"issues":[{"id":"10002","key":"DEV-2"}],"warningMessages":["An issue with key '10001' does not exist for field 'id'."]}`,
+		)),
+	}, nil).Once()
+
+	existing := map[uint64]bool{}
+	err := searchExistingIssues(apiClient, "api/2/search", []boardEpic{{10001, "DEV-1"}, {10002, "DEV-2"}}, existing)
+	assert.Nil(t, err)
+	assert.Equal(t, map[uint64]bool{10002: true}, existing)
+}
+
+func TestDiffBoardEpics(t *testing.T) {
+	epics := []boardEpic{{1, "DEV-1"}, {2, "DEV-2"}, {3, "DEV-3"}}
+	deleted, restored := diffBoardEpics(epics, map[uint64]bool{2: true})
+	assert.Equal(t, []boardEpic{{1, "DEV-1"}, {3, "DEV-3"}}, deleted)
+	assert.Equal(t, []boardEpic{{2, "DEV-2"}}, restored)
+}
+
+func TestMarkDeletedEpics(t *testing.T) {
+	foundBefore := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+	now := foundBefore.Add(24 * time.Hour)
+	mockMeta := mocks.NewPluginMeta(t)
+	mockMeta.On("RootPkgPath").Return("github.com/apache/incubator-devlake/plugins/jira")
+	assert.Nil(t, core.RegisterPlugin("jira", mockMeta))
+	mockDal := new(mocks.Dal)
+	mockDal.On("CreateIfNotExist", mock.Anything, mock.Anything).Return(nil).Once()
+	// the epic was found deleted by a previous run, which kept the record created back then
+	mockDal.On("First", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.JiraDeletedIssue).DeletedAt = foundBefore
+	}).Return(nil).Once()
+	var marked []dal.DalSet
+	mockDal.On("UpdateColumns", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		marked = args.Get(1).([]dal.DalSet)
+	}).Return(nil).Once()
+	var unmarked []dal.DalSet
+	mockDal.On("Delete", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDal.On("UpdateColumns", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		unmarked = args.Get(1).([]dal.DalSet)
+	}).Return(nil).Once()
+
+	err := markDeletedEpics(mockDal, 1, []boardEpic{{1, "DEV-1"}}, []boardEpic{{2, "DEV-2"}}, now)
+	assert.Nil(t, err)
+	mockDal.AssertExpectations(t)
+	assert.Equal(t, []dal.DalSet{
+		{ColumnName: "is_deleted", Value: true},
+		{ColumnName: "deleted_date", Value: foundBefore},
+	}, marked)
+	assert.Equal(t, []dal.DalSet{
+		{ColumnName: "is_deleted", Value: false},
+		{ColumnName: "deleted_date", Value: nil},
+	}, unmarked)
+}
+
+func TestFindExistingEpicsInBatches(t *testing.T) {
+	epics := make([]boardEpic, reconcileBatchSize+1)
+	for i := range epics {
+		epics[i] = boardEpic{IssueId: uint64(i + 1), IssueKey: fmt.Sprintf("DEV-%d", i+1)}
+	}
+	apiClient := mocks.NewApiClientGetter(t)
+	mockDiagnosticResponse(apiClient, "api/2/search", http.StatusOK, `{"issues":[{"id":"1"}]}`)
+	mockDiagnosticResponse(apiClient, "api/2/search", http.StatusOK, `{"issues":[{"id":"101"}]}`)
+
+	existing, err := findExistingEpics(apiClient, "api/2/search", epics, reconcileBatchSize)
+	assert.Nil(t, err)
+	assert.Equal(t, map[uint64]bool{1: true, 101: true}, existing)
+}
+
+func TestFindExistingEpicsFailure(t *testing.T) {
+	apiClient := mocks.NewApiClientGetter(t)
+	mockDiagnosticResponse(apiClient, "api/2/search", http.StatusBadRequest, `{"errorMessages":["invalid"]}`)
+
+	_, err := findExistingEpics(apiClient, "api/2/search", []boardEpic{{1, "DEV-1"}}, reconcileBatchSize)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.HttpStatus(http.StatusBadRequest), err.GetType())
+	}
+}
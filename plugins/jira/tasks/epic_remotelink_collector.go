@@ -0,0 +1,51 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+const RAW_EPIC_REMOTELINK_TABLE = "jira_api_epic_remotelinks"
+
+var _ core.SubTaskEntryPoint = CollectEpicRemotelinks
+
+var CollectEpicRemotelinksMeta = core.SubTaskMeta{
+	Name:             "collectEpicRemotelinks",
+	EntryPoint:       CollectEpicRemotelinks,
+	EnabledByDefault: false,
+	Description:      "collect the remote links of Jira epics from all boards, i.e. to Confluence pages and GitHub pull requests",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+}
+
+// CollectEpicRemotelinks collects the remote links of the epics, one request per epic since they are not returned by
+// the search. The links of an epic are saved as a single raw row with the epic key in its input. Like
+// CollectEpicSprints, an epic shared by several boards is collected under the first board only
+func CollectEpicRemotelinks(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	boardIds := data.Options.GetBoardIds()
+	for i, boardId := range boardIds {
+		taskCtx.GetLogger().Info("collect epic remote links of board %d", boardId)
+		err := collectBoardEpicResponses(taskCtx, boardId, boardIds[:i], "issue/{{ .Input.EpicKey }}/remotelink", RAW_EPIC_REMOTELINK_TABLE)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+var _ core.SubTaskEntryPoint = ExtractEpicRemotelinks
+
+var ExtractEpicRemotelinksMeta = core.SubTaskMeta{
+	Name:             "extractEpicRemotelinks",
+	EntryPoint:       ExtractEpicRemotelinks,
+	EnabledByDefault: false,
+	Description:      "extract the remote links of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+	DependsOn:        []string{"collectEpicRemotelinks"},
+}
+
+func ExtractEpicRemotelinks(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	connectionId := data.Options.ConnectionId
+	for _, boardId := range data.Options.GetBoardIds() {
+		extractor, err := helper.NewApiExtractor(helper.ApiExtractorArgs{
+			RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+				Ctx: taskCtx,
+				Params: JiraApiParams{
+					ConnectionId: connectionId,
+					BoardId:      boardId,
+				},
+				Table: data.Options.RawTable(RAW_EPIC_REMOTELINK_TABLE),
+			},
+			Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
+				input := &archivedEpicInput{}
+				err := errors.Convert(json.Unmarshal(row.Input, input))
+				if err != nil {
+					return nil, err
+				}
+				return parseEpicRemotelinks(connectionId, input.EpicKey, row.Data)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		err = extractor.Execute()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseEpicRemotelinks parses the response of the remote link api of an epic, the epic is told by the input since
+// the response doesn't carry the key of the issue. The global id identifies the linked object across the issues,
+// i.e. the Confluence page or the GitHub pull request, it is empty for the links created without one
+func parseEpicRemotelinks(connectionId uint64, epicKey string, body json.RawMessage) ([]interface{}, errors.Error) {
+	var remotelinks []struct {
+		ID           uint64 `json:"id"`
+		GlobalId     string `json:"globalId"`
+		Relationship string `json:"relationship"`
+		Application  struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		} `json:"application"`
+		Object struct {
+			Url   string `json:"url"`
+			Title string `json:"title"`
+		} `json:"object"`
+	}
+	err := errors.Convert(json.Unmarshal(body, &remotelinks))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]interface{}, 0, len(remotelinks))
+	for _, remotelink := range remotelinks {
+		results = append(results, &models.JiraEpicRemotelink{
+			ConnectionId:    connectionId,
+			RemotelinkId:    remotelink.ID,
+			EpicKey:         epicKey,
+			GlobalId:        remotelink.GlobalId,
+			Title:           remotelink.Object.Title,
+			Url:             remotelink.Object.Url,
+			Relationship:    remotelink.Relationship,
+			ApplicationType: remotelink.Application.Type,
+			ApplicationName: remotelink.Application.Name,
+		})
+	}
+	return results, nil
+}
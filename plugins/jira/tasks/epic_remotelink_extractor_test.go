@@ -0,0 +1,72 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEpicRemotelinks(t *testing.T) {
+	raw := `[
+		{
+			"id": 10000,
+			"self": "https://jira.example.com/rest/api/2/issue/EPIC-1/remotelink/10000",
+			"globalId": "appId=5e7d6222-8225-3bcd-be58-5fecbf0e2ea6&pageId=65537",
+			"application": {"type": "com.atlassian.confluence", "name": "Confluence"},
+			"relationship": "Wiki Page",
+			"object": {"url": "https://confluence.example.com/pages/viewpage.action?pageId=65537", "title": "Design"}
+		},
+		{
+			"id": 10001,
+			"self": "https://jira.example.com/rest/api/2/issue/EPIC-1/remotelink/10001",
+			"object": {"url": "https://github.com/apache/incubator-devlake/pull/3801", "title": "feat: epic remote links"}
+		}
+	]`
+	results, err := parseEpicRemotelinks(1, "EPIC-1", json.RawMessage(raw))
+	assert.Nil(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, &models.JiraEpicRemotelink{
+			ConnectionId:    1,
+			RemotelinkId:    10000,
+			EpicKey:         "EPIC-1",
+			GlobalId:        "appId=5e7d6222-8225-3bcd-be58-5fecbf0e2ea6&pageId=65537",
+			Title:           "Design",
+			Url:             "https://confluence.example.com/pages/viewpage.action?pageId=65537",
+			Relationship:    "Wiki Page",
+			ApplicationType: "com.atlassian.confluence",
+			ApplicationName: "Confluence",
+		}, results[0])
+		// the links added by url have neither a global id nor an application
+		link := results[1].(*models.JiraEpicRemotelink)
+		assert.Equal(t, uint64(10001), link.RemotelinkId)
+		assert.Equal(t, "https://github.com/apache/incubator-devlake/pull/3801", link.Url)
+		assert.Empty(t, link.GlobalId)
+		assert.Empty(t, link.ApplicationType)
+	}
+
+	results, err = parseEpicRemotelinks(1, "EPIC-2", json.RawMessage(`[]`))
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+
+	_, err = parseEpicRemotelinks(1, "EPIC-2", json.RawMessage(`{"errorMessages":["Issue does not exist"]}`))
+	assert.NotNil(t, err)
+}
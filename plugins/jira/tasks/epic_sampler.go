@@ -0,0 +1,113 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"net/http"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+// epicSampler fetches every Nth page of the epic search for a quick preview of a board, see JiraOptions.Sample.
+// The first page of every input is always fetched, the offset of the following pages advances by N pages at a
+// time. Cursor based responses can't be jumped through, so only their first pages are sampled
+type epicSampler struct {
+	every int
+}
+
+var _ helper.Paginator = (*epicSampler)(nil)
+
+// sampleEpicPages replaces the pagination of the epic search by the sampler if the options ask for a sample
+func sampleEpicPages(args *helper.ApiCollectorArgs, op *JiraOptions) {
+	every := op.SampleEvery()
+	if every == 0 {
+		return
+	}
+	args.GetTotalPages = nil
+	args.GetNextPageCustomData = nil
+	args.Paginator = &epicSampler{every: every}
+}
+
+// NextRequest skips N-1 pages after the previous one, till the total of the search is passed
+func (sampler *epicSampler) NextRequest(prev *helper.RequestData, res *http.Response) (*helper.RequestData, bool, errors.Error) {
+	body := &JiraSearchResponse{}
+	err := helper.UnmarshalResponse(res, body)
+	if err != nil {
+		return nil, false, err
+	}
+	if body.isCursorBased() || prev.Pager == nil || prev.Pager.Size <= 0 {
+		return nil, false, nil
+	}
+	skip := prev.Pager.Skip + sampler.every*prev.Pager.Size
+	if skip >= body.Total {
+		return nil, false, nil
+	}
+	return &helper.RequestData{
+		Pager: &helper.Pager{
+			Page: prev.Pager.Page + sampler.every,
+			Skip: skip,
+			Size: prev.Pager.Size,
+		},
+	}, true, nil
+}
+
+// sampledEpicKeysIterator yields every Nth batch of epic keys, a batch of keys is searched by a page at most, so
+// the pages of the key based search are sampled by their batches
+type sampledEpicKeysIterator struct {
+	helper.Iterator
+	every int
+	index int
+	next  interface{}
+	err   errors.Error
+}
+
+// newSampledEpicKeysIterator returns the iterator itself if the options don't ask for a sample
+func newSampledEpicKeysIterator(iterator helper.Iterator, op *JiraOptions) helper.Iterator {
+	every := op.SampleEvery()
+	if every == 0 {
+		return iterator
+	}
+	return &sampledEpicKeysIterator{Iterator: iterator, every: every}
+}
+
+// HasNext reads ahead till the next sampled batch, the batches between the sampled ones are skipped. The
+// underlying iterator is read once per batch, since the HasNext of a cursor based one advances the cursor
+func (it *sampledEpicKeysIterator) HasNext() bool {
+	for it.next == nil && it.err == nil && it.Iterator.HasNext() {
+		batch, err := it.Iterator.Fetch()
+		if err != nil {
+			it.err = err
+			break
+		}
+		if it.index%it.every == 0 {
+			it.next = batch
+		}
+		it.index++
+	}
+	return it.next != nil || it.err != nil
+}
+
+func (it *sampledEpicKeysIterator) Fetch() (interface{}, errors.Error) {
+	if !it.HasNext() {
+		return nil, errors.Default.New("no more epic keys in the sample")
+	}
+	next, err := it.next, it.err
+	it.next, it.err = nil, nil
+	return next, err
+}
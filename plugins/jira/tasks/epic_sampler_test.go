@@ -0,0 +1,121 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func searchResponse(body string) *http.Response {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}
+}
+
+func TestEpicSamplerNextRequest(t *testing.T) {
+	sampler := &epicSampler{every: 10}
+	reqData := &helper.RequestData{Pager: &helper.Pager{Page: 1, Skip: 0, Size: 100}}
+	var pages []int
+	for {
+		pages = append(pages, reqData.Pager.Skip/reqData.Pager.Size)
+		next, ok, err := sampler.NextRequest(reqData, searchResponse(`{"startAt":0,"maxResults":100,"total":2500,"issues":[]}`))
+		assert.Nil(t, err)
+		if !ok {
+			break
+		}
+		assert.Equal(t, reqData.Pager.Page+10, next.Pager.Page)
+		reqData = next
+	}
+	assert.Equal(t, []int{0, 10, 20}, pages)
+
+	// cursor based pages can't be jumped through
+	_, ok, err := sampler.NextRequest(reqData, searchResponse(`{"nextPageToken":"abc","isLast":false,"issues":[{}]}`))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestSampleEpicPages(t *testing.T) {
+	pager := searchPager{}
+	args := helper.ApiCollectorArgs{GetTotalPages: pager.GetTotalPages, GetNextPageCustomData: pager.GetNextPageCustomData}
+	sampleEpicPages(&args, &JiraOptions{})
+	assert.Nil(t, args.Paginator)
+	assert.NotNil(t, args.GetTotalPages)
+
+	sampleEpicPages(&args, &JiraOptions{Sample: &SampleOptions{Every: 10}})
+	assert.Equal(t, &epicSampler{every: 10}, args.Paginator)
+	assert.Nil(t, args.GetTotalPages)
+	assert.Nil(t, args.GetNextPageCustomData)
+}
+
+func TestSampledEpicKeysIterator(t *testing.T) {
+	op := &JiraOptions{Sample: &SampleOptions{Every: 10}}
+	iterator := newSampledEpicKeysIterator(&batchesIterator{epicKeyBatches(2500, 100)}, op)
+	var firstKeys []string
+	for iterator.HasNext() {
+		batch, err := iterator.Fetch()
+		assert.Nil(t, err)
+		firstKeys = append(firstKeys, *batch.([]interface{})[0].(*string))
+	}
+	assert.Equal(t, []string{"EPIC-0", "EPIC-1000", "EPIC-2000"}, firstKeys)
+
+	// the iterator is returned as is without a sample
+	unsampled := &batchesIterator{}
+	assert.Equal(t, unsampled, newSampledEpicKeysIterator(unsampled, &JiraOptions{}))
+}
+
+// epicKeysCursor is a cursor over the keys EPIC-0 to EPIC-{count-1}, Next moves to the following row as sql.Rows does
+func epicKeysCursor(count int) *mocks.Rows {
+	cursor := new(mocks.Rows)
+	row := -1
+	cursor.On("Next").Return(func() bool {
+		row++
+		return row < count
+	})
+	cursor.On("Scan", mock.Anything).Return(func(dest ...interface{}) error {
+		*dest[0].(*string) = fmt.Sprintf("EPIC-%d", row)
+		return nil
+	})
+	cursor.On("Close").Return(nil)
+	return cursor
+}
+
+func TestSampledEpicKeysIteratorOverCursor(t *testing.T) {
+	// the iterator of the epic collector is not wrapped by the shard iterator without sharding, whose HasNext is
+	// read ahead, so the sampler is over the cursor itself
+	op := &JiraOptions{Sample: &SampleOptions{Every: 2}}
+	cursorIterator, err := helper.NewBatchedDalCursorIterator(nil, epicKeysCursor(10), reflect.TypeOf(""), 2)
+	assert.Nil(t, err)
+	iterator := newSampledEpicKeysIterator(newEpicShardIterator(cursorIterator, op, nil), op)
+	var keys []string
+	for iterator.HasNext() {
+		batch, err := iterator.Fetch()
+		assert.Nil(t, err)
+		for _, key := range batch.([]interface{}) {
+			keys = append(keys, *key.(*string))
+		}
+	}
+	assert.Equal(t, []string{"EPIC-0", "EPIC-1", "EPIC-4", "EPIC-5", "EPIC-8", "EPIC-9"}, keys)
+}
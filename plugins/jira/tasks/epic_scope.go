@@ -0,0 +1,71 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	goerrors "errors"
+	"fmt"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+)
+
+// EpicScopeErrorCode is attached by `errors.WithData` to the errors of the epic collector telling what is wrong
+// with its scope rather than with the collection, so they could be told apart by automation and the UI
+type EpicScopeErrorCode string
+
+// EmptyEpicScope is the code of the error returned if none of the boards of the scope has an epic key to collect
+const EmptyEpicScope EpicScopeErrorCode = "jira-empty-epic-scope"
+
+// GetEpicScopeErrorCode returns the EpicScopeErrorCode attached to `err` or any error wrapped by it, i.e. by the
+// runner failing the subtask, an empty code is returned if there is none
+func GetEpicScopeErrorCode(err error) EpicScopeErrorCode {
+	for err != nil {
+		if lakeErr := errors.AsLakeErrorType(err); lakeErr != nil {
+			if code, ok := lakeErr.GetData().(EpicScopeErrorCode); ok {
+				return code
+			}
+		}
+		err = goerrors.Unwrap(err)
+	}
+	return ""
+}
+
+// checkEpicScope fails with a NotFound error of EmptyEpicScope if none of the boards has an issue linked to an epic,
+// instead of succeeding with nothing collected. The epic keys are counted the way GetUncollectedEpicKeysIterator
+// queries them, the scopes of which the epics are searched by the project are not checked
+func checkEpicScope(taskCtx core.SubTaskContext, boardIds []uint64) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	if data.Options.EpicKeySource == EpicKeySourceProject || data.Options.EpicWindowDays > 0 {
+		return nil
+	}
+	db := taskCtx.GetDal()
+	for _, boardId := range boardIds {
+		count, err := db.Count(uncollectedEpicKeysClauses(data.Options.ConnectionId, boardId, nil)...)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to count the epic keys of board %d", boardId))
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+	return errors.NotFound.New(
+		fmt.Sprintf("no epics in scope, none of the issues of boards %v is linked to an epic, please check the boards and make sure their issues were collected", boardIds),
+		errors.WithData(EmptyEpicScope),
+	)
+}
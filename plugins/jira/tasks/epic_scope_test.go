@@ -0,0 +1,78 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCollectEpicsEmptyScope(t *testing.T) {
+	mockDal := new(mocks.Dal)
+	mockDal.On("Count", mock.Anything).Return(int64(0), nil)
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+	mockCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{ConnectionId: 1, BoardIds: []uint64{1, 2}}})
+	err := CollectEpics(mockCtx)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.NotFound, err.GetType())
+		assert.Equal(t, EmptyEpicScope, GetEpicScopeErrorCode(err))
+		assert.Contains(t, err.Error(), "no epics in scope")
+		// the code is kept by the error of the subtask wrapping it
+		assert.Equal(t, EmptyEpicScope, GetEpicScopeErrorCode(errors.SubtaskErr.Wrap(err, "subtask collectEpics ended unexpectedly")))
+	}
+	// every board of the scope is checked
+	mockDal.AssertNumberOfCalls(t, "Count", 2)
+}
+
+func TestCheckEpicScope(t *testing.T) {
+	// the scope is not empty as soon as any board has an epic key
+	mockDal := new(mocks.Dal)
+	mockDal.On("Count", mock.Anything).Return(int64(0), nil).Once()
+	mockDal.On("Count", mock.Anything).Return(int64(3), nil).Once()
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+	mockCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{ConnectionId: 1, BoardIds: []uint64{1, 2, 3}}})
+	assert.Nil(t, checkEpicScope(mockCtx, []uint64{1, 2, 3}))
+	mockDal.AssertNumberOfCalls(t, "Count", 2)
+
+	// the epics searched by the project are not checked
+	for _, options := range []*JiraOptions{
+		{ConnectionId: 1, BoardId: 1, EpicKeySource: EpicKeySourceProject},
+		{ConnectionId: 1, BoardId: 1, EpicWindowDays: 30},
+	} {
+		mockCtx := unithelper.DummySubTaskContext(new(mocks.Dal))
+		mockCtx.On("GetData").Return(&JiraTaskData{Options: options})
+		assert.Nil(t, checkEpicScope(mockCtx, []uint64{1}))
+	}
+
+	// a failure of the query is not taken for an empty scope
+	mockDal = new(mocks.Dal)
+	mockDal.On("Count", mock.Anything).Return(int64(0), errors.Default.New("db is gone"))
+	mockCtx = unithelper.DummySubTaskContext(mockDal)
+	mockCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{ConnectionId: 1, BoardId: 1}})
+	err := checkEpicScope(mockCtx, []uint64{1})
+	if assert.NotNil(t, err) {
+		assert.NotEqual(t, errors.NotFound, err.GetType())
+		assert.Empty(t, GetEpicScopeErrorCode(err))
+	}
+	assert.Empty(t, GetEpicScopeErrorCode(nil))
+}
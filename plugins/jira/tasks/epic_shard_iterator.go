@@ -0,0 +1,100 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"hash/fnv"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+// epicShardIterator leaves out the epics of other shards from the elements, or batches of elements, of the
+// underlying iterator, so the workers running the shards of the epic collector collect disjoint sets of epics
+type epicShardIterator struct {
+	helper.Iterator
+	epicKey func(elem interface{}) string
+	count   uint32
+	index   uint32
+	next    interface{}
+	err     errors.Error
+}
+
+// newEpicShardIterator returns the iterator itself if the epic collector is not sharded by the options
+func newEpicShardIterator(iterator helper.Iterator, op *JiraOptions, epicKey func(elem interface{}) string) helper.Iterator {
+	if op.EpicShardCount <= 1 {
+		return iterator
+	}
+	return &epicShardIterator{
+		Iterator: iterator,
+		epicKey:  epicKey,
+		count:    uint32(op.EpicShardCount),
+		index:    uint32(op.EpicShardIndex),
+	}
+}
+
+// epicShardOf returns the shard of the epic by the hash of its key, which stays the same across workers and runs
+func epicShardOf(epicKey string, count uint32) uint32 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(epicKey))
+	return hash.Sum32() % count
+}
+
+// HasNext reads ahead till an element of the shard is found, since the rest of the underlying iterator might
+// belong to other shards
+func (it *epicShardIterator) HasNext() bool {
+	for it.next == nil && it.err == nil && it.Iterator.HasNext() {
+		elem, err := it.Iterator.Fetch()
+		if err != nil {
+			it.err = err
+			break
+		}
+		it.next = it.filter(elem)
+	}
+	return it.next != nil || it.err != nil
+}
+
+func (it *epicShardIterator) Fetch() (interface{}, errors.Error) {
+	if !it.HasNext() {
+		return nil, errors.Default.New("no more epics in the shard")
+	}
+	next, err := it.next, it.err
+	it.next, it.err = nil, nil
+	return next, err
+}
+
+// filter returns the element if it belongs to the shard, or the part of the batch which does, nil if none
+func (it *epicShardIterator) filter(elem interface{}) interface{} {
+	batch, ok := elem.([]interface{})
+	if !ok {
+		if epicShardOf(it.epicKey(elem), it.count) != it.index {
+			return nil
+		}
+		return elem
+	}
+	var owned []interface{}
+	for _, e := range batch {
+		if epicShardOf(it.epicKey(e), it.count) == it.index {
+			owned = append(owned, e)
+		}
+	}
+	if len(owned) == 0 {
+		return nil
+	}
+	return owned
+}
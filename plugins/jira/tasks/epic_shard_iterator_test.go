@@ -0,0 +1,124 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// elemsIterator yields the given elements one by one, as the DAL cursor iterator does without batches
+type elemsIterator struct {
+	elems []interface{}
+}
+
+func (it *elemsIterator) HasNext() bool {
+	return len(it.elems) > 0
+}
+
+func (it *elemsIterator) Fetch() (interface{}, errors.Error) {
+	next := it.elems[0]
+	it.elems = it.elems[1:]
+	return next, nil
+}
+
+func (it *elemsIterator) Close() errors.Error {
+	return nil
+}
+
+func epicKeyBatches(count, batchSize int) [][]interface{} {
+	var batches [][]interface{}
+	var batch []interface{}
+	for i := 0; i < count; i++ {
+		key := fmt.Sprintf("EPIC-%d", i)
+		batch = append(batch, &key)
+		if len(batch) == batchSize {
+			batches = append(batches, batch)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+func TestEpicShardIterator(t *testing.T) {
+	const shardCount = 3
+	collected := map[string]int{}
+	for index := 0; index < shardCount; index++ {
+		op := &JiraOptions{EpicShardCount: shardCount, EpicShardIndex: index}
+		iterator := newEpicShardIterator(&batchesIterator{epicKeyBatches(100, 7)}, op, func(elem interface{}) string {
+			return *elem.(*string)
+		})
+		for iterator.HasNext() {
+			batch, err := iterator.Fetch()
+			assert.Nil(t, err)
+			// batches of other shards only are skipped rather than returned empty
+			assert.NotEmpty(t, batch)
+			for _, e := range batch.([]interface{}) {
+				key := *e.(*string)
+				assert.Equal(t, uint32(index), epicShardOf(key, shardCount))
+				collected[key]++
+			}
+		}
+	}
+	// the shards are disjoint and cover all epics
+	assert.Len(t, collected, 100)
+	for key, times := range collected {
+		assert.Equal(t, 1, times, key)
+	}
+}
+
+func TestEpicShardIteratorSingleElements(t *testing.T) {
+	var elems []interface{}
+	for i := 0; i < 20; i++ {
+		elems = append(elems, &archivedEpicInput{EpicKey: fmt.Sprintf("EPIC-%d", i)})
+	}
+	op := &JiraOptions{EpicShardCount: 2, EpicShardIndex: 1}
+	iterator := newEpicShardIterator(&elemsIterator{elems}, op, func(elem interface{}) string {
+		return elem.(*archivedEpicInput).EpicKey
+	})
+	fetched := 0
+	for iterator.HasNext() {
+		elem, err := iterator.Fetch()
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(1), epicShardOf(elem.(*archivedEpicInput).EpicKey, 2))
+		fetched++
+	}
+	assert.Greater(t, fetched, 0)
+	assert.Less(t, fetched, 20)
+}
+
+func TestEpicShardIteratorNotSharded(t *testing.T) {
+	iterator := &batchesIterator{}
+	assert.Same(t, iterator, newEpicShardIterator(iterator, &JiraOptions{EpicShardCount: 1}, nil))
+}
+
+func TestValidateEpicShard(t *testing.T) {
+	assert.Nil(t, (&JiraOptions{}).ValidateEpicShard())
+	assert.Nil(t, (&JiraOptions{EpicShardCount: 4, EpicShardIndex: 3}).ValidateEpicShard())
+	assert.NotNil(t, (&JiraOptions{EpicShardCount: 4, EpicShardIndex: 4}).ValidateEpicShard())
+	assert.NotNil(t, (&JiraOptions{EpicShardIndex: 1}).ValidateEpicShard())
+	assert.NotNil(t, (&JiraOptions{EpicShardCount: 2, EpicWindowDays: 7}).ValidateEpicShard())
+	assert.Equal(t, "", (&JiraOptions{}).EpicShard())
+	assert.Equal(t, "1/4", (&JiraOptions{EpicShardCount: 4, EpicShardIndex: 1}).EpicShard())
+}
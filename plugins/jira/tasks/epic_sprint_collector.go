@@ -0,0 +1,124 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+const RAW_EPIC_SPRINT_TABLE = "jira_api_epic_sprints"
+
+var _ core.SubTaskEntryPoint = CollectEpicSprints
+
+var CollectEpicSprintsMeta = core.SubTaskMeta{
+	Name:             "collectEpicSprints",
+	EntryPoint:       CollectEpicSprints,
+	EnabledByDefault: true,
+	Description:      "collect the sprints of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+}
+
+// CollectEpicSprints collects the sprint field of the epics, epics are not necessarily on the boards of the sprints,
+// so they are missing from the sprint issues of the boards. The field is configured by the connection, and skipped
+// if absent
+func CollectEpicSprints(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	if data.SprintField == "" {
+		taskCtx.GetLogger().Info("sprint field of the connection is not configured, skip collecting epic sprints")
+		return nil
+	}
+	boardIds := data.Options.GetBoardIds()
+	for i, boardId := range boardIds {
+		err := collectBoardEpicSprints(taskCtx, boardId, boardIds[:i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectBoardEpicSprints collects the sprints of the epics in full mode, a sprint removed from an epic doesn't
+// show up in an incremental collection
+func collectBoardEpicSprints(taskCtx core.SubTaskContext, boardId uint64, collectedBoardIds []uint64) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	taskCtx.GetLogger().Info("collect epic sprints of board %d", boardId)
+	return collectBoardEpicFields(taskCtx, boardId, collectedBoardIds, RAW_EPIC_SPRINT_TABLE, getEpicSprintFields(data))
+}
+
+// collectBoardEpicFields collects the fields of the epics left uncollected by the boards before into the raw table,
+// the epics are searched by their keys batch by batch
+func collectBoardEpicFields(taskCtx core.SubTaskContext, boardId uint64, collectedBoardIds []uint64, table string, fields []string) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	batchSize := data.Options.EpicKeysBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEpicKeysBatchSize
+	}
+	epicIterator, err := GetUncollectedEpicKeysIterator(taskCtx, boardId, collectedBoardIds, batchSize)
+	if err != nil {
+		return err
+	}
+	overhead := len(buildEpicJql(defaultEpicOrderBy, nil, "", "")) - len(epicKeysCriteria(nil))
+	limitedIterator := newJqlLimitedEpicKeysIterator(epicIterator, maxEpicJqlLength-overhead)
+	pager := searchPager{logger: logger}
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: data.Options.ConnectionId,
+				BoardId:      boardId,
+			},
+			Table: data.Options.RawTable(table),
+		},
+		ApiClient:   data.ApiClient,
+		PageSize:    data.PageSize(100),
+		UrlTemplate: data.ApiVersion().SearchPath(),
+		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+			query := url.Values{}
+			epicKeys := []string{}
+			for _, e := range reqData.Input.([]interface{}) {
+				epicKeys = append(epicKeys, *e.(*string))
+			}
+			query.Set("jql", buildEpicJql(defaultEpicOrderBy, epicKeys, "", ""))
+			query.Set("fields", strings.Join(fields, ","))
+			pager.SetQuery(query, reqData)
+			return query, nil
+		},
+		Input:                 limitedIterator,
+		GetTotalPages:         pager.GetTotalPages,
+		GetPageSize:           GetPageSizeFromResponse,
+		GetNextPageCustomData: pager.GetNextPageCustomData,
+		DryRun:                data.Options.DryRun,
+		PageTimeout:           data.PageTimeout,
+		Concurrency:           data.Concurrency,
+		AfterResponse: chainAfterResponse(
+			newRateLimitObserver(logger).AfterResponse,
+			ignoreNonexistentEpics(logger, limitedIterator),
+		),
+		ResponseParser: pager.ResponseParser,
+	})
+	if err != nil {
+		return err
+	}
+	return collector.Execute()
+}
@@ -0,0 +1,88 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"reflect"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/models/domainlayer/didgen"
+	"github.com/apache/incubator-devlake/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+var _ core.SubTaskEntryPoint = ConvertEpicSprints
+
+var ConvertEpicSprintsMeta = core.SubTaskMeta{
+	Name:             "convertEpicSprints",
+	EntryPoint:       ConvertEpicSprints,
+	EnabledByDefault: true,
+	Description:      "convert the sprints of Jira epics",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+}
+
+// ConvertEpicSprints converts the sprint issues extracted by ExtractEpicSprints, the rest of sprint issues are
+// converted by ConvertSprintIssues
+func ConvertEpicSprints(taskCtx core.SubTaskContext) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*JiraTaskData)
+	if data.SprintField == "" {
+		return nil
+	}
+	clauses := []dal.Clause{
+		dal.From(&models.JiraSprintIssue{}),
+		dal.Where("connection_id = ? AND _raw_data_table = ?", data.Options.ConnectionId, "_raw_"+data.Options.RawTable(RAW_EPIC_SPRINT_TABLE)),
+	}
+	cursor, err := db.Cursor(clauses...)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	issueIdGen := didgen.NewDomainIdGenerator(&models.JiraIssue{})
+	sprintIdGen := didgen.NewDomainIdGenerator(&models.JiraSprint{})
+
+	converter, err := helper.NewDataConverter(helper.DataConverterArgs{
+		InputRowType: reflect.TypeOf(models.JiraSprintIssue{}),
+		Input:        cursor,
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: data.Options.ConnectionId,
+				BoardId:      data.Options.BoardId,
+			},
+			Table: data.Options.RawTable(RAW_EPIC_SPRINT_TABLE),
+		},
+		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
+			jiraSprintIssue := inputRow.(*models.JiraSprintIssue)
+			return []interface{}{
+				&ticket.SprintIssue{
+					SprintId: sprintIdGen.Generate(data.Options.ConnectionId, jiraSprintIssue.SprintId),
+					IssueId:  issueIdGen.Generate(data.Options.ConnectionId, jiraSprintIssue.IssueId),
+				},
+			}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return converter.Execute()
+}
@@ -0,0 +1,156 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+var _ core.SubTaskEntryPoint = ExtractEpicSprints
+
+var ExtractEpicSprintsMeta = core.SubTaskMeta{
+	Name:             "extractEpicSprints",
+	EntryPoint:       ExtractEpicSprints,
+	EnabledByDefault: true,
+	Description:      "extract the sprints of Jira epics from all boards",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET},
+}
+
+func ExtractEpicSprints(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	if data.SprintField == "" {
+		return nil
+	}
+	for _, boardId := range data.Options.GetBoardIds() {
+		err := extractBoardEpicSprints(taskCtx, boardId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractBoardEpicSprints(taskCtx core.SubTaskContext, boardId uint64) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	connectionId := data.Options.ConnectionId
+	extractor, err := helper.NewApiExtractor(helper.ApiExtractorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: connectionId,
+				BoardId:      boardId,
+			},
+			Table: data.Options.RawTable(RAW_EPIC_SPRINT_TABLE),
+		},
+		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
+			var epic struct {
+				ID     uint64                     `json:"id,string"`
+				Fields map[string]json.RawMessage `json:"fields"`
+			}
+			err := errors.Convert(json.Unmarshal(row.Data, &epic))
+			if err != nil {
+				return nil, err
+			}
+			var created, resolutionDate *helper.Iso8601Time
+			err = unmarshalOptionalField(epic.Fields["created"], &created)
+			if err != nil {
+				return nil, err
+			}
+			err = unmarshalOptionalField(epic.Fields["resolutiondate"], &resolutionDate)
+			if err != nil {
+				return nil, err
+			}
+			sprintIds, err := parseSprintIds(epic.Fields[data.SprintField])
+			if err != nil {
+				return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to parse %s of issue %d", data.SprintField, epic.ID))
+			}
+			var results []interface{}
+			for _, sprintId := range sprintIds {
+				results = append(results, &models.JiraSprintIssue{
+					ConnectionId:     connectionId,
+					SprintId:         sprintId,
+					IssueId:          epic.ID,
+					IssueCreatedDate: created.ToNullableTime(),
+					ResolutionDate:   resolutionDate.ToNullableTime(),
+				})
+			}
+			return results, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return extractor.Execute()
+}
+
+func unmarshalOptionalField(raw json.RawMessage, v interface{}) errors.Error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return errors.Convert(json.Unmarshal(raw, v))
+}
+
+// legacySprintIdPattern matches the id of sprints serialized by older Jira Server, e.g.
+// "com.atlassian.greenhopper.service.sprint.Sprint@1a2b[id=12,rapidViewId=3,state=CLOSED,name=Sprint 1,...]"
+var legacySprintIdPattern = regexp.MustCompile(`\[id=(\d+),`)
+
+// parseSprintIds returns the ids of all sprints of the sprint field, including the closed ones, the field is an array
+// of sprint objects on Jira Cloud and Data Center, or an array of serialized strings on older Jira Server
+func parseSprintIds(raw json.RawMessage) ([]uint64, errors.Error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var values []json.RawMessage
+	err := errors.Convert(json.Unmarshal(raw, &values))
+	if err != nil {
+		return nil, err
+	}
+	var sprintIds []uint64
+	for _, value := range values {
+		var legacy string
+		if json.Unmarshal(value, &legacy) == nil {
+			matches := legacySprintIdPattern.FindStringSubmatch(legacy)
+			if matches == nil {
+				return nil, errors.Default.New(fmt.Sprintf("unrecognized sprint %s", legacy))
+			}
+			sprintId, e := strconv.ParseUint(matches[1], 10, 64)
+			if e != nil {
+				return nil, errors.Convert(e)
+			}
+			sprintIds = append(sprintIds, sprintId)
+			continue
+		}
+		var sprint struct {
+			ID uint64 `json:"id"`
+		}
+		err = errors.Convert(json.Unmarshal(value, &sprint))
+		if err != nil {
+			return nil, err
+		}
+		sprintIds = append(sprintIds, sprint.ID)
+	}
+	return sprintIds, nil
+}
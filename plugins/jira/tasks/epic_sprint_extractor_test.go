@@ -0,0 +1,45 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSprintIds(t *testing.T) {
+	cases := map[string][]uint64{
+		``:     nil,
+		`null`: nil,
+		`[]`:   nil,
+		`[{"id":12,"name":"Sprint 1","state":"closed","boardId":3},{"id":15,"name":"Sprint 2","state":"active","boardId":3}]`: {12, 15},
+		`["com.atlassian.greenhopper.service.sprint.Sprint@1a2b[id=12,rapidViewId=3,state=CLOSED,name=Sprint 1,goal=]"]`:      {12},
+	}
+	for raw, expected := range cases {
+		sprintIds, err := parseSprintIds(json.RawMessage(raw))
+		assert.Nil(t, err, raw)
+		assert.Equal(t, expected, sprintIds, raw)
+	}
+
+	_, err := parseSprintIds(json.RawMessage(`["not a sprint"]`))
+	assert.NotNil(t, err)
+	_, err = parseSprintIds(json.RawMessage(`{"id":12}`))
+	assert.NotNil(t, err)
+}
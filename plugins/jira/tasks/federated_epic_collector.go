@@ -0,0 +1,140 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+var _ core.SubTaskEntryPoint = CollectFederatedEpics
+
+var CollectFederatedEpicsMeta = core.SubTaskMeta{
+	Name:             "collectFederatedEpics",
+	EntryPoint:       CollectFederatedEpics,
+	EnabledByDefault: true,
+	Description:      "collect Jira epics from the boards of the federated connections",
+	DomainTypes:      []string{core.DOMAIN_TYPE_TICKET, core.DOMAIN_TYPE_CROSS},
+}
+
+// CollectFederatedEpics fans CollectEpics out over the federated connections one after another. Each connection is
+// collected by an api client of its own, so it is authenticated and rate limited independently of the others, and
+// its raw rows are saved with its own connection id in the params, to be extracted by the task of the connection
+func CollectFederatedEpics(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	for _, federated := range data.Options.FederatedConnections {
+		err := collectFederatedConnectionEpics(taskCtx, federated)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to collect epics of federated connection %d", federated.ConnectionId))
+		}
+	}
+	return nil
+}
+
+func collectFederatedConnectionEpics(taskCtx core.SubTaskContext, federated FederatedConnection) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	connection := &models.JiraConnection{}
+	err := helper.NewConnectionHelper(taskCtx, nil).FirstById(connection, federated.ConnectionId)
+	if err != nil {
+		return errors.Default.Wrap(err, "unable to get Jira connection")
+	}
+	apiClient, err := NewJiraApiClient(taskCtx.TaskContext(), connection)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to create jira api client")
+	}
+	defer apiClient.Release()
+	// the federated instances are talked to in v2, their deployment is left unknown by newFederatedTaskData
+	federatedData, err := newFederatedTaskData(data, federated, connection, NewFieldResolver(apiClient, JiraApiV2))
+	if err != nil {
+		return err
+	}
+	federatedData.ApiClient = apiClient
+	// the raw epics of the federated connection are exported to the bucket of its own, if any
+	federatedData.RawExporter, err = LoadRawExporter(taskCtx, federated.ConnectionId)
+	if err != nil {
+		return err
+	}
+	// the user of the federated connection might be in another time zone
+	federatedData.TimeZone, err = GetJiraTimeZone(apiClient, nil)
+	if err != nil {
+		return errors.Default.Wrap(err, "fail to get the time zone of the Jira user")
+	}
+	taskCtx.GetLogger().Info("collect epics of federated connection %d", federated.ConnectionId)
+	return CollectEpics(&federatedSubTaskContext{SubTaskContext: taskCtx, data: federatedData})
+}
+
+// newFederatedTaskData derives the task data of the federated connection from the one of the task, the scope,
+// fields and settings of the connection are replaced while the rest of the options are shared
+func newFederatedTaskData(
+	data *JiraTaskData,
+	federated FederatedConnection,
+	connection *models.JiraConnection,
+	fieldResolver *FieldResolver,
+) (*JiraTaskData, errors.Error) {
+	options := *data.Options
+	options.ConnectionId = federated.ConnectionId
+	options.BoardId = federated.BoardIds[0]
+	options.BoardIds = federated.BoardIds
+	options.BoardNames = nil
+	options.FederatedConnections = nil
+	// fields configured for the instance of the task don't apply to the federated one
+	options.TransformationRules = TransformationRules{StoryPointField: federated.StoryPointField}
+	sprintField := connection.SprintField
+	err := fieldResolver.ResolveAll(&options.TransformationRules.StoryPointField, &sprintField)
+	if err != nil {
+		return nil, err
+	}
+	federatedData := *data
+	federatedData.Options = &options
+	federatedData.ConnectionType = connection.Type
+	federatedData.Concurrency = GetCollectorConcurrency(connection)
+	federatedData.MaxResultsPerPage = connection.MaxResultsPerPage
+	federatedData.SprintField = sprintField
+	federatedData.FieldResolver = fieldResolver
+	federatedData.JiraServerInfo = models.JiraServerInfo{}
+	return &federatedData, nil
+}
+
+// federatedSubTaskContext runs a subtask with the task data of a federated connection
+type federatedSubTaskContext struct {
+	core.SubTaskContext
+	data *JiraTaskData
+}
+
+// GetData returns the task data of the federated connection
+func (c *federatedSubTaskContext) GetData() interface{} {
+	return c.data
+}
+
+// ReportSubTaskResult passes the result on to the context of the subtask, the interface is not promoted by embedding
+func (c *federatedSubTaskContext) ReportSubTaskResult(result core.SubTaskResult) {
+	if reporter, ok := c.SubTaskContext.(core.SubTaskResultReporter); ok {
+		reporter.ReportSubTaskResult(result)
+	}
+}
+
+// ReportCollectorStats passes the stats on to the context of the subtask
+func (c *federatedSubTaskContext) ReportCollectorStats(stats core.CollectorStats) {
+	if reporter, ok := c.SubTaskContext.(core.CollectorStatsReporter); ok {
+		reporter.ReportCollectorStats(stats)
+	}
+}
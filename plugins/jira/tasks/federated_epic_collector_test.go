@@ -0,0 +1,104 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestValidateFederatedConnections(t *testing.T) {
+	op := &JiraOptions{ConnectionId: 1, FederatedConnections: []FederatedConnection{
+		{ConnectionId: 2, BoardIds: []uint64{10, 11}},
+		{ConnectionId: 3, BoardIds: []uint64{12}},
+	}}
+	assert.Nil(t, op.ValidateFederatedConnections())
+
+	invalid := [][]FederatedConnection{
+		{{ConnectionId: 0, BoardIds: []uint64{10}}},
+		{{ConnectionId: 1, BoardIds: []uint64{10}}},
+		{{ConnectionId: 2, BoardIds: []uint64{10}}, {ConnectionId: 2, BoardIds: []uint64{11}}},
+		{{ConnectionId: 2}},
+		{{ConnectionId: 2, BoardIds: []uint64{0}}},
+	}
+	for _, federated := range invalid {
+		op := &JiraOptions{ConnectionId: 1, FederatedConnections: federated}
+		err := op.ValidateFederatedConnections()
+		if assert.NotNil(t, err, federated) {
+			assert.Equal(t, errors.BadInput, err.GetType(), federated)
+		}
+	}
+}
+
+func TestNewFederatedTaskData(t *testing.T) {
+	apiClient := mocks.NewApiClientGetter(t)
+	apiClient.On("Get", "api/2/field", mock.Anything, mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Request:    &http.Request{URL: &url.URL{}},
+		Body: io.NopCloser(bytes.NewBufferString(`[
+			{"id":"customfield_20024","name":"Story Points","custom":true},
+			{"id":"customfield_20020","name":"Sprint","custom":true}
+		]`)),
+	}, nil).Once()
+	data := &JiraTaskData{
+		Options: &JiraOptions{
+			ConnectionId:         1,
+			BoardId:              5,
+			BoardNames:           []string{"Team A"},
+			Labels:               []string{"roadmap"},
+			TransformationRules:  TransformationRules{StoryPointField: "customfield_10024", EpicKeyField: "customfield_10014"},
+			FederatedConnections: []FederatedConnection{{ConnectionId: 2, BoardIds: []uint64{10, 11}, StoryPointField: "Story Points"}},
+		},
+		Concurrency: 10,
+		SprintField: "customfield_10020",
+	}
+	connection := &models.JiraConnection{SprintField: "Sprint", Type: models.ConnectionTypeJira}
+
+	federatedData, err := newFederatedTaskData(data, data.Options.FederatedConnections[0], connection, NewFieldResolver(apiClient, JiraApiV2))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), federatedData.Options.ConnectionId)
+	assert.Equal(t, []uint64{10, 11}, federatedData.Options.GetBoardIds())
+	assert.Empty(t, federatedData.Options.BoardNames)
+	assert.Empty(t, federatedData.Options.FederatedConnections)
+	assert.Equal(t, TransformationRules{StoryPointField: "customfield_20024"}, federatedData.Options.TransformationRules)
+	assert.Equal(t, []string{"roadmap"}, federatedData.Options.Labels)
+	assert.Equal(t, "customfield_20020", federatedData.SprintField)
+	assert.Equal(t, GetCollectorConcurrency(connection), federatedData.Concurrency)
+	// the task data of the task is left intact
+	assert.Equal(t, uint64(1), data.Options.ConnectionId)
+	assert.Equal(t, "customfield_10020", data.SprintField)
+
+	ctx := &federatedSubTaskContext{SubTaskContext: unithelper.DummySubTaskContext(nil), data: federatedData}
+	assert.Equal(t, federatedData, ctx.GetData())
+}
+
+func TestCollectFederatedEpicsWithoutConnections(t *testing.T) {
+	mockCtx := unithelper.DummySubTaskContext(new(mocks.Dal))
+	mockCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{ConnectionId: 1, BoardId: 2}})
+	assert.Nil(t, CollectFederatedEpics(mockCtx))
+}
@@ -0,0 +1,117 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+var customFieldIdPattern = regexp.MustCompile(`^customfield_\d+$`)
+
+// FieldResolver resolves the ids of Jira fields by their names, custom fields are assigned different ids by every
+// Jira instance, e.g. "Epic Link" could be `customfield_10014` on one and `customfield_10100` on another.
+// The fields are loaded once on the first resolution of a name, so a resolver kept along with the task data is
+// shared by all subtasks
+type FieldResolver struct {
+	apiClient helper.ApiClientGetter
+	version   JiraApiVersion
+	mu        sync.Mutex
+	// ids maps lowercased names and ids to ids, names shared by several fields are mapped to all of them
+	ids map[string][]string
+}
+
+// NewFieldResolver creates a resolver loading the fields by the api client in the given version of the REST api
+func NewFieldResolver(apiClient helper.ApiClientGetter, version JiraApiVersion) *FieldResolver {
+	return &FieldResolver{apiClient: apiClient, version: version}
+}
+
+// Resolve returns the id of the field of the given name, case-insensitively, ids are returned as they are.
+// An empty string is returned for an empty name
+func (r *FieldResolver) Resolve(nameOrId string) (string, errors.Error) {
+	if nameOrId == "" || customFieldIdPattern.MatchString(nameOrId) {
+		return nameOrId, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ids == nil {
+		err := r.load()
+		if err != nil {
+			return "", err
+		}
+	}
+	ids := r.ids[strings.ToLower(nameOrId)]
+	switch len(ids) {
+	case 0:
+		return "", errors.BadInput.New(fmt.Sprintf("jira field %s does not exist", nameOrId))
+	case 1:
+		return ids[0], nil
+	default:
+		return "", errors.BadInput.New(fmt.Sprintf("jira field %s is ambiguous, please use one of the ids %s instead", nameOrId, strings.Join(ids, ", ")))
+	}
+}
+
+// ResolveAll replaces the names pointed by `fields` with their ids
+func (r *FieldResolver) ResolveAll(fields ...*string) errors.Error {
+	for _, field := range fields {
+		id, err := r.Resolve(*field)
+		if err != nil {
+			return err
+		}
+		*field = id
+	}
+	return nil
+}
+
+func (r *FieldResolver) load() errors.Error {
+	res, err := r.apiClient.Get(r.version.Path("field"), nil, nil)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load jira fields")
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to load jira fields, unexpected status code: %d", res.StatusCode))
+	}
+	var fields []struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+	err = helper.UnmarshalResponse(res, &fields)
+	if err != nil {
+		return err
+	}
+	r.ids = make(map[string][]string, len(fields)*2)
+	for _, field := range fields {
+		r.ids[strings.ToLower(field.Id)] = []string{field.Id}
+	}
+	for _, field := range fields {
+		name := strings.ToLower(field.Name)
+		// ids take precedence over names, a system field is not shadowed by a custom field named after its id
+		if ids, ok := r.ids[name]; ok && len(ids) == 1 && strings.EqualFold(ids[0], name) {
+			continue
+		}
+		r.ids[name] = append(r.ids[name], field.Id)
+	}
+	return nil
+}
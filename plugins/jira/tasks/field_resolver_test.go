@@ -0,0 +1,74 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFieldResolver(t *testing.T) {
+	apiClient := mocks.NewApiClientGetter(t)
+	apiClient.On("Get", "api/2/field", mock.Anything, mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Request:    &http.Request{URL: &url.URL{}},
+		Body: io.NopCloser(bytes.NewBufferString(`[
+			{"id":"parent","name":"Parent","custom":false},
+			{"id":"customfield_10014","name":"Epic Link","custom":true},
+			{"id":"customfield_10024","name":"Story Points","custom":true},
+			{"id":"customfield_10025","name":"Story Points","custom":true},
+			{"id":"customfield_10020","name":"Sprint","custom":true}
+		]`)),
+	}, nil).Once()
+	resolver := NewFieldResolver(apiClient, JiraApiV2)
+
+	// ids don't need fields to be loaded
+	id, err := resolver.Resolve("customfield_10099")
+	assert.Nil(t, err)
+	assert.Equal(t, "customfield_10099", id)
+	id, err = resolver.Resolve("")
+	assert.Nil(t, err)
+	assert.Equal(t, "", id)
+
+	id, err = resolver.Resolve("Epic Link")
+	assert.Nil(t, err)
+	assert.Equal(t, "customfield_10014", id)
+	id, err = resolver.Resolve("sprint")
+	assert.Nil(t, err)
+	assert.Equal(t, "customfield_10020", id)
+	id, err = resolver.Resolve("parent")
+	assert.Nil(t, err)
+	assert.Equal(t, "parent", id)
+	_, err = resolver.Resolve("Story Points")
+	assert.NotNil(t, err)
+	_, err = resolver.Resolve("Missing")
+	assert.NotNil(t, err)
+
+	epicKeyField, sprintField := "Epic Link", "Sprint"
+	assert.Nil(t, resolver.ResolveAll(&epicKeyField, &sprintField))
+	assert.Equal(t, "customfield_10014", epicKeyField)
+	assert.Equal(t, "customfield_10020", sprintField)
+	// fields are loaded only once, which is asserted by the mock
+}
@@ -95,21 +95,23 @@ func CollectIssueChangelogs(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_CHANGELOG_TABLE,
+			Table: data.Options.RawTable(RAW_CHANGELOG_TABLE),
 		},
 		ApiClient:     data.ApiClient,
-		PageSize:      100,
+		PageSize:      data.PageSize(100),
 		Incremental:   since == nil,
 		GetTotalPages: GetTotalPagesFromResponse,
+		GetPageSize:   GetPageSizeFromResponse,
+		IsLastPage:    IsLastPageFromResponse,
 		Input:         iterator,
-		UrlTemplate:   "api/3/issue/{{ .Input.IssueId }}/changelog",
+		UrlTemplate:   data.ApiVersion().Path("issue/{{ .Input.IssueId }}/changelog"),
 		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
 			query := url.Values{}
 			query.Set("startAt", fmt.Sprintf("%v", reqData.Pager.Skip))
 			query.Set("maxResults", fmt.Sprintf("%v", reqData.Pager.Size))
 			return query, nil
 		},
-		Concurrency: 10,
+		Concurrency: data.Concurrency,
 		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
 			var data struct {
 				Values []json.RawMessage
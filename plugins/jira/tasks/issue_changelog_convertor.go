@@ -97,7 +97,7 @@ func ConvertIssueChangelogs(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: connectionId,
 				BoardId:      boardId,
 			},
-			Table: RAW_CHANGELOG_TABLE,
+			Table: data.Options.RawTable(RAW_CHANGELOG_TABLE),
 		},
 		InputRowType: reflect.TypeOf(IssueChangelogItemResult{}),
 		Input:        cursor,
@@ -49,7 +49,7 @@ func ExtractIssueChangelogs(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_CHANGELOG_TABLE,
+			Table: data.Options.RawTable(RAW_CHANGELOG_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			// process input
@@ -25,6 +25,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/apache/incubator-devlake/plugins/core"
 	"github.com/apache/incubator-devlake/plugins/core/dal"
@@ -39,6 +40,12 @@ const RAW_ISSUE_TABLE = "jira_api_issues"
 type JiraApiParams struct {
 	ConnectionId uint64
 	BoardId      uint64
+	// Shard is the shard of the epic collector which collected the raw rows, see JiraOptions.EpicShard
+	Shard string `json:",omitempty"`
+	// Sampled tells the raw rows were collected by a sample rather than a full collection, see JiraOptions.Sample
+	Sampled bool `json:",omitempty"`
+	// ProjectId is the project whose epics were collected for the board, see JiraOptions.EpicKeySource
+	ProjectId uint `json:",omitempty"`
 }
 
 var _ core.SubTaskEntryPoint = CollectIssues
@@ -55,10 +62,8 @@ func CollectIssues(taskCtx core.SubTaskContext) errors.Error {
 	db := taskCtx.GetDal()
 	data := taskCtx.GetData().(*JiraTaskData)
 
-	since := data.Since
-	incremental := false
-	// user didn't specify a time range to sync, try load from database
-	if since == nil {
+	// the incremental state is the latest updated issue of the board
+	since, incremental, err := getCollectionSince(taskCtx.GetLogger(), data, func() (*time.Time, errors.Error) {
 		var latestUpdated models.JiraIssue
 		clauses := []dal.Clause{
 			dal.Select("_tool_jira_issues.*"),
@@ -69,21 +74,22 @@ func CollectIssues(taskCtx core.SubTaskContext) errors.Error {
 		}
 		err := db.First(&latestUpdated, clauses...)
 		if err != nil && !goerror.Is(err, gorm.ErrRecordNotFound) {
-			return errors.NotFound.Wrap(err, "failed to get latest jira issue record")
+			return nil, errors.NotFound.Wrap(err, "failed to get latest jira issue record")
 		}
 		if latestUpdated.IssueId > 0 {
-			since = &latestUpdated.Updated
-			incremental = true
+			return &latestUpdated.Updated, nil
 		}
+		return nil, nil
+	})
+	if err != nil {
+		return err
 	}
 	// build jql
 	// IMPORTANT: we have to keep paginated data in a consistence order to avoid data-missing, if we sort issues by
 	//  `updated`, issue will be jumping between pages if it got updated during the collection process
-	jql := "ORDER BY created ASC"
-	if since != nil {
-		// prepend a time range criteria if `since` was specified, either by user or from database
-		jql = fmt.Sprintf("updated >= '%v' %v", since.Format("2006/01/02 15:04"), jql)
-	}
+	// add a time range criteria if `since` was specified, either by user or from database
+	updatedCriteria := updatedSinceCriteria(since, data.TimeZone)
+	jql := buildJql("created ASC", updatedCriteria, userJqlCriteria(data.Options.Jql))
 
 	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
 		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
@@ -99,10 +105,10 @@ func CollectIssues(taskCtx core.SubTaskContext) errors.Error {
 			/*
 				Table store raw data
 			*/
-			Table: RAW_ISSUE_TABLE,
+			Table: data.Options.RawTable(RAW_ISSUE_TABLE),
 		},
 		ApiClient:   data.ApiClient,
-		PageSize:    100,
+		PageSize:    data.PageSize(100),
 		Incremental: incremental,
 		/*
 			url may use arbitrary variables from different connection in any order, we need GoTemplate to allow more
@@ -143,7 +149,10 @@ func CollectIssues(taskCtx core.SubTaskContext) errors.Error {
 			or other techniques are required if this information was missing.
 		*/
 		GetTotalPages: GetTotalPagesFromResponse,
-		Concurrency:   10,
+		GetPageSize:   GetPageSizeFromResponse,
+		IsLastPage:    IsLastPageFromResponse,
+		Concurrency:   data.Concurrency,
+		PageTimeout:   data.PageTimeout,
 		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
 			var data struct {
 				Issues []json.RawMessage `json:"issues"`
@@ -69,7 +69,7 @@ func ConvertIssueCommits(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: connectionId,
 				BoardId:      boardId,
 			},
-			Table: RAW_REMOTELINK_TABLE,
+			Table: data.Options.RawTable(RAW_REMOTELINK_TABLE),
 		},
 		InputRowType: reflect.TypeOf(models.JiraIssueCommit{}),
 		Input:        cursor,
@@ -79,46 +79,11 @@ func ConvertIssues(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_ISSUE_TABLE,
+			Table: data.Options.RawTable(RAW_ISSUE_TABLE),
 		},
 		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
 			jiraIssue := inputRow.(*jiraModels.JiraIssue)
-			issue := &ticket.Issue{
-				DomainEntity: domainlayer.DomainEntity{
-					Id: issueIdGen.Generate(jiraIssue.ConnectionId, jiraIssue.IssueId),
-				},
-				Url:                     convertURL(jiraIssue.Self, jiraIssue.IssueKey),
-				IconURL:                 jiraIssue.IconURL,
-				IssueKey:                jiraIssue.IssueKey,
-				Title:                   jiraIssue.Summary,
-				EpicKey:                 jiraIssue.EpicKey,
-				Type:                    jiraIssue.StdType,
-				Status:                  jiraIssue.StdStatus,
-				OriginalStatus:          jiraIssue.StatusName,
-				StoryPoint:              jiraIssue.StdStoryPoint,
-				OriginalEstimateMinutes: jiraIssue.OriginalEstimateMinutes,
-				ResolutionDate:          jiraIssue.ResolutionDate,
-				Priority:                jiraIssue.PriorityName,
-				CreatedDate:             &jiraIssue.Created,
-				UpdatedDate:             &jiraIssue.Updated,
-				LeadTimeMinutes:         int64(jiraIssue.LeadTimeMinutes),
-				TimeSpentMinutes:        jiraIssue.SpentMinutes,
-			}
-			if jiraIssue.CreatorAccountId != "" {
-				issue.CreatorId = accountIdGen.Generate(data.Options.ConnectionId, jiraIssue.CreatorAccountId)
-			}
-			if jiraIssue.CreatorDisplayName != "" {
-				issue.CreatorName = jiraIssue.CreatorDisplayName
-			}
-			if jiraIssue.AssigneeAccountId != "" {
-				issue.AssigneeId = accountIdGen.Generate(data.Options.ConnectionId, jiraIssue.AssigneeAccountId)
-			}
-			if jiraIssue.AssigneeDisplayName != "" {
-				issue.AssigneeName = jiraIssue.AssigneeDisplayName
-			}
-			if jiraIssue.ParentId != 0 {
-				issue.ParentIssueId = issueIdGen.Generate(data.Options.ConnectionId, jiraIssue.ParentId)
-			}
+			issue := convertIssue(data, jiraIssue, issueIdGen, accountIdGen)
 			boardIssue := &ticket.BoardIssue{
 				BoardId: boardId,
 				IssueId: issue.Id,
@@ -136,6 +101,55 @@ func ConvertIssues(taskCtx core.SubTaskContext) errors.Error {
 	return converter.Execute()
 }
 
+// epicIssueType is the name of the issue type of the epics, the one the projects are searched for epics by
+const epicIssueType = "Epic"
+
+// convertIssue maps a Jira issue to the domain layer issue
+func convertIssue(data *JiraTaskData, jiraIssue *jiraModels.JiraIssue, issueIdGen, accountIdGen *didgen.DomainIdGenerator) *ticket.Issue {
+	issue := &ticket.Issue{
+		DomainEntity: domainlayer.DomainEntity{
+			Id: issueIdGen.Generate(jiraIssue.ConnectionId, jiraIssue.IssueId),
+		},
+		Url:                     convertURL(jiraIssue.Self, jiraIssue.IssueKey),
+		IconURL:                 jiraIssue.IconURL,
+		IssueKey:                jiraIssue.IssueKey,
+		Title:                   jiraIssue.Summary,
+		EpicKey:                 jiraIssue.EpicKey,
+		Type:                    jiraIssue.StdType,
+		Status:                  jiraIssue.StdStatus,
+		OriginalStatus:          jiraIssue.StatusName,
+		StoryPoint:              jiraIssue.StdStoryPoint,
+		OriginalEstimateMinutes: jiraIssue.OriginalEstimateMinutes,
+		ResolutionDate:          jiraIssue.ResolutionDate,
+		Priority:                jiraIssue.PriorityName,
+		CreatedDate:             &jiraIssue.Created,
+		UpdatedDate:             &jiraIssue.Updated,
+		LeadTimeMinutes:         int64(jiraIssue.LeadTimeMinutes),
+		TimeSpentMinutes:        jiraIssue.SpentMinutes,
+	}
+	if jiraIssue.CreatorAccountId != "" {
+		issue.CreatorId = accountIdGen.Generate(data.Options.ConnectionId, jiraIssue.CreatorAccountId)
+	}
+	if jiraIssue.CreatorDisplayName != "" {
+		issue.CreatorName = jiraIssue.CreatorDisplayName
+	}
+	if jiraIssue.AssigneeAccountId != "" {
+		issue.AssigneeId = accountIdGen.Generate(data.Options.ConnectionId, jiraIssue.AssigneeAccountId)
+	}
+	if jiraIssue.AssigneeDisplayName != "" {
+		issue.AssigneeName = jiraIssue.AssigneeDisplayName
+	}
+	if jiraIssue.ParentId != 0 {
+		issue.ParentIssueId = issueIdGen.Generate(data.Options.ConnectionId, jiraIssue.ParentId)
+	}
+	// epics are typed by their issue type rather than the mapping of it, so the epics on the board are typed the same
+	// as the ones put onto it by ConvertEpics
+	if jiraIssue.Type == epicIssueType {
+		issue.Type = ticket.EPIC
+	}
+	return issue
+}
+
 func convertURL(api, issueKey string) string {
 	u, err := url.Parse(api)
 	if err != nil {
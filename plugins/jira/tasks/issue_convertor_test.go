@@ -17,7 +17,19 @@ limitations under the License.
 
 package tasks
 
-import "testing"
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+)
 
 func Test_convertURL(t *testing.T) {
 	type args struct {
@@ -48,3 +60,48 @@ func Test_convertURL(t *testing.T) {
 		})
 	}
 }
+
+// boardIssuesDal returns all the issues as the ones of the board, the join of the board issues is not supported by
+// the MemoryDal
+type boardIssuesDal struct {
+	*unithelper.MemoryDal
+}
+
+func (d *boardIssuesDal) Cursor(clauses ...dal.Clause) (dal.Rows, errors.Error) {
+	return d.MemoryDal.Cursor(dal.From(&models.JiraIssue{}))
+}
+
+func TestConvertIssuesEpicType(t *testing.T) {
+	mockMeta := mocks.NewPluginMeta(t)
+	mockMeta.On("RootPkgPath").Return("github.com/apache/incubator-devlake/plugins/jira")
+	assert.Nil(t, core.RegisterPlugin("jira", mockMeta))
+	db := unithelper.NewMemoryDal()
+	created := time.Date(2022, 11, 1, 8, 0, 0, 0, time.UTC)
+	// the epics are mapped to requirements like the stories, by the type mappings of the transformation rules
+	db.Insert(models.JiraIssue{}.TableName(),
+		&models.JiraIssue{ConnectionId: 1, IssueId: 10001, IssueKey: "K-1", Type: "Epic", StdType: "REQUIREMENT", Created: created, Updated: created},
+		&models.JiraIssue{ConnectionId: 1, IssueId: 10002, IssueKey: "K-2", Type: "Story", StdType: "REQUIREMENT", EpicKey: "K-1", Created: created, Updated: created},
+	)
+	taskCtx := unithelper.DummySubTaskContext(&boardIssuesDal{db})
+	taskCtx.On("GetData").Return(&JiraTaskData{Options: &JiraOptions{ConnectionId: 1, BoardId: 8}})
+	unithelper.AssertIdempotent(t, db, func() errors.Error {
+		return ConvertIssues(taskCtx)
+	}, ticket.Issue{}.TableName(), ticket.BoardIssue{}.TableName())
+
+	types := map[string]string{}
+	for _, row := range db.Rows(ticket.Issue{}.TableName()) {
+		issue := row.(*ticket.Issue)
+		types[issue.Id] = issue.Type
+	}
+	assert.Equal(t, map[string]string{
+		"jira:JiraIssue:1:10001": ticket.EPIC,
+		"jira:JiraIssue:1:10002": "REQUIREMENT",
+	}, types)
+	var boardIssues []string
+	for _, row := range db.Rows(ticket.BoardIssue{}.TableName()) {
+		boardIssue := row.(*ticket.BoardIssue)
+		assert.Equal(t, "jira:JiraBoard:1:8", boardIssue.BoardId)
+		boardIssues = append(boardIssues, boardIssue.IssueId)
+	}
+	assert.ElementsMatch(t, []string{"jira:JiraIssue:1:10001", "jira:JiraIssue:1:10002"}, boardIssues)
+}
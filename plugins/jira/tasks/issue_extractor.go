@@ -74,11 +74,13 @@ func ExtractIssues(taskCtx core.SubTaskContext) errors.Error {
 			/*
 				Table store raw data
 			*/
-			Table: RAW_ISSUE_TABLE,
+			Table: data.Options.RawTable(RAW_ISSUE_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			return extractIssues(data, mappings, false, row)
 		},
+		// the issues are extracted again and again over the same raw rows, they must be upserted by their keys
+		StrictPrimaryKey: true,
 	})
 	if err != nil {
 		return err
@@ -22,7 +22,6 @@ import (
 	"github.com/apache/incubator-devlake/errors"
 	"github.com/apache/incubator-devlake/plugins/core"
 	"github.com/apache/incubator-devlake/plugins/helper"
-	"github.com/apache/incubator-devlake/plugins/jira/models"
 	"net/http"
 )
 
@@ -43,10 +42,7 @@ func CollectIssueTypes(taskCtx core.SubTaskContext) errors.Error {
 	logger := taskCtx.GetLogger()
 	logger.Info("collect issue_types")
 
-	urlTemplate := "api/3/issuetype"
-	if data.JiraServerInfo.DeploymentType == models.DeploymentServer {
-		urlTemplate = "api/2/issuetype"
-	}
+	urlTemplate := data.ApiVersion().Path("issuetype")
 	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
 		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
 			Ctx: taskCtx,
@@ -54,7 +50,7 @@ func CollectIssueTypes(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_ISSUE_TYPE_TABLE,
+			Table: data.Options.RawTable(RAW_ISSUE_TYPE_TABLE),
 		},
 		ApiClient:   data.ApiClient,
 		Concurrency: 1,
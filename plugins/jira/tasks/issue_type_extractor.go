@@ -46,7 +46,7 @@ func ExtractIssueType(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: connectionId,
 				BoardId:      boardId,
 			},
-			Table: RAW_ISSUE_TYPE_TABLE,
+			Table: data.Options.RawTable(RAW_ISSUE_TYPE_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			issueType := &models.JiraIssueType{}
@@ -0,0 +1,249 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+var orderByPattern = regexp.MustCompile(`(?i)\border\s+by\b`)
+
+var jqlEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+var jqlUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+
+// accountIdPattern matches the account ids of Jira Cloud, i.e. `5b10ac8d82e05b22cc7d4ef5` or the older
+// `557058:f58131cb-b67d-43c7-b30d-6b58d40bd077`
+var accountIdPattern = regexp.MustCompile(`^[0-9A-Za-z]+(:[0-9A-Za-z-]+)?$`)
+
+// maxJiraUserLength is the max length of account ids and usernames accepted by Jira
+const maxJiraUserLength = 255
+
+// jqlValuePattern matches a value quoted by jqlValue
+var jqlValuePattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+
+// jqlValue quotes and escapes a value to be interpolated into JQL, so that quotes, spaces, reserved characters and
+// reserved words in it are taken literally. It must be used for any value derived from the database or the user
+func jqlValue(value string) string {
+	return fmt.Sprintf(`"%s"`, jqlEscaper.Replace(value))
+}
+
+// jqlValues quotes the values by jqlValue and joins them by commas, i.e. for the list of the `in` operator
+func jqlValues(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = jqlValue(value)
+	}
+	return strings.Join(quoted, ",")
+}
+
+// parseJqlValues reverses jqlValues, values which are not quoted are ignored
+func parseJqlValues(list string) []string {
+	var values []string
+	for _, matches := range jqlValuePattern.FindAllStringSubmatch(list, -1) {
+		values = append(values, jqlUnescaper.Replace(matches[1]))
+	}
+	return values
+}
+
+// formatJqlTime formats the time for JQL, which accepts minute precision only, i.e. `2022/11/01 08:00`. Jira takes
+// the time in the time zone of the user, the time is converted into `location` unless it is nil
+func formatJqlTime(t time.Time, location *time.Location) string {
+	if location != nil {
+		t = t.In(location)
+	}
+	return t.Format("2006/01/02 15:04")
+}
+
+// buildJql AND-s all non-empty criteria together and appends the ORDER BY clause
+func buildJql(orderBy string, criteria ...string) string {
+	var conditions []string
+	for _, c := range criteria {
+		if c != "" {
+			conditions = append(conditions, c)
+		}
+	}
+	jql := strings.Join(conditions, " AND ")
+	if orderBy != "" {
+		jql = strings.TrimSpace(fmt.Sprintf("%s ORDER BY %s", jql, orderBy))
+	}
+	return jql
+}
+
+// userJqlCriteria returns the user-supplied JQL fragment wrapped in parentheses, so that combining it with other
+// criteria wouldn't change the operator precedence
+func userJqlCriteria(jql string) string {
+	jql = strings.TrimSpace(jql)
+	if jql == "" {
+		return ""
+	}
+	return fmt.Sprintf("(%s)", jql)
+}
+
+// labelsCriteria returns the criteria matching issues labeled by any of the labels, blank labels are ignored and
+// empty is returned if there is none
+func labelsCriteria(labels []string) string {
+	var values []string
+	for _, label := range labels {
+		if label = strings.TrimSpace(label); label != "" {
+			values = append(values, label)
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("labels in (%s)", jqlValues(values))
+}
+
+// updatedByCriteria selects the issues reported by or assigned to any of the users, which are account ids on Jira
+// Cloud and usernames on Jira Server and Data Center, the `reporter` and `assignee` fields accept either
+func updatedByCriteria(users []string) string {
+	var values []string
+	for _, user := range users {
+		if user = strings.TrimSpace(user); user != "" {
+			values = append(values, user)
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	list := jqlValues(values)
+	return fmt.Sprintf("(reporter in (%s) OR assignee in (%s))", list, list)
+}
+
+// ValidateJql checks the user-supplied JQL fragment for mistakes which can be detected without calling Jira
+func ValidateJql(jql string) errors.Error {
+	if strings.TrimSpace(jql) == "" {
+		return nil
+	}
+	depth := 0
+	var quote rune
+	escaped := false
+	// unquoted is the jql with the strings blanked out, which is where ORDER BY is looked for, `summary ~ "order by"`
+	// is a valid filter
+	var unquoted strings.Builder
+	for _, c := range jql {
+		if escaped {
+			escaped = false
+			continue
+		}
+		if quote != 0 {
+			if c == '\\' {
+				escaped = true
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+			unquoted.WriteRune(' ')
+			continue
+		}
+		unquoted.WriteRune(c)
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return errors.BadInput.New(fmt.Sprintf("invalid jql %s: unbalanced parentheses", jql))
+			}
+		}
+	}
+	if quote != 0 {
+		return errors.BadInput.New(fmt.Sprintf("invalid jql %s: unterminated string", jql))
+	}
+	if depth != 0 {
+		return errors.BadInput.New(fmt.Sprintf("invalid jql %s: unbalanced parentheses", jql))
+	}
+	if orderByPattern.MatchString(unquoted.String()) {
+		return errors.BadInput.New(fmt.Sprintf("invalid jql %s: ORDER BY is not allowed in the filter", jql))
+	}
+	return nil
+}
+
+// VerifyJql asks Jira to run the user-supplied JQL fragment without returning any issue, so that a malformed filter
+// fails the task right away instead of in the middle of the collection
+func VerifyJql(client *helper.ApiAsyncClient, searchPath string, jql string) errors.Error {
+	if strings.TrimSpace(jql) == "" {
+		return nil
+	}
+	query := url.Values{}
+	query.Set("jql", userJqlCriteria(jql))
+	query.Set("maxResults", "0")
+	res, err := client.Get(searchPath, query, nil)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode == http.StatusBadRequest {
+		var body struct {
+			ErrorMessages []string `json:"errorMessages"`
+		}
+		err = helper.UnmarshalResponse(res, &body)
+		if err != nil {
+			return errors.BadInput.Wrap(err, fmt.Sprintf("invalid jql %s", jql))
+		}
+		return errors.BadInput.New(fmt.Sprintf("invalid jql %s: %s", jql, strings.Join(body.ErrorMessages, "; ")))
+	}
+	res.Body.Close()
+	if res.StatusCode >= 300 || res.StatusCode < 200 {
+		return errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to verify jql, status code: %d", res.StatusCode))
+	}
+	return nil
+}
+
+// jiraStatusCategories are the status categories of Jira by their keys, every status of a workflow falls into one
+var jiraStatusCategories = map[string]string{
+	"new":           "To Do",
+	"indeterminate": "In Progress",
+	"done":          "Done",
+}
+
+// getStatusCategory returns the name of the status category by its name or key case-insensitively, empty if unknown
+func getStatusCategory(category string) string {
+	category = strings.ToLower(strings.TrimSpace(category))
+	for key, name := range jiraStatusCategories {
+		if category == key || category == strings.ToLower(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// statusCategoriesCriteria returns the criteria matching issues in any of the status categories, unknown categories
+// are ignored and empty is returned if there is none
+func statusCategoriesCriteria(categories []string) string {
+	var values []string
+	for _, category := range categories {
+		if name := getStatusCategory(category); name != "" {
+			values = append(values, name)
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("statusCategory in (%s)", jqlValues(values))
+}
@@ -0,0 +1,89 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildJql(t *testing.T) {
+	assert.Equal(t, "ORDER BY created ASC", buildJql("created ASC"))
+	assert.Equal(t, "ORDER BY created ASC", buildJql("created ASC", "", userJqlCriteria("  ")))
+	assert.Equal(t,
+		`issue in ("K-1","K-2") AND updated >= '2022/11/01 08:00' AND (status = Done OR labels = roadmap) ORDER BY created ASC`,
+		buildJql(
+			"created ASC",
+			`issue in ("K-1","K-2")`,
+			"updated >= '2022/11/01 08:00'",
+			userJqlCriteria("status = Done OR labels = roadmap"),
+		),
+	)
+}
+
+func TestJqlValue(t *testing.T) {
+	cases := map[string]string{
+		"K-1":       `"K-1"`,
+		"MY PROJ":   `"MY PROJ"`,
+		`O"Brien`:   `"O\"Brien"`,
+		`back\`:     `"back\\"`,
+		"AND":       `"AND"`,
+		"EMPTY":     `"EMPTY"`,
+		"ORDER":     `"ORDER"`,
+		"K-1) OR (": `"K-1) OR ("`,
+	}
+	var values []string
+	for value, quoted := range cases {
+		assert.Equal(t, quoted, jqlValue(value), value)
+		assert.Nil(t, ValidateJql(fmt.Sprintf("project = %s", jqlValue(value))), value)
+		values = append(values, value)
+	}
+	// the values are recovered from the list
+	assert.Equal(t, values, parseJqlValues(jqlValues(values)))
+}
+
+func TestValidateJql(t *testing.T) {
+	valid := []string{
+		"",
+		"status = Done",
+		"(status = Done OR labels = roadmap) AND project = K",
+		`summary ~ "a (quoted) paren"`,
+		`summary ~ "escaped \" quote)"`,
+		`summary ~ "order by date"`,
+		`summary ~ 'sort ORDER  BY hand' AND status = Done`,
+	}
+	for _, jql := range valid {
+		assert.Nil(t, ValidateJql(jql), jql)
+	}
+	invalid := []string{
+		"(status = Done",
+		"status = Done)",
+		"status = 'Done",
+		"status = Done order by created",
+		`summary ~ "order" ORDER BY created`,
+	}
+	for _, jql := range invalid {
+		err := ValidateJql(jql)
+		if assert.NotNil(t, err, jql) {
+			assert.Equal(t, errors.BadInput, err.GetType(), jql)
+		}
+	}
+}
@@ -51,10 +51,10 @@ func CollectProjects(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_PROJECT_TABLE,
+			Table: data.Options.RawTable(RAW_PROJECT_TABLE),
 		},
 		ApiClient:   data.ApiClient,
-		UrlTemplate: "api/2/project",
+		UrlTemplate: data.ApiVersion().Path("project"),
 		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
 			query := url.Values{}
 			query.Set("jql", jql)
@@ -0,0 +1,181 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+const (
+	// EpicKeySourceBoard takes the epic keys from the issues of the board, the default
+	EpicKeySourceBoard = "board"
+	// EpicKeySourceProject takes the epic keys from a JQL search of the epics of the project of the board
+	EpicKeySourceProject = "project"
+)
+
+// projectEpicKeysIterator iterates the keys of the epics of a project by a JQL search, page by page, each page
+// makes a batch of keys like the ones of GetUncollectedEpicKeysIterator. Unlike them, the epics don't have to be
+// linked to the issues of the board, which are flaky for team-managed projects
+type projectEpicKeysIterator struct {
+	apiClient  helper.ApiClientGetter
+	searchPath string
+	jql        string
+	pageSize   int
+	startAt    int
+	done       bool
+	batch      []interface{}
+	err        errors.Error
+}
+
+func newProjectEpicKeysIterator(apiClient helper.ApiClientGetter, searchPath string, projectId uint, pageSize int) *projectEpicKeysIterator {
+	return &projectEpicKeysIterator{
+		apiClient:  apiClient,
+		searchPath: searchPath,
+		// the keys are searched in a stable order, so the pages don't overlap
+		jql:      buildJql("key ASC", projectEpicsCriteria(projectId)),
+		pageSize: pageSize,
+	}
+}
+
+// HasNext searches for the next page unless the last one was reached, a failed search is returned by Fetch
+func (it *projectEpicKeysIterator) HasNext() bool {
+	if it.batch != nil || it.err != nil {
+		return true
+	}
+	if it.done {
+		return false
+	}
+	it.batch, it.err = it.searchNextPage()
+	return it.batch != nil || it.err != nil
+}
+
+// Fetch returns the keys of the next page as a batch of *string
+func (it *projectEpicKeysIterator) Fetch() (interface{}, errors.Error) {
+	if !it.HasNext() {
+		return nil, errors.Default.New("no more epic keys of the project")
+	}
+	batch, err := it.batch, it.err
+	it.batch, it.err = nil, nil
+	if err != nil {
+		it.done = true
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (it *projectEpicKeysIterator) Close() errors.Error {
+	return nil
+}
+
+func (it *projectEpicKeysIterator) searchNextPage() ([]interface{}, errors.Error) {
+	query := url.Values{
+		"jql":        {it.jql},
+		"startAt":    {strconv.Itoa(it.startAt)},
+		"maxResults": {strconv.Itoa(it.pageSize)},
+		"fields":     {"key"},
+	}
+	res, err := it.apiClient.Get(it.searchPath, query, nil)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to search for the epic keys of the project")
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to search for the epic keys of the project, unexpected status code: %d", res.StatusCode))
+	}
+	var body struct {
+		JiraPagination
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	err = helper.UnmarshalResponse(res, &body)
+	if err != nil {
+		return nil, err
+	}
+	// the page might be capped by the server, the next one starts right after the keys returned
+	it.startAt += len(body.Issues)
+	if len(body.Issues) == 0 || it.startAt >= body.Total {
+		it.done = true
+	}
+	if len(body.Issues) == 0 {
+		return nil, nil
+	}
+	batch := make([]interface{}, len(body.Issues))
+	for i, issue := range body.Issues {
+		key := issue.Key
+		batch[i] = &key
+	}
+	return batch, nil
+}
+
+// getEpicKeysIterator iterates the epic keys of the board from the source selected by EpicKeySource. The epics of
+// a project shared with any board of `collectedBoardIds` were collected along with that board
+func getEpicKeysIterator(taskCtx core.SubTaskContext, boardId uint64, collectedBoardIds []uint64, batchSize int) (helper.Iterator, errors.Error) {
+	data := taskCtx.GetData().(*JiraTaskData)
+	if data.Options.EpicKeySource != EpicKeySourceProject {
+		return GetUncollectedEpicKeysIterator(taskCtx, boardId, collectedBoardIds, batchSize)
+	}
+	db := taskCtx.GetDal()
+	projectId, err := getBoardProjectId(db, data.Options.ConnectionId, boardId)
+	if err != nil {
+		return nil, err
+	}
+	if projectId == 0 {
+		return nil, errors.Default.New(fmt.Sprintf("the project of board %d is unknown, which is required by `epicKeySource` %s", boardId, EpicKeySourceProject))
+	}
+	for _, collectedBoardId := range collectedBoardIds {
+		collectedProjectId, err := getBoardProjectId(db, data.Options.ConnectionId, collectedBoardId)
+		if err != nil {
+			return nil, err
+		}
+		if collectedProjectId == projectId {
+			taskCtx.GetLogger().Info("epics of project %d were collected along with board %d, skipping them for board %d", projectId, collectedBoardId, boardId)
+			return helper.NewQueueIterator(), nil
+		}
+	}
+	return newProjectEpicKeysIterator(data.ApiClient, data.ApiVersion().SearchPath(), projectId, data.PageSize(batchSize)), nil
+}
+
+// getEpicRawDataParams returns the params of the raw epics of the board, the project is recorded for the epics
+// whose keys were taken from the project, since they are not necessarily on the board
+func getEpicRawDataParams(db dal.Dal, data *JiraTaskData, boardId uint64) (JiraApiParams, errors.Error) {
+	params := JiraApiParams{
+		ConnectionId: data.Options.ConnectionId,
+		BoardId:      boardId,
+		// shards collect the epics into their own rows, so they don't delete or resume the rows of each other
+		Shard: data.Options.EpicShard(),
+		// samples are collected into their own rows as well, apart from the full collections
+		Sampled: data.Options.SampleEvery() > 0,
+	}
+	if data.Options.EpicKeySource == EpicKeySourceProject {
+		projectId, err := getBoardProjectId(db, data.Options.ConnectionId, boardId)
+		if err != nil {
+			return params, err
+		}
+		params.ProjectId = projectId
+	}
+	return params, nil
+}
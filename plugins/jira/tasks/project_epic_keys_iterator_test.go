@@ -0,0 +1,100 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProjectEpicKeysIterator(t *testing.T) {
+	respond := func(body string) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Request:    &http.Request{URL: &url.URL{}},
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+		}
+	}
+	searchedPage := func(startAt string) interface{} {
+		return mock.MatchedBy(func(query url.Values) bool {
+			return query.Get("jql") == "issuetype = Epic AND project = 10 ORDER BY key ASC" &&
+				query.Get("startAt") == startAt && query.Get("maxResults") == "3"
+		})
+	}
+	// none of the epics has any child issue on the board, so the issues of the board don't lead to them. The first
+	// page is capped at 2 by the server
+	apiClient := mocks.NewApiClientGetter(t)
+	apiClient.On("Get", "api/2/search", searchedPage("0"), mock.Anything).
+		Return(respond(`{"startAt":0,"maxResults":2,"total":3,"issues":[{"key":"K-1"},{"key":"K-2"}]}`), nil).Once()
+	apiClient.On("Get", "api/2/search", searchedPage("2"), mock.Anything).
+		Return(respond(`{"startAt":2,"maxResults":2,"total":3,"issues":[{"key":"K-3"}]}`), nil).Once()
+	it := newProjectEpicKeysIterator(apiClient, "api/2/search", 10, 3)
+	var keys []string
+	for it.HasNext() {
+		batch, err := it.Fetch()
+		assert.Nil(t, err)
+		for _, key := range batch.([]interface{}) {
+			keys = append(keys, *key.(*string))
+		}
+	}
+	assert.Equal(t, []string{"K-1", "K-2", "K-3"}, keys)
+	assert.Nil(t, it.Close())
+
+	// a failed search is returned by the fetch
+	apiClient = mocks.NewApiClientGetter(t)
+	apiClient.On("Get", "api/2/search", mock.Anything, mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusBadRequest,
+		Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+	}, nil).Once()
+	it = newProjectEpicKeysIterator(apiClient, "api/2/search", 10, 3)
+	assert.True(t, it.HasNext())
+	_, err := it.Fetch()
+	assert.NotNil(t, err)
+	assert.False(t, it.HasNext())
+}
+
+func TestGetEpicRawDataParams(t *testing.T) {
+	data := &JiraTaskData{Options: &JiraOptions{ConnectionId: 1}}
+	params, err := getEpicRawDataParams(nil, data, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, JiraApiParams{ConnectionId: 1, BoardId: 2}, params)
+
+	// the epics taken from the project are attributed to it
+	data.Options.EpicKeySource = EpicKeySourceProject
+	mockDal := new(mocks.Dal)
+	mockDal.On("First", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.JiraBoard).ProjectId = 10
+	}).Return(nil).Once()
+	params, err = getEpicRawDataParams(mockDal, data, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, JiraApiParams{ConnectionId: 1, BoardId: 2, ProjectId: 10}, params)
+	mockDal.AssertExpectations(t)
+
+	assert.NotNil(t, (&JiraOptions{EpicKeySource: EpicKeySourceProject, EpicWindowDays: 7}).ValidateEpicKeySource())
+	err = (&JiraOptions{ConnectionId: 1, BoardId: 2, EpicKeySource: "issues"}).ValidateOptions()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "`epicKeySource` must be any of board, project, got issues")
+}
@@ -44,7 +44,7 @@ func ExtractProjects(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_PROJECT_TABLE,
+			Table: data.Options.RawTable(RAW_PROJECT_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			var project apiv2models.Project
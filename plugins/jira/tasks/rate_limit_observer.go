@@ -0,0 +1,97 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper/common"
+)
+
+// rateLimitSlowDownRatio is the ratio of the remaining quota below which the requests are slowed down
+const rateLimitSlowDownRatio = 0.2
+
+// maxRateLimitDelay is the delay added to every response once the quota is exhausted
+const maxRateLimitDelay = 5 * time.Second
+
+// rateLimitObserver logs the rate limit headers of Jira Cloud along with the id of every request, and slows down the
+// worker of a response as the remaining quota drops, so the collection wouldn't run into 429
+type rateLimitObserver struct {
+	logger core.Logger
+	sleep  func(time.Duration)
+}
+
+func newRateLimitObserver(logger core.Logger) *rateLimitObserver {
+	return &rateLimitObserver{logger: logger, sleep: time.Sleep}
+}
+
+// AfterResponse is the callback to be called with every response, it only reads the headers
+func (o *rateLimitObserver) AfterResponse(res *http.Response) errors.Error {
+	limit := res.Header.Get("X-RateLimit-Limit")
+	remaining := res.Header.Get("X-RateLimit-Remaining")
+	requestId := res.Header.Get("X-AREQUESTID")
+	if limit == "" && remaining == "" && requestId == "" {
+		return nil
+	}
+	o.logger.Debug(
+		"%s responded with %d, X-AREQUESTID: %s, X-RateLimit-Limit: %s, X-RateLimit-Remaining: %s, X-RateLimit-Reset: %s",
+		res.Request.URL.Path, res.StatusCode, requestId, limit, remaining, res.Header.Get("X-RateLimit-Reset"),
+	)
+	if delay := getRateLimitDelay(limit, remaining); delay > 0 {
+		o.logger.Info("%s of %s requests remaining in the rate limit window, slowing down by %v", remaining, limit, delay)
+		o.sleep(delay)
+	}
+	return nil
+}
+
+// getRateLimitDelay grows the delay linearly from 0 to maxRateLimitDelay as the remaining quota drops from
+// rateLimitSlowDownRatio of the limit to 0, 0 is returned if the headers are missing or invalid
+func getRateLimitDelay(limit, remaining string) time.Duration {
+	l, err := strconv.Atoi(limit)
+	if err != nil || l <= 0 {
+		return 0
+	}
+	r, err := strconv.Atoi(remaining)
+	if err != nil {
+		return 0
+	}
+	threshold := float64(l) * rateLimitSlowDownRatio
+	if float64(r) >= threshold {
+		return 0
+	}
+	if r < 0 {
+		r = 0
+	}
+	return time.Duration(float64(maxRateLimitDelay) * (1 - float64(r)/threshold))
+}
+
+// chainAfterResponse calls the callbacks one after another till any of them returns an error
+func chainAfterResponse(callbacks ...common.ApiClientAfterResponse) common.ApiClientAfterResponse {
+	return func(res *http.Response) errors.Error {
+		for _, callback := range callbacks {
+			if err := callback(res); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
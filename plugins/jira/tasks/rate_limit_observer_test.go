@@ -0,0 +1,83 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRateLimitDelay(t *testing.T) {
+	cases := []struct {
+		limit, remaining string
+		expected         time.Duration
+	}{
+		{"", "", 0},
+		{"100", "", 0},
+		{"invalid", "10", 0},
+		{"100", "50", 0},
+		{"100", "20", 0},
+		{"100", "10", maxRateLimitDelay / 2},
+		{"100", "0", maxRateLimitDelay},
+		{"100", "-1", maxRateLimitDelay},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, getRateLimitDelay(c.limit, c.remaining), c)
+	}
+}
+
+func TestRateLimitObserver(t *testing.T) {
+	var slept []time.Duration
+	observer := newRateLimitObserver(unithelper.DummyLogger())
+	observer.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+	}
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Request:    &http.Request{URL: &url.URL{Path: "/rest/api/2/search"}},
+	}
+	res.Header.Set("X-AREQUESTID", "abc")
+	assert.Nil(t, observer.AfterResponse(res))
+	res.Header.Set("X-RateLimit-Limit", "100")
+	res.Header.Set("X-RateLimit-Remaining", "90")
+	assert.Nil(t, observer.AfterResponse(res))
+	res.Header.Set("X-RateLimit-Remaining", "5")
+	assert.Nil(t, observer.AfterResponse(res))
+	assert.Equal(t, []time.Duration{maxRateLimitDelay * 3 / 4}, slept)
+}
+
+func TestChainAfterResponse(t *testing.T) {
+	var called []int
+	callback := func(i int, err errors.Error) func(res *http.Response) errors.Error {
+		return func(res *http.Response) errors.Error {
+			called = append(called, i)
+			return err
+		}
+	}
+	chain := chainAfterResponse(callback(1, nil), callback(2, helper.ErrIgnoreAndContinue), callback(3, nil))
+	assert.Equal(t, helper.ErrIgnoreAndContinue, chain(&http.Response{}))
+	assert.Equal(t, []int{1, 2}, called)
+}
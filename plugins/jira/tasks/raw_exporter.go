@@ -0,0 +1,153 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	goerror "errors"
+	"fmt"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"gorm.io/gorm"
+)
+
+// ObjectStore puts objects into the bucket the raw data is exported to
+type ObjectStore interface {
+	PutObject(ctx context.Context, key string, body []byte) errors.Error
+}
+
+// exportedRawRow is a line of the NDJSON objects exported, the raw row along with what it was collected by
+type exportedRawRow struct {
+	Params string          `json:"params"`
+	Url    string          `json:"url"`
+	Input  json.RawMessage `json:"input,omitempty"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// RawExporter exports the raw epics to an object store as they are saved by the collectors, every batch saved is
+// streamed as an NDJSON object of its own, so neither the raw table is read again nor the whole collection is
+// buffered. The objects are partitioned by the connection, the board and the date of the collection, i.e.
+// `<prefix>/connection=1/board=8/date=2022-11-24/epics-20221124T080000Z-00001.ndjson`
+type RawExporter struct {
+	store        ObjectStore
+	prefix       string
+	connectionId uint64
+	startedAt    time.Time
+	seq          int32
+}
+
+// NewRawExporter creates an exporter of the raw epics of the connection collected from now on
+func NewRawExporter(store ObjectStore, prefix string, connectionId uint64) *RawExporter {
+	return &RawExporter{
+		store:        store,
+		prefix:       prefix,
+		connectionId: connectionId,
+		startedAt:    time.Now().UTC(),
+	}
+}
+
+// AfterSave returns the callback of the collector of the board exporting the raw rows saved, nil if the exporter
+// is nil, which means the export is not configured for the connection
+func (exporter *RawExporter) AfterSave(ctx context.Context, boardId uint64) func(rows []*helper.RawData) errors.Error {
+	if exporter == nil {
+		return nil
+	}
+	return func(rows []*helper.RawData) errors.Error {
+		return exporter.export(ctx, boardId, rows)
+	}
+}
+
+func (exporter *RawExporter) export(ctx context.Context, boardId uint64, rows []*helper.RawData) errors.Error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, row := range rows {
+		data, dataErr := row.GetData()
+		if dataErr != nil {
+			return dataErr
+		}
+		err := encoder.Encode(exportedRawRow{
+			Params: row.Params,
+			Url:    row.Url,
+			Input:  json.RawMessage(row.Input),
+			Data:   data,
+		})
+		if err != nil {
+			return errors.Default.Wrap(err, "failed to encode the raw epics to be exported")
+		}
+	}
+	key := exporter.objectKey(boardId, atomic.AddInt32(&exporter.seq, 1))
+	err := exporter.store.PutObject(ctx, key, body.Bytes())
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to export the raw epics of board %d to %s", boardId, key))
+	}
+	return nil
+}
+
+func (exporter *RawExporter) objectKey(boardId uint64, seq int32) string {
+	return path.Join(
+		exporter.prefix,
+		fmt.Sprintf("connection=%d", exporter.connectionId),
+		fmt.Sprintf("board=%d", boardId),
+		fmt.Sprintf("date=%s", exporter.startedAt.Format("2006-01-02")),
+		fmt.Sprintf("epics-%s-%05d.ndjson", exporter.startedAt.Format("20060102T150405Z"), seq),
+	)
+}
+
+// LoadRawExporter creates the exporter of the bucket configured for the connection, nil is returned if the export
+// is not configured
+func LoadRawExporter(basicRes core.BasicRes, connectionId uint64) (*RawExporter, errors.Error) {
+	export, err := LoadRawExport(basicRes, connectionId)
+	if err != nil || export == nil {
+		return nil, err
+	}
+	store, err := NewObjectStore(export)
+	if err != nil {
+		return nil, err
+	}
+	return NewRawExporter(store, export.Prefix, connectionId), nil
+}
+
+// LoadRawExport loads the bucket the raw data of the connection is exported to with the secret decrypted, nil is
+// returned if the export is not configured
+func LoadRawExport(basicRes core.BasicRes, connectionId uint64) (*models.JiraRawExport, errors.Error) {
+	export := &models.JiraRawExport{}
+	err := basicRes.GetDal().First(export, dal.Where("connection_id = ?", connectionId))
+	if err != nil {
+		if goerror.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to load the raw export of connection %d", connectionId))
+	}
+	encKey := basicRes.GetConfig(core.EncodeKeyEnvStr)
+	err = helper.UpdateEncryptFields(export, func(encrypted string) (string, errors.Error) {
+		return core.Decrypt(encKey, encrypted)
+	})
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to decrypt the raw export")
+	}
+	return export, nil
+}
@@ -0,0 +1,140 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeObjectStore keeps the objects put in memory
+type fakeObjectStore struct {
+	mu      sync.Mutex
+	objects map[string]string
+	err     errors.Error
+}
+
+func (store *fakeObjectStore) PutObject(_ context.Context, key string, body []byte) errors.Error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.err != nil {
+		return store.err
+	}
+	if store.objects == nil {
+		store.objects = map[string]string{}
+	}
+	store.objects[key] = string(body)
+	return nil
+}
+
+func TestRawExporter(t *testing.T) {
+	store := &fakeObjectStore{}
+	exporter := NewRawExporter(store, "devlake/jira", 1)
+	exporter.startedAt = time.Date(2022, 11, 24, 8, 0, 0, 0, time.UTC)
+	afterSave := exporter.AfterSave(context.Background(), 8)
+	assert.Nil(t, afterSave([]*helper.RawData{
+		{Params: `{"ConnectionId":1,"BoardId":8}`, Url: "https://jira/api/2/search", Input: []byte(`{"k":1}`), Data: []byte(`{"key":"K-1"}`)},
+		{Params: `{"ConnectionId":1,"BoardId":8}`, Url: "https://jira/api/2/search", Data: []byte(`{"key":"K-2"}`)},
+	}))
+	assert.Nil(t, afterSave([]*helper.RawData{
+		{Params: `{"ConnectionId":1,"BoardId":8}`, Url: "https://jira/api/2/search", Data: []byte(`{"key":"K-3"}`)},
+	}))
+	// every batch saved is an object of its own, partitioned by the connection, the board and the date
+	assert.Equal(t, map[string]string{
+		"devlake/jira/connection=1/board=8/date=2022-11-24/epics-20221124T080000Z-00001.ndjson": `{"params":"{\"ConnectionId\":1,\"BoardId\":8}","url":"https://jira/api/2/search","input":{"k":1},"data":{"key":"K-1"}}
+{"params":"{\"ConnectionId\":1,\"BoardId\":8}","url":"https://jira/api/2/search","data":{"key":"K-2"}}
+`,
+		"devlake/jira/connection=1/board=8/date=2022-11-24/epics-20221124T080000Z-00002.ndjson": `{"params":"{\"ConnectionId\":1,\"BoardId\":8}","url":"https://jira/api/2/search","data":{"key":"K-3"}}
+`,
+	}, store.objects)
+
+	store.err = errors.Default.New("bucket is gone")
+	err := afterSave([]*helper.RawData{{Params: "{}", Data: []byte("{}")}})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "board 8")
+
+	// nothing is exported unless configured
+	var unconfigured *RawExporter
+	assert.Nil(t, unconfigured.AfterSave(context.Background(), 8))
+}
+
+func TestS3ObjectStorePutObject(t *testing.T) {
+	var req *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		if strings.Contains(r.URL.Path, "forbidden") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewObjectStore(&models.JiraRawExport{
+		Provider:        models.RawExportProviderS3,
+		Endpoint:        server.URL + "/",
+		Bucket:          "exports",
+		AccessKeyId:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	})
+	assert.Nil(t, err)
+	store.(*s3ObjectStore).now = func() time.Time { return time.Date(2022, 11, 24, 8, 0, 0, 0, time.UTC) }
+	assert.Nil(t, store.PutObject(context.Background(), "jira/connection=1/epics.ndjson", []byte("{}\n")))
+	assert.Equal(t, http.MethodPut, req.Method)
+	// the path is sent the way it was signed
+	assert.Equal(t, "/exports/jira/connection%3D1/epics.ndjson", req.URL.RawPath)
+	assert.Equal(t, "{}\n", body)
+	assert.Equal(t, "20221124T080000Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, sha256Hex([]byte("{}\n")), req.Header.Get("X-Amz-Content-Sha256"))
+	assert.Regexp(t,
+		`^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20221124/us-east-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`,
+		req.Header.Get("Authorization"),
+	)
+
+	err = store.PutObject(context.Background(), "forbidden.ndjson", []byte("{}\n"))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "AccessDenied")
+}
+
+func TestNewObjectStore(t *testing.T) {
+	store, err := NewObjectStore(&models.JiraRawExport{Provider: models.RawExportProviderGCS, Bucket: "exports"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://storage.googleapis.com", store.(*s3ObjectStore).endpoint)
+	assert.Equal(t, "auto", store.(*s3ObjectStore).region)
+
+	store, err = NewObjectStore(&models.JiraRawExport{Provider: models.RawExportProviderS3, Region: "eu-west-1", Bucket: "exports"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://s3.eu-west-1.amazonaws.com", store.(*s3ObjectStore).endpoint)
+
+	_, err = NewObjectStore(&models.JiraRawExport{Provider: "azure", Bucket: "exports"})
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.BadInput, err.GetType())
+}
@@ -80,12 +80,12 @@ func CollectRemotelinks(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_REMOTELINK_TABLE,
+			Table: data.Options.RawTable(RAW_REMOTELINK_TABLE),
 		},
 		ApiClient:   data.ApiClient,
 		Input:       iterator,
 		Incremental: since == nil,
-		UrlTemplate: "api/2/issue/{{ .Input.IssueId }}/remotelink",
+		UrlTemplate: data.ApiVersion().Path("issue/{{ .Input.IssueId }}/remotelink"),
 		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
 			if res.StatusCode == http.StatusNotFound {
 				return nil, nil
@@ -72,7 +72,7 @@ func ExtractRemotelinks(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: connectionId,
 				BoardId:      boardId,
 			},
-			Table: RAW_REMOTELINK_TABLE,
+			Table: data.Options.RawTable(RAW_REMOTELINK_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			var result []interface{}
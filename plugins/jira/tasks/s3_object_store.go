@@ -0,0 +1,170 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+)
+
+const (
+	defaultS3Region    = "us-east-1"
+	defaultGCSRegion   = "auto"
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+	// s3PutTimeout bounds the upload of a single object
+	s3PutTimeout = time.Minute
+)
+
+// s3ObjectStore puts objects by the S3 api signed by AWS Signature Version 4, which is spoken by AWS S3, Google Cloud
+// Storage (by HMAC keys) and S3 compatible storages alike. The objects are addressed path-style, i.e.
+// `<endpoint>/<bucket>/<key>`, so buckets with dots in their names work over TLS
+type s3ObjectStore struct {
+	client          *http.Client
+	endpoint        string
+	region          string
+	bucket          string
+	accessKeyId     string
+	secretAccessKey string
+	now             func() time.Time
+}
+
+// NewObjectStore creates the object store of the bucket the raw data is exported to
+func NewObjectStore(export *models.JiraRawExport) (ObjectStore, errors.Error) {
+	store := &s3ObjectStore{
+		client:          &http.Client{Timeout: s3PutTimeout},
+		endpoint:        strings.TrimSuffix(export.Endpoint, "/"),
+		region:          export.Region,
+		bucket:          export.Bucket,
+		accessKeyId:     export.AccessKeyId,
+		secretAccessKey: export.SecretAccessKey,
+		now:             time.Now,
+	}
+	switch export.Provider {
+	case models.RawExportProviderS3:
+		if store.region == "" {
+			store.region = defaultS3Region
+		}
+		if store.endpoint == "" {
+			store.endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", store.region)
+		}
+	case models.RawExportProviderGCS:
+		if store.region == "" {
+			store.region = defaultGCSRegion
+		}
+		if store.endpoint == "" {
+			store.endpoint = defaultGCSEndpoint
+		}
+	default:
+		return nil, errors.BadInput.New(fmt.Sprintf("unknown provider of the raw export: %q", export.Provider))
+	}
+	return store, nil
+}
+
+func (store *s3ObjectStore) PutObject(ctx context.Context, key string, body []byte) errors.Error {
+	objectPath := "/" + s3EscapePath(store.bucket) + "/" + s3EscapePath(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, store.endpoint+objectPath, bytes.NewReader(body))
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to create the request of the object")
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	store.sign(req, objectPath, body)
+	res, err := store.client.Do(req)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to put object %s", key))
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to put object %s, unexpected status code %d: %s", key, res.StatusCode, message))
+	}
+	return nil
+}
+
+// sign signs the request by AWS Signature Version 4 in the Authorization header, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (store *s3ObjectStore) sign(req *http.Request, escapedPath string, body []byte) {
+	now := store.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	// the request must be sent to the path signed, rather than the one re-escaped by net/http
+	req.URL.RawPath = escapedPath
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapedPath,
+		"",
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, store.region)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	signingKey := hmacSha256([]byte("AWS4"+store.secretAccessKey), date)
+	for _, part := range []string{store.region, "s3", "aws4_request"} {
+		signingKey = hmacSha256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		store.accessKeyId, scope, signedHeaders, signature,
+	))
+}
+
+// s3EscapePath escapes every character of the path but the unreserved ones and the slashes, as the canonical
+// request of Signature Version 4 requires
+func s3EscapePath(p string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(p) {
+		if 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || '0' <= b && b <= '9' || strings.IndexByte("-._~/", b) >= 0 {
+			escaped.WriteByte(b)
+		} else {
+			escaped.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return escaped.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
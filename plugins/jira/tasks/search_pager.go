@@ -0,0 +1,125 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+// JiraSearchResponse is the response of the issue search api, Jira Data Center paginates it by `startAt`, while
+// Jira Cloud is moving toward `nextPageToken` and `isLast`, in which case `total` is no longer returned
+type JiraSearchResponse struct {
+	JiraPagination
+	Issues        []json.RawMessage `json:"issues"`
+	NextPageToken *string           `json:"nextPageToken"`
+	IsLast        *bool             `json:"isLast"`
+}
+
+// isCursorBased tells if the response follows the `nextPageToken` pagination scheme
+func (r *JiraSearchResponse) isCursorBased() bool {
+	return r.NextPageToken != nil || r.IsLast != nil
+}
+
+// searchPager fetches pages of the issue search api with whichever pagination scheme the endpoint supports, the
+// scheme is detected from the first response: offset based pages are fetched in parallel like before, while cursor
+// based pages are fetched one after another by feeding `nextPageToken` into the request of the following page.
+// Issues which are not valid json are logged and skipped by the logger if there is one, rather than failing the page
+type searchPager struct {
+	logger core.Logger
+}
+
+// SetQuery sets the pagination parameters of the page to be fetched
+func (searchPager) SetQuery(query url.Values, reqData *helper.RequestData) {
+	query.Set("maxResults", fmt.Sprintf("%v", reqData.Pager.Size))
+	if token, ok := reqData.CustomData.(string); ok {
+		query.Set("nextPageToken", token)
+		return
+	}
+	query.Set("startAt", fmt.Sprintf("%v", reqData.Pager.Skip))
+}
+
+// GetTotalPages counts the pages of an offset based response, only the first page is counted for a cursor based one
+// since the rest of them are chained by `GetNextPageCustomData`
+func (searchPager) GetTotalPages(res *http.Response, args *helper.ApiCollectorArgs) (int, errors.Error) {
+	body := &JiraSearchResponse{}
+	err := helper.UnmarshalResponse(res, body)
+	if err != nil {
+		return 0, err
+	}
+	if body.isCursorBased() {
+		return 1, nil
+	}
+	pages := body.Total / args.PageSize
+	if body.Total%args.PageSize > 0 {
+		pages++
+	}
+	return pages, nil
+}
+
+// HasMore tells if there is another page after a cursor based response, the pages end once `isLast` is returned, or
+// the token or the issues are missing, since Jira Cloud is known to return a token along with an empty last page.
+// False is returned for offset based responses, whose pages are counted by `GetTotalPages` instead
+func (searchPager) HasMore(res *http.Response) (bool, errors.Error) {
+	body := &JiraSearchResponse{}
+	err := helper.UnmarshalResponse(res, body)
+	if err != nil {
+		return false, err
+	}
+	return body.hasMore(), nil
+}
+
+// hasMore tells if there is another page after the response, see `HasMore`
+func (r *JiraSearchResponse) hasMore() bool {
+	if !r.isCursorBased() || r.NextPageToken == nil || *r.NextPageToken == "" {
+		return false
+	}
+	if r.IsLast != nil && *r.IsLast {
+		return false
+	}
+	return len(r.Issues) > 0
+}
+
+// GetNextPageCustomData returns the token of the next page of a cursor based response if there are more pages,
+// offset based responses are finished right away since their pages were counted by `GetTotalPages`
+func (searchPager) GetNextPageCustomData(_ *helper.RequestData, res *http.Response) (interface{}, errors.Error) {
+	body := &JiraSearchResponse{}
+	err := helper.UnmarshalResponse(res, body)
+	if err != nil {
+		return nil, err
+	}
+	if !body.hasMore() {
+		return nil, helper.ErrFinishCollect
+	}
+	return *body.NextPageToken, nil
+}
+
+// ResponseParser extracts the issues from the response regardless of the pagination scheme, the body is decoded as a
+// stream since pages of issues with their changelogs expanded could be huge
+func (pager searchPager) ResponseParser(res *http.Response) ([]json.RawMessage, errors.Error) {
+	if pager.logger != nil {
+		return helper.DecodeJsonArrayFieldFromResponseLeniently(res, "issues", pager.logger)
+	}
+	return helper.DecodeJsonArrayFieldFromResponse(res, "issues")
+}
@@ -0,0 +1,337 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/helper/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newPagerResponse(body string) *http.Response {
+	return &http.Response{
+		Request: &http.Request{URL: &url.URL{}},
+		Body:    io.NopCloser(bytes.NewBufferString(body)),
+	}
+}
+
+func TestSearchPagerOffsetBased(t *testing.T) {
+	pager := searchPager{}
+	args := &helper.ApiCollectorArgs{PageSize: 100}
+	body := `{"startAt":0,"maxResults":100,"total":250,"issues":[{"id":"1"}]}`
+
+	pages, err := pager.GetTotalPages(newPagerResponse(body), args)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, pages)
+	_, err = pager.GetNextPageCustomData(nil, newPagerResponse(body))
+	assert.Equal(t, helper.ErrFinishCollect, err)
+	issues, err := pager.ResponseParser(newPagerResponse(body))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(issues))
+
+	query := url.Values{}
+	pager.SetQuery(query, &helper.RequestData{Pager: &helper.Pager{Page: 2, Skip: 100, Size: 100}})
+	assert.Equal(t, "100", query.Get("startAt"))
+	assert.Equal(t, "100", query.Get("maxResults"))
+	assert.Equal(t, "", query.Get("nextPageToken"))
+}
+
+func TestSearchPagerSkipsMalformedIssues(t *testing.T) {
+	body := `{"startAt":0,"maxResults":100,"total":4,"issues":[{"id":"1","key":"K-1"},{"id":"2","key":"K-2","fields":{"summary":"a""b"}},{"id":"3","key":"K-3"},{"id":"4","key":"K-4"}]}`
+	logger := new(mocks.Logger)
+	logger.On("Warn", mock.Anything, mock.Anything, mock.Anything).Once()
+	pager := searchPager{logger: logger}
+	issues, err := pager.ResponseParser(newPagerResponse(body))
+	assert.Nil(t, err)
+	// the malformed issue is logged and left out, the rest of the page persists
+	assert.Equal(t, []json.RawMessage{
+		json.RawMessage(`{"id":"1","key":"K-1"}`),
+		json.RawMessage(`{"id":"3","key":"K-3"}`),
+		json.RawMessage(`{"id":"4","key":"K-4"}`),
+	}, issues)
+	logger.AssertExpectations(t)
+
+	// the whole page fails without a logger to report the malformed issue to
+	_, err = searchPager{}.ResponseParser(newPagerResponse(body))
+	assert.NotNil(t, err)
+}
+
+func TestSearchPagerCursorBased(t *testing.T) {
+	pager := searchPager{}
+	args := &helper.ApiCollectorArgs{PageSize: 100}
+	body := `{"issues":[{"id":"1"},{"id":"2"}],"nextPageToken":"token2","isLast":false}`
+
+	pages, err := pager.GetTotalPages(newPagerResponse(body), args)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pages)
+	token, err := pager.GetNextPageCustomData(nil, newPagerResponse(body))
+	assert.Nil(t, err)
+	assert.Equal(t, "token2", token)
+	issues, err := pager.ResponseParser(newPagerResponse(body))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(issues))
+
+	query := url.Values{}
+	pager.SetQuery(query, &helper.RequestData{Pager: &helper.Pager{Page: 2, Skip: 100, Size: 100}, CustomData: token})
+	assert.Equal(t, "token2", query.Get("nextPageToken"))
+	assert.Equal(t, "", query.Get("startAt"))
+
+	// the last page
+	_, err = pager.GetNextPageCustomData(nil, newPagerResponse(`{"issues":[{"id":"3"}],"isLast":true}`))
+	assert.Equal(t, helper.ErrFinishCollect, err)
+	pages, err = pager.GetTotalPages(newPagerResponse(`{"issues":[{"id":"3"}],"isLast":true}`), args)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pages)
+}
+
+func TestSearchPagerHasMore(t *testing.T) {
+	pager := searchPager{}
+	cases := map[string]bool{
+		`{"issues":[{"id":"1"}],"nextPageToken":"token2","isLast":false}`: true,
+		`{"issues":[{"id":"1"}],"nextPageToken":"token2"}`:                true,
+		`{"issues":[{"id":"1"}],"isLast":true}`:                           false,
+		`{"issues":[{"id":"1"}],"nextPageToken":"","isLast":false}`:       false,
+		// an empty page ends the collection even though a token was returned
+		`{"issues":[],"nextPageToken":"token3","isLast":false}`: false,
+		// offset based pages are counted instead
+		`{"startAt":0,"maxResults":100,"total":250,"issues":[{"id":"1"}]}`: false,
+	}
+	for body, expected := range cases {
+		hasMore, err := pager.HasMore(newPagerResponse(body))
+		assert.Nil(t, err, body)
+		assert.Equal(t, expected, hasMore, body)
+		_, err = pager.GetNextPageCustomData(nil, newPagerResponse(body))
+		if expected {
+			assert.Nil(t, err, body)
+		} else {
+			assert.Equal(t, helper.ErrFinishCollect, err, body)
+		}
+	}
+}
+
+var (
+	largeSearchResponse     []byte
+	largeSearchResponseOnce sync.Once
+)
+
+// collectFromCappingServer collects issues from a search api capping `maxResults` to 50 like Jira does for some
+// endpoints, and returns the ids of the issues saved into the raw table
+func collectFromCappingServer(t *testing.T, getPageSize func(res *http.Response, args *helper.ApiCollectorArgs) (int, errors.Error)) []string {
+	const total, honored = 120, 50
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		maxResults, _ := strconv.Atoi(r.URL.Query().Get("maxResults"))
+		if maxResults > honored {
+			maxResults = honored
+		}
+		issues := []string{}
+		for i := startAt; i < total && i < startAt+maxResults; i++ {
+			issues = append(issues, fmt.Sprintf(`{"id":"%d"}`, i))
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf(
+			`{"startAt":%d,"maxResults":%d,"total":%d,"issues":[%s]}`,
+			startAt, maxResults, total, strings.Join(issues, ","),
+		)))
+	}))
+	defer server.Close()
+
+	taskCtx := new(mocks.TaskContext)
+	taskCtx.On("GetConfig", mock.Anything).Return("")
+	taskCtx.On("GetLogger").Return(unithelper.DummyLogger())
+	taskCtx.On("GetContext").Return(context.Background())
+	apiClient := &helper.ApiClient{}
+	apiClient.Setup(server.URL, nil, 10*time.Second)
+	asyncClient, err := helper.CreateAsyncApiClient(taskCtx, apiClient, &helper.ApiRateLimitCalculator{UserRateLimitPerHour: 360000})
+	assert.Nil(t, err)
+	defer asyncClient.Release()
+
+	var mu sync.Mutex
+	var ids []string
+	mockDal := new(mocks.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range args.Get(0).([]*helper.RawData) {
+			issue := &struct {
+				Id string `json:"id"`
+			}{}
+			assert.Nil(t, json.Unmarshal(row.Data, issue))
+			ids = append(ids, issue.Id)
+		}
+	}).Return(nil)
+	mockCtx := unithelper.DummySubTaskContext(mockDal)
+
+	pager := searchPager{}
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx:    mockCtx,
+			Table:  RAW_EPIC_TABLE,
+			Params: "whatever params",
+		},
+		ApiClient:   asyncClient,
+		PageSize:    100,
+		UrlTemplate: "api/2/search",
+		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+			query := url.Values{}
+			pager.SetQuery(query, reqData)
+			return query, nil
+		},
+		GetTotalPages:         pager.GetTotalPages,
+		GetPageSize:           getPageSize,
+		GetNextPageCustomData: pager.GetNextPageCustomData,
+		ResponseParser:        pager.ResponseParser,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, collector.Execute())
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+	return ids
+}
+
+func TestSearchPagerCappedMaxResults(t *testing.T) {
+	expected := make([]string, 0, 120)
+	for i := 0; i < 120; i++ {
+		expected = append(expected, strconv.Itoa(i))
+	}
+	assert.Equal(t, expected, collectFromCappingServer(t, GetPageSizeFromResponse))
+	// the page math based on the requested page size skips issues 50-99
+	assert.Len(t, collectFromCappingServer(t, nil), 70)
+}
+
+// getLargeSearchResponse generates a 50MB page of issues with their changelogs expanded
+func getLargeSearchResponse() []byte {
+	largeSearchResponseOnce.Do(func() {
+		buf := &bytes.Buffer{}
+		buf.WriteString(`{"startAt":0,"maxResults":100,"total":100,"issues":[`)
+		history := fmt.Sprintf(`{"id":"1","items":[{"field":"description","toString":"%s"}]}`, strings.Repeat("x", 1000))
+		histories := strings.TrimSuffix(strings.Repeat(history+",", 500), ",")
+		for i := 0; i < 100; i++ {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			fmt.Fprintf(buf, `{"id":"%d","key":"K-%d","changelog":{"histories":[%s]}}`, i, i, histories)
+		}
+		buf.WriteString(`]}`)
+		largeSearchResponse = buf.Bytes()
+	})
+	return largeSearchResponse
+}
+
+// benchmarkSearchCollector collects pages of the large search response by an ApiCollector with `parser`, so the
+// response is read the way it is in production
+func benchmarkSearchCollector(b *testing.B, parser func(res *http.Response) ([]json.RawMessage, errors.Error)) {
+	const pages = 5
+	body := getLargeSearchResponse()
+	b.SetBytes(int64(len(body)) * pages)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mockDal := new(mocks.Dal)
+		mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+		mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		mockDal.On("Create", mock.Anything, mock.Anything).Return(nil)
+		mockApi := new(mocks.RateLimitedApiClient)
+		mockApi.On("DoGetAsync", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			handler := args.Get(3).(common.ApiAsyncCallback)
+			err := handler(&http.Response{
+				StatusCode: http.StatusOK,
+				Request:    &http.Request{URL: &url.URL{RawQuery: args.Get(1).(url.Values).Encode()}},
+				Body:       io.NopCloser(bytes.NewReader(body)),
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		})
+		mockApi.On("NextTick", mock.Anything).Run(func(args mock.Arguments) {
+			_ = args.Get(0).(func() errors.Error)()
+		})
+		mockApi.On("HasError").Return(false)
+		mockApi.On("WaitAsync").Return(nil)
+		mockApi.On("GetAfterFunction", mock.Anything).Return(nil)
+		mockApi.On("SetAfterFunction", mock.Anything).Return()
+		pager := searchPager{}
+		collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+			RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+				Ctx:    unithelper.DummySubTaskContext(mockDal),
+				Table:  RAW_EPIC_TABLE,
+				Params: "whatever params",
+			},
+			ApiClient:   mockApi,
+			PageSize:    100,
+			UrlTemplate: "api/2/search",
+			Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+				query := url.Values{}
+				pager.SetQuery(query, reqData)
+				return query, nil
+			},
+			GetTotalPages: func(res *http.Response, args *helper.ApiCollectorArgs) (int, errors.Error) {
+				return pages, nil
+			},
+			ResponseParser: parser,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err = collector.Execute(); err != nil {
+			b.Fatal(err)
+		}
+		if collector.GetRecords() != 100*pages {
+			b.Fatalf("unexpected result: %d issues", collector.GetRecords())
+		}
+	}
+}
+
+// BenchmarkSearchCollectorReadAll is how issues were parsed before streaming, kept for comparison
+func BenchmarkSearchCollectorReadAll(b *testing.B) {
+	benchmarkSearchCollector(b, func(res *http.Response) ([]json.RawMessage, errors.Error) {
+		body := &JiraSearchResponse{}
+		err := helper.UnmarshalResponse(res, body)
+		if err != nil {
+			return nil, err
+		}
+		return body.Issues, nil
+	})
+}
+
+func BenchmarkSearchCollectorStreaming(b *testing.B) {
+	benchmarkSearchCollector(b, searchPager{}.ResponseParser)
+}
@@ -0,0 +1,190 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	goerror "errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/core/dal"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"gorm.io/gorm"
+)
+
+const RAW_SERVICE_DESK_REQUEST_TABLE = "jira_api_service_desk_requests"
+
+// serviceDeskPageSize is the page size requested from the service desk api, which caps it at 100
+const serviceDeskPageSize = 50
+
+// serviceDesk is a service desk of Jira Service Management, it is backed by a project of the instance
+type serviceDesk struct {
+	Id        string `json:"id"`
+	ProjectId string `json:"projectId"`
+}
+
+// serviceDeskPage is a page of the service desk api, which is paginated by `start` and `limit`
+type serviceDeskPage struct {
+	IsLastPage bool `json:"isLastPage"`
+}
+
+// collectServiceDeskRequests collects the customer requests of the service desks behind the boards, they take the
+// place of epics on Jira Service Management connections. The service desk of a board is looked up by the project
+// of the board, boards without a service desk are skipped with a warning
+func collectServiceDeskRequests(taskCtx core.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	serviceDesks, err := listServiceDesks(data.ApiClient)
+	if err != nil {
+		return err
+	}
+	for _, boardId := range data.Options.GetBoardIds() {
+		projectId, err := getBoardProjectId(taskCtx.GetDal(), data.Options.ConnectionId, boardId)
+		if err != nil {
+			return err
+		}
+		serviceDeskId := findServiceDesk(serviceDesks, projectId)
+		if serviceDeskId == "" {
+			logger.Warn(nil, "board %d is not backed by any service desk, skipping its requests", boardId)
+			continue
+		}
+		logger.Info("collect requests of service desk %s for board %d", serviceDeskId, boardId)
+		err = collectBoardServiceDeskRequests(taskCtx, boardId, serviceDeskId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectBoardServiceDeskRequests(taskCtx core.SubTaskContext, boardId uint64, serviceDeskId string) errors.Error {
+	data := taskCtx.GetData().(*JiraTaskData)
+	logger := taskCtx.GetLogger()
+	collector, err := helper.NewApiCollector(helper.ApiCollectorArgs{
+		RawDataSubTaskArgs: helper.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: JiraApiParams{
+				ConnectionId: data.Options.ConnectionId,
+				BoardId:      boardId,
+			},
+			Table: data.Options.RawTable(RAW_SERVICE_DESK_REQUEST_TABLE),
+		},
+		ApiClient:   data.ApiClient,
+		PageSize:    data.PageSize(serviceDeskPageSize),
+		UrlTemplate: "servicedeskapi/request",
+		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
+			return url.Values{
+				"serviceDeskId": {serviceDeskId},
+				// requests are returned regardless of their status and the customers who raised them
+				"requestStatus":    {"ALL_REQUESTS"},
+				"requestOwnership": {"ALL_REQUESTS"},
+				"start":            {strconv.Itoa(reqData.Pager.Skip)},
+				"limit":            {strconv.Itoa(reqData.Pager.Size)},
+			}, nil
+		},
+		Header: func(reqData *helper.RequestData) (http.Header, errors.Error) {
+			// some of the service desk apis are still flagged as experimental by Jira
+			return http.Header{"X-ExperimentalApi": {"opt-in"}}, nil
+		},
+		IsLastPage:  isLastServiceDeskPage,
+		Concurrency: data.Concurrency,
+		PageTimeout: data.PageTimeout,
+		DryRun:      data.Options.DryRun,
+		AfterResponse: chainAfterResponse(
+			newRateLimitObserver(logger).AfterResponse,
+			ignoreHTTPStatus404,
+		),
+		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
+			return helper.DecodeJsonArrayFieldFromResponse(res, "values")
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return collector.Execute()
+}
+
+// isLastServiceDeskPage tells if the response is the last page by its `isLastPage`
+func isLastServiceDeskPage(res *http.Response, _ *helper.ApiCollectorArgs) (bool, errors.Error) {
+	page := &serviceDeskPage{}
+	err := helper.UnmarshalResponse(res, page)
+	if err != nil {
+		return false, err
+	}
+	return page.IsLastPage, nil
+}
+
+// listServiceDesks lists all service desks visible to the credential of the connection page by page
+func listServiceDesks(apiClient helper.ApiClientGetter) ([]serviceDesk, errors.Error) {
+	var serviceDesks []serviceDesk
+	for {
+		query := url.Values{
+			"start": {strconv.Itoa(len(serviceDesks))},
+			"limit": {strconv.Itoa(serviceDeskPageSize)},
+		}
+		res, err := apiClient.Get("servicedeskapi/servicedesk", query, nil)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "failed to list jira service desks")
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to list jira service desks, unexpected status code: %d", res.StatusCode))
+		}
+		var page struct {
+			serviceDeskPage
+			Values []serviceDesk `json:"values"`
+		}
+		err = helper.UnmarshalResponse(res, &page)
+		if err != nil {
+			return nil, err
+		}
+		serviceDesks = append(serviceDesks, page.Values...)
+		if page.IsLastPage || len(page.Values) == 0 {
+			return serviceDesks, nil
+		}
+	}
+}
+
+// findServiceDesk returns the id of the service desk backed by the project, empty if there is none
+func findServiceDesk(serviceDesks []serviceDesk, projectId uint) string {
+	for _, desk := range serviceDesks {
+		if desk.ProjectId == strconv.FormatUint(uint64(projectId), 10) {
+			return desk.Id
+		}
+	}
+	return ""
+}
+
+// getBoardProjectId returns the project of the board collected by collectBoard, 0 if the board is unknown
+func getBoardProjectId(db dal.Dal, connectionId uint64, boardId uint64) (uint, errors.Error) {
+	board := &models.JiraBoard{}
+	err := db.First(board, dal.Where("connection_id = ? AND board_id = ?", connectionId, boardId))
+	if err != nil {
+		if goerror.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, errors.Default.Wrap(err, fmt.Sprintf("failed to load board %d", boardId))
+	}
+	return board.ProjectId, nil
+}
@@ -0,0 +1,78 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListServiceDesks(t *testing.T) {
+	respond := func(body string) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Request:    &http.Request{URL: &url.URL{}},
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+		}
+	}
+	pageAt := func(start string) interface{} {
+		return mock.MatchedBy(func(query url.Values) bool { return query.Get("start") == start })
+	}
+	apiClient := mocks.NewApiClientGetter(t)
+	apiClient.On("Get", "servicedeskapi/servicedesk", pageAt("0"), mock.Anything).Return(respond(`{"isLastPage":false,"values":[
+		{"id":"1","projectId":"10001","projectKey":"HELP"},
+		{"id":"2","projectId":"10002","projectKey":"IT"}
+	]}`), nil).Once()
+	apiClient.On("Get", "servicedeskapi/servicedesk", pageAt("2"), mock.Anything).Return(respond(`{"isLastPage":true,"values":[
+		{"id":"3","projectId":"10003","projectKey":"HR"}
+	]}`), nil).Once()
+
+	serviceDesks, err := listServiceDesks(apiClient)
+	assert.Nil(t, err)
+	assert.Len(t, serviceDesks, 3)
+	assert.Equal(t, "2", findServiceDesk(serviceDesks, 10002))
+	assert.Equal(t, "", findServiceDesk(serviceDesks, 10004))
+	// boards not collected yet have no project
+	assert.Equal(t, "", findServiceDesk(serviceDesks, 0))
+
+	apiClient = mocks.NewApiClientGetter(t)
+	apiClient.On("Get", "servicedeskapi/servicedesk", pageAt("0"), mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusNotFound,
+		Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+	}, nil).Once()
+	_, err = listServiceDesks(apiClient)
+	assert.NotNil(t, err)
+}
+
+func TestIsLastServiceDeskPage(t *testing.T) {
+	for body, expected := range map[string]bool{
+		`{"size":50,"start":0,"limit":50,"isLastPage":false,"values":[]}`: false,
+		`{"size":3,"start":50,"limit":50,"isLastPage":true,"values":[]}`:  true,
+	} {
+		isLast, err := isLastServiceDeskPage(&http.Response{Body: io.NopCloser(bytes.NewBufferString(body))}, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, isLast)
+	}
+}
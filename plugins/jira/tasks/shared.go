@@ -18,26 +18,156 @@ limitations under the License.
 package tasks
 
 import (
+	"encoding/json"
 	"github.com/apache/incubator-devlake/errors"
 	"net/http"
+	"time"
 
 	"github.com/apache/incubator-devlake/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/plugins/core"
 	"github.com/apache/incubator-devlake/plugins/helper"
 )
 
+// getCollectionSince resolves the time range of a collector, `getLatest` loads the incremental state which is the
+// time the previous collection reached, nil if nothing was collected before.
+//   - without any option, the collection is incremental from the state, or full if there is no state
+//   - `Since` makes a full collection from that time, and the state is ignored
+//   - `TimeAfter` forces the collection in incremental mode from that time, existing data is kept. If the state is
+//     earlier than `TimeAfter`, the collection starts from the state instead, so the data in between wouldn't be
+//     skipped forever once the state moves past `TimeAfter`
+func getCollectionSince(
+	logger core.Logger,
+	data *JiraTaskData,
+	getLatest func() (*time.Time, errors.Error),
+) (since *time.Time, incremental bool, err errors.Error) {
+	if data.TimeAfter == nil && data.Since != nil {
+		return data.Since, false, nil
+	}
+	latest, err := getLatest()
+	if err != nil {
+		return nil, false, err
+	}
+	if data.TimeAfter == nil {
+		return latest, latest != nil, nil
+	}
+	if latest != nil && latest.Before(*data.TimeAfter) {
+		logger.Warn(nil, "timeAfter %s is later than the previous collection, collecting since %s instead", data.TimeAfter, latest)
+		return latest, true, nil
+	}
+	logger.Info("manual time window is in effect, collecting data updated since %s", data.TimeAfter)
+	return data.TimeAfter, true, nil
+}
+
+// collectionResumeKey identifies the query of a resumable collection by the options it was derived from, along with
+// the time range resolved for it
+type collectionResumeKey struct {
+	// Query is the query apart from the time range, i.e. the filter, the ordering and the fields
+	Query     string `json:"query"`
+	Since     string `json:"since"`
+	TimeAfter string `json:"timeAfter"`
+	// LookbackDays is kept along with the window resolved by the interrupted collection, which is resumed as is
+	LookbackDays int        `json:"lookbackDays,omitempty"`
+	From         *time.Time `json:"from"`
+	Incremental  bool       `json:"incremental"`
+}
+
+// getResumableCollectionSince resolves the time range of a collector by getCollectionSince, unless the previous
+// collection of `args` was interrupted and the options of the task stay the same, in which case the time range of
+// the interrupted collection is resumed instead, since the incremental state has moved on along with the raw rows
+// collected by it. `query` is the rest of the query, whose change starts the collection over. The resume key of the
+// collection is returned along with the time range. A rolling window of `LookbackDays` replaces getCollectionSince,
+// it makes a full collection of the window regardless of the incremental state
+func getResumableCollectionSince(
+	logger core.Logger,
+	data *JiraTaskData,
+	args helper.RawDataSubTaskArgs,
+	query string,
+	getLatest func() (*time.Time, errors.Error),
+) (since *time.Time, incremental bool, resumeKey string, err errors.Error) {
+	previousKey, err := helper.GetResumeKey(args.Ctx.GetDal(), args)
+	if err != nil {
+		return nil, false, "", err
+	}
+	key := collectionResumeKey{
+		Query:        query,
+		Since:        data.Options.Since,
+		TimeAfter:    data.Options.TimeAfter,
+		LookbackDays: data.Options.LookbackDays,
+	}
+	if previousKey != "" {
+		previous := collectionResumeKey{}
+		if json.Unmarshal([]byte(previousKey), &previous) == nil &&
+			previous.Query == key.Query && previous.Since == key.Since && previous.TimeAfter == key.TimeAfter &&
+			previous.LookbackDays == key.LookbackDays {
+			logger.Info("resuming the interrupted collection of %s", args.Table)
+			return previous.From, previous.Incremental, previousKey, nil
+		}
+		logger.Info("options have changed since the interrupted collection of %s, starting over", args.Table)
+	}
+	if data.LookbackSince != nil {
+		since, incremental = data.LookbackSince, false
+	} else {
+		since, incremental, err = getCollectionSince(logger, data, getLatest)
+		if err != nil {
+			return nil, false, "", err
+		}
+	}
+	key.From = since
+	key.Incremental = incremental
+	blob, e := json.Marshal(key)
+	if e != nil {
+		return nil, false, "", errors.Convert(e)
+	}
+	return since, incremental, string(blob), nil
+}
+
+// GetTotalPagesFromResponse counts the pages by the `total` of the response, `helper.UnknownTotalPages` is returned
+// if `total` is missing or negative, so the collector could fall back to `IsLastPageFromResponse`
 func GetTotalPagesFromResponse(res *http.Response, args *helper.ApiCollectorArgs) (int, errors.Error) {
-	body := &JiraPagination{}
+	body := &struct {
+		Total *int `json:"total"`
+	}{}
 	err := helper.UnmarshalResponse(res, body)
 	if err != nil {
 		return 0, err
 	}
-	pages := body.Total / args.PageSize
-	if body.Total%args.PageSize > 0 {
+	if body.Total == nil || *body.Total < 0 {
+		return helper.UnknownTotalPages, nil
+	}
+	total := *body.Total
+	pages := total / args.PageSize
+	if total%args.PageSize > 0 {
 		pages++
 	}
 	return pages, nil
 }
 
+// GetPageSizeFromResponse reads the `maxResults` honored by Jira, which might be smaller than the requested one since
+// Jira caps it silently per endpoint. 0 is returned if `maxResults` is missing, so the requested one is kept
+func GetPageSizeFromResponse(res *http.Response, args *helper.ApiCollectorArgs) (int, errors.Error) {
+	body := &struct {
+		MaxResults int `json:"maxResults"`
+	}{}
+	err := helper.UnmarshalResponse(res, body)
+	if err != nil {
+		return 0, err
+	}
+	return body.MaxResults, nil
+}
+
+// IsLastPageFromResponse tells if the response is the last page by its `isLast`, the pages of responses without
+// `isLast` are fetched till an empty one
+func IsLastPageFromResponse(res *http.Response, args *helper.ApiCollectorArgs) (bool, errors.Error) {
+	body := &struct {
+		IsLast *bool `json:"isLast"`
+	}{}
+	err := helper.UnmarshalResponse(res, body)
+	if err != nil {
+		return false, err
+	}
+	return body.IsLast != nil && *body.IsLast, nil
+}
+
 func getStdStatus(statusKey string) string {
 	if statusKey == "done" {
 		return ticket.DONE
@@ -0,0 +1,187 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/helpers/unithelper"
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetTotalPagesFromResponse(t *testing.T) {
+	args := &helper.ApiCollectorArgs{PageSize: 100}
+	cases := map[string]int{
+		`{"startAt":0,"maxResults":100,"total":250}`:              3,
+		`{"startAt":0,"maxResults":100,"total":0}`:                0,
+		`{"startAt":0,"maxResults":100,"isLast":false}`:           helper.UnknownTotalPages,
+		`{"startAt":0,"maxResults":100,"total":-1,"isLast":true}`: helper.UnknownTotalPages,
+	}
+	for body, expected := range cases {
+		pages, err := GetTotalPagesFromResponse(newPagerResponse(body), args)
+		assert.Nil(t, err, body)
+		assert.Equal(t, expected, pages, body)
+	}
+}
+
+func TestIsLastPageFromResponse(t *testing.T) {
+	args := &helper.ApiCollectorArgs{PageSize: 100}
+	cases := map[string]bool{
+		`{"isLast":true,"values":[]}`:  true,
+		`{"isLast":false,"values":[]}`: false,
+		`{"total":-1,"values":[]}`:     false,
+	}
+	for body, expected := range cases {
+		isLast, err := IsLastPageFromResponse(newPagerResponse(body), args)
+		assert.Nil(t, err, body)
+		assert.Equal(t, expected, isLast, body)
+	}
+}
+
+func TestGetCollectionSince(t *testing.T) {
+	day := func(d int) *time.Time {
+		t := time.Date(2022, 11, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	cases := []struct {
+		name                string
+		since, timeAfter    *time.Time
+		latest              *time.Time
+		expectedSince       *time.Time
+		expectedIncremental bool
+	}{
+		{name: "first collection"},
+		{name: "incremental", latest: day(10), expectedSince: day(10), expectedIncremental: true},
+		{name: "since", since: day(5), latest: day(10), expectedSince: day(5)},
+		{name: "timeAfter", timeAfter: day(5), latest: day(10), expectedSince: day(5), expectedIncremental: true},
+		{name: "timeAfter overrides since", since: day(1), timeAfter: day(5), latest: day(10), expectedSince: day(5), expectedIncremental: true},
+		{name: "timeAfter without state", timeAfter: day(5), expectedSince: day(5), expectedIncremental: true},
+		{name: "timeAfter later than state", timeAfter: day(15), latest: day(10), expectedSince: day(10), expectedIncremental: true},
+	}
+	for _, c := range cases {
+		data := &JiraTaskData{Since: c.since, TimeAfter: c.timeAfter}
+		since, incremental, err := getCollectionSince(unithelper.DummyLogger(), data, func() (*time.Time, errors.Error) {
+			return c.latest, nil
+		})
+		assert.Nil(t, err, c.name)
+		assert.Equal(t, c.expectedSince, since, c.name)
+		assert.Equal(t, c.expectedIncremental, incremental, c.name)
+	}
+}
+
+func TestGetResumableCollectionSince(t *testing.T) {
+	interrupted := time.Date(2022, 11, 1, 8, 0, 0, 0, time.UTC)
+	latest := time.Date(2022, 11, 2, 8, 0, 0, 0, time.UTC)
+	interruptedKey := `{"query":"status = Done","since":"","timeAfter":"","from":"2022-11-01T08:00:00Z","incremental":true}`
+	cases := []struct {
+		name                string
+		jql                 string
+		previousKey         string
+		expectedSince       time.Time
+		expectedResumeKey   string
+		expectedIncremental bool
+	}{
+		{"resumed", "status = Done", interruptedKey, interrupted, interruptedKey, true},
+		{"jql changed", "status = Open", interruptedKey, latest,
+			`{"query":"status = Open","since":"","timeAfter":"","from":"2022-11-02T08:00:00Z","incremental":true}`, true},
+		{"not interrupted", "status = Done", "", latest,
+			`{"query":"status = Done","since":"","timeAfter":"","from":"2022-11-02T08:00:00Z","incremental":true}`, true},
+	}
+	for _, c := range cases {
+		mockDal := new(mocks.Dal)
+		mockDal.On("Pluck", "resume_key", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			if c.previousKey != "" {
+				*args.Get(1).(*[]string) = []string{c.previousKey}
+			}
+		}).Return(nil).Once()
+		data := &JiraTaskData{Options: &JiraOptions{}}
+		args := helper.RawDataSubTaskArgs{
+			Ctx:    unithelper.DummySubTaskContext(mockDal),
+			Params: JiraApiParams{ConnectionId: 1, BoardId: 2},
+			Table:  RAW_EPIC_TABLE,
+		}
+		since, incremental, resumeKey, err := getResumableCollectionSince(unithelper.DummyLogger(), data, args, c.jql, func() (*time.Time, errors.Error) {
+			return &latest, nil
+		})
+		assert.Nil(t, err, c.name)
+		if assert.NotNil(t, since, c.name) {
+			assert.True(t, c.expectedSince.Equal(*since), c.name)
+		}
+		assert.Equal(t, c.expectedIncremental, incremental, c.name)
+		assert.Equal(t, c.expectedResumeKey, resumeKey, c.name)
+		mockDal.AssertExpectations(t)
+	}
+}
+
+func TestGetResumableCollectionSinceLookback(t *testing.T) {
+	now := time.Date(2022, 11, 30, 8, 0, 0, 0, time.UTC)
+	op := &JiraOptions{LookbackDays: 30, Jql: "project = DEV"}
+	lookbackSince, err := op.GetLookbackSince(now)
+	assert.Nil(t, err)
+	mockDal := new(mocks.Dal)
+	mockDal.On("Pluck", "resume_key", mock.Anything, mock.Anything).Return(nil).Once()
+	data := &JiraTaskData{Options: op, LookbackSince: lookbackSince}
+	args := helper.RawDataSubTaskArgs{
+		Ctx:    unithelper.DummySubTaskContext(mockDal),
+		Params: JiraApiParams{ConnectionId: 1, BoardId: 2},
+		Table:  RAW_EPIC_TABLE,
+	}
+	since, incremental, resumeKey, err := getResumableCollectionSince(unithelper.DummyLogger(), data, args, "project = DEV", func() (*time.Time, errors.Error) {
+		t.Fatal("the incremental state should be ignored")
+		return nil, nil
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, since) {
+		assert.True(t, since.Equal(time.Date(2022, 10, 31, 8, 0, 0, 0, time.UTC)))
+	}
+	assert.False(t, incremental)
+	assert.Equal(t,
+		`{"query":"project = DEV","since":"","timeAfter":"","lookbackDays":30,"from":"2022-10-31T08:00:00Z","incremental":false}`,
+		resumeKey,
+	)
+	// the window is AND-ed with the filter of the user
+	assert.Equal(t,
+		`issue in ("K-1") AND updated >= '2022/10/31 08:00' AND (project = DEV) ORDER BY created ASC, key ASC`,
+		buildEpicJql(defaultEpicOrderBy, []string{"K-1"}, fmt.Sprintf("updated >= '%s'", since.Format("2006/01/02 15:04")), userJqlCriteria(op.Jql)),
+	)
+	mockDal.AssertExpectations(t)
+}
+
+func TestGetLookbackSince(t *testing.T) {
+	now := time.Now()
+	since, err := (&JiraOptions{}).GetLookbackSince(now)
+	assert.Nil(t, err)
+	assert.Nil(t, since)
+	invalid := []*JiraOptions{
+		{LookbackDays: -1},
+		{LookbackDays: 7, Since: "2022-11-01T00:00:00Z"},
+		{LookbackDays: 7, TimeAfter: "2022-11-01T00:00:00Z"},
+	}
+	for _, op := range invalid {
+		_, err = op.GetLookbackSince(now)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, errors.BadInput, err.GetType())
+		}
+	}
+}
@@ -52,10 +52,10 @@ func CollectSprints(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_SPRINT_TABLE,
+			Table: data.Options.RawTable(RAW_SPRINT_TABLE),
 		},
 		ApiClient:   data.ApiClient,
-		PageSize:    50,
+		PageSize:    data.PageSize(50),
 		UrlTemplate: "agile/1.0/board/{{ .Params.BoardId }}/sprint",
 		Query: func(reqData *helper.RequestData) (url.Values, errors.Error) {
 			query := url.Values{}
@@ -70,7 +70,7 @@ func ConvertSprints(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_SPRINT_TABLE,
+			Table: data.Options.RawTable(RAW_SPRINT_TABLE),
 		},
 		InputRowType: reflect.TypeOf(models.JiraSprint{}),
 		Input:        cursor,
@@ -46,7 +46,7 @@ func ExtractSprints(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_SPRINT_TABLE,
+			Table: data.Options.RawTable(RAW_SPRINT_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			var sprint apiv2models.Sprint
@@ -66,7 +66,7 @@ func ConvertSprintIssues(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_ISSUE_TABLE,
+			Table: data.Options.RawTable(RAW_ISSUE_TABLE),
 		},
 		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
 			jiraSprintIssue := inputRow.(*models.JiraSprintIssue)
@@ -46,10 +46,10 @@ func CollectStatus(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_STATUS_TABLE,
+			Table: data.Options.RawTable(RAW_STATUS_TABLE),
 		},
 		ApiClient:     data.ApiClient,
-		UrlTemplate:   "api/2/status",
+		UrlTemplate:   data.ApiVersion().Path("status"),
 		GetTotalPages: GetTotalPagesFromResponse,
 		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
 			var data []json.RawMessage
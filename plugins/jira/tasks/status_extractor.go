@@ -47,7 +47,7 @@ func ExtractStatus(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: connectionId,
 				BoardId:      boardId,
 			},
-			Table: RAW_STATUS_TABLE,
+			Table: data.Options.RawTable(RAW_STATUS_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			var apiStatus apiv2models.Status
@@ -18,12 +18,17 @@ limitations under the License.
 package tasks
 
 import (
+	goerrors "errors"
 	"fmt"
-	"github.com/apache/incubator-devlake/errors"
+	"reflect"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/apache/incubator-devlake/errors"
 	"github.com/apache/incubator-devlake/plugins/helper"
 	"github.com/apache/incubator-devlake/plugins/jira/models"
+	"github.com/go-playground/validator/v10"
 )
 
 type StatusMapping struct {
@@ -40,24 +45,500 @@ type TypeMapping struct {
 type TypeMappings map[string]TypeMapping
 
 type TransformationRules struct {
+	// EpicKeyField and StoryPointField accept either ids or names of the fields, e.g. "Epic Link"
 	EpicKeyField               string       `json:"epicKeyField"`
 	StoryPointField            string       `json:"storyPointField"`
 	RemotelinkCommitShaPattern string       `json:"remotelinkCommitShaPattern"`
 	TypeMappings               TypeMappings `json:"typeMappings"`
 }
 
+// FederatedConnection is another connection whose epics are collected by the task along with the connection of the
+// task, i.e. a Jira instance federated with it. Its issues must have been collected by a task of its own
+type FederatedConnection struct {
+	ConnectionId uint64   `json:"connectionId"`
+	BoardIds     []uint64 `json:"boardIds"`
+	// StoryPointField is the story point field of the instance of the connection, fields are assigned per instance
+	StoryPointField string `json:"storyPointField"`
+}
+
 type JiraOptions struct {
-	ConnectionId        uint64 `json:"connectionId"`
-	BoardId             uint64 `json:"boardId"`
+	ConnectionId uint64 `json:"connectionId" validate:"required,gt=0"`
+	// BoardId may be omitted if the boards are selected by BoardIds or BoardNames
+	BoardId             uint64 `json:"boardId" validate:"required_without_all=BoardIds BoardNames"`
 	Since               string
 	TransformationRules TransformationRules `json:"transformationRules"`
+	// Jql is an optional filter which will be AND-ed with the JQL generated by the issue and epic collectors
+	Jql string `json:"jql"`
+	// DryRun makes the epic collector report the number of requests it would issue instead of collecting
+	DryRun bool `json:"dryRun"`
+	// EpicKeysBatchSize is the number of epic keys to be put into a single `issue in (...)` JQL, 100 by default
+	EpicKeysBatchSize int `json:"epicKeysBatchSize" validate:"gte=0"`
+	// BoardIds selects the boards whose epics are collected in one run, BoardId is used if omitted
+	BoardIds []uint64 `json:"boardIds" validate:"dive,gt=0"`
+	// BoardNames selects boards by their names in addition to BoardIds, they are resolved to the ids by the
+	// subtask discoverBoards
+	BoardNames []string `json:"boardNames"`
+	// TimeAfter forces the collectors to re-collect data updated after it (RFC3339), unlike Since, data collected
+	// before is kept and the incremental state is left intact
+	TimeAfter string `json:"timeAfter"`
+	// PageTimeout aborts the collectors of issues and epics if no page was completed within it, e.g. "5m"
+	PageTimeout string `json:"pageTimeout"`
+	// ConnectionScopedRawTables suffixes the raw tables by the connection id, so raw data of connections are never
+	// mixed up even if the params are misread. It should be kept unchanged across the tasks of a connection
+	ConnectionScopedRawTables bool `json:"connectionScopedRawTables"`
+	// EpicOrderBy is the ORDER BY clause of the JQL of the epic collector, one of `created ASC` (default),
+	// `created DESC`, `updated ASC`, `updated DESC`, `key ASC` and `key DESC`. The epics are ordered by their
+	// keys next unless they are ordered by their keys already, so the pagination is deterministic
+	EpicOrderBy string `json:"epicOrderBy"`
+	// EpicFields are the fields requested for the epics, the fields consumed by the extractors are requested if
+	// omitted, or `*all` to request all of them. The task fails if any field consumed by the extractors is left out
+	EpicFields []string `json:"epicFields"`
+	// IncludeArchived collects the epics left out by the JQL search one by one by their keys, i.e. epics archived
+	// by Jira Data Center 8.1+ or Jira Cloud Premium/Enterprise. Epics the issue api doesn't return either, as on
+	// editions without archiving, are skipped with a warning. Off by default
+	IncludeArchived bool `json:"includeArchived"`
+	// LookbackDays limits the epic collector to epics updated within the last days, the window is recomputed by
+	// every run and the incremental state is ignored. It can't be combined with Since or TimeAfter
+	LookbackDays int `json:"lookbackDays"`
+	// Labels limits the epic collector to epics labeled by any of them, all epics are collected if omitted
+	Labels []string `json:"labels"`
+	// StatusCategories limits the epic collector to epics in any of the status categories, i.e. `["To Do",
+	// "In Progress"]` to leave out the finished ones. Categories stay the same across workflows, unlike the names
+	// of statuses. They are either the names or the keys (new, indeterminate, done) of the categories
+	StatusCategories []string `json:"statusCategories"`
+	// UpdatedBy limits the epic collector to epics reported by or assigned to any of the users. Jira Cloud
+	// identifies users by their account ids, i.e. `5b10ac8d82e05b22cc7d4ef5`, while Jira Server and Data Center by
+	// their usernames, so the values differ by the deployment of the connection, see ValidateUpdatedBy
+	UpdatedBy []string `json:"updatedBy" validate:"dive,required"`
+	// EpicWindowDays splits the time range of the epic collector into windows of the days, the epics of the project
+	// of a board are searched window by window rather than by their keys, so the pagination of every search stays
+	// shallow on huge instances. A full collection starts from the epic updated the earliest. Off by default
+	EpicWindowDays int `json:"epicWindowDays" validate:"gte=0"`
+	// FederatedConnections are the connections whose epics are collected by the subtask collectFederatedEpics, each
+	// by its own api client. The raw rows are attributed to the connections by their params
+	FederatedConnections []FederatedConnection `json:"federatedConnections"`
+	// Limit stops the epic collector once that many epics were collected from all boards, i.e. for trying out a
+	// blueprint against a production instance. Unlike the page size, it caps the whole collection. 0 means no limit
+	Limit int `json:"limit" validate:"gte=0"`
+	// Sample makes the epic collector fetch a slice of the pages only, for a quick preview of a new board scope
+	// rather than a full collection. The raw rows are attributed to the sample by their params, so the metrics
+	// could leave them out. A sample is always collected in full mode and leaves out the archived epics
+	Sample *SampleOptions `json:"sample"`
+	// EpicShardCount and EpicShardIndex split the epic keys of the epic collector into shards by their hashes, so
+	// the subtask could be run by several workers, each collecting the shard of its index. They are set by the
+	// orchestrator, a count of 0 or 1 means no sharding. The raw rows are attributed to the shards by their params
+	EpicShardCount int `json:"epicShardCount"`
+	EpicShardIndex int `json:"epicShardIndex"`
+	// EmptyEpicsWarningKeys is the number of epic keys a full collection of a board searches at least for a warning
+	// to be reported if no epic was collected at all. It defaults to 1, a negative number turns the warning off for
+	// scopes expected to be empty
+	EmptyEpicsWarningKeys int `json:"emptyEpicsWarningKeys"`
+	// MaxConcurrency makes the epic collector adapt the number of concurrent requests to the responses instead of
+	// issuing the fixed concurrency of the connection: it ramps up from MinConcurrency while the responses are fast
+	// and backs off on 429/503 or latency spikes. 0 keeps the fixed concurrency
+	MaxConcurrency int `json:"maxConcurrency" validate:"gte=0"`
+	// MinConcurrency is the lower bound of the adaptive concurrency, 1 by default
+	MinConcurrency int `json:"minConcurrency" validate:"gte=0"`
+	// EpicKeySource is where the epic collector takes the keys of the epics of a board from, either `board`
+	// (default) for the epics of the issues of the board, or `project` for all epics of the project of the board by
+	// a JQL search, for team-managed projects whose issues are not reliably on their boards. The project is recorded
+	// in the params of the raw epics
+	EpicKeySource string `json:"epicKeySource" validate:"omitempty,oneof=board project"`
+	// RawTableOverrides collects into and extracts from the raw tables of other names, i.e. `{"jira_api_epics":
+	// "jira_api_epics_experimental"}` to try out a new extractor without touching the production raw epics. They
+	// fragment the raw data, so they are only accepted if the MODE of the server is debug or test
+	RawTableOverrides map[string]string `json:"rawTableOverrides"`
+	// ChangelogDeltaSync makes the epic collector track the highest changelog id of the epics of every board, so
+	// an incremental collection keeps only the epics with changelog entries beyond it instead of trusting the
+	// boundary of `updated`, which is shared by epics updated in the same minute. Changes leaving no changelog
+	// entry, i.e. comments, are not collected then. Off by default, since it depends on the changelogs being
+	// expanded in the search, which is not permitted by every Jira version or connection
+	ChangelogDeltaSync bool `json:"changelogDeltaSync"`
+	// CompressRawData gzips the raw epics before they are saved, which takes a fraction of the space for the
+	// epics with long descriptions and changelogs. The raw epics saved before are still extracted as they are
+	CompressRawData bool `json:"compressRawData"`
+}
+
+// SampleOptions selects the pages of a sample, see JiraOptions.Sample
+type SampleOptions struct {
+	// Every fetches the first of every that many pages, i.e. pages 0, 10, 20 for 10
+	Every int `json:"every" validate:"gt=1"`
+}
+
+// SampleEvery returns the interval of the pages sampled, 0 if the epics are not sampled
+func (op *JiraOptions) SampleEvery() int {
+	if op.Sample == nil {
+		return 0
+	}
+	return op.Sample.Every
+}
+
+// GetSince parses Since, nil is returned if it was omitted
+func (op *JiraOptions) GetSince() (*time.Time, errors.Error) {
+	if op.Since == "" {
+		return nil, nil
+	}
+	since, err := time.Parse("2006-01-02T15:04:05Z", op.Since)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid value for `since`")
+	}
+	return &since, nil
+}
+
+// GetTimeAfter parses TimeAfter, nil is returned if it was omitted
+func (op *JiraOptions) GetTimeAfter() (*time.Time, errors.Error) {
+	if op.TimeAfter == "" {
+		return nil, nil
+	}
+	timeAfter, err := time.Parse(time.RFC3339, op.TimeAfter)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid value for `timeAfter`")
+	}
+	return &timeAfter, nil
+}
+
+// GetLookbackSince returns the start of the window selected by LookbackDays relative to `now`, nil if it was
+// omitted
+func (op *JiraOptions) GetLookbackSince(now time.Time) (*time.Time, errors.Error) {
+	if op.LookbackDays == 0 {
+		return nil, nil
+	}
+	if op.LookbackDays < 0 {
+		return nil, errors.BadInput.New(fmt.Sprintf("invalid value for `lookbackDays`: %d", op.LookbackDays))
+	}
+	if op.Since != "" || op.TimeAfter != "" {
+		return nil, errors.BadInput.New("`lookbackDays` can't be combined with `since` or `timeAfter`")
+	}
+	since := now.AddDate(0, 0, -op.LookbackDays)
+	return &since, nil
+}
+
+// GetPageTimeout parses PageTimeout, 0 is returned if it was omitted
+func (op *JiraOptions) GetPageTimeout() (time.Duration, errors.Error) {
+	if op.PageTimeout == "" {
+		return 0, nil
+	}
+	pageTimeout, err := time.ParseDuration(op.PageTimeout)
+	if err != nil {
+		return 0, errors.BadInput.Wrap(err, "invalid value for `pageTimeout`")
+	}
+	return pageTimeout, nil
+}
+
+// epicOrderByFields are the fields the epics could be ordered by
+var epicOrderByFields = map[string]bool{"created": true, "updated": true, "key": true}
+
+// GetEpicOrderBy validates EpicOrderBy and returns it in the canonical form along with the tie-breaker, i.e.
+// `updated ASC, key ASC` for `Updated`, the direction is ASC if omitted. `created ASC, key ASC` is returned if it
+// was omitted
+func (op *JiraOptions) GetEpicOrderBy() (string, errors.Error) {
+	if strings.TrimSpace(op.EpicOrderBy) == "" {
+		return defaultEpicOrderBy, nil
+	}
+	terms := strings.Fields(op.EpicOrderBy)
+	field := strings.ToLower(terms[0])
+	if len(terms) > 2 || !epicOrderByFields[field] {
+		return "", errors.BadInput.New(fmt.Sprintf("invalid value for `epicOrderBy`: %s", op.EpicOrderBy))
+	}
+	direction := "ASC"
+	if len(terms) == 2 {
+		direction = strings.ToUpper(terms[1])
+		if direction != "ASC" && direction != "DESC" {
+			return "", errors.BadInput.New(fmt.Sprintf("invalid value for `epicOrderBy`: %s", op.EpicOrderBy))
+		}
+	}
+	if field == "key" {
+		return fmt.Sprintf("%s %s", field, direction), nil
+	}
+	return fmt.Sprintf("%s %s, %s", field, direction, epicOrderByTieBreaker), nil
+}
+
+// GetBoardIds returns the boards selected by BoardIds, or BoardId if no board was selected
+func (op *JiraOptions) GetBoardIds() []uint64 {
+	if len(op.BoardIds) > 0 {
+		return op.BoardIds
+	}
+	return []uint64{op.BoardId}
+}
+
+// ValidateBoardScope rejects options missing the connection or any of the boards, which would make the board based
+// subtasks query nothing and succeed silently
+func (op *JiraOptions) ValidateBoardScope() errors.Error {
+	if op.ConnectionId == 0 {
+		return errors.BadInput.New("jira connectionId is invalid")
+	}
+	for _, boardId := range op.GetBoardIds() {
+		if boardId == 0 {
+			return errors.BadInput.New("jira boardId is invalid")
+		}
+	}
+	return nil
+}
+
+// ValidateFederatedConnections rejects federated connections missing the connection or the boards, or duplicating
+// the connection of the task
+func (op *JiraOptions) ValidateFederatedConnections() errors.Error {
+	seen := map[uint64]bool{op.ConnectionId: true}
+	for _, federated := range op.FederatedConnections {
+		if federated.ConnectionId == 0 || seen[federated.ConnectionId] {
+			return errors.BadInput.New(fmt.Sprintf("invalid federated connectionId:%d", federated.ConnectionId))
+		}
+		seen[federated.ConnectionId] = true
+		if len(federated.BoardIds) == 0 {
+			return errors.BadInput.New(fmt.Sprintf("no board is selected for federated connection %d", federated.ConnectionId))
+		}
+		for _, boardId := range federated.BoardIds {
+			if boardId == 0 {
+				return errors.BadInput.New(fmt.Sprintf("invalid boardId:%d of federated connection %d", boardId, federated.ConnectionId))
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateStatusCategories checks every status category is known by Jira
+func (op *JiraOptions) ValidateStatusCategories() errors.Error {
+	for _, category := range op.StatusCategories {
+		if getStatusCategory(category) == "" {
+			return errors.BadInput.New(fmt.Sprintf("unknown status category %q, expected any of \"To Do\", \"In Progress\" and \"Done\"", category))
+		}
+	}
+	return nil
+}
+
+// ValidateEpicKeySource checks the epic keys are searched for, which is not the case with EpicWindowDays
+func (op *JiraOptions) ValidateEpicKeySource() errors.Error {
+	if op.EpicKeySource == EpicKeySourceProject && op.EpicWindowDays > 0 {
+		return errors.BadInput.New("epicKeySource can't be combined with epicWindowDays, which doesn't search by epic keys")
+	}
+	return nil
+}
+
+// ValidateChangelogDeltaSync checks the epics of a board are collected as a whole with ChangelogDeltaSync, the
+// watermark of a board would skip the epics left out by a sample or by the other shards otherwise
+func (op *JiraOptions) ValidateChangelogDeltaSync() errors.Error {
+	if !op.ChangelogDeltaSync {
+		return nil
+	}
+	if op.SampleEvery() > 0 {
+		return errors.BadInput.New("changelogDeltaSync can't be combined with sample, which leaves out epics of the boards")
+	}
+	if op.EpicShardCount > 1 {
+		return errors.BadInput.New("changelogDeltaSync can't be combined with epicShardCount, which leaves out epics of the boards")
+	}
+	return nil
+}
+
+// rawTablePattern matches the names accepted for the raw tables of RawTableOverrides
+var rawTablePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// rawTableOverrideModes are the MODEs of the server accepting RawTableOverrides
+var rawTableOverrideModes = map[string]bool{"debug": true, "test": true}
+
+// ValidateRawTableOverrides checks the raw tables are overridden by valid names, and only in the debug or test MODE
+// of the server, so the raw data of production wouldn't be scattered over the raw tables of experiments
+func (op *JiraOptions) ValidateRawTableOverrides(mode string) errors.Error {
+	if len(op.RawTableOverrides) == 0 {
+		return nil
+	}
+	if !rawTableOverrideModes[mode] {
+		return errors.BadInput.New(fmt.Sprintf("rawTableOverrides is only accepted in the debug or test MODE, got %q", mode))
+	}
+	for table, override := range op.RawTableOverrides {
+		if !rawTablePattern.MatchString(override) || override == table {
+			return errors.BadInput.New(fmt.Sprintf("invalid override %q of raw table %s", override, table))
+		}
+	}
+	return nil
+}
+
+// ValidateConcurrency checks the bounds of the adaptive concurrency are in order
+func (op *JiraOptions) ValidateConcurrency() errors.Error {
+	if op.MaxConcurrency > 0 && op.MinConcurrency > op.MaxConcurrency {
+		return errors.BadInput.New(fmt.Sprintf("`minConcurrency` %d must not be greater than `maxConcurrency` %d", op.MinConcurrency, op.MaxConcurrency))
+	}
+	return nil
+}
+
+// ValidateUpdatedBy checks the users of UpdatedBy are identified the way the deployment of the connection does,
+// Jira Cloud accepts account ids only in JQL, while Jira Server and Data Center accept usernames
+func (op *JiraOptions) ValidateUpdatedBy(deploymentType models.DeploymentType) errors.Error {
+	for _, user := range op.UpdatedBy {
+		user = strings.TrimSpace(user)
+		if user == "" {
+			return errors.BadInput.New("empty user in `updatedBy`")
+		}
+		if len(user) > maxJiraUserLength {
+			return errors.BadInput.New(fmt.Sprintf("user %q in `updatedBy` is too long", user))
+		}
+		if deploymentType == models.DeploymentCloud && !accountIdPattern.MatchString(user) {
+			return errors.BadInput.New(fmt.Sprintf("user %q in `updatedBy` is not an account id, Jira Cloud identifies users by their account ids rather than their usernames or emails", user))
+		}
+	}
+	return nil
+}
+
+// ValidateEpicShard checks the index is within the count, and the epics are collected by their keys
+func (op *JiraOptions) ValidateEpicShard() errors.Error {
+	if op.EpicShardCount < 0 {
+		return errors.BadInput.New(fmt.Sprintf("invalid epicShardCount:%d", op.EpicShardCount))
+	}
+	if op.EpicShardCount <= 1 {
+		if op.EpicShardIndex != 0 {
+			return errors.BadInput.New(fmt.Sprintf("epicShardIndex:%d requires epicShardCount", op.EpicShardIndex))
+		}
+		return nil
+	}
+	if op.EpicShardIndex < 0 || op.EpicShardIndex >= op.EpicShardCount {
+		return errors.BadInput.New(fmt.Sprintf("epicShardIndex:%d out of epicShardCount:%d", op.EpicShardIndex, op.EpicShardCount))
+	}
+	if op.EpicWindowDays > 0 {
+		return errors.BadInput.New("epicShardCount can't be combined with epicWindowDays, which doesn't search by epic keys")
+	}
+	return nil
+}
+
+// EpicShard returns the shard of the epic collector as `index/count`, empty if it is not sharded
+func (op *JiraOptions) EpicShard() string {
+	if op.EpicShardCount <= 1 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", op.EpicShardIndex, op.EpicShardCount)
+}
+
+// optionsValidator validates the options by their `validate` tags, the fields are named by their json names
+var optionsValidator = newOptionsValidator()
+
+func newOptionsValidator() *validator.Validate {
+	vld := validator.New()
+	vld.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	})
+	return vld
+}
+
+// ValidateOptions checks the options as a whole before any subtask runs, every problem found is reported by a
+// single BadInput error, so they could be fixed in one go rather than one failed run after another. The boards
+// selected by names are checked by the subtasks once they were resolved, see ValidateBoardScope, and the users of
+// UpdatedBy once the deployment of the connection is known, see ValidateUpdatedBy
+func (op *JiraOptions) ValidateOptions() errors.Error {
+	var errs []error
+	err := optionsValidator.Struct(op)
+	if err != nil {
+		var fieldErrors validator.ValidationErrors
+		if !goerrors.As(err, &fieldErrors) {
+			return errors.Default.Wrap(err, "failed to validate the jira options")
+		}
+		for _, fieldError := range fieldErrors {
+			errs = append(errs, goerrors.New(describeFieldError(fieldError)))
+		}
+	}
+	checks := []func() errors.Error{
+		func() errors.Error { return ValidateJql(op.Jql) },
+		func() errors.Error { _, err := op.GetSince(); return err },
+		func() errors.Error { _, err := op.GetTimeAfter(); return err },
+		func() errors.Error { _, err := op.GetPageTimeout(); return err },
+		func() errors.Error { _, err := op.GetLookbackSince(time.Now()); return err },
+		func() errors.Error { _, err := op.GetEpicOrderBy(); return err },
+		op.ValidateFederatedConnections,
+		op.ValidateEpicShard,
+		op.ValidateStatusCategories,
+		op.ValidateConcurrency,
+		op.ValidateEpicKeySource,
+		op.ValidateChangelogDeltaSync,
+	}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.BadInput.Combine(errs)
+}
+
+// describeFieldError describes the violation of a `validate` tag in terms of the json names of the fields
+func describeFieldError(fieldError validator.FieldError) string {
+	// the namespace is prefixed by the struct, i.e. `JiraOptions.boardIds[0]`
+	field := fieldError.Namespace()
+	if i := strings.Index(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("`%s` is required", field)
+	case "required_without_all":
+		others := strings.Fields(fieldError.Param())
+		for i, other := range others {
+			others[i] = fmt.Sprintf("`%s`", strings.ToLower(other[:1])+other[1:])
+		}
+		return fmt.Sprintf("`%s` is required unless %s is given", field, strings.Join(others, " or "))
+	case "gt":
+		return fmt.Sprintf("`%s` must be greater than %s, got %v", field, fieldError.Param(), fieldError.Value())
+	case "gte":
+		return fmt.Sprintf("`%s` must not be less than %s, got %v", field, fieldError.Param(), fieldError.Value())
+	case "oneof":
+		return fmt.Sprintf("`%s` must be any of %s, got %v", field, strings.Join(strings.Fields(fieldError.Param()), ", "), fieldError.Value())
+	}
+	return fmt.Sprintf("`%s` is invalid, got %v", field, fieldError.Value())
+}
+
+// RawTable returns the name of the raw table `table` of the task, replaced by its RawTableOverrides if any, and
+// suffixed by the connection id if ConnectionScopedRawTables is on, i.e. `jira_api_epics_1`
+func (op *JiraOptions) RawTable(table string) string {
+	if override, ok := op.RawTableOverrides[table]; ok {
+		table = override
+	}
+	if op.ConnectionScopedRawTables {
+		return fmt.Sprintf("%s_%d", table, op.ConnectionId)
+	}
+	return table
 }
 
 type JiraTaskData struct {
-	Options        *JiraOptions
-	ApiClient      *helper.ApiAsyncClient
-	Since          *time.Time
+	Options   *JiraOptions
+	ApiClient *helper.ApiAsyncClient
+	Since     *time.Time
+	TimeAfter *time.Time
+	// LookbackSince is the start of the rolling window of LookbackDays, computed once per task
+	LookbackSince  *time.Time
+	PageTimeout    time.Duration
 	JiraServerInfo models.JiraServerInfo
+	// TimeZone is the time zone of the Jira user the dates of JQL are interpreted in, see GetJiraTimeZone
+	TimeZone    *time.Location
+	Concurrency int
+	// SprintField is the custom field holding sprints, configured by the connection
+	SprintField string
+	// ConnectionType is the type of the connection, epics of JSM connections are collected from the service desks
+	ConnectionType string
+	// FieldResolver resolves ids of fields by names, the fields are loaded once per task
+	FieldResolver *FieldResolver
+	// RawExporter exports the raw epics collected to the bucket configured by the connection, nil if not configured
+	RawExporter *RawExporter
+	// MaxResultsPerPage is the cap of the page size configured by the connection, 0 if not capped
+	MaxResultsPerPage int
+	// Clock tells the present of the time-based queries, SystemClock is used if nil
+	Clock Clock
+	// EnabledSubtasks are the subtasks enabled for the task, set by the validation of the subtasks before any of
+	// them runs. The optional raw fields are requested for the enabled extractors only, see rawFieldRequirement
+	EnabledSubtasks map[string]bool
+}
+
+// Now returns the present told by the Clock of the task
+func (data *JiraTaskData) Now() time.Time {
+	if data.Clock == nil {
+		return SystemClock.Now()
+	}
+	return data.Clock.Now()
+}
+
+// PageSize returns the page size to be requested in place of `size`, clamped to the cap of the connection
+func (data *JiraTaskData) PageSize(size int) int {
+	if data.MaxResultsPerPage > 0 && size > data.MaxResultsPerPage {
+		return data.MaxResultsPerPage
+	}
+	return size
 }
 
 func DecodeAndValidateTaskOptions(options map[string]interface{}) (*JiraOptions, errors.Error) {
@@ -66,11 +547,9 @@ func DecodeAndValidateTaskOptions(options map[string]interface{}) (*JiraOptions,
 	if err != nil {
 		return nil, err
 	}
-	if op.ConnectionId == 0 {
-		return nil, errors.BadInput.New(fmt.Sprintf("invalid connectionId:%d", op.ConnectionId))
-	}
-	if op.BoardId == 0 {
-		return nil, errors.BadInput.New(fmt.Sprintf("invalid boardId:%d", op.BoardId))
+	err = op.ValidateOptions()
+	if err != nil {
+		return nil, err
 	}
 	return &op, nil
 }
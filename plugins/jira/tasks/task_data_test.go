@@ -0,0 +1,97 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOptions(t *testing.T) {
+	op := &JiraOptions{ConnectionId: 1, BoardId: 8, Since: "2022-11-01T00:00:00Z", EpicWindowDays: 30}
+	assert.Nil(t, op.ValidateOptions())
+
+	// the boards might be selected by names instead of ids
+	op = &JiraOptions{ConnectionId: 1, BoardNames: []string{"Team Board"}}
+	assert.Nil(t, op.ValidateOptions())
+
+	op = &JiraOptions{BoardId: 8}
+	err := op.ValidateOptions()
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.BadInput, err.GetType())
+	assert.Contains(t, err.Error(), "`connectionId` is required")
+
+	op = &JiraOptions{ConnectionId: 1}
+	err = op.ValidateOptions()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "`boardId` is required unless `boardIds` or `boardNames` is given")
+
+	op = &JiraOptions{ConnectionId: 1, BoardIds: []uint64{8, 0}}
+	err = op.ValidateOptions()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "`boardIds[1]` must be greater than 0")
+
+	op = &JiraOptions{ConnectionId: 1, BoardId: 8, Sample: &SampleOptions{Every: 1}}
+	err = op.ValidateOptions()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "`sample.every` must be greater than 1, got 1")
+}
+
+func TestValidateOptionsAggregatesErrors(t *testing.T) {
+	op := &JiraOptions{BoardId: 8, Limit: -1, Since: "yesterday", PageTimeout: "forever", MinConcurrency: 4, MaxConcurrency: 2}
+	err := op.ValidateOptions()
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.BadInput, err.GetType())
+	assert.Contains(t, err.Error(), "`connectionId` is required")
+	assert.Contains(t, err.Error(), "`limit` must not be less than 0, got -1")
+	assert.Contains(t, err.Error(), "invalid value for `since`")
+	assert.Contains(t, err.Error(), "pageTimeout")
+	assert.Contains(t, err.Error(), "`minConcurrency` 4 must not be greater than `maxConcurrency` 2")
+}
+
+func TestPageSize(t *testing.T) {
+	data := &JiraTaskData{}
+	assert.Equal(t, 100, data.PageSize(100))
+	data.MaxResultsPerPage = 50
+	assert.Equal(t, 50, data.PageSize(100))
+	assert.Equal(t, 20, data.PageSize(20))
+}
+
+func TestRawTableOverrides(t *testing.T) {
+	op := &JiraOptions{ConnectionId: 1, RawTableOverrides: map[string]string{RAW_EPIC_TABLE: "jira_api_epics_experimental"}}
+	assert.Equal(t, "jira_api_epics_experimental", op.RawTable(RAW_EPIC_TABLE))
+	assert.Equal(t, RAW_ISSUE_TABLE, op.RawTable(RAW_ISSUE_TABLE))
+	op.ConnectionScopedRawTables = true
+	assert.Equal(t, "jira_api_epics_experimental_1", op.RawTable(RAW_EPIC_TABLE))
+
+	// the overrides are rejected by the servers in production
+	assert.Nil(t, op.ValidateRawTableOverrides("debug"))
+	assert.Nil(t, op.ValidateRawTableOverrides("test"))
+	err := op.ValidateRawTableOverrides("release")
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.BadInput, err.GetType())
+	assert.NotNil(t, op.ValidateRawTableOverrides(""))
+	assert.Nil(t, (&JiraOptions{}).ValidateRawTableOverrides("release"))
+
+	op.RawTableOverrides[RAW_EPIC_TABLE] = "jira_api_epics; DROP TABLE x"
+	assert.NotNil(t, op.ValidateRawTableOverrides("debug"))
+	op.RawTableOverrides[RAW_EPIC_TABLE] = RAW_EPIC_TABLE
+	assert.NotNil(t, op.ValidateRawTableOverrides("debug"))
+}
@@ -0,0 +1,97 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/incubator-devlake/errors"
+	"github.com/apache/incubator-devlake/plugins/helper"
+)
+
+var _ helper.Iterator = (*timeWindowIterator)(nil)
+
+// timeWindow is the input of a collection limited to the time range [Start, End), End is nil for the window reaching
+// the present, so data updated during the collection is not left out
+type timeWindow struct {
+	Start time.Time
+	End   *time.Time
+}
+
+// updatedCriteria returns the criteria matching issues updated within the window, see formatJqlTime for `location`
+func (w *timeWindow) updatedCriteria(location *time.Location) string {
+	criteria := fmt.Sprintf("updated >= '%s'", formatJqlTime(w.Start, location))
+	if w.End != nil {
+		criteria = fmt.Sprintf("%s AND updated < '%s'", criteria, formatJqlTime(*w.End, location))
+	}
+	return criteria
+}
+
+// timeWindowIterator splits the time range from `since` till now into windows of `days`, the windows are aligned to
+// minutes since JQL doesn't accept finer precision
+type timeWindowIterator struct {
+	next time.Time
+	now  time.Time
+	days int
+	done bool
+}
+
+// newTimeWindowIterator returns an iterator of the windows from `since` till `now`, nothing is iterated if `since`
+// is nil
+func newTimeWindowIterator(since *time.Time, now time.Time, days int) *timeWindowIterator {
+	if since == nil {
+		return &timeWindowIterator{done: true}
+	}
+	return &timeWindowIterator{
+		next: since.Truncate(time.Minute),
+		now:  now,
+		days: days,
+	}
+}
+
+// newEpicTimeWindowIterator returns an iterator of the windows of EpicWindowDays from `since` till the present told
+// by the clock of the task
+func newEpicTimeWindowIterator(data *JiraTaskData, since *time.Time) *timeWindowIterator {
+	return newTimeWindowIterator(since, data.Now(), data.Options.EpicWindowDays)
+}
+
+// HasNext returns true until the window reaching the present was fetched
+func (it *timeWindowIterator) HasNext() bool {
+	return !it.done
+}
+
+// Fetch returns the next window, which is left open if it reaches the present
+func (it *timeWindowIterator) Fetch() (interface{}, errors.Error) {
+	if it.done {
+		return nil, errors.Default.New("no more time windows")
+	}
+	start := it.next
+	end := start.AddDate(0, 0, it.days)
+	if !end.Before(it.now) {
+		it.done = true
+		return &timeWindow{Start: start}, nil
+	}
+	it.next = end
+	return &timeWindow{Start: start, End: &end}, nil
+}
+
+// Close does nothing since the windows are computed on the fly
+func (it *timeWindowIterator) Close() errors.Error {
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTimeWindowIterator(t *testing.T) {
+	since := time.Date(2022, 11, 1, 8, 30, 45, 0, time.UTC)
+	now := time.Date(2022, 11, 20, 0, 0, 0, 0, time.UTC)
+	it := newTimeWindowIterator(&since, now, 7)
+	var windows []string
+	for it.HasNext() {
+		window, err := it.Fetch()
+		assert.Nil(t, err)
+		windows = append(windows, window.(*timeWindow).updatedCriteria(nil))
+	}
+	assert.Equal(t, []string{
+		"updated >= '2022/11/01 08:30' AND updated < '2022/11/08 08:30'",
+		"updated >= '2022/11/08 08:30' AND updated < '2022/11/15 08:30'",
+		// the last window is left open
+		"updated >= '2022/11/15 08:30'",
+	}, windows)
+	_, err := it.Fetch()
+	assert.NotNil(t, err)
+	assert.Nil(t, it.Close())
+
+	// a range shorter than a window
+	it = newTimeWindowIterator(&since, since.Add(time.Hour), 7)
+	assert.True(t, it.HasNext())
+	window, err := it.Fetch()
+	assert.Nil(t, err)
+	assert.Nil(t, window.(*timeWindow).End)
+	assert.False(t, it.HasNext())
+
+	// nothing to collect
+	assert.False(t, newTimeWindowIterator(nil, now, 7).HasNext())
+}
+
+func TestGetEarliestUpdated(t *testing.T) {
+	respond := func(body string) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Request:    &http.Request{URL: &url.URL{}},
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+		}
+	}
+	jql := buildJql("", projectEpicsCriteria(10), userJqlCriteria("labels = roadmap"))
+	apiClient := mocks.NewApiClientGetter(t)
+	apiClient.On("Get", "api/2/search", mock.MatchedBy(func(query url.Values) bool {
+		return query.Get("jql") == `issuetype = Epic AND project = 10 AND (labels = roadmap) ORDER BY updated ASC` &&
+			query.Get("maxResults") == "1"
+	}), mock.Anything).Return(respond(`{"issues":[{"key":"K-1","fields":{"updated":"2021-03-04T05:06:07.000+0000"}}]}`), nil).Once()
+	earliest, err := getEarliestUpdated(apiClient, "api/2/search", jql)
+	assert.Nil(t, err)
+	if assert.NotNil(t, earliest) {
+		assert.True(t, time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC).Equal(*earliest))
+	}
+
+	apiClient = mocks.NewApiClientGetter(t)
+	apiClient.On("Get", "api/2/search", mock.Anything, mock.Anything).Return(respond(`{"issues":[]}`), nil).Once()
+	earliest, err = getEarliestUpdated(apiClient, "api/2/search", jql)
+	assert.Nil(t, err)
+	assert.Nil(t, earliest)
+}
+
+func TestEpicTimeWindowsOfPinnedClock(t *testing.T) {
+	data := &JiraTaskData{
+		Options:  &JiraOptions{LookbackDays: 10, EpicWindowDays: 7},
+		TimeZone: time.FixedZone("UTC+8", 8*3600),
+		Clock:    FixedClock(time.Date(2022, 11, 20, 0, 0, 0, 0, time.UTC)),
+	}
+	since, err := data.Options.GetLookbackSince(data.Now())
+	assert.Nil(t, err)
+	it := newEpicTimeWindowIterator(data, since)
+	var windows []string
+	for it.HasNext() {
+		window, err := it.Fetch()
+		assert.Nil(t, err)
+		windows = append(windows, window.(*timeWindow).updatedCriteria(data.TimeZone))
+	}
+	assert.Equal(t, []string{
+		"updated >= '2022/11/10 08:00' AND updated < '2022/11/17 08:00'",
+		"updated >= '2022/11/17 08:00'",
+	}, windows)
+
+	// the system clock is used by default
+	data.Clock = nil
+	assert.WithinDuration(t, time.Now(), data.Now(), time.Minute)
+}
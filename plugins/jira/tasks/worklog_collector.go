@@ -78,14 +78,16 @@ func CollectWorklogs(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_WORKLOGS_TABLE,
+			Table: data.Options.RawTable(RAW_WORKLOGS_TABLE),
 		},
 		Input:         iterator,
 		ApiClient:     data.ApiClient,
-		UrlTemplate:   "api/2/issue/{{ .Input.IssueId }}/worklog",
-		PageSize:      50,
+		UrlTemplate:   data.ApiVersion().Path("issue/{{ .Input.IssueId }}/worklog"),
+		PageSize:      data.PageSize(50),
 		Incremental:   since == nil,
 		GetTotalPages: GetTotalPagesFromResponse,
+		GetPageSize:   GetPageSizeFromResponse,
+		IsLastPage:    IsLastPageFromResponse,
 		ResponseParser: func(res *http.Response) ([]json.RawMessage, errors.Error) {
 			var data struct {
 				Worklogs []json.RawMessage `json:"worklogs"`
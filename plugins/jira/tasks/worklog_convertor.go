@@ -72,7 +72,7 @@ func ConvertWorklogs(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_WORKLOGS_TABLE,
+			Table: data.Options.RawTable(RAW_WORKLOGS_TABLE),
 		},
 		InputRowType: reflect.TypeOf(models.JiraWorklog{}),
 		Input:        cursor,
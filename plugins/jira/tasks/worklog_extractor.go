@@ -44,7 +44,7 @@ func ExtractWorklogs(taskCtx core.SubTaskContext) errors.Error {
 				ConnectionId: data.Options.ConnectionId,
 				BoardId:      data.Options.BoardId,
 			},
-			Table: RAW_WORKLOGS_TABLE,
+			Table: data.Options.RawTable(RAW_WORKLOGS_TABLE),
 		},
 		Extract: func(row *helper.RawData) ([]interface{}, errors.Error) {
 			var input apiv2models.Input
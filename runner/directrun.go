@@ -105,12 +105,24 @@ func DirectRun(cmd *cobra.Command, args []string, pluginTask core.PluginTask, op
 
 func createContext() context.Context {
 	ctx, cancel := context.WithCancel(context.Background())
+	shutdown := make(chan struct{})
+	ctx = core.WithShutdown(ctx, shutdown)
 	sigc := make(chan os.Signal, 1)
 	signal.Notify(sigc, getStopSignals()...)
 	go func() {
 		<-sigc
 		cancel()
 	}()
+	// a termination lets the collectors save what they collected so far, a second one aborts them
+	termc := make(chan os.Signal, 1)
+	signal.Notify(termc, syscall.SIGTERM)
+	go func() {
+		<-termc
+		println("shutting down, send the signal again to abort")
+		close(shutdown)
+		<-termc
+		cancel()
+	}()
 	go func() {
 		var buf string
 
@@ -23,13 +23,16 @@ import (
 	"fmt"
 	"github.com/apache/incubator-devlake/errors"
 	"github.com/apache/incubator-devlake/logger"
+	"strings"
 	"time"
 
 	"github.com/apache/incubator-devlake/config"
 	"github.com/apache/incubator-devlake/models"
 	"github.com/apache/incubator-devlake/utils"
 	"github.com/spf13/viper"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/apache/incubator-devlake/plugins/core"
 	"github.com/apache/incubator-devlake/plugins/helper"
@@ -190,13 +193,15 @@ func RunPluginSubTasks(
 	pluginTask core.PluginTask,
 	progress chan core.RunningProgress,
 ) errors.Error {
+	// every message of the task and its subtasks is attributable to the connection and scope of the task
+	log = log.WithFields(taskLogFields(options))
 	log.Info("start plugin")
+	if rawDataOnly, _ := options[helper.RawDataOnlyOption].(bool); rawDataOnly {
+		log.Info("running against the raw data collected before, collectors with raw data are skipped")
+		ctx = helper.WithRawDataOnly(ctx)
+	}
 	// find out all possible subtasks this plugin can offer
 	subtaskMetas := pluginTask.SubTaskMetas()
-	subtasksFlag := make(map[string]bool)
-	for _, subtaskMeta := range subtaskMetas {
-		subtasksFlag[subtaskMeta.Name] = subtaskMeta.EnabledByDefault
-	}
 	/* subtasksFlag example
 	subtasksFlag := map[string]bool{
 		"collectProject": true,
@@ -206,42 +211,17 @@ func RunPluginSubTasks(
 	*/
 
 	// user specifies what subtasks to run
+	var specifiedTasks []string
 	if len(subtaskNames) != 0 {
 		// decode user specified subtasks
-		var specifiedTasks []string
 		err := helper.Decode(subtaskNames, &specifiedTasks, nil)
 		if err != nil {
 			return errors.Default.Wrap(err, "subtasks could not be decoded")
 		}
-		if len(specifiedTasks) > 0 {
-			// first, disable all subtasks
-			for task := range subtasksFlag {
-				subtasksFlag[task] = false
-			}
-			// second, check specified subtasks is valid and enable them if so
-			for _, task := range specifiedTasks {
-				if _, ok := subtasksFlag[task]; ok {
-					subtasksFlag[task] = true
-				} else {
-					return errors.Default.New(fmt.Sprintf("subtask %s does not exist", task))
-				}
-			}
-		}
-	}
-
-	// make sure `Required` subtasks are always enabled
-	for _, subtaskMeta := range subtaskMetas {
-		if subtaskMeta.Required {
-			subtasksFlag[subtaskMeta.Name] = true
-		}
 	}
-
-	// calculate total step(number of task to run)
-	steps := 0
-	for _, enabled := range subtasksFlag {
-		if enabled {
-			steps++
-		}
+	subtasksFlag, err := getSubtasksFlag(subtaskMetas, specifiedTasks)
+	if err != nil {
+		return err
 	}
 
 	taskCtx := helper.NewDefaultTaskContext(ctx, cfg, log, db, name, subtasksFlag, progress)
@@ -253,6 +233,30 @@ func RunPluginSubTasks(
 		return errors.Default.Wrap(err, fmt.Sprintf("error preparing task data for %s", name))
 	}
 	taskCtx.SetData(taskData)
+	// the subtasks enabled by default might depend on the type of the connection, which is known by now
+	if filter, ok := pluginTask.(core.SubTaskFilter); ok && len(specifiedTasks) == 0 {
+		filterSubTasks(subtaskMetas, filter.FilterSubTasks(filter.GetConnectionType(taskData)), subtasksFlag)
+	}
+
+	// make sure the dependencies of enabled subtasks are enabled and run first
+	subtaskMetas, err = orderSubTasks(subtaskMetas, subtasksFlag)
+	if err != nil {
+		return err
+	}
+
+	// calculate total step(number of task to run)
+	steps := 0
+	for _, enabled := range subtasksFlag {
+		if enabled {
+			steps++
+		}
+	}
+	if validator, ok := pluginTask.(core.SubTaskValidator); ok {
+		err = validator.ValidateSubTasks(taskData, subtasksFlag)
+		if err != nil {
+			return err
+		}
+	}
 
 	// execute subtasks in order
 	taskCtx.SetProgress(0, steps)
@@ -268,6 +272,10 @@ func RunPluginSubTasks(
 			continue
 		}
 
+		// the subtasks left are run by the next run
+		if core.IsShuttingDown(ctx) {
+			return errors.Default.New(fmt.Sprintf("the task was interrupted by shutdown before subtask %s", subtaskMeta.Name))
+		}
 		// run subtask
 		log.Info("executing subtask %s", subtaskMeta.Name)
 		subtaskNumber++
@@ -288,6 +296,104 @@ func RunPluginSubTasks(
 	return nil
 }
 
+// getSubtasksFlag returns which of the subtasks are enabled, either the ones specified by the user or the ones
+// enabled by default if none was specified. The `Required` subtasks are always enabled
+func getSubtasksFlag(subtaskMetas []core.SubTaskMeta, specifiedTasks []string) (map[string]bool, errors.Error) {
+	subtasksFlag := make(map[string]bool)
+	for _, subtaskMeta := range subtaskMetas {
+		subtasksFlag[subtaskMeta.Name] = subtaskMeta.EnabledByDefault && len(specifiedTasks) == 0
+	}
+	// check specified subtasks is valid and enable them if so
+	for _, task := range specifiedTasks {
+		if _, ok := subtasksFlag[task]; !ok {
+			return nil, errors.Default.New(fmt.Sprintf("subtask %s does not exist", task))
+		}
+		subtasksFlag[task] = true
+	}
+	// make sure `Required` subtasks are always enabled
+	for _, subtaskMeta := range subtaskMetas {
+		if subtaskMeta.Required {
+			subtasksFlag[subtaskMeta.Name] = true
+		}
+	}
+	return subtasksFlag, nil
+}
+
+// filterSubTasks disables the subtasks left out by the SubTaskFilter of the plugin, except the `Required` ones. The
+// subtasks kept are enabled by the EnabledByDefault returned by the filter, which might differ from the plugin's
+func filterSubTasks(subtaskMetas []core.SubTaskMeta, filtered []core.SubTaskMeta, subtasksFlag map[string]bool) {
+	applying := make(map[string]bool, len(filtered))
+	for _, subtaskMeta := range filtered {
+		applying[subtaskMeta.Name] = subtaskMeta.EnabledByDefault
+	}
+	for _, subtaskMeta := range subtaskMetas {
+		subtasksFlag[subtaskMeta.Name] = subtaskMeta.Required || applying[subtaskMeta.Name]
+	}
+}
+
+// logFieldOptions are the options identifying the connection and scope of a task, which are attached to the log
+// messages of the task by taskLogFields
+var logFieldOptions = []string{"connectionId", "boardId", "projectId", "repoId"}
+
+// taskLogFields returns the options of logFieldOptions set for the task
+func taskLogFields(options map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for _, name := range logFieldOptions {
+		if value, ok := options[name]; ok && value != nil {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+// orderSubTasks validates the `DependsOn` of the subtasks against the enabled ones and orders them so that every
+// subtask comes after its dependencies, the order of registration is kept wherever the dependencies allow
+func orderSubTasks(subtaskMetas []core.SubTaskMeta, subtasksFlag map[string]bool) ([]core.SubTaskMeta, errors.Error) {
+	for _, subtaskMeta := range subtaskMetas {
+		for _, dependency := range subtaskMeta.DependsOn {
+			enabled, ok := subtasksFlag[dependency]
+			if !ok {
+				return nil, errors.Default.New(fmt.Sprintf("subtask %s depends on subtask %s which does not exist", subtaskMeta.Name, dependency))
+			}
+			if subtasksFlag[subtaskMeta.Name] && !enabled {
+				return nil, errors.BadInput.New(fmt.Sprintf("subtask %s depends on subtask %s which is disabled", subtaskMeta.Name, dependency))
+			}
+		}
+	}
+	ordered := make([]core.SubTaskMeta, 0, len(subtaskMetas))
+	placed := make(map[string]bool, len(subtaskMetas))
+	for len(ordered) < len(subtaskMetas) {
+		next := -1
+		for i, subtaskMeta := range subtaskMetas {
+			if !placed[subtaskMeta.Name] && dependenciesPlaced(subtaskMeta, placed) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var pending []string
+			for _, subtaskMeta := range subtaskMetas {
+				if !placed[subtaskMeta.Name] {
+					pending = append(pending, subtaskMeta.Name)
+				}
+			}
+			return nil, errors.Default.New(fmt.Sprintf("circular dependency among subtasks %s", strings.Join(pending, ", ")))
+		}
+		ordered = append(ordered, subtaskMetas[next])
+		placed[subtaskMetas[next].Name] = true
+	}
+	return ordered, nil
+}
+
+func dependenciesPlaced(subtaskMeta core.SubTaskMeta, placed map[string]bool) bool {
+	for _, dependency := range subtaskMeta.DependsOn {
+		if !placed[dependency] {
+			return false
+		}
+	}
+	return true
+}
+
 // UpdateProgressDetail FIXME ...
 func UpdateProgressDetail(db *gorm.DB, log core.Logger, taskId uint64, progressDetail *models.TaskProgressDetail, p *core.RunningProgress) {
 	task := &models.Task{}
@@ -312,6 +418,15 @@ func UpdateProgressDetail(db *gorm.DB, log core.Logger, taskId uint64, progressD
 	case core.SetCurrentSubTask:
 		progressDetail.SubTaskName = p.SubTaskName
 		progressDetail.SubTaskNumber = p.SubTaskNumber
+		progressDetail.RawTable = ""
+		progressDetail.FinishedPages = 0
+		progressDetail.TotalPages = 0
+		progressDetail.CollectedRecords = 0
+	case core.CollectorProgress:
+		progressDetail.RawTable = p.RawTable
+		progressDetail.FinishedPages = p.Current
+		progressDetail.TotalPages = p.Total
+		progressDetail.CollectedRecords = p.Records
 	}
 }
 
@@ -335,6 +450,8 @@ func runSubtask(
 		subtask.FinishedAt = &finishedAt
 		subtask.SpentSeconds = finishedAt.Unix() - beginAt.Unix()
 		recordSubtask(log, db, subtask)
+		recordCollectorStats(log, db, parentID, subtask.Name, ctx)
+		recordSubtaskResult(log, db, parentID, subtask.Name, ctx)
 	}()
 	return entryPoint(ctx)
 }
@@ -345,6 +462,102 @@ func recordSubtask(log core.Logger, db *gorm.DB, subtask *models.Subtask) {
 	}
 }
 
+// recordCollectorStats saves the stats of collectors reported to the subtask context, once the subtask is finished
+func recordCollectorStats(log core.Logger, db *gorm.DB, taskId uint64, subtaskName string, ctx core.SubTaskContext) {
+	holder, ok := ctx.(interface {
+		GetCollectorStats() []core.CollectorStats
+	})
+	if !ok {
+		return
+	}
+	reported := holder.GetCollectorStats()
+	if len(reported) == 0 {
+		return
+	}
+	task := &models.Task{}
+	if err := db.Select("pipeline_id").First(task, taskId).Error; err != nil {
+		log.Error(err, "error finding pipeline of task %d", taskId)
+		return
+	}
+	stats := make([]*models.CollectorStats, 0, len(reported))
+	for _, s := range reported {
+		stats = append(stats, &models.CollectorStats{
+			PipelineId:      task.PipelineId,
+			TaskId:          taskId,
+			SubtaskName:     subtaskName,
+			RawTable:        s.RawTable,
+			Requests:        s.Requests,
+			Bytes:           s.Bytes,
+			DurationMs:      s.Duration.Milliseconds(),
+			InputBatches:    s.InputBatches,
+			InputDurationMs: s.InputDuration.Milliseconds(),
+		})
+	}
+	// a rerun of the task overwrites the stats of the previous run
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(stats).Error; err != nil {
+		log.Error(err, "error writing collector stats of subtask %s", subtaskName)
+	}
+}
+
+// recordSubtaskResult saves the result reported by the subtask onto the task, once the subtask is finished
+func recordSubtaskResult(log core.Logger, db *gorm.DB, taskId uint64, subtaskName string, ctx core.SubTaskContext) {
+	holder, ok := ctx.(interface {
+		GetSubTaskResult() *core.SubTaskResult
+	})
+	if !ok {
+		return
+	}
+	reported := holder.GetSubTaskResult()
+	if reported == nil {
+		return
+	}
+	task := &models.Task{}
+	if err := db.Select("subtask_results").First(task, taskId).Error; err != nil {
+		log.Error(err, "error finding task %d", taskId)
+		return
+	}
+	results, err := mergeSubtaskResult(task.SubtaskResults, &models.SubtaskResult{
+		SubtaskName:    subtaskName,
+		Records:        reported.Records,
+		Pages:          reported.Pages,
+		DryRunRequests: reported.DryRunRequests,
+		Warnings:       reported.Warnings,
+	})
+	if err != nil {
+		log.Error(err, "error merging result of subtask %s", subtaskName)
+		return
+	}
+	if err := db.Model(task).Where("id = ?", taskId).Update("subtask_results", results).Error; err != nil {
+		log.Error(err, "error writing result of subtask %s", subtaskName)
+	}
+}
+
+// mergeSubtaskResult adds the result to the results saved on the task, the result of a previous run of the same
+// subtask is replaced
+func mergeSubtaskResult(saved datatypes.JSON, result *models.SubtaskResult) (datatypes.JSON, errors.Error) {
+	var results []*models.SubtaskResult
+	if len(saved) > 0 {
+		if err := json.Unmarshal(saved, &results); err != nil {
+			return nil, errors.Convert(err)
+		}
+	}
+	replaced := false
+	for i := range results {
+		if results[i].SubtaskName == result.SubtaskName {
+			results[i] = result
+			replaced = true
+		}
+	}
+	if !replaced {
+		results = append(results, result)
+	}
+	merged, err := json.Marshal(results)
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	return merged, nil
+}
+
 func getTaskLogger(parentLogger core.Logger, task *models.Task) (core.Logger, errors.Error) {
 	log := parentLogger.Nested(fmt.Sprintf("task #%d", task.ID))
 	loggingPath := logger.GetTaskLoggerPath(log.GetConfig(), task)
@@ -0,0 +1,179 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/incubator-devlake/logger"
+	"github.com/apache/incubator-devlake/plugins/core"
+	"github.com/apache/incubator-devlake/plugins/helper"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func subtaskNames(subtaskMetas []core.SubTaskMeta) []string {
+	names := make([]string, len(subtaskMetas))
+	for i, subtaskMeta := range subtaskMetas {
+		names[i] = subtaskMeta.Name
+	}
+	return names
+}
+
+func TestOrderSubTasks(t *testing.T) {
+	subtaskMetas := []core.SubTaskMeta{
+		{Name: "extractEpics", DependsOn: []string{"collectEpics"}},
+		{Name: "collectBoard"},
+		{Name: "collectEpics", DependsOn: []string{"collectBoard"}},
+		{Name: "convertEpics", DependsOn: []string{"extractEpics"}},
+	}
+	enabled := map[string]bool{"extractEpics": true, "collectBoard": true, "collectEpics": true, "convertEpics": true}
+	ordered, err := orderSubTasks(subtaskMetas, enabled)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"collectBoard", "collectEpics", "extractEpics", "convertEpics"}, subtaskNames(ordered))
+
+	// disabled subtasks may depend on disabled ones
+	ordered, err = orderSubTasks(subtaskMetas, map[string]bool{"extractEpics": false, "collectBoard": true, "collectEpics": false, "convertEpics": false})
+	assert.Nil(t, err)
+	assert.Len(t, ordered, 4)
+}
+
+func TestOrderSubTasksDisabledDependency(t *testing.T) {
+	subtaskMetas := []core.SubTaskMeta{
+		{Name: "collectEpics"},
+		{Name: "extractEpics", DependsOn: []string{"collectEpics"}},
+	}
+	_, err := orderSubTasks(subtaskMetas, map[string]bool{"collectEpics": false, "extractEpics": true})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "subtask extractEpics depends on subtask collectEpics which is disabled")
+
+	_, err = orderSubTasks([]core.SubTaskMeta{
+		{Name: "extractEpics", DependsOn: []string{"collectIssues"}},
+	}, map[string]bool{"extractEpics": true})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "does not exist")
+}
+
+func TestOrderSubTasksCircularDependency(t *testing.T) {
+	subtaskMetas := []core.SubTaskMeta{
+		{Name: "collectBoard"},
+		{Name: "collectEpics", DependsOn: []string{"extractEpics"}},
+		{Name: "extractEpics", DependsOn: []string{"collectEpics"}},
+	}
+	enabled := map[string]bool{"collectBoard": true, "collectEpics": true, "extractEpics": true}
+	_, err := orderSubTasks(subtaskMetas, enabled)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "circular dependency among subtasks collectEpics, extractEpics")
+}
+
+// connectionTypeFilter leaves the epics out of the connections of type `desk`, where the requests are not epics
+type connectionTypeFilter struct {
+	subtaskMetas []core.SubTaskMeta
+}
+
+func (filter connectionTypeFilter) GetConnectionType(taskData interface{}) string {
+	return taskData.(string)
+}
+
+func (filter connectionTypeFilter) FilterSubTasks(connectionType string) []core.SubTaskMeta {
+	var filtered []core.SubTaskMeta
+	for _, subtaskMeta := range filter.subtaskMetas {
+		if connectionType == "desk" {
+			if subtaskMeta.Name == "extractEpics" {
+				continue
+			}
+			// requests are collected by default on desks only
+			if subtaskMeta.Name == "collectRequests" {
+				subtaskMeta.EnabledByDefault = true
+			}
+		}
+		filtered = append(filtered, subtaskMeta)
+	}
+	return filtered
+}
+
+func TestFilterSubTasksByConnectionType(t *testing.T) {
+	subtaskMetas := []core.SubTaskMeta{
+		{Name: "collectBoard", EnabledByDefault: true, Required: true},
+		{Name: "collectEpics", EnabledByDefault: true},
+		{Name: "extractEpics", EnabledByDefault: true, DependsOn: []string{"collectEpics"}},
+		{Name: "collectRequests"},
+	}
+	var filter core.SubTaskFilter = connectionTypeFilter{subtaskMetas: subtaskMetas}
+	enabledSubTasks := func(connectionType string) []string {
+		subtasksFlag, err := getSubtasksFlag(subtaskMetas, nil)
+		assert.Nil(t, err)
+		filterSubTasks(subtaskMetas, filter.FilterSubTasks(filter.GetConnectionType(connectionType)), subtasksFlag)
+		ordered, err := orderSubTasks(subtaskMetas, subtasksFlag)
+		assert.Nil(t, err)
+		var enabled []string
+		for _, subtaskMeta := range ordered {
+			if subtasksFlag[subtaskMeta.Name] {
+				enabled = append(enabled, subtaskMeta.Name)
+			}
+		}
+		return enabled
+	}
+	assert.Equal(t, []string{"collectBoard", "collectEpics", "extractEpics"}, enabledSubTasks("jira"))
+	assert.Equal(t, []string{"collectBoard", "collectEpics", "collectRequests"}, enabledSubTasks("desk"))
+}
+
+func TestGetSubtasksFlag(t *testing.T) {
+	subtaskMetas := []core.SubTaskMeta{
+		{Name: "collectBoard", Required: true},
+		{Name: "collectEpics", EnabledByDefault: true},
+		{Name: "collectRequests"},
+	}
+	subtasksFlag, err := getSubtasksFlag(subtaskMetas, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"collectBoard": true, "collectEpics": true, "collectRequests": false}, subtasksFlag)
+
+	// the subtasks specified take the place of the default ones
+	subtasksFlag, err = getSubtasksFlag(subtaskMetas, []string{"collectRequests"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"collectBoard": true, "collectEpics": false, "collectRequests": true}, subtasksFlag)
+
+	_, err = getSubtasksFlag(subtaskMetas, []string{"collectSprints"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "subtask collectSprints does not exist")
+}
+
+func TestSubTaskLogFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := logrus.New()
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.SetOutput(buf)
+	root, err := logger.NewDefaultLogger(log)
+	assert.Nil(t, err)
+
+	options := map[string]interface{}{"connectionId": float64(1), "boardId": float64(2), "since": "2022-01-01"}
+	taskCtx := helper.NewDefaultTaskContext(context.Background(), nil, root.WithFields(taskLogFields(options)), nil, "jira", map[string]bool{"collectEpics": true}, nil)
+	subtaskCtx, err := taskCtx.SubTaskContext("collectEpics")
+	assert.Nil(t, err)
+	subtaskCtx.GetLogger().Info("collect epics of board %d", 2)
+
+	entry := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, float64(1), entry["connectionId"])
+	assert.Equal(t, float64(2), entry["boardId"])
+	assert.Equal(t, "collectEpics", entry["subtask"])
+	assert.NotContains(t, entry, "since")
+}
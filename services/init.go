@@ -56,6 +56,11 @@ func Init() {
 		panic(err)
 	}
 	basicRes = impl.NewDefaultBasicRes(cfg, log, dalgorm.NewDalgorm(db))
+	shutdownGracePeriod := cfg.GetDuration("SHUTDOWN_GRACE_PERIOD")
+	if shutdownGracePeriod <= 0 {
+		shutdownGracePeriod = defaultShutdownGracePeriod
+	}
+	go watchShutdown(shutdownGracePeriod)
 
 	// initialize db migrator singletone
 	migrator, err = runner.InitMigrator(basicRes)
@@ -19,6 +19,7 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -139,6 +140,40 @@ func GetPipeline(pipelineId uint64) (*models.Pipeline, errors.Error) {
 	return pipeline, nil
 }
 
+// GetPipelineCollectorStats returns the stats of collectors run by the pipeline
+func GetPipelineCollectorStats(pipelineId uint64) ([]*models.CollectorStats, errors.Error) {
+	stats := make([]*models.CollectorStats, 0)
+	err := db.Where("pipeline_id = ?", pipelineId).Order("task_id, subtask_name, raw_table").Find(&stats).Error
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error getting collector stats of the pipeline")
+	}
+	return stats, nil
+}
+
+// GetPipelineSubtaskResults returns the results reported by subtasks of the pipeline, task by task
+func GetPipelineSubtaskResults(pipelineId uint64) ([]*models.SubtaskResult, errors.Error) {
+	tasks := make([]*models.Task, 0)
+	err := db.Select("id", "subtask_results").Where("pipeline_id = ?", pipelineId).Order("id").Find(&tasks).Error
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error getting subtask results of the pipeline")
+	}
+	results := make([]*models.SubtaskResult, 0)
+	for _, task := range tasks {
+		if len(task.SubtaskResults) == 0 {
+			continue
+		}
+		var taskResults []*models.SubtaskResult
+		if err := json.Unmarshal(task.SubtaskResults, &taskResults); err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("error parsing subtask results of task %d", task.ID))
+		}
+		for _, result := range taskResults {
+			result.TaskId = task.ID
+		}
+		results = append(results, taskResults...)
+	}
+	return results, nil
+}
+
 // GetPipelineLogsArchivePath creates an archive for the logs of this pipeline and returns its file path
 func GetPipelineLogsArchivePath(pipeline *models.Pipeline) (string, errors.Error) {
 	logPath, err := getPipelineLogsPath(pipeline)
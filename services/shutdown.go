@@ -0,0 +1,47 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// defaultShutdownGracePeriod stays below the 30s Kubernetes waits for a pod to terminate before killing it
+const defaultShutdownGracePeriod = 25 * time.Second
+
+// shutdown is closed once the server is asked to terminate, it is attached to the contexts of the tasks, see
+// core.WithShutdown
+var shutdown = make(chan struct{})
+
+// watchShutdown lets the running tasks save what they collected when the server is asked to terminate, i.e. when
+// the pod is evicted. The server exits once the tasks stopped or the grace period is over, whichever comes first
+func watchShutdown(gracePeriod time.Duration) {
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGTERM)
+	<-sigc
+	log.Info("shutting down, waiting up to %v for the running tasks to stop", gracePeriod)
+	close(shutdown)
+	deadline := time.Now().Add(gracePeriod)
+	for runningTasks.count() > 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	os.Exit(0)
+}
@@ -116,6 +116,13 @@ func (rt *RunningTask) Remove(taskId uint64) (context.CancelFunc, errors.Error)
 	return nil, errors.NotFound.New(fmt.Sprintf("task with id %d not found", taskId))
 }
 
+// count returns the number of the tasks running
+func (rt *RunningTask) count() int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return len(rt.tasks)
+}
+
 var runningTasks RunningTask
 
 // TaskQuery FIXME ...
@@ -238,6 +245,7 @@ func SpawnTasks(input []models.Task) ([]models.Task, errors.Error) {
 		task.BeganAt = nil
 		task.FinishedAt = nil
 		task.SpentSeconds = 0
+		task.SubtaskResults = nil
 		task.SkipOnFail = true
 		result = append(result, task)
 	}
@@ -323,7 +331,7 @@ func runTaskStandalone(parentLog core.Logger, taskId uint64) errors.Error {
 	progress := make(chan core.RunningProgress, 100)
 	go updateTaskProgress(taskId, progress)
 	err = runner.RunTask(
-		ctx,
+		core.WithShutdown(ctx, shutdown),
 		cfg,
 		parentLog,
 		db,